@@ -51,6 +51,10 @@ var baseFlags = []cli.Flag{
 		Usage:   "LiveKit config in YAML, typically passed in as an environment var in a container",
 		EnvVars: []string{"LIVEKIT_CONFIG"},
 	},
+	&cli.BoolFlag{
+		Name:  "watch-config",
+		Usage: "reload keys, limits, room configurations and webhooks when the config or key file changes. SIGHUP always triggers a reload",
+	},
 	&cli.StringFlag{
 		Name:  "key-file",
 		Usage: "path to file that contains API keys/secrets",
@@ -188,6 +192,11 @@ func main() {
 }
 
 func getConfig(c *cli.Context) (*config.Config, error) {
+	return loadConfig(c, true)
+}
+
+// loadConfig parses the config, at startup it also sets up logging from it
+func loadConfig(c *cli.Context, startup bool) (*config.Config, error) {
 	confString, err := getConfigString(c.String("config"), c.String("config-body"))
 	if err != nil {
 		return nil, err
@@ -202,16 +211,22 @@ func getConfig(c *cli.Context) (*config.Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	config.InitLoggerFromConfig(&conf.Logging)
+	if startup {
+		config.InitLoggerFromConfig(&conf.Logging)
+	}
 
 	if conf.Development {
-		logger.Infow("starting in development mode")
+		if startup {
+			logger.Infow("starting in development mode")
+		}
 
 		if len(conf.Keys) == 0 {
-			logger.Infow("no keys provided, using placeholder keys",
-				"API Key", "devkey",
-				"API Secret", "secret",
-			)
+			if startup {
+				logger.Infow("no keys provided, using placeholder keys",
+					"API Key", "devkey",
+					"API Secret", "secret",
+				)
+			}
 			conf.Keys = map[string]string{
 				"devkey": "secret",
 			}
@@ -281,6 +296,12 @@ func startServer(c *cli.Context) error {
 		return err
 	}
 
+	stopWatching, err := watchConfig(c, server, conf.KeyFile)
+	if err != nil {
+		return err
+	}
+	defer stopWatching()
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 
@@ -0,0 +1,147 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/urfave/cli/v2"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+// editors and config management write files in several steps
+const configWatchDebounce = 500 * time.Millisecond
+
+// watchConfig reloads the config on SIGHUP, and with --watch-config whenever the config or key file changes.
+// The returned function stops watching.
+func watchConfig(c *cli.Context, server *service.LivekitServer, keyFile string) (func(), error) {
+	trigger := make(chan struct{}, 1)
+	requestReload := func() {
+		select {
+		case trigger <- struct{}{}:
+		default:
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	var watcher *fsnotify.Watcher
+	if c.Bool("watch-config") {
+		files := make(map[string]bool)
+		for _, f := range []string{c.String("config"), keyFile} {
+			if f == "" {
+				continue
+			}
+			abs, err := filepath.Abs(f)
+			if err != nil {
+				return nil, err
+			}
+			files[abs] = true
+		}
+		if len(files) == 0 {
+			logger.Warnw("watch-config requires a config or key file", nil)
+		} else {
+			var err error
+			if watcher, err = fsnotify.NewWatcher(); err != nil {
+				return nil, err
+			}
+			// directories are watched, files are often replaced rather than written to
+			dirs := make(map[string]bool)
+			for f := range files {
+				dir := filepath.Dir(f)
+				if !dirs[dir] {
+					dirs[dir] = true
+					if err = watcher.Add(dir); err != nil {
+						_ = watcher.Close()
+						return nil, err
+					}
+				}
+			}
+			go watchConfigFiles(watcher, files, requestReload)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-hup:
+				logger.Infow("reload requested")
+				reloadConfig(c, server)
+			case <-trigger:
+				logger.Infow("config changed, reloading")
+				reloadConfig(c, server)
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(hup)
+		if watcher != nil {
+			_ = watcher.Close()
+		}
+		close(done)
+	}, nil
+}
+
+func watchConfigFiles(watcher *fsnotify.Watcher, files map[string]bool, onChange func()) {
+	var debounce *time.Timer
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			// Kubernetes updates mounted config maps by swapping the ..data symlink
+			if !files[event.Name] && filepath.Base(event.Name) != "..data" {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			if debounce == nil {
+				debounce = time.AfterFunc(configWatchDebounce, onChange)
+			} else {
+				debounce.Reset(configWatchDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Warnw("config watch error", err)
+		}
+	}
+}
+
+func reloadConfig(c *cli.Context, server *service.LivekitServer) {
+	conf, err := loadConfig(c, false)
+	if err == nil {
+		err = server.Reload(conf)
+	}
+	if err != nil {
+		logger.Errorw("config reload rejected, keeping current config", err)
+	}
+}
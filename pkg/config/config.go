@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"regexp"
 	"strings"
 	"time"
 
@@ -25,6 +26,7 @@ import (
 	"github.com/pion/webrtc/v4"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
+	"go.uber.org/atomic"
 	"gopkg.in/yaml.v3"
 
 	"github.com/livekit/livekit-server/pkg/metric"
@@ -56,6 +58,9 @@ type Config struct {
 	Prometheus     PrometheusConfig         `yaml:"prometheus,omitempty"`
 	RTC            RTCConfig                `yaml:"rtc,omitempty"`
 	Redis          redisLiveKit.RedisConfig `yaml:"redis,omitempty"`
+	SQL            SQLConfig                `yaml:"sql,omitempty"`
+	LocalStore     LocalStoreConfig         `yaml:"local_store,omitempty"`
+	RTPCapture     RTPCaptureConfig         `yaml:"rtp_capture,omitempty"`
 	Audio          sfu.AudioConfig          `yaml:"audio,omitempty"`
 	Video          VideoConfig              `yaml:"video,omitempty"`
 	Room           RoomConfig               `yaml:"room,omitempty"`
@@ -73,10 +78,15 @@ type Config struct {
 	LogLevel string        `yaml:"log_level,omitempty"`
 	Logging  LoggingConfig `yaml:"logging,omitempty"`
 	Limit    LimitConfig   `yaml:"limit,omitempty"`
+	// request rate limits, enforced by each node separately
+	RateLimit RateLimitConfig `yaml:"rate_limit,omitempty"`
 
 	Development bool `yaml:"development,omitempty"`
 
 	Metric metric.MetricConfig `yaml:"metric,omitempty"`
+
+	// settings applied by Reload, set by NewConfig
+	reloaded *atomic.Pointer[reloadableConfig]
 }
 
 type RTCConfig struct {
@@ -118,9 +128,25 @@ type RTCConfig struct {
 	// be dropped for a slow data channel to avoid blocking the room.
 	DatachannelSlowThreshold int `yaml:"datachannel_slow_threshold,omitempty"`
 
+	// keep reliable data sent to participants, to replay what they missed when they resume
+	ReliableDataReplay ReliableDataReplayConfig `yaml:"reliable_data_replay,omitempty"`
+
 	ForwardStats ForwardStatsConfig `yaml:"forward_stats,omitempty"`
 }
 
+// ReliableDataReplayConfig stamps the reliable data packets sent to each participant with a sequence number,
+// and keeps the latest ones. Resuming participants report the last sequence number they received,
+// and are sent the packets they missed that are still kept.
+// The sequence number replaces the one set by the sender in DataPacket.sequence, it counts the packets
+// a participant received from all senders instead of the packets a sender sent.
+type ReliableDataReplayConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// packets kept per participant, default 256
+	MaxPackets int `yaml:"max_packets,omitempty"`
+	// bytes kept per participant, default 1MiB
+	MaxBytes int `yaml:"max_bytes,omitempty"`
+}
+
 type TURNServer struct {
 	Host       string `yaml:"host,omitempty"`
 	Port       int    `yaml:"port,omitempty"`
@@ -174,6 +200,197 @@ type RoomConfig struct {
 	// deprecated, moved to limits
 	MaxParticipantIdentityLength int                                   `yaml:"max_participant_identity_length,omitempty"`
 	RoomConfigurations           map[string]*livekit.RoomConfiguration `yaml:"room_configurations,omitempty"`
+	// subscription policies keyed by room configuration name, applied to rooms created with that configuration
+	SubscriptionPolicies map[string][]SubscriptionRule `yaml:"subscription_policies,omitempty"`
+	// lobbies keyed by room configuration name, participants of rooms created with that configuration wait to be admitted
+	Lobbies map[string]LobbyConfig `yaml:"lobbies,omitempty"`
+	// stages keyed by room configuration name, rooms created with that configuration cap their publishers
+	Stages map[string]StageConfig `yaml:"stages,omitempty"`
+	// data filters keyed by room configuration name, data published in rooms created with that configuration goes through them
+	DataFilters map[string]DataFilterConfig `yaml:"data_filters,omitempty"`
+	// chat histories keyed by room configuration name, replayed to participants joining rooms created with that configuration
+	ChatHistories map[string][]ChatHistoryConfig `yaml:"chat_histories,omitempty"`
+	// metadata schemas keyed by room configuration name, attributes and metadata of rooms created with that configuration follow them
+	MetadataSchemas map[string]MetadataSchemaConfig `yaml:"metadata_schemas,omitempty"`
+	// last-N audio keyed by room configuration name, subscribers of rooms created with that configuration only receive the loudest audio tracks
+	LastNAudio map[string]LastNAudioConfig `yaml:"last_n_audio,omitempty"`
+	// stream allocation policies keyed by room configuration name, deciding which tracks get which layers for
+	// subscribers of rooms created with that configuration that are short of bandwidth
+	AllocationPolicies map[string]string `yaml:"allocation_policies,omitempty"`
+	// how long before a scheduled room expires its participants are sent the expiry, on rtc.RoomExpiringTopic
+	ExpiryWarnings []time.Duration `yaml:"expiry_warnings,omitempty"`
+}
+
+// LobbyConfig holds participants joining a room in a lobby, until a room admin admits them.
+// Participants with a room admin grant, hidden participants and non standard participants such as agents skip the lobby.
+type LobbyConfig struct {
+	// number of participants that can wait in the lobby, counted separately from max_participants. unlimited when 0
+	MaxSize uint32 `yaml:"max_size,omitempty" json:"max_size,omitempty"`
+}
+
+// StageConfig limits how many participants of a room can publish at once.
+// Participants take a publisher slot when they are promoted by a room admin, or when they publish and a slot is free,
+// and keep it until they are demoted or leave. Agents and hidden participants do not take slots.
+type StageConfig struct {
+	// number of publisher slots, unlimited when 0
+	MaxPublishers uint32 `yaml:"max_publishers,omitempty" json:"max_publishers,omitempty"`
+}
+
+// LastNAudioConfig forwards to each subscriber the audio of the loudest other speakers only, the other audio tracks
+// stay subscribed but are paused. A forwarded track keeps its place until it has not been among the loudest for
+// min_forward_duration, so that speakers close in level do not take turns. Audio of agents, of screen shares and of
+// participants pinned with the lk.audio_pinned attribute is always forwarded, agents and egress receive all audio.
+// Participants cannot pin themselves, the attribute is set in their token or through the server API.
+type LastNAudioConfig struct {
+	// audio tracks forwarded to each subscriber, on top of the ones always forwarded
+	MaxTracks int `yaml:"max_tracks,omitempty"`
+	// default 2s
+	MinForwardDuration time.Duration `yaml:"min_forward_duration,omitempty"`
+}
+
+func ValidateLastNAudio(conf LastNAudioConfig) error {
+	if conf.MaxTracks <= 0 {
+		return fmt.Errorf("invalid max tracks %d", conf.MaxTracks)
+	}
+	if conf.MinForwardDuration < 0 {
+		return fmt.Errorf("invalid min forward duration %v", conf.MinForwardDuration)
+	}
+	return nil
+}
+
+// DataFilterConfig moderates the data packets participants publish to a room, before they are forwarded.
+// Filters are applied in the order they are listed here, a packet dropped by one is not seen by the next.
+type DataFilterConfig struct {
+	// topics user packets and data streams can be published on, any topic when empty
+	AllowedTopics []string `yaml:"allowed_topics,omitempty"`
+	// data packets each participant can publish per second, unlimited when 0
+	MaxMessageRate float64 `yaml:"max_message_rate,omitempty"`
+	// data packets each participant can publish at once, defaults to the rate rounded up
+	MaxMessageBurst int `yaml:"max_message_burst,omitempty"`
+	// regular expressions whose matches are redacted from chat messages
+	Redact []string `yaml:"redact,omitempty"`
+	// text matches are replaced with, defaults to ***
+	RedactReplacement string `yaml:"redact_replacement,omitempty"`
+	// endpoint deciding on each packet, after the other filters
+	Callback DataFilterCallbackConfig `yaml:"callback,omitempty"`
+}
+
+type DataFilterCallbackConfig struct {
+	// URL packets are posted to, disabled when empty
+	URL string `yaml:"url,omitempty"`
+	// time to wait for a decision, default 200ms
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// forward packets when the endpoint fails or times out, packets are dropped otherwise
+	FailOpen bool `yaml:"fail_open,omitempty"`
+}
+
+func ValidateDataFilter(conf DataFilterConfig) error {
+	for _, expr := range conf.Redact {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid redact expression %q: %v", expr, err)
+		}
+	}
+	if conf.MaxMessageRate < 0 {
+		return fmt.Errorf("invalid max message rate %v", conf.MaxMessageRate)
+	}
+	return nil
+}
+
+// ChatHistoryConfig keeps the latest chat messages of a topic. Chat message packets are kept under the lk.chat topic,
+// text data streams under the topic they are sent on. Messages sent to specific participants are not kept.
+type ChatHistoryConfig struct {
+	Topic string `yaml:"topic,omitempty"`
+	// messages kept, default 100
+	MaxMessages int `yaml:"max_messages,omitempty"`
+	// messages older than this are forgotten, messages are kept until pushed out by newer ones when 0
+	MaxAge time.Duration `yaml:"max_age,omitempty"`
+}
+
+func ValidateChatHistory(topics []ChatHistoryConfig) error {
+	for i, topic := range topics {
+		if topic.Topic == "" {
+			return fmt.Errorf("topic %d: missing topic", i)
+		}
+	}
+	return nil
+}
+
+const (
+	MetadataFieldTypeString  = "string"
+	MetadataFieldTypeNumber  = "number"
+	MetadataFieldTypeBoolean = "boolean"
+	MetadataFieldTypeJSON    = "json"
+)
+
+// MetadataSchemaConfig restricts the participant attributes, participant metadata and room metadata of a room.
+// Metadata with a schema has to be a JSON object, whose keys are checked like attributes. Nothing is restricted when
+// its schema is not set.
+type MetadataSchemaConfig struct {
+	Attributes          *MetadataFieldsConfig `yaml:"attributes,omitempty"`
+	ParticipantMetadata *MetadataFieldsConfig `yaml:"participant_metadata,omitempty"`
+	RoomMetadata        *MetadataFieldsConfig `yaml:"room_metadata,omitempty"`
+}
+
+type MetadataFieldsConfig struct {
+	// keys that can be set, keyed by name
+	Fields map[string]MetadataFieldConfig `yaml:"fields,omitempty"`
+	// keys not listed in fields can be set, by participants too. they are rejected otherwise
+	AllowUnknown bool `yaml:"allow_unknown,omitempty"`
+}
+
+type MetadataFieldConfig struct {
+	// string, number, boolean or json, defaults to string
+	Type string `yaml:"type,omitempty"`
+	// regular expression values have to match in full. attribute values are matched as is, metadata values when they are strings
+	Pattern string `yaml:"pattern,omitempty"`
+	// participants can update the field themselves, only room admins can otherwise. not used for room metadata
+	SelfUpdate bool `yaml:"self_update,omitempty"`
+}
+
+func ValidateMetadataSchema(conf MetadataSchemaConfig) error {
+	for name, fields := range map[string]*MetadataFieldsConfig{
+		"attributes":           conf.Attributes,
+		"participant_metadata": conf.ParticipantMetadata,
+		"room_metadata":        conf.RoomMetadata,
+	} {
+		if fields == nil {
+			continue
+		}
+		for key, field := range fields.Fields {
+			switch field.Type {
+			case "", MetadataFieldTypeString, MetadataFieldTypeNumber, MetadataFieldTypeBoolean, MetadataFieldTypeJSON:
+			default:
+				return fmt.Errorf("%s.%s: invalid type %q", name, key, field.Type)
+			}
+			if _, err := regexp.Compile(field.Pattern); err != nil {
+				return fmt.Errorf("%s.%s: invalid pattern %q: %v", name, key, field.Pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// SubscriptionRule decides who can subscribe to tracks based on participant attributes.
+// Rules of a policy are matched in order, the first rule matching a track applies.
+// Tracks no rule matches are only restricted by the permissions of their publisher.
+type SubscriptionRule struct {
+	// track sources the rule applies to, e.g. camera, microphone, screen_share. all sources when empty
+	Sources []string `yaml:"sources,omitempty" json:"sources,omitempty"`
+	// attributes a publisher needs for the rule to apply, any publisher when empty
+	Publisher map[string]string `yaml:"publisher,omitempty" json:"publisher,omitempty"`
+	// attributes a subscriber needs to be allowed, everyone when empty
+	Subscriber map[string]string `yaml:"subscriber,omitempty" json:"subscriber,omitempty"`
+}
+
+func ValidateSubscriptionRules(rules []SubscriptionRule) error {
+	for i, rule := range rules {
+		for _, source := range rule.Sources {
+			if _, ok := livekit.TrackSource_value[strings.ToUpper(source)]; !ok {
+				return fmt.Errorf("rule %d: unknown track source %q", i, source)
+			}
+		}
+	}
+	return nil
 }
 
 type CodecSpec struct {
@@ -202,6 +419,30 @@ type WebHookConfig struct {
 	URLs []string `yaml:"urls,omitempty"`
 	// key to use for webhook
 	APIKey string `yaml:"api_key,omitempty"`
+	// endpoints with their own filters, signing key and delivery policy, notified in addition to URLs
+	Endpoints []WebHookEndpointConfig `yaml:"endpoints,omitempty"`
+	// directory to persist pending and dead-lettered events in, when neither redis, sql nor a persistent
+	// local store is configured. events are kept in memory only when unset.
+	StoreDir string `yaml:"store_dir,omitempty"`
+}
+
+type WebHookEndpointConfig struct {
+	URL string `yaml:"url,omitempty"`
+	// key to sign requests with, defaults to api_key
+	APIKey string `yaml:"api_key,omitempty"`
+	// event types delivered to this endpoint, e.g. room_finished, all events when empty
+	Events []string `yaml:"events,omitempty"`
+	// glob patterns (as in path.Match) the room name has to match, all rooms when empty
+	Rooms []string `yaml:"rooms,omitempty"`
+	// timeout of a single request
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// number of retries of a failed request and the exponential backoff between them,
+	// events are dead-lettered once retries are exhausted
+	MaxRetries   int           `yaml:"max_retries,omitempty"`
+	RetryWaitMin time.Duration `yaml:"retry_wait_min,omitempty"`
+	RetryWaitMax time.Duration `yaml:"retry_wait_max,omitempty"`
+	// number of events queued in memory for this endpoint, further events wait in the store
+	QueueSize int `yaml:"queue_size,omitempty"`
 }
 
 type NodeSelectorConfig struct {
@@ -239,6 +480,10 @@ type LimitConfig struct {
 	MaxRoomNameLength            int    `yaml:"max_room_name_length,omitempty"`
 	MaxParticipantIdentityLength int    `yaml:"max_participant_identity_length,omitempty"`
 	MaxParticipantNameLength     int    `yaml:"max_participant_name_length,omitempty"`
+	// concurrent participants and rooms for each API key, shared by all nodes when redis is configured.
+	// 0 for no limit
+	MaxParticipantsPerAPIKey int `yaml:"max_participants_per_api_key,omitempty"`
+	MaxRoomsPerAPIKey        int `yaml:"max_rooms_per_api_key,omitempty"`
 }
 
 func (l LimitConfig) CheckRoomNameLength(name string) bool {
@@ -272,6 +517,68 @@ type IngressConfig struct {
 
 type SIPConfig struct{}
 
+type SQLConfig struct {
+	// DSN of the SQLite database used to persist room, participant, egress, ingress, SIP and agent state,
+	// e.g. file:/var/lib/livekit/livekit.db
+	DSN string `yaml:"dsn,omitempty"`
+}
+
+func (c *SQLConfig) IsConfigured() bool {
+	return c.DSN != ""
+}
+
+type LocalStoreConfig struct {
+	// directory to persist rooms and agent dispatches to when running without redis,
+	// state is kept in memory only when empty
+	Dir string `yaml:"dir,omitempty"`
+	// how often the write-ahead log is compacted into a snapshot
+	SnapshotInterval time.Duration `yaml:"snapshot_interval,omitempty"`
+}
+
+func (c *LocalStoreConfig) IsPersistent() bool {
+	return c.Dir != ""
+}
+
+type RTPCaptureConfig struct {
+	// directory packet captures are written to, captures cannot be started when empty
+	Dir string `yaml:"dir,omitempty"`
+	// upper bound for the size of a single capture file
+	MaxBytes int64 `yaml:"max_bytes,omitempty"`
+	// upper bound for how long a single capture runs
+	MaxDuration time.Duration `yaml:"max_duration,omitempty"`
+	// number of captures that can run at the same time
+	MaxActive int `yaml:"max_active,omitempty"`
+	// upper bound for the total size of the capture files in dir, the oldest files are deleted to make room
+	// for new captures, and captures cannot be started while running ones fill it. unlimited when 0
+	MaxTotalBytes int64 `yaml:"max_total_bytes,omitempty"`
+	// capture files in dir are deleted once they are older than this. kept until max_total_bytes is reached when 0
+	MaxAge time.Duration `yaml:"max_age,omitempty"`
+}
+
+func (c *RTPCaptureConfig) IsEnabled() bool {
+	return c.Dir != ""
+}
+
+type RateLimitConfig struct {
+	// token buckets for each API key, applied to authenticated requests.
+	// keyed by Twirp method ("RoomService/CreateRoom"), all methods of a service ("RoomService/*"),
+	// "join" for joining a room over /rtc, WHIP or WHEP, or "*" for anything not listed
+	APIKey map[string]RateLimit `yaml:"api_key,omitempty"`
+	// token buckets for each client IP, same keys as api_key, applied before the request is authenticated
+	ClientIP map[string]RateLimit `yaml:"client_ip,omitempty"`
+}
+
+func (c *RateLimitConfig) IsEnabled() bool {
+	return len(c.APIKey) != 0 || len(c.ClientIP) != 0
+}
+
+type RateLimit struct {
+	// sustained requests per second
+	Rate float64 `yaml:"rate,omitempty"`
+	// requests allowed at once, defaults to the rate rounded up
+	Burst int `yaml:"burst,omitempty"`
+}
+
 type APIConfig struct {
 	// amount of time to wait for API to execute, default 2s
 	ExecutionTimeout time.Duration `yaml:"execution_timeout,omitempty"`
@@ -333,6 +640,16 @@ var DefaultConfig = Config{
 		StreamTrackerManager: sfu.DefaultStreamTrackerManagerConfig,
 	},
 	Redis: redisLiveKit.RedisConfig{},
+	LocalStore: LocalStoreConfig{
+		SnapshotInterval: time.Minute,
+	},
+	RTPCapture: RTPCaptureConfig{
+		MaxBytes:      100 << 20,
+		MaxDuration:   5 * time.Minute,
+		MaxActive:     4,
+		MaxTotalBytes: 1 << 30,
+		MaxAge:        24 * time.Hour,
+	},
 	Room: RoomConfig{
 		AutoCreate: true,
 		EnabledCodecs: []CodecSpec{
@@ -349,6 +666,7 @@ var DefaultConfig = Config{
 		CreateRoomEnabled:  true,
 		CreateRoomTimeout:  10 * time.Second,
 		CreateRoomAttempts: 3,
+		ExpiryWarnings:     []time.Duration{5 * time.Minute, time.Minute, 10 * time.Second},
 	},
 	Limit: LimitConfig{
 		MaxMetadataSize:              64000,
@@ -388,7 +706,9 @@ func NewConfig(confString string, strictMode bool, c *cli.Context, baseFlags []c
 		return nil, err
 	}
 
-	var conf Config
+	conf := Config{
+		reloaded: &atomic.Pointer[reloadableConfig]{},
+	}
 	err = yaml.Unmarshal(marshalled, &conf)
 	if err != nil {
 		return nil, err
@@ -411,6 +731,36 @@ func NewConfig(confString string, strictMode bool, c *cli.Context, baseFlags []c
 	if err := conf.RTC.Validate(conf.Development); err != nil {
 		return nil, fmt.Errorf("could not validate RTC config: %v", err)
 	}
+	for name, rules := range conf.Room.SubscriptionPolicies {
+		if err := ValidateSubscriptionRules(rules); err != nil {
+			return nil, fmt.Errorf("invalid subscription policy %s: %v", name, err)
+		}
+	}
+	for name, filter := range conf.Room.DataFilters {
+		if err := ValidateDataFilter(filter); err != nil {
+			return nil, fmt.Errorf("invalid data filter %s: %v", name, err)
+		}
+	}
+	for name, topics := range conf.Room.ChatHistories {
+		if err := ValidateChatHistory(topics); err != nil {
+			return nil, fmt.Errorf("invalid chat history %s: %v", name, err)
+		}
+	}
+	for name, schema := range conf.Room.MetadataSchemas {
+		if err := ValidateMetadataSchema(schema); err != nil {
+			return nil, fmt.Errorf("invalid metadata schema %s: %v", name, err)
+		}
+	}
+	for name, lastN := range conf.Room.LastNAudio {
+		if err := ValidateLastNAudio(lastN); err != nil {
+			return nil, fmt.Errorf("invalid last-N audio %s: %v", name, err)
+		}
+	}
+	for name, policy := range conf.Room.AllocationPolicies {
+		if _, ok := streamallocator.GetAllocationPolicy(policy); !ok {
+			return nil, fmt.Errorf("invalid allocation policy %s: unknown policy %q", name, policy)
+		}
+	}
 
 	// expand env vars in filenames
 	file, err := homedir.Expand(os.ExpandEnv(conf.KeyFile))
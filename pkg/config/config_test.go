@@ -49,6 +49,51 @@ room:
 	require.Error(t, err)
 }
 
+func TestConfig_Reload(t *testing.T) {
+	conf, err := NewConfig(`keys:
+  key1: secret1
+port: 7880
+limit:
+  num_tracks: 10
+`, true, nil, nil)
+	require.NoError(t, err)
+
+	next, err := NewConfig(`keys:
+  key2: secret2
+port: 7881
+limit:
+  num_tracks: 20
+room:
+  room_configurations:
+    small:
+      max_participants: 2
+`, true, nil, nil)
+	require.NoError(t, err)
+
+	diff, err := conf.Diff(next)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"keys.key1: removed",
+		"keys.key2: added",
+		"limit.num_tracks: 10 -> 20",
+		"room.room_configurations.small.max_participants: 2",
+	}, diff.Reloadable)
+	require.Equal(t, []string{"port: 7880 -> 7881"}, diff.RestartRequired)
+
+	_, ok := conf.GetRoomConfiguration("small")
+	require.False(t, ok)
+
+	require.NoError(t, conf.Reload(next))
+	require.Equal(t, int32(20), conf.GetLimit().NumTracks)
+	rc, ok := conf.GetRoomConfiguration("small")
+	require.True(t, ok)
+	require.Equal(t, uint32(2), rc.MaxParticipants)
+	// only reloadable settings are applied
+	require.Equal(t, uint32(7880), conf.Port)
+
+	require.ErrorIs(t, (&Config{}).Reload(next), ErrReloadNotSupported)
+}
+
 func TestGeneratedFlags(t *testing.T) {
 	generatedFlags, err := GenerateCLIFlags(nil, false)
 	require.NoError(t, err)
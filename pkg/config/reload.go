@@ -0,0 +1,314 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var ErrReloadNotSupported = errors.New("config was not created by NewConfig and cannot be reloaded")
+
+// settings that can be changed without a restart, keys and webhooks are applied by the services using them
+var reloadablePaths = []string{
+	"keys",
+	"key_file",
+	"limit",
+	"webhook",
+	"room.room_configurations",
+	"room.subscription_policies",
+	"room.lobbies",
+	"room.stages",
+	"room.data_filters",
+	"room.chat_histories",
+	"room.metadata_schemas",
+	"room.last_n_audio",
+	"room.allocation_policies",
+	// deprecated, copied to limits
+	"room.max_metadata_size",
+	"room.max_room_name_length",
+	"room.max_participant_identity_length",
+}
+
+type reloadableConfig struct {
+	limit                LimitConfig
+	roomConfigurations   map[string]*livekit.RoomConfiguration
+	subscriptionPolicies map[string][]SubscriptionRule
+	lobbies              map[string]LobbyConfig
+	stages               map[string]StageConfig
+	dataFilters          map[string]DataFilterConfig
+	chatHistories        map[string][]ChatHistoryConfig
+	metadataSchemas      map[string]MetadataSchemaConfig
+	lastNAudio           map[string]LastNAudioConfig
+	allocationPolicies   map[string]string
+}
+
+// GetLimit returns the limits in effect, including changes applied by Reload
+func (conf *Config) GetLimit() LimitConfig {
+	if conf.reloaded != nil {
+		if r := conf.reloaded.Load(); r != nil {
+			return r.limit
+		}
+	}
+	return conf.Limit
+}
+
+// GetRoomConfiguration returns the named room configuration in effect, including changes applied by Reload
+func (conf *Config) GetRoomConfiguration(name string) (*livekit.RoomConfiguration, bool) {
+	roomConfigurations := conf.Room.RoomConfigurations
+	if conf.reloaded != nil {
+		if r := conf.reloaded.Load(); r != nil {
+			roomConfigurations = r.roomConfigurations
+		}
+	}
+	rc, ok := roomConfigurations[name]
+	return rc, ok
+}
+
+// GetSubscriptionPolicy returns the subscription policy of a room configuration in effect, including changes applied by Reload
+func (conf *Config) GetSubscriptionPolicy(name string) ([]SubscriptionRule, bool) {
+	subscriptionPolicies := conf.Room.SubscriptionPolicies
+	if conf.reloaded != nil {
+		if r := conf.reloaded.Load(); r != nil {
+			subscriptionPolicies = r.subscriptionPolicies
+		}
+	}
+	rules, ok := subscriptionPolicies[name]
+	return rules, ok
+}
+
+// GetLobby returns the lobby of a room configuration in effect, including changes applied by Reload
+func (conf *Config) GetLobby(name string) (LobbyConfig, bool) {
+	lobbies := conf.Room.Lobbies
+	if conf.reloaded != nil {
+		if r := conf.reloaded.Load(); r != nil {
+			lobbies = r.lobbies
+		}
+	}
+	lobby, ok := lobbies[name]
+	return lobby, ok
+}
+
+// GetStage returns the stage of a room configuration in effect, including changes applied by Reload
+func (conf *Config) GetStage(name string) (StageConfig, bool) {
+	stages := conf.Room.Stages
+	if conf.reloaded != nil {
+		if r := conf.reloaded.Load(); r != nil {
+			stages = r.stages
+		}
+	}
+	stage, ok := stages[name]
+	return stage, ok
+}
+
+// GetDataFilter returns the data filter of a room configuration in effect, including changes applied by Reload
+func (conf *Config) GetDataFilter(name string) (DataFilterConfig, bool) {
+	dataFilters := conf.Room.DataFilters
+	if conf.reloaded != nil {
+		if r := conf.reloaded.Load(); r != nil {
+			dataFilters = r.dataFilters
+		}
+	}
+	filter, ok := dataFilters[name]
+	return filter, ok
+}
+
+// GetChatHistory returns the chat history topics of a room configuration in effect, including changes applied by Reload
+func (conf *Config) GetChatHistory(name string) ([]ChatHistoryConfig, bool) {
+	chatHistories := conf.Room.ChatHistories
+	if conf.reloaded != nil {
+		if r := conf.reloaded.Load(); r != nil {
+			chatHistories = r.chatHistories
+		}
+	}
+	topics, ok := chatHistories[name]
+	return topics, ok
+}
+
+// GetMetadataSchema returns the metadata schema of a room configuration in effect, including changes applied by Reload
+func (conf *Config) GetMetadataSchema(name string) (MetadataSchemaConfig, bool) {
+	metadataSchemas := conf.Room.MetadataSchemas
+	if conf.reloaded != nil {
+		if r := conf.reloaded.Load(); r != nil {
+			metadataSchemas = r.metadataSchemas
+		}
+	}
+	schema, ok := metadataSchemas[name]
+	return schema, ok
+}
+
+// GetLastNAudio returns the last-N audio of a room configuration in effect, including changes applied by Reload
+func (conf *Config) GetLastNAudio(name string) (LastNAudioConfig, bool) {
+	lastNAudio := conf.Room.LastNAudio
+	if conf.reloaded != nil {
+		if r := conf.reloaded.Load(); r != nil {
+			lastNAudio = r.lastNAudio
+		}
+	}
+	lastN, ok := lastNAudio[name]
+	return lastN, ok
+}
+
+// GetAllocationPolicy returns the name of the allocation policy of a room configuration in effect, including changes applied by Reload
+func (conf *Config) GetAllocationPolicy(name string) (string, bool) {
+	allocationPolicies := conf.Room.AllocationPolicies
+	if conf.reloaded != nil {
+		if r := conf.reloaded.Load(); r != nil {
+			allocationPolicies = r.allocationPolicies
+		}
+	}
+	policy, ok := allocationPolicies[name]
+	return policy, ok
+}
+
+// Reload atomically applies the limits, named room configurations, subscription policies, lobbies, stages,
+// data filters, chat histories, metadata schemas, last-N audio and allocation policies of next. conf itself is left
+// unchanged, readers see the new values through GetLimit, GetRoomConfiguration, GetSubscriptionPolicy, GetLobby,
+// GetStage, GetDataFilter, GetChatHistory, GetMetadataSchema, GetLastNAudio and GetAllocationPolicy.
+func (conf *Config) Reload(next *Config) error {
+	if conf.reloaded == nil {
+		return ErrReloadNotSupported
+	}
+	conf.reloaded.Store(&reloadableConfig{
+		limit:                next.Limit,
+		roomConfigurations:   next.Room.RoomConfigurations,
+		subscriptionPolicies: next.Room.SubscriptionPolicies,
+		lobbies:              next.Room.Lobbies,
+		stages:               next.Room.Stages,
+		dataFilters:          next.Room.DataFilters,
+		chatHistories:        next.Room.ChatHistories,
+		metadataSchemas:      next.Room.MetadataSchemas,
+		lastNAudio:           next.Room.LastNAudio,
+		allocationPolicies:   next.Room.AllocationPolicies,
+	})
+	return nil
+}
+
+// ConfigDiff lists the settings that differ between two configs, as yaml paths with old and new values.
+// secrets are never included.
+type ConfigDiff struct {
+	// applied by a reload
+	Reloadable []string
+	// only applied after a restart
+	RestartRequired []string
+}
+
+func (d ConfigDiff) IsEmpty() bool {
+	return len(d.Reloadable) == 0 && len(d.RestartRequired) == 0
+}
+
+// Diff compares conf to next
+func (conf *Config) Diff(next *Config) (ConfigDiff, error) {
+	prev, err := flattenConfig(conf)
+	if err != nil {
+		return ConfigDiff{}, err
+	}
+	curr, err := flattenConfig(next)
+	if err != nil {
+		return ConfigDiff{}, err
+	}
+
+	paths := make(map[string]struct{}, len(prev)+len(curr))
+	for p := range prev {
+		paths[p] = struct{}{}
+	}
+	for p := range curr {
+		paths[p] = struct{}{}
+	}
+
+	var diff ConfigDiff
+	for p := range paths {
+		old, hadOld := prev[p]
+		value, hasNew := curr[p]
+		if hadOld && hasNew && reflect.DeepEqual(old, value) {
+			continue
+		}
+
+		var change string
+		switch {
+		case strings.HasPrefix(p, "keys.") || isSecretPath(p):
+			switch {
+			case !hadOld:
+				change = p + ": added"
+			case !hasNew:
+				change = p + ": removed"
+			default:
+				change = p + ": changed"
+			}
+		case !hadOld:
+			change = fmt.Sprintf("%s: %v", p, value)
+		case !hasNew:
+			change = fmt.Sprintf("%s: %v -> unset", p, old)
+		default:
+			change = fmt.Sprintf("%s: %v -> %v", p, old, value)
+		}
+
+		if isReloadablePath(p) {
+			diff.Reloadable = append(diff.Reloadable, change)
+		} else {
+			diff.RestartRequired = append(diff.RestartRequired, change)
+		}
+	}
+	sort.Strings(diff.Reloadable)
+	sort.Strings(diff.RestartRequired)
+	return diff, nil
+}
+
+func isReloadablePath(p string) bool {
+	for _, r := range reloadablePaths {
+		if p == r || strings.HasPrefix(p, r+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func isSecretPath(p string) bool {
+	name := p[strings.LastIndex(p, ".")+1:]
+	return name == "password" || strings.HasSuffix(name, "secret")
+}
+
+// flattenConfig maps yaml paths to values, lists are compared as a whole
+func flattenConfig(conf *Config) (map[string]any, error) {
+	data, err := yaml.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err = yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	flat := make(map[string]any)
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		if children, ok := v.(map[string]any); ok && len(children) != 0 {
+			for k, child := range children {
+				walk(prefix+"."+k, child)
+			}
+			return
+		}
+		flat[prefix[1:]] = v
+	}
+	walk("", m)
+	return flat, nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
+)
+
+// SetAllocationPolicy changes how layers are allocated to the tracks subscribed by participants short of bandwidth,
+// for participants in the room and the ones joining it
+func (r *Room) SetAllocationPolicy(name string) error {
+	policy, ok := streamallocator.GetAllocationPolicy(name)
+	if !ok {
+		return ErrUnknownAllocationPolicy
+	}
+
+	r.lock.Lock()
+	r.allocationPolicy = policy
+	r.lock.Unlock()
+
+	for _, p := range r.GetParticipants() {
+		p.SetSubscriberAllocationPolicy(policy)
+	}
+	return nil
+}
+
+// updateAllocationSpeakers is called by the audio update worker when active speakers change,
+// for policies favouring tracks of active speakers
+func (r *Room) updateAllocationSpeakers(speakers []*livekit.SpeakerInfo) {
+	r.lock.RLock()
+	policy := r.allocationPolicy
+	r.lock.RUnlock()
+	if policy == nil || !policy.UsesActiveSpeakers() {
+		return
+	}
+
+	for _, p := range r.GetParticipants() {
+		p.SetSubscriberActiveSpeakers(speakers)
+	}
+}
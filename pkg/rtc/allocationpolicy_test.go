@@ -0,0 +1,54 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
+)
+
+func TestRoomAllocationPolicy(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+	existing := rm.GetParticipants()[0].(*typesfakes.FakeLocalParticipant)
+
+	require.ErrorIs(t, rm.SetAllocationPolicy("unknown"), ErrUnknownAllocationPolicy)
+	require.Zero(t, existing.SetSubscriberAllocationPolicyCallCount())
+
+	require.NoError(t, rm.SetAllocationPolicy(streamallocator.AllocationPolicyEqualShare))
+	require.Equal(t, 1, existing.SetSubscriberAllocationPolicyCallCount())
+	require.Equal(t, streamallocator.AllocationPolicyEqualShare, existing.SetSubscriberAllocationPolicyArgsForCall(0).Name())
+
+	// participants joining get the policy of the room
+	joining := NewMockParticipant("joining", types.CurrentProtocol, false, false)
+	require.NoError(t, rm.Join(joining, nil, nil, iceServersForRoom))
+	require.Equal(t, 1, joining.SetSubscriberAllocationPolicyCallCount())
+
+	speakers := []*livekit.SpeakerInfo{{Sid: string(existing.ID()), Level: 0.5, Active: true}}
+	rm.updateAllocationSpeakers(speakers)
+	require.Zero(t, existing.SetSubscriberActiveSpeakersCallCount())
+
+	// active speakers are only followed by policies using them
+	require.NoError(t, rm.SetAllocationPolicy(streamallocator.AllocationPolicyActiveSpeakerFirst))
+	rm.updateAllocationSpeakers(speakers)
+	require.Equal(t, 1, joining.SetSubscriberActiveSpeakersCallCount())
+	require.Equal(t, speakers, joining.SetSubscriberActiveSpeakersArgsForCall(0))
+}
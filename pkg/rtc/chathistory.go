@@ -0,0 +1,290 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// topic chat message packets are kept under
+	ChatTopic = "lk.chat"
+
+	defaultChatHistoryMaxMessages = 100
+	// text streams larger than this are not kept
+	maxChatHistoryStreamSize = 64 * 1024
+	// text streams being received at once, the oldest is forgotten past this
+	maxChatHistoryPendingStreams = 64
+)
+
+// ChatHistoryMessage is a chat message or a completed text stream kept for participants joining later
+type ChatHistoryMessage struct {
+	Topic string
+	// chat message or stream ID
+	ID                  string
+	ParticipantIdentity livekit.ParticipantIdentity
+	ReceivedAt          time.Time
+	Text                string
+
+	// encoded data packets replayed to joining participants
+	packets [][]byte
+	size    int
+}
+
+type chatHistoryTopic struct {
+	conf     config.ChatHistoryConfig
+	messages []*ChatHistoryMessage
+}
+
+type pendingTextStream struct {
+	message   *ChatHistoryMessage
+	startedAt time.Time
+}
+
+// ChatHistory records the latest chat messages and text streams of the configured topics
+type ChatHistory struct {
+	logger logger.Logger
+
+	lock    sync.Mutex
+	topics  map[string]*chatHistoryTopic
+	pending map[string]*pendingTextStream
+}
+
+func NewChatHistory(topics []config.ChatHistoryConfig, logger logger.Logger) *ChatHistory {
+	h := &ChatHistory{
+		logger:  logger,
+		topics:  make(map[string]*chatHistoryTopic, len(topics)),
+		pending: make(map[string]*pendingTextStream),
+	}
+	for _, conf := range topics {
+		if conf.MaxMessages <= 0 {
+			conf.MaxMessages = defaultChatHistoryMaxMessages
+		}
+		h.topics[conf.Topic] = &chatHistoryTopic{conf: conf}
+	}
+	return h
+}
+
+// Record keeps a data packet forwarded to the whole room, when it is a chat message or part of a text stream
+// on a configured topic
+func (h *ChatHistory) Record(dp *livekit.DataPacket) {
+	if len(dp.DestinationIdentities) != 0 {
+		return
+	}
+
+	now := time.Now()
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	switch payload := dp.Value.(type) {
+	case *livekit.DataPacket_ChatMessage:
+		t := h.topics[ChatTopic]
+		if t == nil || payload.ChatMessage == nil {
+			return
+		}
+		h.recordChatMessageLocked(t, dp, payload.ChatMessage, now)
+
+	case *livekit.DataPacket_StreamHeader:
+		header := payload.StreamHeader
+		if header == nil || header.GetTextHeader() == nil || h.topics[header.Topic] == nil {
+			return
+		}
+		h.startStreamLocked(dp, header, now)
+
+	case *livekit.DataPacket_StreamChunk:
+		ps := h.pending[payload.StreamChunk.GetStreamId()]
+		if ps == nil {
+			return
+		}
+		if !h.appendLocked(ps.message, dp) {
+			delete(h.pending, payload.StreamChunk.GetStreamId())
+			return
+		}
+		ps.message.Text += string(payload.StreamChunk.Content)
+
+	case *livekit.DataPacket_StreamTrailer:
+		streamID := payload.StreamTrailer.GetStreamId()
+		ps := h.pending[streamID]
+		if ps == nil {
+			return
+		}
+		delete(h.pending, streamID)
+		if !h.appendLocked(ps.message, dp) {
+			return
+		}
+		ps.message.ReceivedAt = now
+		h.pushLocked(h.topics[ps.message.Topic], ps.message, now)
+	}
+}
+
+func (h *ChatHistory) recordChatMessageLocked(t *chatHistoryTopic, dp *livekit.DataPacket, chat *livekit.ChatMessage, now time.Time) {
+	m := &ChatHistoryMessage{
+		Topic:               ChatTopic,
+		ID:                  chat.Id,
+		ParticipantIdentity: livekit.ParticipantIdentity(dp.ParticipantIdentity),
+		ReceivedAt:          now,
+		Text:                chat.Message,
+	}
+
+	// edits and deletions apply to the message kept, in place, when they come from its sender
+	if idx := slices.IndexFunc(t.messages, func(m *ChatHistoryMessage) bool { return m.ID == chat.Id }); idx >= 0 {
+		if t.messages[idx].ParticipantIdentity != m.ParticipantIdentity {
+			return
+		}
+		if chat.Deleted {
+			t.messages = slices.Delete(t.messages, idx, idx+1)
+			return
+		}
+		m.ReceivedAt = t.messages[idx].ReceivedAt
+		if h.appendLocked(m, dp) {
+			t.messages[idx] = m
+		}
+		return
+	}
+	if chat.Deleted {
+		return
+	}
+	if h.appendLocked(m, dp) {
+		h.pushLocked(t, m, now)
+	}
+}
+
+func (h *ChatHistory) startStreamLocked(dp *livekit.DataPacket, header *livekit.DataStream_Header, now time.Time) {
+	if len(h.pending) >= maxChatHistoryPendingStreams {
+		var oldestID string
+		var oldest *pendingTextStream
+		for id, ps := range h.pending {
+			if oldest == nil || ps.startedAt.Before(oldest.startedAt) {
+				oldestID, oldest = id, ps
+			}
+		}
+		delete(h.pending, oldestID)
+	}
+
+	m := &ChatHistoryMessage{
+		Topic:               header.Topic,
+		ID:                  header.StreamId,
+		ParticipantIdentity: livekit.ParticipantIdentity(dp.ParticipantIdentity),
+	}
+	if h.appendLocked(m, dp) {
+		h.pending[header.StreamId] = &pendingTextStream{message: m, startedAt: now}
+	}
+}
+
+// appendLocked adds an encoded packet to a message, it returns false when the message grew too large to be kept
+func (h *ChatHistory) appendLocked(m *ChatHistoryMessage, dp *livekit.DataPacket) bool {
+	data, err := proto.Marshal(dp)
+	if err != nil {
+		h.logger.Warnw("could not marshal chat history packet", err)
+		return false
+	}
+	m.size += len(data)
+	if m.size > maxChatHistoryStreamSize {
+		return false
+	}
+	m.packets = append(m.packets, data)
+	return true
+}
+
+func (h *ChatHistory) pushLocked(t *chatHistoryTopic, m *ChatHistoryMessage, now time.Time) {
+	t.messages = append(t.messages, m)
+	if len(t.messages) > t.conf.MaxMessages {
+		t.messages = slices.Delete(t.messages, 0, len(t.messages)-t.conf.MaxMessages)
+	}
+	h.expireLocked(t, now)
+}
+
+func (h *ChatHistory) expireLocked(t *chatHistoryTopic, now time.Time) {
+	if t.conf.MaxAge <= 0 {
+		return
+	}
+	idx := slices.IndexFunc(t.messages, func(m *ChatHistoryMessage) bool { return now.Sub(m.ReceivedAt) < t.conf.MaxAge })
+	if idx < 0 {
+		idx = len(t.messages)
+	}
+	t.messages = slices.Delete(t.messages, 0, idx)
+}
+
+// Messages returns the messages kept for a topic, or for all topics when empty, oldest first
+func (h *ChatHistory) Messages(topic string) []*ChatHistoryMessage {
+	now := time.Now()
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	var messages []*ChatHistoryMessage
+	for name, t := range h.topics {
+		if topic != "" && name != topic {
+			continue
+		}
+		h.expireLocked(t, now)
+		messages = append(messages, t.messages...)
+	}
+	slices.SortStableFunc(messages, func(a, b *ChatHistoryMessage) int {
+		return a.ReceivedAt.Compare(b.ReceivedAt)
+	})
+	return messages
+}
+
+// Replay sends the messages kept to a participant, over the reliable data channel
+func (h *ChatHistory) Replay(p types.LocalParticipant) {
+	messages := h.Messages("")
+	if len(messages) == 0 {
+		return
+	}
+	for _, m := range messages {
+		for _, data := range m.packets {
+			if err := p.SendDataPacket(livekit.DataPacket_RELIABLE, data); err != nil {
+				p.GetLogger().Warnw("could not replay chat history", err)
+				return
+			}
+		}
+	}
+	p.GetLogger().Debugw("replayed chat history", "messages", len(messages))
+}
+
+// ---------------------------------------------------------------
+
+// SetChatHistory starts keeping the latest messages of topics, replacing the history kept so far
+func (r *Room) SetChatHistory(topics []config.ChatHistoryConfig) {
+	r.lock.Lock()
+	r.chatHistory = NewChatHistory(topics, r.Logger)
+	r.lock.Unlock()
+}
+
+// GetChatHistory returns the history of the room, nil when it keeps none
+func (r *Room) GetChatHistory() *ChatHistory {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.chatHistory
+}
+
+func (r *Room) replayChatHistory(p types.LocalParticipant) {
+	if r.IsInLobby(p.Identity()) {
+		return
+	}
+	if h := r.GetChatHistory(); h != nil {
+		h.Replay(p)
+	}
+}
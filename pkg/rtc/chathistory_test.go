@@ -0,0 +1,161 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+func chatMessage(id string, message string) *livekit.DataPacket {
+	return &livekit.DataPacket{
+		ParticipantIdentity: "sender",
+		Value:               &livekit.DataPacket_ChatMessage{ChatMessage: &livekit.ChatMessage{Id: id, Message: message}},
+	}
+}
+
+func textStream(streamID string, topic string, chunks ...string) []*livekit.DataPacket {
+	packets := []*livekit.DataPacket{{
+		ParticipantIdentity: "sender",
+		Value: &livekit.DataPacket_StreamHeader{StreamHeader: &livekit.DataStream_Header{
+			StreamId:      streamID,
+			Topic:         topic,
+			ContentHeader: &livekit.DataStream_Header_TextHeader{TextHeader: &livekit.DataStream_TextHeader{}},
+		}},
+	}}
+	for i, c := range chunks {
+		packets = append(packets, &livekit.DataPacket{
+			Value: &livekit.DataPacket_StreamChunk{StreamChunk: &livekit.DataStream_Chunk{StreamId: streamID, ChunkIndex: uint64(i), Content: []byte(c)}},
+		})
+	}
+	return append(packets, &livekit.DataPacket{
+		Value: &livekit.DataPacket_StreamTrailer{StreamTrailer: &livekit.DataStream_Trailer{StreamId: streamID}},
+	})
+}
+
+func TestChatHistory(t *testing.T) {
+	t.Run("chat messages", func(t *testing.T) {
+		h := NewChatHistory([]config.ChatHistoryConfig{{Topic: ChatTopic, MaxMessages: 2}}, logger.GetLogger())
+		h.Record(chatMessage("1", "one"))
+		h.Record(chatMessage("2", "two"))
+
+		// messages to specific participants are not kept
+		private := chatMessage("3", "private")
+		private.DestinationIdentities = []string{"other"}
+		h.Record(private)
+
+		// edits replace the message in place
+		edited := chatMessage("1", "one, edited")
+		edited.GetChatMessage().EditTimestamp = proto.Int64(time.Now().UnixMilli())
+		h.Record(edited)
+		messages := h.Messages("")
+		require.Len(t, messages, 2)
+		require.Equal(t, "one, edited", messages[0].Text)
+		require.Equal(t, "two", messages[1].Text)
+		require.Equal(t, livekit.ParticipantIdentity("sender"), messages[0].ParticipantIdentity)
+
+		// only the sender edits or deletes a message
+		forged := chatMessage("1", "forged")
+		forged.ParticipantIdentity = "other"
+		forged.GetChatMessage().EditTimestamp = proto.Int64(time.Now().UnixMilli())
+		h.Record(forged)
+		forged = chatMessage("2", "")
+		forged.ParticipantIdentity = "other"
+		forged.GetChatMessage().Deleted = true
+		h.Record(forged)
+		messages = h.Messages("")
+		require.Len(t, messages, 2)
+		require.Equal(t, "one, edited", messages[0].Text)
+		require.Equal(t, "two", messages[1].Text)
+
+		// oldest are pushed out
+		h.Record(chatMessage("4", "four"))
+		messages = h.Messages(ChatTopic)
+		require.Len(t, messages, 2)
+		require.Equal(t, "two", messages[0].Text)
+
+		deleted := chatMessage("4", "")
+		deleted.GetChatMessage().Deleted = true
+		h.Record(deleted)
+		require.Len(t, h.Messages(""), 1)
+	})
+
+	t.Run("text streams", func(t *testing.T) {
+		h := NewChatHistory([]config.ChatHistoryConfig{{Topic: "notes"}}, logger.GetLogger())
+		for _, dp := range textStream("s1", "notes", "hello ", "world") {
+			require.Empty(t, h.Messages(""))
+			h.Record(dp)
+		}
+		for _, dp := range textStream("s2", "other", "ignored") {
+			h.Record(dp)
+		}
+		h.Record(chatMessage("1", "not kept without the lk.chat topic"))
+
+		messages := h.Messages("")
+		require.Len(t, messages, 1)
+		require.Equal(t, "notes", messages[0].Topic)
+		require.Equal(t, "s1", messages[0].ID)
+		require.Equal(t, "hello world", messages[0].Text)
+		require.Len(t, messages[0].packets, 4)
+		require.Empty(t, h.pending)
+	})
+
+	t.Run("max age", func(t *testing.T) {
+		h := NewChatHistory([]config.ChatHistoryConfig{{Topic: ChatTopic, MaxAge: time.Minute}}, logger.GetLogger())
+		h.Record(chatMessage("1", "old"))
+		h.Record(chatMessage("2", "new"))
+		h.topics[ChatTopic].messages[0].ReceivedAt = time.Now().Add(-2 * time.Minute)
+		messages := h.Messages("")
+		require.Len(t, messages, 1)
+		require.Equal(t, "new", messages[0].Text)
+	})
+
+	t.Run("pending streams are bounded", func(t *testing.T) {
+		h := NewChatHistory([]config.ChatHistoryConfig{{Topic: "notes"}}, logger.GetLogger())
+		for i := 0; i < maxChatHistoryPendingStreams+10; i++ {
+			h.Record(textStream(fmt.Sprintf("s%d", i), "notes")[0])
+		}
+		require.Len(t, h.pending, maxChatHistoryPendingStreams)
+	})
+}
+
+func TestRoomChatHistory(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+	rm.SetChatHistory([]config.ChatHistoryConfig{{Topic: ChatTopic}})
+	source := rm.GetParticipants()[0].(types.LocalParticipant)
+
+	rm.onDataPacket(source, livekit.DataPacket_RELIABLE, chatMessage("1", "before you joined"))
+	rm.onDataPacket(source, livekit.DataPacket_RELIABLE, userPacket("lk.chat", "not a chat message"))
+	require.Len(t, rm.GetChatHistory().Messages(""), 1)
+
+	late := NewMockParticipant("late", types.CurrentProtocol, false, false)
+	rm.replayChatHistory(late)
+	require.Equal(t, 1, late.SendDataPacketCallCount())
+	kind, data := late.SendDataPacketArgsForCall(0)
+	require.Equal(t, livekit.DataPacket_RELIABLE, kind)
+	dp := &livekit.DataPacket{}
+	require.NoError(t, proto.Unmarshal(data, dp))
+	require.Equal(t, "before you joined", dp.GetChatMessage().Message)
+}
@@ -49,6 +49,11 @@ func (c ClientInfo) SupportsAudioRED() bool {
 	return !c.isFirefox() && !c.isSafari()
 }
 
+// GoSDK(pion) does not group FEC-FR ssrcs in a remote offer and fails negotiation with them
+func (c ClientInfo) SupportsFlexFEC() bool {
+	return !c.isGo()
+}
+
 func (c ClientInfo) SupportPrflxOverRelay() bool {
 	return !c.isFirefox()
 }
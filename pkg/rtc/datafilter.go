@@ -0,0 +1,422 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	defaultRedactReplacement         = "***"
+	defaultDataFilterCallbackTimeout = 200 * time.Millisecond
+	maxDataFilterCallbackResponse    = 1 << 20
+
+	DataFilterActionAllow = "allow"
+	DataFilterActionDrop  = "drop"
+)
+
+// DataPacketFilter moderates a data packet published by a participant, before it is forwarded to the room.
+// It returns the packet to forward, either dp, possibly modified in place, or a replacement, or nil to drop it.
+// Filters are called concurrently for packets of different participants.
+type DataPacketFilter interface {
+	FilterDataPacket(source types.LocalParticipant, dp *livekit.DataPacket) *livekit.DataPacket
+}
+
+// ParticipantStateFilter is implemented by filters keeping state per participant, to forget a participant
+// once it left the room
+type ParticipantStateFilter interface {
+	RemoveParticipant(pID livekit.ParticipantID)
+}
+
+// DataPacketFilters applies filters in order, until one drops the packet
+type DataPacketFilters []DataPacketFilter
+
+func (fs DataPacketFilters) FilterDataPacket(source types.LocalParticipant, dp *livekit.DataPacket) *livekit.DataPacket {
+	for _, f := range fs {
+		if dp = f.FilterDataPacket(source, dp); dp == nil {
+			return nil
+		}
+	}
+	return dp
+}
+
+func (fs DataPacketFilters) RemoveParticipant(pID livekit.ParticipantID) {
+	for _, f := range fs {
+		if sf, ok := f.(ParticipantStateFilter); ok {
+			sf.RemoveParticipant(pID)
+		}
+	}
+}
+
+// NewDataPacketFilter builds the filters of a data filter config, in the order they are documented in the config
+func NewDataPacketFilter(roomName livekit.RoomName, conf config.DataFilterConfig, logger logger.Logger) (DataPacketFilter, error) {
+	var filters DataPacketFilters
+	if len(conf.AllowedTopics) != 0 {
+		filters = append(filters, NewTopicAllowlistFilter(conf.AllowedTopics))
+	}
+	if conf.MaxMessageRate > 0 {
+		filters = append(filters, NewRateLimitFilter(conf.MaxMessageRate, conf.MaxMessageBurst))
+	}
+	if len(conf.Redact) != 0 {
+		f, err := NewRedactFilter(conf.Redact, conf.RedactReplacement)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	if conf.Callback.URL != "" {
+		filters = append(filters, NewCallbackFilter(roomName, conf.Callback, logger))
+	}
+	return filters, nil
+}
+
+// SetDataFilter replaces the filters data packets published to the room go through
+func (r *Room) SetDataFilter(conf config.DataFilterConfig) error {
+	filter, err := NewDataPacketFilter(r.Name(), conf, r.Logger)
+	if err != nil {
+		return err
+	}
+	r.SetDataPacketFilter(filter)
+	return nil
+}
+
+// SetDataPacketFilter replaces the filter data packets published to the room go through, nil removes it
+func (r *Room) SetDataPacketFilter(filter DataPacketFilter) {
+	r.lock.Lock()
+	r.dataPacketFilter = filter
+	r.lock.Unlock()
+}
+
+func (r *Room) getDataPacketFilter() DataPacketFilter {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.dataPacketFilter
+}
+
+func (r *Room) removeDataPacketFilterParticipantLocked(pID livekit.ParticipantID) {
+	if sf, ok := r.dataPacketFilter.(ParticipantStateFilter); ok {
+		sf.RemoveParticipant(pID)
+	}
+}
+
+// ---------------------------------------------------------------
+
+// TopicAllowlistFilter drops user packets and data streams published on topics not listed.
+// Packets without a topic are dropped unless the empty topic is listed, other packet types are not affected.
+type TopicAllowlistFilter struct {
+	topics []string
+
+	lock sync.Mutex
+	// streams of each participant whose header was dropped, their chunks and trailer are dropped too.
+	// streams never finished are forgotten when their participant leaves
+	droppedStreams map[livekit.ParticipantID]map[string]struct{}
+}
+
+func NewTopicAllowlistFilter(topics []string) *TopicAllowlistFilter {
+	return &TopicAllowlistFilter{
+		topics:         topics,
+		droppedStreams: make(map[livekit.ParticipantID]map[string]struct{}),
+	}
+}
+
+func (f *TopicAllowlistFilter) FilterDataPacket(source types.LocalParticipant, dp *livekit.DataPacket) *livekit.DataPacket {
+	switch payload := dp.Value.(type) {
+	case *livekit.DataPacket_User:
+		if !slices.Contains(f.topics, payload.User.GetTopic()) {
+			return nil
+		}
+	case *livekit.DataPacket_StreamHeader:
+		if !slices.Contains(f.topics, payload.StreamHeader.GetTopic()) {
+			f.lock.Lock()
+			streams := f.droppedStreams[source.ID()]
+			if streams == nil {
+				streams = make(map[string]struct{})
+				f.droppedStreams[source.ID()] = streams
+			}
+			streams[payload.StreamHeader.GetStreamId()] = struct{}{}
+			f.lock.Unlock()
+			return nil
+		}
+	case *livekit.DataPacket_StreamChunk:
+		f.lock.Lock()
+		_, dropped := f.droppedStreams[source.ID()][payload.StreamChunk.GetStreamId()]
+		f.lock.Unlock()
+		if dropped {
+			return nil
+		}
+	case *livekit.DataPacket_StreamTrailer:
+		f.lock.Lock()
+		streams := f.droppedStreams[source.ID()]
+		_, dropped := streams[payload.StreamTrailer.GetStreamId()]
+		delete(streams, payload.StreamTrailer.GetStreamId())
+		if dropped && len(streams) == 0 {
+			delete(f.droppedStreams, source.ID())
+		}
+		f.lock.Unlock()
+		if dropped {
+			return nil
+		}
+	}
+	return dp
+}
+
+func (f *TopicAllowlistFilter) RemoveParticipant(pID livekit.ParticipantID) {
+	f.lock.Lock()
+	delete(f.droppedStreams, pID)
+	f.lock.Unlock()
+}
+
+// ---------------------------------------------------------------
+
+type dataRateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimitFilter drops the data packets of participants publishing faster than a token bucket allows
+type RateLimitFilter struct {
+	rate  float64
+	burst float64
+
+	lock      sync.Mutex
+	buckets   map[livekit.ParticipantID]*dataRateBucket
+	lastSweep time.Time
+}
+
+func NewRateLimitFilter(rate float64, burst int) *RateLimitFilter {
+	if burst <= 0 {
+		burst = int(math.Ceil(rate))
+	}
+	return &RateLimitFilter{
+		rate:      rate,
+		burst:     float64(burst),
+		buckets:   make(map[livekit.ParticipantID]*dataRateBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (f *RateLimitFilter) FilterDataPacket(source types.LocalParticipant, dp *livekit.DataPacket) *livekit.DataPacket {
+	if f.allow(source.ID(), time.Now()) {
+		return dp
+	}
+	source.GetLogger().Debugw("data packet dropped, rate limited")
+	return nil
+}
+
+func (f *RateLimitFilter) allow(pID livekit.ParticipantID, now time.Time) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.sweepLocked(now)
+
+	b := f.buckets[pID]
+	if b == nil {
+		b = &dataRateBucket{tokens: f.burst, last: now}
+		f.buckets[pID] = b
+	} else {
+		b.tokens = math.Min(f.burst, b.tokens+now.Sub(b.last).Seconds()*f.rate)
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (f *RateLimitFilter) RemoveParticipant(pID livekit.ParticipantID) {
+	f.lock.Lock()
+	delete(f.buckets, pID)
+	f.lock.Unlock()
+}
+
+// sweepLocked forgets buckets that refilled, they are no different from a new one
+func (f *RateLimitFilter) sweepLocked(now time.Time) {
+	refill := time.Duration(f.burst / f.rate * float64(time.Second))
+	if now.Sub(f.lastSweep) < max(refill, time.Minute) {
+		return
+	}
+	f.lastSweep = now
+	for pID, b := range f.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(f.buckets, pID)
+		}
+	}
+}
+
+// ---------------------------------------------------------------
+
+// RedactFilter replaces the text of chat messages matching any of its expressions
+type RedactFilter struct {
+	exprs       []*regexp.Regexp
+	replacement string
+}
+
+func NewRedactFilter(exprs []string, replacement string) (*RedactFilter, error) {
+	f := &RedactFilter{
+		replacement: replacement,
+	}
+	if f.replacement == "" {
+		f.replacement = defaultRedactReplacement
+	}
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact expression %q: %w", expr, err)
+		}
+		f.exprs = append(f.exprs, re)
+	}
+	return f, nil
+}
+
+func (f *RedactFilter) FilterDataPacket(_ types.LocalParticipant, dp *livekit.DataPacket) *livekit.DataPacket {
+	if chat := dp.GetChatMessage(); chat != nil {
+		for _, re := range f.exprs {
+			chat.Message = re.ReplaceAllLiteralString(chat.Message, f.replacement)
+		}
+	}
+	return dp
+}
+
+// ---------------------------------------------------------------
+
+type DataFilterCallbackRequest struct {
+	Room                string          `json:"room"`
+	ParticipantIdentity string          `json:"participant_identity"`
+	Packet              json.RawMessage `json:"packet"`
+}
+
+type DataFilterCallbackResponse struct {
+	Action string `json:"action"`
+	// replaces the packet when set, senders cannot be changed
+	Packet json.RawMessage `json:"packet,omitempty"`
+}
+
+// CallbackFilter posts each data packet to an endpoint, which allows, drops or rewrites it.
+// Packets are held until the endpoint answers or the timeout expires, when they are forwarded or dropped
+// depending on fail open.
+type CallbackFilter struct {
+	roomName livekit.RoomName
+	conf     config.DataFilterCallbackConfig
+	client   *http.Client
+	logger   logger.Logger
+}
+
+func NewCallbackFilter(roomName livekit.RoomName, conf config.DataFilterCallbackConfig, logger logger.Logger) *CallbackFilter {
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultDataFilterCallbackTimeout
+	}
+	return &CallbackFilter{
+		roomName: roomName,
+		conf:     conf,
+		client:   &http.Client{Timeout: conf.Timeout},
+		logger:   logger,
+	}
+}
+
+func (f *CallbackFilter) FilterDataPacket(source types.LocalParticipant, dp *livekit.DataPacket) *livekit.DataPacket {
+	res, err := f.call(source, dp)
+	if err != nil {
+		f.logger.Warnw("data filter callback failed", err, "participant", source.Identity(), "failOpen", f.conf.FailOpen)
+		if f.conf.FailOpen {
+			return dp
+		}
+		return nil
+	}
+	if res == nil {
+		return nil
+	}
+	if res != dp {
+		keepSender(dp, res)
+	}
+	return res
+}
+
+func (f *CallbackFilter) call(source types.LocalParticipant, dp *livekit.DataPacket) (*livekit.DataPacket, error) {
+	packet, err := protojson.Marshal(dp)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(&DataFilterCallbackRequest{
+		Room:                string(f.roomName),
+		ParticipantIdentity: string(source.Identity()),
+		Packet:              packet,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, f.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var decision DataFilterCallbackResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDataFilterCallbackResponse)).Decode(&decision); err != nil {
+		return nil, err
+	}
+	switch decision.Action {
+	case DataFilterActionDrop:
+		return nil, nil
+	case DataFilterActionAllow:
+		if len(decision.Packet) == 0 {
+			return dp, nil
+		}
+		rewritten := &livekit.DataPacket{}
+		if err := protojson.Unmarshal(decision.Packet, rewritten); err != nil {
+			return nil, err
+		}
+		return rewritten, nil
+	default:
+		return nil, fmt.Errorf("unknown action %q", decision.Action)
+	}
+}
+
+// keepSender carries the sender the server set on a packet over to its replacement
+func keepSender(dp *livekit.DataPacket, rewritten *livekit.DataPacket) {
+	rewritten.Kind = dp.Kind
+	rewritten.ParticipantIdentity = dp.ParticipantIdentity
+	if u := rewritten.GetUser(); u != nil {
+		u.ParticipantSid = dp.GetUser().GetParticipantSid()
+		u.ParticipantIdentity = dp.GetUser().GetParticipantIdentity()
+	}
+}
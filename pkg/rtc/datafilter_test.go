@@ -0,0 +1,217 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func userPacket(topic string, payload string) *livekit.DataPacket {
+	return &livekit.DataPacket{
+		Kind:                livekit.DataPacket_RELIABLE,
+		ParticipantIdentity: "sender",
+		Value: &livekit.DataPacket_User{User: &livekit.UserPacket{
+			ParticipantIdentity: "sender",
+			Topic:               proto.String(topic),
+			Payload:             []byte(payload),
+		}},
+	}
+}
+
+func chatPacket(message string) *livekit.DataPacket {
+	return &livekit.DataPacket{
+		Value: &livekit.DataPacket_ChatMessage{ChatMessage: &livekit.ChatMessage{Message: message}},
+	}
+}
+
+func TestDataPacketFilter(t *testing.T) {
+	sender := NewMockParticipant("sender", types.CurrentProtocol, false, false)
+
+	t.Run("topic allowlist", func(t *testing.T) {
+		f := NewTopicAllowlistFilter([]string{"lk.chat"})
+		require.NotNil(t, f.FilterDataPacket(sender, userPacket("lk.chat", "hi")))
+		require.Nil(t, f.FilterDataPacket(sender, userPacket("other", "hi")))
+		require.NotNil(t, f.FilterDataPacket(sender, chatPacket("hi")))
+
+		// chunks and trailer of a dropped stream are dropped too
+		header := &livekit.DataPacket{Value: &livekit.DataPacket_StreamHeader{StreamHeader: &livekit.DataStream_Header{StreamId: "s1", Topic: "other"}}}
+		chunk := &livekit.DataPacket{Value: &livekit.DataPacket_StreamChunk{StreamChunk: &livekit.DataStream_Chunk{StreamId: "s1"}}}
+		trailer := &livekit.DataPacket{Value: &livekit.DataPacket_StreamTrailer{StreamTrailer: &livekit.DataStream_Trailer{StreamId: "s1"}}}
+		require.Nil(t, f.FilterDataPacket(sender, header))
+		require.Nil(t, f.FilterDataPacket(sender, chunk))
+		require.Nil(t, f.FilterDataPacket(sender, trailer))
+		require.Empty(t, f.droppedStreams)
+
+		// streams are told apart per participant, and forgotten when their participant leaves
+		other := NewMockParticipant("other", types.CurrentProtocol, false, false)
+		require.Nil(t, f.FilterDataPacket(sender, header))
+		require.NotNil(t, f.FilterDataPacket(other, chunk))
+		require.Len(t, f.droppedStreams, 1)
+		f.RemoveParticipant(sender.ID())
+		require.Empty(t, f.droppedStreams)
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		f := NewRateLimitFilter(1, 2)
+		now := time.Now()
+		require.True(t, f.allow("p1", now))
+		require.True(t, f.allow("p1", now))
+		require.False(t, f.allow("p1", now))
+		require.True(t, f.allow("p2", now))
+		require.True(t, f.allow("p1", now.Add(time.Second)))
+		require.False(t, f.allow("p1", now.Add(time.Second)))
+
+		// refilled buckets are forgotten
+		f.allow("p2", now.Add(2*time.Minute))
+		require.Len(t, f.buckets, 1)
+	})
+
+	t.Run("redact", func(t *testing.T) {
+		f, err := NewRedactFilter([]string{`\d{4}-\d{4}`, `(?i)secret`}, "")
+		require.NoError(t, err)
+		dp := f.FilterDataPacket(sender, chatPacket("card 1234-5678, SECRET code"))
+		require.Equal(t, "card ***, *** code", dp.GetChatMessage().Message)
+
+		_, err = NewRedactFilter([]string{`(`}, "")
+		require.Error(t, err)
+	})
+
+	t.Run("filters apply in order", func(t *testing.T) {
+		f, err := NewDataPacketFilter("room", config.DataFilterConfig{
+			AllowedTopics:  []string{"lk.chat"},
+			MaxMessageRate: 1,
+		}, logger.GetLogger())
+		require.NoError(t, err)
+		require.Nil(t, f.FilterDataPacket(sender, userPacket("other", "hi")))
+		require.NotNil(t, f.FilterDataPacket(sender, userPacket("lk.chat", "hi")))
+		require.Nil(t, f.FilterDataPacket(sender, userPacket("lk.chat", "hi")))
+	})
+}
+
+func TestCallbackFilter(t *testing.T) {
+	sender := NewMockParticipant("sender", types.CurrentProtocol, false, false)
+
+	var received []DataFilterCallbackRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req DataFilterCallbackRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		received = append(received, req)
+
+		dp := &livekit.DataPacket{}
+		require.NoError(t, protojson.Unmarshal(req.Packet, dp))
+		switch string(dp.GetUser().GetPayload()) {
+		case "drop":
+			_ = json.NewEncoder(w).Encode(&DataFilterCallbackResponse{Action: DataFilterActionDrop})
+		case "rewrite":
+			rewritten := userPacket("lk.chat", "rewritten")
+			rewritten.ParticipantIdentity = "impostor"
+			rewritten.GetUser().ParticipantIdentity = "impostor"
+			packet, _ := protojson.Marshal(rewritten)
+			_ = json.NewEncoder(w).Encode(&DataFilterCallbackResponse{Action: DataFilterActionAllow, Packet: packet})
+		case "slow":
+			time.Sleep(200 * time.Millisecond)
+			_ = json.NewEncoder(w).Encode(&DataFilterCallbackResponse{Action: DataFilterActionAllow})
+		case "error":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			_ = json.NewEncoder(w).Encode(&DataFilterCallbackResponse{Action: DataFilterActionAllow})
+		}
+	}))
+	defer server.Close()
+
+	newFilter := func(failOpen bool) *CallbackFilter {
+		return NewCallbackFilter("room", config.DataFilterCallbackConfig{
+			URL:      server.URL,
+			Timeout:  50 * time.Millisecond,
+			FailOpen: failOpen,
+		}, logger.GetLogger())
+	}
+
+	t.Run("allow", func(t *testing.T) {
+		dp := userPacket("lk.chat", "hi")
+		require.Equal(t, dp, newFilter(false).FilterDataPacket(sender, dp))
+		require.Equal(t, "room", received[len(received)-1].Room)
+		require.Equal(t, "sender", received[len(received)-1].ParticipantIdentity)
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		require.Nil(t, newFilter(true).FilterDataPacket(sender, userPacket("lk.chat", "drop")))
+	})
+
+	t.Run("rewrite keeps the sender", func(t *testing.T) {
+		dp := newFilter(false).FilterDataPacket(sender, userPacket("lk.chat", "rewrite"))
+		require.NotNil(t, dp)
+		require.Equal(t, "rewritten", string(dp.GetUser().Payload))
+		require.Equal(t, "sender", dp.ParticipantIdentity)
+		require.Equal(t, "sender", dp.GetUser().ParticipantIdentity)
+		require.Equal(t, livekit.DataPacket_RELIABLE, dp.Kind)
+	})
+
+	t.Run("fail open", func(t *testing.T) {
+		require.NotNil(t, newFilter(true).FilterDataPacket(sender, userPacket("lk.chat", "slow")))
+		require.NotNil(t, newFilter(true).FilterDataPacket(sender, userPacket("lk.chat", "error")))
+	})
+
+	t.Run("fail closed", func(t *testing.T) {
+		require.Nil(t, newFilter(false).FilterDataPacket(sender, userPacket("lk.chat", "slow")))
+		require.Nil(t, newFilter(false).FilterDataPacket(sender, userPacket("lk.chat", "error")))
+	})
+}
+
+func TestRoomDataPacketFilter(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+	require.NoError(t, rm.SetDataFilter(config.DataFilterConfig{AllowedTopics: []string{"lk.chat"}}))
+	participants := rm.GetParticipants()
+	source := participants[0].(types.LocalParticipant)
+	dest := participants[1].(*typesfakes.FakeLocalParticipant)
+
+	rm.onDataPacket(source, livekit.DataPacket_RELIABLE, userPacket("other", "hi"))
+	require.Equal(t, 0, dest.SendDataPacketCallCount())
+
+	rm.onDataPacket(source, livekit.DataPacket_RELIABLE, userPacket("lk.chat", "hi"))
+	require.Eventually(t, func() bool {
+		return dest.SendDataPacketCallCount() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// data sent by the server is not filtered
+	rm.onDataPacket(nil, livekit.DataPacket_RELIABLE, userPacket("other", "hi"))
+	require.Eventually(t, func() bool {
+		return dest.SendDataPacketCallCount() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// streams dropped are forgotten when their participant leaves
+	rm.onDataPacket(source, livekit.DataPacket_RELIABLE, &livekit.DataPacket{
+		Value: &livekit.DataPacket_StreamHeader{StreamHeader: &livekit.DataStream_Header{StreamId: "s1", Topic: "other"}},
+	})
+	f := rm.getDataPacketFilter().(DataPacketFilters)[0].(*TopicAllowlistFilter)
+	require.Len(t, f.droppedStreams, 1)
+	rm.RemoveParticipant(source.Identity(), source.ID(), types.ParticipantCloseReasonClientRequestLeave)
+	require.Empty(t, f.droppedStreams)
+}
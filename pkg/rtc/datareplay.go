@@ -0,0 +1,136 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"slices"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// field of DataPacket carrying the sequence number of a reliable packet.
+// appended to the encoded packet, it takes precedence over a sequence number set by the sender:
+// receivers get the sequence of the server for that receiver, the sequence of the sender is not forwarded
+var dataPacketSequenceField = protowire.Number(
+	(&livekit.DataPacket{}).ProtoReflect().Descriptor().Fields().ByName("sequence").Number(),
+)
+
+const (
+	defaultReliableDataReplayMaxPackets = 256
+	defaultReliableDataReplayMaxBytes   = 1 << 20
+)
+
+// LastDataSequence returns the last sequence number a resuming client received, when it reported one.
+// The sequence is shared by all the senders a participant receives from, the last packet received is
+// the one with the highest sequence across senders.
+func LastDataSequence(state *livekit.SyncState) (uint32, bool) {
+	var (
+		lastSeq uint32
+		found   bool
+	)
+	for _, rs := range state.GetDatachannelReceiveStates() {
+		if !found || rs.LastSeq > lastSeq {
+			lastSeq, found = rs.LastSeq, true
+		}
+	}
+	return lastSeq, found
+}
+
+// ---------------------------------------------------------------
+
+type sequencedDataPacket struct {
+	seq     uint32
+	encoded []byte
+}
+
+// reliableDataBuffer stamps the reliable data packets sent to a participant with a sequence number,
+// starting at 1, and keeps the latest ones.
+// The sequence number overrides DataPacket.sequence, so with replay enabled it is a per-receiver count
+// of the packets from all senders rather than the sender's own count.
+type reliableDataBuffer struct {
+	maxPackets int
+	maxBytes   int
+
+	lock    sync.Mutex
+	lastSeq uint32
+	packets []sequencedDataPacket
+	bytes   int
+}
+
+func newReliableDataBuffer(conf config.ReliableDataReplayConfig) *reliableDataBuffer {
+	b := &reliableDataBuffer{
+		maxPackets: conf.MaxPackets,
+		maxBytes:   conf.MaxBytes,
+	}
+	if b.maxPackets <= 0 {
+		b.maxPackets = defaultReliableDataReplayMaxPackets
+	}
+	if b.maxBytes <= 0 {
+		b.maxBytes = defaultReliableDataReplayMaxBytes
+	}
+	return b
+}
+
+// stamp returns a copy of an encoded packet with the next sequence number, and keeps it
+func (b *reliableDataBuffer) stamp(encoded []byte) []byte {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.lastSeq++
+	// encoded is shared between the participants a packet is broadcast to, clip to append to a copy
+	stamped := protowire.AppendTag(slices.Clip(encoded), dataPacketSequenceField, protowire.VarintType)
+	stamped = protowire.AppendVarint(stamped, uint64(b.lastSeq))
+
+	b.packets = append(b.packets, sequencedDataPacket{seq: b.lastSeq, encoded: stamped})
+	b.bytes += len(stamped)
+	drop := 0
+	for len(b.packets)-drop > b.maxPackets || (b.bytes > b.maxBytes && len(b.packets)-drop > 1) {
+		b.bytes -= len(b.packets[drop].encoded)
+		drop++
+	}
+	b.packets = slices.Delete(b.packets, 0, drop)
+	return stamped
+}
+
+// since returns the packets kept after lastSeq, and the number of packets after lastSeq no longer kept
+func (b *reliableDataBuffer) since(lastSeq uint32) ([][]byte, int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if lastSeq >= b.lastSeq {
+		return nil, 0
+	}
+
+	var missed int
+	if len(b.packets) == 0 {
+		missed = int(b.lastSeq - lastSeq)
+	} else if oldest := b.packets[0].seq; oldest > lastSeq+1 {
+		missed = int(oldest - lastSeq - 1)
+	}
+
+	idx, _ := slices.BinarySearchFunc(b.packets, lastSeq+1, func(p sequencedDataPacket, seq uint32) int {
+		return int(int64(p.seq) - int64(seq))
+	})
+	packets := make([][]byte, 0, len(b.packets)-idx)
+	for _, p := range b.packets[idx:] {
+		packets = append(packets, p.encoded)
+	}
+	return packets, missed
+}
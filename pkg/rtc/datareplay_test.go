@@ -0,0 +1,125 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestReliableDataBuffer(t *testing.T) {
+	encoded, err := proto.Marshal(userPacket("lk.chat", "hello"))
+	require.NoError(t, err)
+
+	t.Run("stamps a copy", func(t *testing.T) {
+		b := newReliableDataBuffer(config.ReliableDataReplayConfig{})
+		shared := make([]byte, len(encoded), len(encoded)+16)
+		copy(shared, encoded)
+
+		first := b.stamp(shared)
+		second := b.stamp(shared)
+		require.Equal(t, encoded, shared)
+
+		require.Equal(t, uint32(1), dataPacketSequence(t, first))
+		require.Equal(t, uint32(2), dataPacketSequence(t, second))
+		require.Zero(t, dataPacketSequence(t, encoded))
+
+		dp := &livekit.DataPacket{}
+		require.NoError(t, proto.Unmarshal(first, dp))
+		require.Equal(t, "hello", string(dp.GetUser().Payload))
+	})
+
+	t.Run("overrides the sequence of the sender", func(t *testing.T) {
+		b := newReliableDataBuffer(config.ReliableDataReplayConfig{})
+		dp := userPacket("lk.chat", "hello")
+		dp.Sequence = 7
+		sent, err := proto.Marshal(dp)
+		require.NoError(t, err)
+
+		require.Equal(t, uint32(1), dataPacketSequence(t, b.stamp(sent)))
+	})
+
+	t.Run("replays what was missed", func(t *testing.T) {
+		b := newReliableDataBuffer(config.ReliableDataReplayConfig{MaxPackets: 3})
+		for i := 0; i < 5; i++ {
+			b.stamp(encoded)
+		}
+
+		packets, missed := b.since(3)
+		require.Zero(t, missed)
+		require.Len(t, packets, 2)
+		require.Equal(t, uint32(4), dataPacketSequence(t, packets[0]))
+
+		packets, missed = b.since(5)
+		require.Zero(t, missed)
+		require.Empty(t, packets)
+
+		// packets 1 and 2 were pushed out
+		packets, missed = b.since(0)
+		require.Equal(t, 2, missed)
+		require.Len(t, packets, 3)
+	})
+
+	t.Run("bounded by bytes", func(t *testing.T) {
+		b := newReliableDataBuffer(config.ReliableDataReplayConfig{MaxBytes: 2*len(encoded) + 8})
+		for i := 0; i < 5; i++ {
+			b.stamp(encoded)
+		}
+		packets, missed := b.since(0)
+		require.Len(t, packets, 2)
+		require.Equal(t, 3, missed)
+	})
+}
+
+func dataPacketSequence(t *testing.T, encoded []byte) uint32 {
+	dp := &livekit.DataPacket{}
+	require.NoError(t, proto.Unmarshal(encoded, dp))
+	return dp.Sequence
+}
+
+func TestSyncStateLastDataSequence(t *testing.T) {
+	state := &livekit.SyncState{
+		Subscription: &livekit.UpdateSubscription{},
+	}
+	_, ok := LastDataSequence(state)
+	require.False(t, ok)
+
+	state.DatachannelReceiveStates = []*livekit.DataChannelReceiveState{
+		{PublisherSid: "PA_1", LastSeq: 42},
+		{PublisherSid: "PA_2", LastSeq: 17},
+	}
+	seq, ok := LastDataSequence(state)
+	require.True(t, ok)
+	require.Equal(t, uint32(42), seq)
+
+	t.Run("resume replays reliable data", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		p := rm.GetParticipants()[0].(*typesfakes.FakeLocalParticipant)
+
+		require.NoError(t, rm.SyncState(p, &livekit.SyncState{Subscription: &livekit.UpdateSubscription{}}))
+		require.Zero(t, p.ReplayReliableDataCallCount())
+
+		require.NoError(t, rm.SyncState(p, state))
+		require.Equal(t, 1, p.ReplayReliableDataCallCount())
+		require.Equal(t, uint32(42), p.ReplayReliableDataArgsForCall(0))
+	})
+}
@@ -35,6 +35,19 @@ var (
 	ErrNameExceedsLimits        = errors.New("name length exceeds limits")
 	ErrMetadataExceedsLimits    = errors.New("metadata size exceeds limits")
 	ErrAttributesExceedsLimits  = errors.New("attributes size exceeds limits")
+	ErrParticipantNotFound      = errors.New("participant is not in the room")
+	ErrParticipantNotActive     = errors.New("participant is not active")
+	ErrCannotMoveAgent          = errors.New("agent participants cannot be moved")
+	ErrBridgeSameRoom           = errors.New("track cannot be bridged to the room it is published in")
+	ErrTrackIsBridged           = errors.New("bridged tracks cannot be bridged again")
+	ErrTrackAlreadyBridged      = errors.New("track is already bridged to the room")
+	ErrLobbyFull                = errors.New("room lobby is full")
+	ErrParticipantNotInLobby    = errors.New("participant is not waiting in the lobby")
+	ErrParticipantInLobby       = errors.New("participant is waiting in the lobby")
+	ErrStageFull                = errors.New("all publisher slots on stage are taken")
+	ErrParticipantNotOnStage    = errors.New("participant is not on stage")
+	ErrUnknownAllocationPolicy  = errors.New("unknown allocation policy")
+	ErrAudioPinSelfUpdate       = errors.New("lk.audio_pinned can only be set in the token or through the server API")
 
 	// Track subscription related
 	ErrNoTrackPermission         = errors.New("participant is not allowed to subscribe to this track")
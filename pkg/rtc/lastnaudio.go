@@ -0,0 +1,281 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// participant attribute pinning the audio of a participant, forwarded to everyone when "true"
+	LastNAudioPinnedAttribute = "lk.audio_pinned"
+
+	defaultLastNAudioMinForwardDuration = 2 * time.Second
+)
+
+type lastNAudioTrack struct {
+	TrackID   livekit.TrackID
+	Publisher livekit.ParticipantIdentity
+	Level     float64
+	Active    bool
+}
+
+// lastNAudio picks the audio tracks forwarded to a subscriber, ranked by audio level.
+// It is only used by the audio update worker.
+type lastNAudio struct {
+	config config.LastNAudioConfig
+	// forwarded tracks, with the last time each was among the loudest
+	forwarded map[livekit.TrackID]time.Time
+}
+
+func newLastNAudio(conf config.LastNAudioConfig) *lastNAudio {
+	if conf.MinForwardDuration <= 0 {
+		conf.MinForwardDuration = defaultLastNAudioMinForwardDuration
+	}
+	return &lastNAudio{
+		config:    conf,
+		forwarded: make(map[livekit.TrackID]time.Time),
+	}
+}
+
+// Update ranks the tracks by audio level and returns whether the forwarded tracks changed.
+// A track among the loudest that is not forwarded takes the place of the forwarded track that has not been among
+// the loudest for the longest time, once that is longer than the min forward duration.
+// Free places are given to the other tracks, so that every track is forwarded while there are not more than max tracks.
+func (l *lastNAudio) Update(tracks []lastNAudioTrack, now time.Time) bool {
+	published := make(map[livekit.TrackID]struct{}, len(tracks))
+	for _, t := range tracks {
+		published[t.TrackID] = struct{}{}
+	}
+	changed := false
+	for trackID := range l.forwarded {
+		if _, ok := published[trackID]; !ok {
+			delete(l.forwarded, trackID)
+			changed = true
+		}
+	}
+
+	ranked := slices.Clone(tracks)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Active != ranked[j].Active {
+			return ranked[i].Active
+		}
+		return ranked[i].Level > ranked[j].Level
+	})
+
+	var challengers []livekit.TrackID
+	for i, t := range ranked {
+		if i == l.config.MaxTracks || !t.Active {
+			break
+		}
+		if _, ok := l.forwarded[t.TrackID]; ok {
+			l.forwarded[t.TrackID] = now
+		} else {
+			challengers = append(challengers, t.TrackID)
+		}
+	}
+
+	for _, trackID := range challengers {
+		if len(l.forwarded) >= l.config.MaxTracks {
+			quietest, ok := l.quietestForwarded(now)
+			if !ok {
+				break
+			}
+			delete(l.forwarded, quietest)
+		}
+		l.forwarded[trackID] = now
+		changed = true
+	}
+
+	for _, t := range ranked {
+		if len(l.forwarded) >= l.config.MaxTracks {
+			break
+		}
+		if _, ok := l.forwarded[t.TrackID]; !ok {
+			// never among the loudest, can be replaced at once
+			l.forwarded[t.TrackID] = time.Time{}
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (l *lastNAudio) IsForwarded(trackID livekit.TrackID) bool {
+	_, ok := l.forwarded[trackID]
+	return ok
+}
+
+func (l *lastNAudio) Forwarded() []livekit.TrackID {
+	forwarded := slices.Collect(maps.Keys(l.forwarded))
+	slices.Sort(forwarded)
+	return forwarded
+}
+
+// quietestForwarded returns the forwarded track that can be replaced, if any
+func (l *lastNAudio) quietestForwarded(now time.Time) (livekit.TrackID, bool) {
+	var (
+		quietest   livekit.TrackID
+		quietestAt time.Time
+		found      bool
+	)
+	for trackID, at := range l.forwarded {
+		if now.Sub(at) < l.config.MinForwardDuration {
+			continue
+		}
+		if !found || at.Before(quietestAt) || (at.Equal(quietestAt) && trackID < quietest) {
+			quietest, quietestAt, found = trackID, at, true
+		}
+	}
+	return quietest, found
+}
+
+// lastNAudioSubscribers holds the tracks forwarded to each subscriber of a room. Subscribers are not forwarded
+// their own audio, so each picks the loudest among the others, a loud subscriber still hears max tracks speakers.
+// It is only used by the audio update worker.
+type lastNAudioSubscribers struct {
+	config      config.LastNAudioConfig
+	subscribers map[livekit.ParticipantIdentity]*lastNAudio
+}
+
+func newLastNAudioSubscribers(conf config.LastNAudioConfig) *lastNAudioSubscribers {
+	return &lastNAudioSubscribers{
+		config:      conf,
+		subscribers: make(map[livekit.ParticipantIdentity]*lastNAudio),
+	}
+}
+
+// Update ranks the tracks not published by the subscriber, see lastNAudio.Update
+func (s *lastNAudioSubscribers) Update(subscriber livekit.ParticipantIdentity, tracks []lastNAudioTrack, now time.Time) (*lastNAudio, bool) {
+	l := s.subscribers[subscriber]
+	if l == nil {
+		l = newLastNAudio(s.config)
+		s.subscribers[subscriber] = l
+	}
+	others := slices.DeleteFunc(slices.Clone(tracks), func(t lastNAudioTrack) bool {
+		return t.Publisher == subscriber
+	})
+	return l, l.Update(others, now)
+}
+
+// Prune drops the tracks forwarded to subscribers that left
+func (s *lastNAudioSubscribers) Prune(subscribers map[livekit.ParticipantIdentity]struct{}) {
+	for subscriber := range s.subscribers {
+		if _, ok := subscribers[subscriber]; !ok {
+			delete(s.subscribers, subscriber)
+		}
+	}
+}
+
+// SetLastNAudio only forwards the audio of the loudest speakers to subscribers of the room.
+// The other audio tracks are paused and subscribers are sent a stream state update, until they are among the loudest.
+func (r *Room) SetLastNAudio(conf config.LastNAudioConfig) {
+	r.lock.Lock()
+	r.lastNAudio = newLastNAudioSubscribers(conf)
+	r.lock.Unlock()
+}
+
+// checkAudioPinSelfUpdate rejects participants pinning or unpinning their own audio,
+// pins are set in the token or by room admins through the server API
+func checkAudioPinSelfUpdate(current, update map[string]string) error {
+	if value, ok := update[LastNAudioPinnedAttribute]; ok && value != current[LastNAudioPinnedAttribute] {
+		return ErrAudioPinSelfUpdate
+	}
+	return nil
+}
+
+func isLastNAudioExempt(p types.LocalParticipant) bool {
+	return p.IsDependent() || p.ClaimGrants().Attributes[LastNAudioPinnedAttribute] == "true"
+}
+
+// updateLastNAudio is called by the audio update worker, with the latest audio levels
+func (r *Room) updateLastNAudio() {
+	r.lock.RLock()
+	s := r.lastNAudio
+	r.lock.RUnlock()
+	if s == nil {
+		return
+	}
+
+	participants := r.GetParticipants()
+	var tracks []lastNAudioTrack
+	ranked := make(map[livekit.TrackID]struct{})
+	for _, p := range participants {
+		if isLastNAudioExempt(p) {
+			continue
+		}
+		for _, track := range p.GetPublishedTracks() {
+			if track.Kind() != livekit.TrackType_AUDIO || track.Source() == livekit.TrackSource_SCREEN_SHARE_AUDIO {
+				continue
+			}
+			level, active := track.GetAudioLevel()
+			ranked[track.ID()] = struct{}{}
+			tracks = append(tracks, lastNAudioTrack{
+				TrackID:   track.ID(),
+				Publisher: p.Identity(),
+				Level:     level,
+				Active:    active,
+			})
+		}
+	}
+
+	now := time.Now()
+	subscribers := make(map[livekit.ParticipantIdentity]struct{}, len(participants))
+	for _, p := range participants {
+		if p.IsDependent() {
+			continue
+		}
+		subscribers[p.Identity()] = struct{}{}
+		l, changed := s.Update(p.Identity(), tracks, now)
+		if changed {
+			p.GetLogger().Debugw("last-N audio forwarded tracks changed", "forwarded", l.Forwarded())
+		}
+
+		update := &livekit.StreamStateUpdate{}
+		for _, st := range p.GetSubscribedTracks() {
+			trackID := st.ID()
+			if st.MediaTrack().Kind() != livekit.TrackType_AUDIO {
+				continue
+			}
+			_, isRanked := ranked[trackID]
+			paused := isRanked && !l.IsForwarded(trackID)
+			if !st.SetForwardingPaused(paused) {
+				continue
+			}
+			state := livekit.StreamState_ACTIVE
+			if paused {
+				state = livekit.StreamState_PAUSED
+			}
+			update.StreamStates = append(update.StreamStates, &livekit.StreamStateInfo{
+				ParticipantSid: string(st.PublisherID()),
+				TrackSid:       string(trackID),
+				State:          state,
+			})
+		}
+		if len(update.StreamStates) != 0 {
+			if err := p.SendStreamStateUpdate(update); err != nil {
+				p.GetLogger().Warnw("could not send last-N audio stream states", err)
+			}
+		}
+	}
+	s.Prune(subscribers)
+}
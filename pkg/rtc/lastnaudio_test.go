@@ -0,0 +1,187 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestLastNAudio(t *testing.T) {
+	l := newLastNAudio(config.LastNAudioConfig{MaxTracks: 2, MinForwardDuration: time.Second})
+	now := time.Now()
+
+	speaking := func(levels map[livekit.TrackID]float64) []lastNAudioTrack {
+		var tracks []lastNAudioTrack
+		for _, trackID := range []livekit.TrackID{"a", "b", "c", "d"} {
+			level, active := levels[trackID]
+			tracks = append(tracks, lastNAudioTrack{TrackID: trackID, Level: level, Active: active})
+		}
+		return tracks
+	}
+
+	// free places are given to tracks not speaking
+	require.True(t, l.Update(speaking(map[livekit.TrackID]float64{"c": 0.5}), now))
+	require.Equal(t, []livekit.TrackID{"a", "c"}, l.Forwarded())
+
+	// a track that was never among the loudest is replaced at once
+	require.True(t, l.Update(speaking(map[livekit.TrackID]float64{"c": 0.5, "d": 0.3}), now))
+	require.Equal(t, []livekit.TrackID{"c", "d"}, l.Forwarded())
+
+	// forwarded tracks keep their place for the min forward duration
+	now = now.Add(500 * time.Millisecond)
+	require.False(t, l.Update(speaking(map[livekit.TrackID]float64{"a": 0.9, "b": 0.8}), now))
+	require.Equal(t, []livekit.TrackID{"c", "d"}, l.Forwarded())
+
+	// then the quietest forwarded track is replaced first
+	now = now.Add(500 * time.Millisecond)
+	require.True(t, l.Update(speaking(map[livekit.TrackID]float64{"a": 0.9, "d": 0.3}), now))
+	require.Equal(t, []livekit.TrackID{"a", "d"}, l.Forwarded())
+
+	now = now.Add(2 * time.Second)
+	require.True(t, l.Update(speaking(map[livekit.TrackID]float64{"b": 0.9, "c": 0.8, "d": 0.1}), now))
+	require.Equal(t, []livekit.TrackID{"b", "c"}, l.Forwarded())
+
+	// unpublished tracks free their place
+	require.True(t, l.Update([]lastNAudioTrack{{TrackID: "c"}, {TrackID: "d"}}, now))
+	require.Equal(t, []livekit.TrackID{"c", "d"}, l.Forwarded())
+}
+
+func TestLastNAudioSubscribers(t *testing.T) {
+	s := newLastNAudioSubscribers(config.LastNAudioConfig{MaxTracks: 1})
+	now := time.Now()
+	tracks := []lastNAudioTrack{
+		{TrackID: "a", Publisher: "pa", Level: 0.9, Active: true},
+		{TrackID: "b", Publisher: "pb", Level: 0.5, Active: true},
+	}
+
+	l, changed := s.Update("pc", tracks, now)
+	require.True(t, changed)
+	require.Equal(t, []livekit.TrackID{"a"}, l.Forwarded())
+
+	// subscribers are not forwarded their own audio
+	l, changed = s.Update("pa", tracks, now)
+	require.True(t, changed)
+	require.Equal(t, []livekit.TrackID{"b"}, l.Forwarded())
+
+	l, changed = s.Update("pa", tracks, now)
+	require.False(t, changed)
+	require.Equal(t, []livekit.TrackID{"b"}, l.Forwarded())
+
+	s.Prune(map[livekit.ParticipantIdentity]struct{}{"pc": {}})
+	require.Len(t, s.subscribers, 1)
+	require.Contains(t, s.subscribers, livekit.ParticipantIdentity("pc"))
+}
+
+func TestAudioPinSelfUpdate(t *testing.T) {
+	pinned := map[string]string{LastNAudioPinnedAttribute: "true"}
+	require.NoError(t, checkAudioPinSelfUpdate(nil, map[string]string{"role": "speaker"}))
+	require.NoError(t, checkAudioPinSelfUpdate(pinned, pinned))
+	require.ErrorIs(t, checkAudioPinSelfUpdate(nil, pinned), ErrAudioPinSelfUpdate)
+	require.ErrorIs(t, checkAudioPinSelfUpdate(pinned, map[string]string{LastNAudioPinnedAttribute: ""}), ErrAudioPinSelfUpdate)
+}
+
+func TestRoomLastNAudio(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 0})
+	rm.SetLastNAudio(config.LastNAudioConfig{MaxTracks: 1})
+
+	join := func(identity livekit.ParticipantIdentity, grants *auth.ClaimGrants) *typesfakes.FakeLocalParticipant {
+		p := NewMockParticipant(identity, types.CurrentProtocol, false, true)
+		p.ClaimGrantsReturns(grants)
+		require.NoError(t, rm.Join(p, nil, nil, iceServersForRoom))
+		return p
+	}
+	publish := func(p *typesfakes.FakeLocalParticipant, level float64) *typesfakes.FakeMediaTrack {
+		track := NewMockTrack(livekit.TrackType_AUDIO, "mic")
+		track.SourceReturns(livekit.TrackSource_MICROPHONE)
+		track.GetAudioLevelReturns(level, level > 0)
+		p.GetPublishedTracksReturns([]types.MediaTrack{track})
+		return track
+	}
+
+	grants := &auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}}
+	loudParticipant := join("loud", grants)
+	loud := publish(loudParticipant, 0.8)
+	quiet := publish(join("quiet", grants), 0.2)
+	pinned := publish(join("pinned", &auth.ClaimGrants{
+		Video:      &auth.VideoGrant{RoomJoin: true},
+		Attributes: map[string]string{LastNAudioPinnedAttribute: "true"},
+	}), 0)
+	agentParticipant := join("agent", &auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true, Agent: true}})
+	agentParticipant.IsDependentReturns(true)
+	agent := publish(agentParticipant, 0)
+
+	type subscription struct {
+		subscriber livekit.ParticipantIdentity
+		trackID    livekit.TrackID
+	}
+	var lock sync.Mutex
+	paused := make(map[subscription]bool)
+	subscribe := func(p *typesfakes.FakeLocalParticipant, tracks ...*typesfakes.FakeMediaTrack) {
+		var subscribedTracks []types.SubscribedTrack
+		for _, track := range tracks {
+			st := &typesfakes.FakeSubscribedTrack{}
+			st.IDReturns(track.ID())
+			st.MediaTrackReturns(track)
+			key := subscription{subscriber: p.Identity(), trackID: track.ID()}
+			st.SetForwardingPausedCalls(func(p bool) bool {
+				lock.Lock()
+				defer lock.Unlock()
+				changed := paused[key] != p
+				paused[key] = p
+				return changed
+			})
+			subscribedTracks = append(subscribedTracks, st)
+		}
+		p.GetSubscribedTracksReturns(subscribedTracks)
+	}
+	subscriber := join("subscriber", grants)
+	subscribe(subscriber, loud, quiet, pinned, agent)
+	subscribe(loudParticipant, quiet, pinned, agent)
+
+	isPaused := func(p *typesfakes.FakeLocalParticipant, track *typesfakes.FakeMediaTrack) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return paused[subscription{subscriber: p.Identity(), trackID: track.ID()}]
+	}
+	require.Eventually(t, func() bool { return isPaused(subscriber, quiet) }, time.Second, 10*time.Millisecond)
+	require.False(t, isPaused(subscriber, loud))
+	require.False(t, isPaused(subscriber, pinned))
+	require.False(t, isPaused(subscriber, agent))
+
+	// the loudest speaker does not take a place with its own audio, and hears the next loudest
+	require.Never(t, func() bool { return isPaused(loudParticipant, quiet) }, 200*time.Millisecond, 10*time.Millisecond)
+	require.Zero(t, loudParticipant.SendStreamStateUpdateCallCount())
+
+	// subscriber is told about the paused track
+	require.Eventually(t, func() bool { return subscriber.SendStreamStateUpdateCallCount() > 0 }, time.Second, 10*time.Millisecond)
+	update := subscriber.SendStreamStateUpdateArgsForCall(0)
+	require.Len(t, update.StreamStates, 1)
+	require.Equal(t, string(quiet.ID()), update.StreamStates[0].TrackSid)
+	require.Equal(t, livekit.StreamState_PAUSED, update.StreamStates[0].State)
+
+	// agents receive all audio
+	require.Zero(t, agentParticipant.SendStreamStateUpdateCallCount())
+}
@@ -0,0 +1,204 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sort"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// permission of participants waiting in the lobby, they hold a signal connection but are invisible
+// and can neither publish nor subscribe
+var lobbyPermission = &livekit.ParticipantPermission{
+	Hidden: true,
+}
+
+type lobbyEntry struct {
+	participant types.LocalParticipant
+	// permission granted by the token of the participant, restored when it is admitted
+	permission *livekit.ParticipantPermission
+	joinedAt   time.Time
+}
+
+// SetLobby makes participants joining the room wait in a lobby until they are admitted.
+// Participants already in the room are not affected.
+func (r *Room) SetLobby(lobby config.LobbyConfig) {
+	r.lock.Lock()
+	r.lobbyConfig = &lobby
+	r.lock.Unlock()
+}
+
+func (r *Room) HasLobby() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.lobbyConfig != nil
+}
+
+// GetLobbyParticipants returns the participants waiting in the lobby, in the order they joined
+func (r *Room) GetLobbyParticipants() []types.LocalParticipant {
+	r.lock.RLock()
+	entries := make([]*lobbyEntry, 0, len(r.lobby))
+	for _, e := range r.lobby {
+		entries = append(entries, e)
+	}
+	r.lock.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].joinedAt.Before(entries[j].joinedAt)
+	})
+	participants := make([]types.LocalParticipant, 0, len(entries))
+	for _, e := range entries {
+		participants = append(participants, e.participant)
+	}
+	return participants
+}
+
+func (r *Room) IsInLobby(identity livekit.ParticipantIdentity) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.lobby[identity] != nil
+}
+
+// AdmitParticipant lets a participant waiting in the lobby into the room, with the permissions of its token.
+// It becomes visible to the others and is subscribed to their tracks as on join.
+func (r *Room) AdmitParticipant(identity livekit.ParticipantIdentity) (types.LocalParticipant, error) {
+	r.lock.Lock()
+	e := r.lobby[identity]
+	if e == nil {
+		r.lock.Unlock()
+		return nil, ErrParticipantNotInLobby
+	}
+	if err := r.checkMaxParticipantsLocked(e.participant); err != nil {
+		r.lock.Unlock()
+		return nil, err
+	}
+	delete(r.lobby, identity)
+	r.lock.Unlock()
+	r.protoProxy.MarkDirty(false)
+
+	p := e.participant
+	p.GetLogger().Infow("participant admitted from lobby", "waited", time.Since(e.joinedAt))
+
+	// broadcasts the participant to the room, and reconciles the subscriptions it requested while waiting
+	p.SetPermission(e.permission)
+	if err := p.SendParticipantUpdate(r.getOtherParticipantInfo(identity)); err != nil {
+		p.GetLogger().Warnw("could not send update to participant", err)
+	}
+	r.replayChatHistory(p)
+	return p, nil
+}
+
+// RejectParticipant turns away a participant waiting in the lobby, closing its session
+func (r *Room) RejectParticipant(identity livekit.ParticipantIdentity) error {
+	r.lock.RLock()
+	e := r.lobby[identity]
+	r.lock.RUnlock()
+	if e == nil {
+		return ErrParticipantNotInLobby
+	}
+
+	e.participant.GetLogger().Infow("participant rejected from lobby")
+	r.RemoveParticipant(identity, e.participant.ID(), types.ParticipantCloseReasonServiceRequestRemoveParticipant)
+	return nil
+}
+
+// needsAdmissionLocked returns whether a joining participant has to wait in the lobby, assumes lock is already acquired.
+// room admins, hidden participants and non standard participants such as agents are let in directly.
+func (r *Room) needsAdmissionLocked(participant types.LocalParticipant) bool {
+	if r.lobbyConfig == nil || participant.Hidden() || participant.IsDependent() {
+		return false
+	}
+	if participant.Kind() != livekit.ParticipantInfo_STANDARD {
+		return false
+	}
+	return !isLobbyHost(participant)
+}
+
+// canEnterLobbyLocked checks that participant can wait in the lobby, assumes lock is already acquired.
+// participants in the lobby do not count towards max participants.
+func (r *Room) canEnterLobbyLocked(participant types.LocalParticipant) error {
+	if r.participants[participant.Identity()] != nil {
+		return ErrAlreadyJoined
+	}
+	if r.lobbyConfig.MaxSize > 0 && uint32(len(r.lobby)) >= r.lobbyConfig.MaxSize {
+		return ErrLobbyFull
+	}
+	return nil
+}
+
+// enterLobbyLocked holds a joining participant in the lobby, assumes lock is already acquired.
+// it has to be called before the participant callbacks are set, the room is not notified of the permission change.
+func (r *Room) enterLobbyLocked(participant types.LocalParticipant) {
+	r.lobby[participant.Identity()] = &lobbyEntry{
+		participant: participant,
+		permission:  participant.ClaimGrants().Video.ToPermission(),
+		joinedAt:    time.Now(),
+	}
+	participant.SetPermission(lobbyPermission)
+	participant.GetLogger().Infow("participant waiting in lobby")
+}
+
+// LobbyParticipantInfo describes a participant in the lobby to room admins, as JOINING until it is admitted.
+// it is not marked hidden, participants drop updates about hidden participants.
+func LobbyParticipantInfo(p types.LocalParticipant) *livekit.ParticipantInfo {
+	pi := utils.CloneProto(p.ToProto())
+	if pi.State != livekit.ParticipantInfo_DISCONNECTED {
+		pi.State = livekit.ParticipantInfo_JOINING
+	}
+	if pi.Permission != nil {
+		pi.Permission.Hidden = false
+	}
+	return pi
+}
+
+func (r *Room) lobbyParticipantInfosLocked() []*livekit.ParticipantInfo {
+	infos := make([]*livekit.ParticipantInfo, 0, len(r.lobby))
+	for _, e := range r.lobby {
+		infos = append(infos, LobbyParticipantInfo(e.participant))
+	}
+	return infos
+}
+
+// isLobbyHost returns whether a participant in the room receives lobby changes
+func isLobbyHost(p types.LocalParticipant) bool {
+	grants := p.ClaimGrants()
+	return grants != nil && grants.Video != nil && grants.Video.RoomAdmin
+}
+
+// notifyLobbyHosts sends a change of the lobby to the room admins in the room, over signalling.
+// participants in the lobby show up as JOINING without permissions, they are DISCONNECTED when they leave or are rejected,
+// admitted participants are broadcast to everyone as they become visible.
+func (r *Room) notifyLobbyHosts(pi *livekit.ParticipantInfo) {
+	r.lock.RLock()
+	var hosts []types.LocalParticipant
+	for identity, p := range r.participants {
+		if r.lobby[identity] == nil && isLobbyHost(p) {
+			hosts = append(hosts, p)
+		}
+	}
+	r.lock.RUnlock()
+
+	for _, host := range hosts {
+		if err := host.SendParticipantUpdate([]*livekit.ParticipantInfo{pi}); err != nil {
+			host.GetLogger().Warnw("could not send lobby update to participant", err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+func TestRoomLobby(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 0})
+	rm.protoRoom.MaxParticipants = 1
+	rm.SetLobby(config.LobbyConfig{MaxSize: 1})
+
+	host := NewMockParticipant("host", types.CurrentProtocol, false, false)
+	host.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true, RoomAdmin: true}})
+	require.NoError(t, rm.Join(host, nil, nil, iceServersForRoom))
+	require.False(t, rm.IsInLobby("host"))
+
+	guest := NewMockParticipant("guest", types.CurrentProtocol, false, false)
+	guest.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}})
+	require.NoError(t, rm.Join(guest, nil, nil, iceServersForRoom))
+	require.True(t, rm.IsInLobby("guest"))
+	require.Equal(t, lobbyPermission, guest.SetPermissionArgsForCall(0))
+	require.Empty(t, guest.SendJoinResponseArgsForCall(0).OtherParticipants)
+
+	// the host is told the guest is waiting
+	require.Eventually(t, func() bool {
+		for i := 0; i < host.SendParticipantUpdateCallCount(); i++ {
+			for _, pi := range host.SendParticipantUpdateArgsForCall(i) {
+				if pi.Identity == "guest" && pi.State == livekit.ParticipantInfo_JOINING {
+					return true
+				}
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+
+	t.Run("lobby is full", func(t *testing.T) {
+		late := NewMockParticipant("late", types.CurrentProtocol, false, false)
+		late.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}})
+		require.ErrorIs(t, rm.Join(late, nil, nil, iceServersForRoom), ErrLobbyFull)
+	})
+
+	t.Run("room is full", func(t *testing.T) {
+		_, err := rm.AdmitParticipant("guest")
+		require.ErrorIs(t, err, ErrMaxParticipantsExceeded)
+		require.True(t, rm.IsInLobby("guest"))
+	})
+
+	t.Run("admitted with token permissions", func(t *testing.T) {
+		rm.protoRoom.MaxParticipants = 2
+		p, err := rm.AdmitParticipant("guest")
+		require.NoError(t, err)
+		require.Equal(t, guest, p)
+		require.False(t, rm.IsInLobby("guest"))
+		require.False(t, guest.SetPermissionArgsForCall(1).Hidden)
+		require.Empty(t, rm.GetLobbyParticipants())
+
+		_, err = rm.AdmitParticipant("guest")
+		require.ErrorIs(t, err, ErrParticipantNotInLobby)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		late := NewMockParticipant("late", types.CurrentProtocol, false, false)
+		late.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}})
+		require.NoError(t, rm.Join(late, nil, nil, iceServersForRoom))
+		require.Len(t, rm.GetLobbyParticipants(), 1)
+
+		require.NoError(t, rm.RejectParticipant("late"))
+		require.Nil(t, rm.GetParticipant("late"))
+		require.Equal(t, 1, late.CloseCallCount())
+	})
+}
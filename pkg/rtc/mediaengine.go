@@ -21,6 +21,7 @@ import (
 	"github.com/pion/webrtc/v4"
 
 	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/fec"
 	"github.com/livekit/protocol/livekit"
 )
 
@@ -40,6 +41,11 @@ var videoRTX = webrtc.RTPCodecCapability{
 	MimeType:  webrtc.MimeTypeRTX,
 	ClockRate: 90000,
 }
+var videoFlexFEC = webrtc.RTPCodecCapability{
+	MimeType:    fec.MimeTypeFlexFEC03,
+	ClockRate:   90000,
+	SDPFmtpLine: "repair-window=10000000",
+}
 
 func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtcpFeedback RTCPFeedbackConfig, filterOutH264HighProfile bool) error {
 	opusCodec := OpusCodecCapability
@@ -65,6 +71,7 @@ func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtcpFeedbac
 	}
 
 	rtxEnabled := IsCodecEnabled(codecs, videoRTX)
+	videoEnabled := false
 
 	h264HighProfileFmtp := "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=640032"
 	for _, codec := range []webrtc.RTPCodecParameters{
@@ -129,6 +136,24 @@ func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtcpFeedbac
 			},
 			PayloadType: 35,
 		},
+		{
+			RTPCodecCapability: webrtc.RTPCodecCapability{
+				MimeType:     webrtc.MimeTypeH265,
+				ClockRate:    90000,
+				SDPFmtpLine:  "level-id=180;profile-id=1;tier-flag=0;tx-mode=SRST",
+				RTCPFeedback: rtcpFeedback.Video,
+			},
+			PayloadType: 116,
+		},
+		{
+			RTPCodecCapability: webrtc.RTPCodecCapability{
+				MimeType:     webrtc.MimeTypeH265,
+				ClockRate:    90000,
+				SDPFmtpLine:  "level-id=93;profile-id=1;tier-flag=0;tx-mode=SRST",
+				RTCPFeedback: rtcpFeedback.Video,
+			},
+			PayloadType: 118,
+		},
 	} {
 		if filterOutH264HighProfile && codec.RTPCodecCapability.SDPFmtpLine == h264HighProfileFmtp {
 			continue
@@ -140,6 +165,7 @@ func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtcpFeedbac
 			if err := me.RegisterCodec(codec, webrtc.RTPCodecTypeVideo); err != nil {
 				return err
 			}
+			videoEnabled = true
 			if rtxEnabled {
 				if err := me.RegisterCodec(webrtc.RTPCodecParameters{
 					RTPCodecCapability: webrtc.RTPCodecCapability{
@@ -154,6 +180,16 @@ func registerCodecs(me *webrtc.MediaEngine, codecs []*livekit.Codec, rtcpFeedbac
 			}
 		}
 	}
+
+	// FEC is only generated by the SFU, for video sent to subscribers
+	if videoEnabled && IsCodecEnabled(codecs, videoFlexFEC) {
+		if err := me.RegisterCodec(webrtc.RTPCodecParameters{
+			RTPCodecCapability: videoFlexFEC,
+			PayloadType:        49,
+		}, webrtc.RTPCodecTypeVideo); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -200,10 +236,15 @@ func IsCodecEnabled(codecs []*livekit.Codec, cap webrtc.RTPCodecCapability) bool
 
 func selectAlternativeVideoCodec(enabledCodecs []*livekit.Codec) string {
 	for _, c := range enabledCodecs {
-		if strings.HasPrefix(c.Mime, "video/") {
+		if strings.HasPrefix(c.Mime, "video/") && !isRepairCodec(c.Mime) {
 			return c.Mime
 		}
 	}
 	// no viable codec in the list of enabled codecs, fall back to the most widely supported codec
 	return webrtc.MimeTypeVP8
 }
+
+// isRepairCodec returns true for codecs carrying retransmissions or FEC of media, rather than media
+func isRepairCodec(mime string) bool {
+	return strings.EqualFold(mime, webrtc.MimeTypeRTX) || strings.EqualFold(mime, fec.MimeTypeFlexFEC03)
+}
@@ -38,3 +38,31 @@ func TestIsCodecEnabled(t *testing.T) {
 		require.False(t, IsCodecEnabled(enabledCodecs, webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}))
 	})
 }
+
+func TestRegisterFlexFEC(t *testing.T) {
+	offer := func(codecs []*livekit.Codec) string {
+		me, err := createMediaEngine(codecs, DirectionConfig{}, true)
+		require.NoError(t, err)
+		pc, err := webrtc.NewAPI(webrtc.WithMediaEngine(me)).NewPeerConnection(webrtc.Configuration{})
+		require.NoError(t, err)
+		defer pc.Close()
+
+		track, err := webrtc.NewTrackLocalStaticRTP(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}, "video", "stream")
+		require.NoError(t, err)
+		_, err = pc.AddTransceiverFromTrack(track, webrtc.RTPTransceiverInit{Direction: webrtc.RTPTransceiverDirectionSendonly})
+		require.NoError(t, err)
+		sd, err := pc.CreateOffer(nil)
+		require.NoError(t, err)
+		return sd.SDP
+	}
+
+	sdp := offer([]*livekit.Codec{{Mime: webrtc.MimeTypeVP8}, {Mime: "video/flexfec-03"}})
+	require.Contains(t, sdp, "a=rtpmap:49 flexfec-03/90000")
+	require.Contains(t, sdp, "a=ssrc-group:FEC-FR")
+
+	sdp = offer([]*livekit.Codec{{Mime: webrtc.MimeTypeVP8}})
+	require.NotContains(t, sdp, "flexfec")
+	require.NotContains(t, sdp, "FEC-FR")
+
+	require.Equal(t, webrtc.MimeTypeVP8, selectAlternativeVideoCodec([]*livekit.Codec{{Mime: "video/flexfec-03"}, {Mime: webrtc.MimeTypeRTX}}))
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/sfu/capture"
 	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
 	"github.com/livekit/livekit-server/pkg/telemetry"
 	util "github.com/livekit/mediatransportutil"
@@ -54,6 +55,10 @@ type MediaTrack struct {
 	lock sync.RWMutex
 
 	rttFromXR atomic.Bool
+
+	// all up track buffers, to attach captures to
+	upTrackBuffers []*buffer.Buffer
+	capture        atomic.Pointer[capture.Writer]
 }
 
 type MediaTrackParams struct {
@@ -200,6 +205,10 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track sfu.TrackRe
 
 	var lastRR uint32
 	rtcpReader.OnPacket(func(bytes []byte) {
+		if w := t.capture.Load(); w != nil {
+			w.WriteRTCP(bytes)
+		}
+
 		pkts, err := rtcp.Unmarshal(bytes)
 		if err != nil {
 			t.params.Logger.Errorw("could not unmarshal RTCP", err)
@@ -238,6 +247,8 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track sfu.TrackRe
 
 	ti := t.MediaTrackReceiver.TrackInfoClone()
 	t.lock.Lock()
+	t.upTrackBuffers = append(t.upTrackBuffers, buff)
+	buff.SetCapture(t.capture.Load())
 	mime := strings.ToLower(track.Codec().MimeType)
 	layer := buffer.RidToSpatialLayer(track.RID(), ti)
 	t.params.Logger.Debugw(
@@ -414,6 +425,18 @@ func (t *MediaTrack) Restart() {
 	}
 }
 
+// SetCapture records RTP and RTCP received from the publisher on all layers and codecs of the track,
+// nil stops recording
+func (t *MediaTrack) SetCapture(w *capture.Writer) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.capture.Store(w)
+	for _, buff := range t.upTrackBuffers {
+		buff.SetCapture(w)
+	}
+}
+
 func (t *MediaTrack) Close(isExpectedToResume bool) {
 	t.MediaTrackReceiver.SetClosing()
 	if t.dynacastManager != nil {
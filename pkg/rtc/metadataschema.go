@@ -0,0 +1,281 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// MetadataSchemaError tells which field of an attributes or metadata update does not follow the room's schema
+type MetadataSchemaError struct {
+	// attributes.<key>, metadata.<key>, or metadata when the metadata is not a JSON object
+	Field  string
+	Reason string
+}
+
+func (e *MetadataSchemaError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+// MetadataSchema checks updates of participant attributes, participant metadata and room metadata.
+// A nil schema allows anything.
+type MetadataSchema struct {
+	attributes          *metadataFields
+	participantMetadata *metadataFields
+	roomMetadata        *metadataFields
+}
+
+type metadataFields struct {
+	name         string
+	fields       map[string]metadataField
+	allowUnknown bool
+}
+
+type metadataField struct {
+	typ        string
+	pattern    *regexp.Regexp
+	selfUpdate bool
+}
+
+func NewMetadataSchema(conf config.MetadataSchemaConfig) (*MetadataSchema, error) {
+	if err := config.ValidateMetadataSchema(conf); err != nil {
+		return nil, err
+	}
+	return &MetadataSchema{
+		attributes:          newMetadataFields("attributes", conf.Attributes),
+		participantMetadata: newMetadataFields("metadata", conf.ParticipantMetadata),
+		roomMetadata:        newMetadataFields("metadata", conf.RoomMetadata),
+	}, nil
+}
+
+func newMetadataFields(name string, conf *config.MetadataFieldsConfig) *metadataFields {
+	if conf == nil {
+		return nil
+	}
+	f := &metadataFields{
+		name:         name,
+		fields:       make(map[string]metadataField, len(conf.Fields)),
+		allowUnknown: conf.AllowUnknown,
+	}
+	for key, field := range conf.Fields {
+		mf := metadataField{
+			typ:        field.Type,
+			selfUpdate: field.SelfUpdate,
+		}
+		if mf.typ == "" {
+			mf.typ = config.MetadataFieldTypeString
+		}
+		if field.Pattern != "" {
+			// validated above
+			mf.pattern = regexp.MustCompile("^(?:" + field.Pattern + ")$")
+		}
+		f.fields[key] = mf
+	}
+	return f
+}
+
+// CheckAttributes checks an update of participant attributes, an empty value deleting the key.
+// Participants updating their own can only change fields the schema lets them.
+func (s *MetadataSchema) CheckAttributes(current, update map[string]string, selfUpdate bool) error {
+	if s == nil || s.attributes == nil {
+		return nil
+	}
+	for _, key := range sortedKeys(update) {
+		value := update[key]
+		if value == current[key] {
+			continue
+		}
+		field, err := s.attributes.checkChange(key, value != "", selfUpdate)
+		if err != nil {
+			return err
+		}
+		if field == nil || value == "" {
+			continue
+		}
+		if reason := field.checkAttribute(value); reason != "" {
+			return s.attributes.error(key, reason)
+		}
+	}
+	return nil
+}
+
+// CheckParticipantMetadata checks participant metadata replacing current.
+// Participants updating their own can only change fields the schema lets them.
+func (s *MetadataSchema) CheckParticipantMetadata(current, metadata string, selfUpdate bool) error {
+	if s == nil {
+		return nil
+	}
+	return s.participantMetadata.checkMetadata(current, metadata, selfUpdate)
+}
+
+// CheckRoomMetadata checks room metadata, which only room admins can update
+func (s *MetadataSchema) CheckRoomMetadata(metadata string) error {
+	if s == nil {
+		return nil
+	}
+	return s.roomMetadata.checkMetadata("", metadata, false)
+}
+
+func (f *metadataFields) checkMetadata(current, metadata string, selfUpdate bool) error {
+	if f == nil || metadata == "" {
+		return nil
+	}
+	var next map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(metadata), &next); err != nil || next == nil {
+		return &MetadataSchemaError{Field: f.name, Reason: "must be a JSON object"}
+	}
+	// metadata set before the schema applied may not be an object, all of its keys are then changed
+	var prev map[string]json.RawMessage
+	_ = json.Unmarshal([]byte(current), &prev)
+
+	keys := sortedKeys(next)
+	for _, key := range sortedKeys(prev) {
+		if _, ok := next[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		value, set := next[key]
+		if old, ok := prev[key]; ok == set && (!set || jsonEqual(old, value)) {
+			continue
+		}
+		field, err := f.checkChange(key, set, selfUpdate)
+		if err != nil {
+			return err
+		}
+		if field == nil || !set {
+			continue
+		}
+		if reason := field.checkJSON(value); reason != "" {
+			return f.error(key, reason)
+		}
+	}
+	return nil
+}
+
+// checkChange checks key can be set or removed, returning its field when it is listed
+func (f *metadataFields) checkChange(key string, set bool, selfUpdate bool) (*metadataField, error) {
+	field, ok := f.fields[key]
+	if !ok {
+		if set && !f.allowUnknown {
+			return nil, f.error(key, "unknown field")
+		}
+		return nil, nil
+	}
+	if selfUpdate && !field.selfUpdate {
+		return nil, f.error(key, "can only be updated by room admins")
+	}
+	return &field, nil
+}
+
+func (f *metadataFields) error(key string, reason string) error {
+	return &MetadataSchemaError{Field: f.name + "." + key, Reason: reason}
+}
+
+func (f *metadataField) checkAttribute(value string) string {
+	switch f.typ {
+	case config.MetadataFieldTypeNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "must be a number"
+		}
+	case config.MetadataFieldTypeBoolean:
+		if value != "true" && value != "false" {
+			return "must be true or false"
+		}
+	case config.MetadataFieldTypeJSON:
+		if !json.Valid([]byte(value)) {
+			return "must be JSON"
+		}
+	}
+	if f.pattern != nil && !f.pattern.MatchString(value) {
+		return "does not match the required pattern"
+	}
+	return ""
+}
+
+func (f *metadataField) checkJSON(raw json.RawMessage) string {
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "must be JSON"
+	}
+	switch f.typ {
+	case config.MetadataFieldTypeString:
+		if _, ok := value.(string); !ok {
+			return "must be a string"
+		}
+	case config.MetadataFieldTypeNumber:
+		if _, ok := value.(float64); !ok {
+			return "must be a number"
+		}
+	case config.MetadataFieldTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return "must be true or false"
+		}
+	}
+	if s, ok := value.(string); ok && f.pattern != nil && !f.pattern.MatchString(s) {
+		return "does not match the required pattern"
+	}
+	return ""
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var av, bv any
+	if json.Unmarshal(a, &av) != nil || json.Unmarshal(b, &bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ---------------------------------------------------------------
+
+// SetMetadataSchema sets the schema attributes and metadata of the room and of participants joining it follow
+func (r *Room) SetMetadataSchema(conf config.MetadataSchemaConfig) error {
+	schema, err := NewMetadataSchema(conf)
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	r.metadataSchema = schema
+	r.lock.Unlock()
+	return nil
+}
+
+// GetMetadataSchema returns the schema of the room, nil when it has none
+func (r *Room) GetMetadataSchema() *MetadataSchema {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.metadataSchema
+}
+
+// CheckMetadata checks room metadata follows the schema of the room
+func (r *Room) CheckMetadata(metadata string) error {
+	return r.GetMetadataSchema().CheckRoomMetadata(metadata)
+}
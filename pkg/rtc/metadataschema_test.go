@@ -0,0 +1,161 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func testMetadataSchema(t *testing.T) *MetadataSchema {
+	schema, err := NewMetadataSchema(config.MetadataSchemaConfig{
+		Attributes: &config.MetadataFieldsConfig{
+			Fields: map[string]config.MetadataFieldConfig{
+				"role":        {Pattern: "teacher|student"},
+				"hand_raised": {Type: config.MetadataFieldTypeBoolean, SelfUpdate: true},
+				"seat":        {Type: config.MetadataFieldTypeNumber, SelfUpdate: true},
+			},
+		},
+		ParticipantMetadata: &config.MetadataFieldsConfig{
+			Fields: map[string]config.MetadataFieldConfig{
+				"grade":    {Type: config.MetadataFieldTypeNumber},
+				"nickname": {Pattern: "[a-z]+", SelfUpdate: true},
+			},
+			AllowUnknown: true,
+		},
+		RoomMetadata: &config.MetadataFieldsConfig{
+			Fields: map[string]config.MetadataFieldConfig{
+				"topic":    {},
+				"settings": {Type: config.MetadataFieldTypeJSON},
+			},
+		},
+	})
+	require.NoError(t, err)
+	return schema
+}
+
+func requireSchemaError(t *testing.T, err error, field string) {
+	var schemaErr *MetadataSchemaError
+	require.ErrorAs(t, err, &schemaErr)
+	require.Equal(t, field, schemaErr.Field)
+}
+
+func TestMetadataSchema(t *testing.T) {
+	schema := testMetadataSchema(t)
+
+	t.Run("attributes", func(t *testing.T) {
+		current := map[string]string{"role": "student"}
+		require.NoError(t, schema.CheckAttributes(current, map[string]string{"role": "teacher", "seat": "3"}, false))
+		requireSchemaError(t, schema.CheckAttributes(current, map[string]string{"other": "x"}, false), "attributes.other")
+		requireSchemaError(t, schema.CheckAttributes(current, map[string]string{"role": "principal"}, false), "attributes.role")
+		requireSchemaError(t, schema.CheckAttributes(current, map[string]string{"hand_raised": "yes"}, false), "attributes.hand_raised")
+		requireSchemaError(t, schema.CheckAttributes(current, map[string]string{"seat": "front"}, false), "attributes.seat")
+
+		// participants only update self_update fields, unchanged values are fine
+		require.NoError(t, schema.CheckAttributes(current, map[string]string{"role": "student", "hand_raised": "true"}, true))
+		requireSchemaError(t, schema.CheckAttributes(current, map[string]string{"role": "teacher"}, true), "attributes.role")
+		requireSchemaError(t, schema.CheckAttributes(current, map[string]string{"role": ""}, true), "attributes.role")
+		require.NoError(t, schema.CheckAttributes(current, map[string]string{"role": ""}, false))
+	})
+
+	t.Run("participant metadata", func(t *testing.T) {
+		current := `{"grade": 5, "nickname": "sam"}`
+		require.NoError(t, schema.CheckParticipantMetadata(current, `{"grade": 6, "nickname": "sam", "color": "red"}`, false))
+		requireSchemaError(t, schema.CheckParticipantMetadata(current, "not json", false), "metadata")
+		requireSchemaError(t, schema.CheckParticipantMetadata(current, `["grade"]`, false), "metadata")
+		requireSchemaError(t, schema.CheckParticipantMetadata(current, `{"grade": "six"}`, false), "metadata.grade")
+		requireSchemaError(t, schema.CheckParticipantMetadata(current, `{"nickname": "Sam"}`, false), "metadata.nickname")
+
+		require.NoError(t, schema.CheckParticipantMetadata(current, `{"nickname": "alex", "grade": 5.0, "color": "red"}`, true))
+		requireSchemaError(t, schema.CheckParticipantMetadata(current, `{"grade": 6, "nickname": "sam"}`, true), "metadata.grade")
+		// removing a field is a change too
+		requireSchemaError(t, schema.CheckParticipantMetadata(current, `{"nickname": "sam"}`, true), "metadata.grade")
+	})
+
+	t.Run("room metadata", func(t *testing.T) {
+		require.NoError(t, schema.CheckRoomMetadata(`{"topic": "math", "settings": {"quiz": true}}`))
+		require.NoError(t, schema.CheckRoomMetadata(""))
+		requireSchemaError(t, schema.CheckRoomMetadata(`{"topic": 1}`), "metadata.topic")
+		requireSchemaError(t, schema.CheckRoomMetadata(`{"agenda": []}`), "metadata.agenda")
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := NewMetadataSchema(config.MetadataSchemaConfig{
+			Attributes: &config.MetadataFieldsConfig{
+				Fields: map[string]config.MetadataFieldConfig{"role": {Pattern: "("}},
+			},
+		})
+		require.Error(t, err)
+		_, err = NewMetadataSchema(config.MetadataSchemaConfig{
+			RoomMetadata: &config.MetadataFieldsConfig{
+				Fields: map[string]config.MetadataFieldConfig{"topic": {Type: "date"}},
+			},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("nil schema", func(t *testing.T) {
+		var schema *MetadataSchema
+		require.NoError(t, schema.CheckAttributes(nil, map[string]string{"any": "thing"}, true))
+		require.NoError(t, schema.CheckParticipantMetadata("", "not json", true))
+		require.NoError(t, schema.CheckRoomMetadata("not json"))
+	})
+}
+
+func TestParticipantMetadataSchema(t *testing.T) {
+	p := newParticipantForTest("student")
+	p.params.MetadataSchema = testMetadataSchema(t)
+	p.SetAttributes(map[string]string{"role": "student"})
+
+	require.NoError(t, p.CheckMetadataSchema("", map[string]string{"hand_raised": "true"}, true))
+	requireSchemaError(t, p.CheckMetadataSchema("", map[string]string{"role": "teacher"}, true), "attributes.role")
+	require.NoError(t, p.CheckMetadataSchema("", map[string]string{"role": "teacher"}, false))
+	requireSchemaError(t, p.CheckMetadataSchema(`{"grade": 1}`, nil, true), "metadata.grade")
+}
+
+func TestSignalUpdateMetadataSchema(t *testing.T) {
+	p := &typesfakes.FakeLocalParticipant{}
+	grants := &auth.ClaimGrants{Video: &auth.VideoGrant{}}
+	grants.Video.SetCanUpdateOwnMetadata(true)
+	p.ClaimGrantsReturns(grants)
+	p.CheckMetadataSchemaReturns(&MetadataSchemaError{Field: "attributes.role", Reason: "can only be updated by room admins"})
+
+	req := &livekit.SignalRequest{
+		Message: &livekit.SignalRequest_UpdateMetadata{
+			UpdateMetadata: &livekit.UpdateParticipantMetadata{
+				Attributes: map[string]string{"role": "teacher"},
+				RequestId:  7,
+			},
+		},
+	}
+	require.NoError(t, HandleParticipantSignal(nil, p, req, logger.GetLogger()))
+
+	_, _, selfUpdate := p.CheckMetadataSchemaArgsForCall(0)
+	require.True(t, selfUpdate)
+	require.Zero(t, p.SetAttributesCallCount())
+	require.Equal(t, 1, p.SendRequestResponseCallCount())
+	res := p.SendRequestResponseArgsForCall(0)
+	require.Equal(t, uint32(7), res.RequestId)
+	require.Equal(t, livekit.RequestResponse_NOT_ALLOWED, res.Reason)
+	require.Equal(t, "attributes.role: can only be updated by room admins", res.Message)
+}
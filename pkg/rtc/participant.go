@@ -49,6 +49,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
+	"github.com/livekit/livekit-server/pkg/sfu/fec"
 	"github.com/livekit/livekit-server/pkg/sfu/pacer"
 	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
 	"github.com/livekit/livekit-server/pkg/telemetry"
@@ -117,6 +118,7 @@ type ParticipantParams struct {
 	AudioConfig             sfu.AudioConfig
 	VideoConfig             config.VideoConfig
 	LimitConfig             config.LimitConfig
+	MetadataSchema          *MetadataSchema
 	ProtocolVersion         types.ProtocolVersion
 	SessionStartTime        time.Time
 	Telemetry               telemetry.TelemetryService
@@ -143,6 +145,7 @@ type ParticipantParams struct {
 	GetParticipantInfo             func(pID livekit.ParticipantID) *livekit.ParticipantInfo
 	GetRegionSettings              func(ip string) *livekit.RegionSettings
 	GetSubscriberForwarderState    func(p types.LocalParticipant) (map[livekit.TrackID]*livekit.RTPForwarderState, error)
+	AcquirePublisherSlot           func(p types.LocalParticipant) bool
 	DisableSupervisor              bool
 	ReconnectOnPublicationError    bool
 	ReconnectOnSubscriptionError   bool
@@ -162,6 +165,7 @@ type ParticipantParams struct {
 	EnableMetrics                  bool
 	DataChannelMaxBufferedAmount   uint64
 	DatachannelSlowThreshold       int
+	ReliableDataReplay             config.ReliableDataReplayConfig
 	FireOnTrackBySdp               bool
 }
 
@@ -465,6 +469,25 @@ func (p *ParticipantImpl) CheckMetadataLimits(
 	return nil
 }
 
+// CheckMetadataSchema checks metadata and attributes updates follow the metadata schema of the room.
+// Participants updating their own can only change fields the schema lets them.
+func (p *ParticipantImpl) CheckMetadataSchema(
+	metadata string,
+	attributes map[string]string,
+	selfUpdate bool,
+) error {
+	schema := p.params.MetadataSchema
+	if schema == nil {
+		return nil
+	}
+
+	grants := p.grants.Load()
+	if err := schema.CheckParticipantMetadata(grants.Metadata, metadata, selfUpdate); err != nil {
+		return err
+	}
+	return schema.CheckAttributes(grants.Attributes, attributes, selfUpdate)
+}
+
 // SetName attaches name to the participant
 func (p *ParticipantImpl) SetName(name string) {
 	p.lock.Lock()
@@ -559,6 +582,23 @@ func (p *ParticipantImpl) ClaimGrants() *auth.ClaimGrants {
 	return p.grants.Load()
 }
 
+// MoveToRoom scopes the grants of the participant to the room it was moved to.
+// The caller is expected to refresh the token of the client, for it to resume in that room.
+func (p *ParticipantImpl) MoveToRoom(roomName livekit.RoomName) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	grants := p.grants.Load()
+	if grants.Video.Room == string(roomName) {
+		return
+	}
+
+	p.params.Logger.Infow("moving participant", "toRoom", roomName)
+	grants = grants.Clone()
+	grants.Video.Room = string(roomName)
+	p.grants.Store(grants)
+}
+
 func (p *ParticipantImpl) SetPermission(permission *livekit.ParticipantPermission) bool {
 	if permission == nil {
 		return false
@@ -824,8 +864,24 @@ func (p *ParticipantImpl) synthesizeAddTrackRequests(offer webrtc.SessionDescrip
 	}
 
 	for _, m := range parsed.MediaDescriptions {
-		if !strings.EqualFold(m.MediaName.Media, "audio") {
-			// ONE-SHOT-SIGNALLING-MODE-TODO: support video
+		var (
+			trackType   livekit.TrackType
+			trackSource livekit.TrackSource
+			trackName   string
+		)
+		switch {
+		case strings.EqualFold(m.MediaName.Media, "audio"):
+			trackType, trackSource, trackName = livekit.TrackType_AUDIO, livekit.TrackSource_MICROPHONE, "synthesized-microphone"
+		case strings.EqualFold(m.MediaName.Media, "video"):
+			trackType, trackSource, trackName = livekit.TrackType_VIDEO, livekit.TrackSource_CAMERA, "synthesized-camera"
+		default:
+			continue
+		}
+		// remote is not sending on this section, e. g. a WHEP viewer
+		if _, ok := m.Attribute(webrtc.RTPTransceiverDirectionRecvonly.String()); ok {
+			continue
+		}
+		if _, ok := m.Attribute(webrtc.RTPTransceiverDirectionInactive.String()); ok {
 			continue
 		}
 
@@ -854,9 +910,9 @@ func (p *ParticipantImpl) synthesizeAddTrackRequests(offer webrtc.SessionDescrip
 
 		req := &livekit.AddTrackRequest{
 			Cid:        trackID,
-			Name:       "synthesized-microphone",
-			Source:     livekit.TrackSource_MICROPHONE,
-			Type:       livekit.TrackType_AUDIO,
+			Name:       trackName,
+			Source:     trackSource,
+			Type:       trackType,
 			DisableDtx: true,
 			Stereo:     false,
 			Stream:     "camera",
@@ -981,6 +1037,10 @@ func (p *ParticipantImpl) AddTrack(req *livekit.AddTrackRequest) {
 		p.pubLogger.Warnw("no permission to publish track", nil)
 		return
 	}
+	if !p.acquirePublisherSlot() {
+		p.pubLogger.Warnw("no publisher slot to publish track", nil)
+		return
+	}
 
 	p.pendingTracksLock.Lock()
 	ti := p.addPendingTrackLocked(req)
@@ -1301,6 +1361,14 @@ func (p *ParticipantImpl) CanPublish() bool {
 	return p.grants.Load().Video.GetCanPublish()
 }
 
+// acquirePublisherSlot checks with the room that the participant may publish, rooms with a stage have a few publisher slots
+func (p *ParticipantImpl) acquirePublisherSlot() bool {
+	if p.params.AcquirePublisherSlot == nil {
+		return true
+	}
+	return p.params.AcquirePublisherSlot(p)
+}
+
 func (p *ParticipantImpl) CanPublishSource(source livekit.TrackSource) bool {
 	return p.grants.Load().Video.GetCanPublishSource(source)
 }
@@ -1363,15 +1431,14 @@ func (p *ParticipantImpl) onTrackSubscribed(subTrack types.SubscribedTrack) {
 				dt.SeedState(sfu.DownTrackState{ForwarderState: p.getAndDeleteForwarderState(subTrack.ID())})
 				dt.SetConnected()
 			}
-			// ONE-SHOT-SIGNALLING-MODE-TODO: video support should add to publisher PC for congestion control
 		} else {
 			if p.TransportManager.HasSubscriberEverConnected() {
 				dt := subTrack.DownTrack()
 				dt.SeedState(sfu.DownTrackState{ForwarderState: p.getAndDeleteForwarderState(subTrack.ID())})
 				dt.SetConnected()
 			}
-			p.TransportManager.AddSubscribedTrack(subTrack)
 		}
+		p.TransportManager.AddSubscribedTrack(subTrack)
 	})
 }
 
@@ -1454,6 +1521,11 @@ func (h PublisherTransportHandler) OnDataPacket(kind livekit.DataPacket_Kind, da
 	h.p.onDataMessage(kind, data)
 }
 
+// only called in one-shot signalling mode when the publisher peer connection carries subscribed tracks
+func (h PublisherTransportHandler) OnStreamStateChange(update *streamallocator.StreamStateUpdate) error {
+	return h.p.onStreamStateChange(update)
+}
+
 // ----------------------------------------------------------
 
 type SubscriberTransportHandler struct {
@@ -1532,11 +1604,13 @@ func (p *ParticipantImpl) setupTransportManager() error {
 		AllowPlayoutDelay:            p.params.PlayoutDelay.GetEnabled(),
 		DataChannelMaxBufferedAmount: p.params.DataChannelMaxBufferedAmount,
 		DatachannelSlowThreshold:     p.params.DatachannelSlowThreshold,
+		ReliableDataReplay:           p.params.ReliableDataReplay,
 		Logger:                       p.params.Logger.WithComponent(sutils.ComponentTransport),
 		PublisherHandler:             pth,
 		SubscriberHandler:            sth,
 		DataChannelStats:             p.dataChannelStats,
 		UseOneShotSignallingMode:     p.params.UseOneShotSignallingMode,
+		OneShotSendSide:              p.CanSubscribe() && !p.CanPublish(),
 		FireOnTrackBySdp:             p.params.FireOnTrackBySdp,
 	}
 	if p.params.SyncStreams && p.params.PlayoutDelay.GetEnabled() && p.params.ClientInfo.isFirefox() {
@@ -1784,6 +1858,13 @@ func (p *ParticipantImpl) onMediaTrack(rtcTrack *webrtc.TrackRemote, rtpReceiver
 		p.removePublishedTrack(publishedTrack)
 		return
 	}
+	if !p.acquirePublisherSlot() {
+		p.pubLogger.Warnw("no publisher slot to publish mediaTrack", nil,
+			"source", publishedTrack.Source(),
+		)
+		p.removePublishedTrack(publishedTrack)
+		return
+	}
 
 	p.setIsPublisher(true)
 	p.dirty.Store(true)
@@ -1920,8 +2001,12 @@ func (p *ParticipantImpl) onDataMessage(kind livekit.DataPacket_Kind, data []byt
 
 	if p.Hidden() {
 		dp.ParticipantIdentity = ""
-	} else if overrideSenderIdentity {
-		dp.ParticipantIdentity = string(p.params.Identity)
+		dp.ParticipantSid = ""
+	} else {
+		if overrideSenderIdentity {
+			dp.ParticipantIdentity = string(p.params.Identity)
+		}
+		dp.ParticipantSid = string(p.params.SID)
 	}
 
 	if shouldForwardData {
@@ -2114,11 +2199,7 @@ func (p *ParticipantImpl) onStreamStateChange(update *streamallocator.StreamStat
 		})
 	}
 
-	return p.writeMessage(&livekit.SignalResponse{
-		Message: &livekit.SignalResponse_StreamStateUpdate{
-			StreamStateUpdate: streamStateUpdate,
-		},
-	})
+	return p.SendStreamStateUpdate(streamStateUpdate)
 }
 
 func (p *ParticipantImpl) onSubscribedMaxQualityChange(
@@ -2259,9 +2340,10 @@ func (p *ParticipantImpl) addPendingTrackLocked(req *livekit.AddTrackRequest) *l
 				clonedLayers = append(clonedLayers, utils.CloneProto(l))
 			}
 			ti.Codecs = append(ti.Codecs, &livekit.SimulcastCodecInfo{
-				MimeType: mime,
-				Cid:      codec.Cid,
-				Layers:   clonedLayers,
+				MimeType:       mime,
+				Cid:            codec.Cid,
+				Layers:         clonedLayers,
+				VideoLayerMode: codec.VideoLayerMode,
 			})
 		}
 	}
@@ -2966,6 +3048,11 @@ func (p *ParticipantImpl) SendDataPacket(kind livekit.DataPacket_Kind, encoded [
 	return p.TransportManager.SendDataPacket(kind, encoded)
 }
 
+// ReplayReliableData sends a resuming participant the reliable data it missed after lastSeq
+func (p *ParticipantImpl) ReplayReliableData(lastSeq uint32) {
+	p.TransportManager.ReplayReliableData(lastSeq)
+}
+
 func (p *ParticipantImpl) onDataSendError(err error) {
 	if p.params.ReconnectOnDataChannelError {
 		p.params.Logger.Infow("issuing full reconnect on data channel error", "error", err)
@@ -2989,6 +3076,10 @@ func (p *ParticipantImpl) setupEnabledCodecs(publishEnabledCodecs []*livekit.Cod
 		if shouldDisable(c, disabledCodecs.GetCodecs()) || shouldDisable(c, disabledCodecs.GetPublish()) {
 			continue
 		}
+		// FEC is only generated by the SFU, for subscribers
+		if strings.EqualFold(c.Mime, fec.MimeTypeFlexFEC03) {
+			continue
+		}
 
 		// sort by compatibility, since we will look for backups in these.
 		if strings.EqualFold(c.Mime, webrtc.MimeTypeVP8) {
@@ -3010,6 +3101,9 @@ func (p *ParticipantImpl) setupEnabledCodecs(publishEnabledCodecs []*livekit.Cod
 		if shouldDisable(c, disabledCodecs.GetCodecs()) {
 			continue
 		}
+		if strings.EqualFold(c.Mime, fec.MimeTypeFlexFEC03) && !p.params.ClientInfo.SupportsFlexFEC() {
+			continue
+		}
 		subscribeCodecs = append(subscribeCodecs, c)
 	}
 	p.enabledSubscribeCodecs = subscribeCodecs
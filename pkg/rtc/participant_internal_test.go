@@ -397,6 +397,31 @@ func TestDisableCodecs(t *testing.T) {
 	require.False(t, found264)
 }
 
+func TestFlexFECCodec(t *testing.T) {
+	codecs := []*livekit.Codec{{Mime: "video/vp8"}, {Mime: "video/flexfec-03"}}
+	hasFlexFEC := func(codecs []*livekit.Codec) bool {
+		for _, c := range codecs {
+			if c.Mime == "video/flexfec-03" {
+				return true
+			}
+		}
+		return false
+	}
+
+	// only generated for subscribers
+	p := newParticipantForTest("123")
+	p.enabledPublishCodecs = nil
+	p.setupEnabledCodecs(codecs, codecs, nil)
+	require.False(t, hasFlexFEC(p.enabledPublishCodecs))
+	require.True(t, hasFlexFEC(p.enabledSubscribeCodecs))
+
+	p = newParticipantForTestWithOpts("456", &participantOpts{
+		clientInfo: &livekit.ClientInfo{Sdk: livekit.ClientInfo_GO},
+	})
+	p.setupEnabledCodecs(codecs, codecs, nil)
+	require.False(t, hasFlexFEC(p.enabledSubscribeCodecs))
+}
+
 func TestDisablePublishCodec(t *testing.T) {
 	participant := newParticipantForTestWithOpts("123", &participantOpts{
 		publisher: true,
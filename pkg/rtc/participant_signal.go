@@ -189,6 +189,14 @@ func (p *ParticipantImpl) SendConnectionQualityUpdate(update *livekit.Connection
 	})
 }
 
+func (p *ParticipantImpl) SendStreamStateUpdate(update *livekit.StreamStateUpdate) error {
+	return p.writeMessage(&livekit.SignalResponse{
+		Message: &livekit.SignalResponse_StreamStateUpdate{
+			StreamStateUpdate: update,
+		},
+	})
+}
+
 func (p *ParticipantImpl) SendRefreshToken(token string) error {
 	return p.writeMessage(&livekit.SignalResponse{
 		Message: &livekit.SignalResponse_RefreshToken{
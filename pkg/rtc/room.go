@@ -42,6 +42,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
 	"github.com/livekit/livekit-server/pkg/telemetry"
 	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 	sutils "github.com/livekit/livekit-server/pkg/utils"
@@ -74,6 +75,12 @@ type AgentStore interface {
 	DeleteAgentJob(ctx context.Context, job *livekit.Job) error
 }
 
+// AgentDispatchRestorer is implemented by agent stores that survive a node restart
+type AgentDispatchRestorer interface {
+	// TakeRestoredAgentDispatches returns the dispatches of a room restored after a restart, once
+	TakeRestoredAgentDispatches(ctx context.Context, roomName livekit.RoomName) ([]*livekit.AgentDispatch, bool)
+}
+
 type broadcastOptions struct {
 	skipSource bool
 	immediate  bool
@@ -98,7 +105,9 @@ type Room struct {
 	joinedAt atomic.Int64
 	// time that the last participant left the room
 	leftAt atomic.Int64
-	holds  atomic.Int32
+	// time the empty timeout counts from when nobody joined, when not the creation time
+	emptySince atomic.Int64
+	holds      atomic.Int32
 
 	lock sync.RWMutex
 
@@ -127,6 +136,37 @@ type Room struct {
 	agentParticpants          map[livekit.ParticipantIdentity]*agentJob
 	bufferFactory             *buffer.FactoryOfBufferFactory
 
+	// tracks published here that are bridged to other rooms, and tracks of other rooms bridged here
+	outgoingBridges map[string]*TrackBridge
+	incomingBridges map[string]*TrackBridge
+
+	// room wide rules on who can subscribe to which tracks, on top of publisher permissions
+	subscriptionRules []config.SubscriptionRule
+
+	// participants waiting to be admitted when the room has a lobby, they are in participants as well
+	lobbyConfig *config.LobbyConfig
+	lobby       map[livekit.ParticipantIdentity]*lobbyEntry
+
+	// participants holding a publisher slot and waiting for one, when the room has a stage
+	stageConfig *config.StageConfig
+	onStage     []livekit.ParticipantIdentity
+	raisedHands []RaisedHand
+
+	// moderates data packets published by participants before they are forwarded
+	dataPacketFilter DataPacketFilter
+	// latest chat messages, replayed to participants joining
+	chatHistory *ChatHistory
+	// attributes and metadata of the room and its participants follow it when set
+	metadataSchema *MetadataSchema
+	// audio tracks forwarded to each subscriber, when only the loudest are
+	lastNAudio *lastNAudioSubscribers
+	// decides which tracks get which layers for subscribers short of bandwidth, default policy when nil
+	allocationPolicy streamallocator.AllocationPolicy
+
+	// set on scheduled rooms, closing the room when reached
+	expiresAt    time.Time
+	expiryTimers []*time.Timer
+
 	// batch update participant info for non-publishers
 	batchedUpdates   map[livekit.ParticipantIdentity]*participantUpdate
 	batchedUpdatesMu sync.Mutex
@@ -138,6 +178,7 @@ type Room struct {
 	onParticipantChanged func(p types.LocalParticipant)
 	onRoomUpdated        func()
 	onClose              func()
+	onExpiring           func(remaining time.Duration)
 
 	simulationLock                                 sync.Mutex
 	disconnectSignalOnResumeParticipants           map[livekit.ParticipantIdentity]time.Time
@@ -264,6 +305,9 @@ func NewRoom(
 		participantRequestSources:            make(map[livekit.ParticipantIdentity]routing.MessageSource),
 		hasPublished:                         make(map[livekit.ParticipantIdentity]bool),
 		agentParticpants:                     make(map[livekit.ParticipantIdentity]*agentJob),
+		outgoingBridges:                      make(map[string]*TrackBridge),
+		incomingBridges:                      make(map[string]*TrackBridge),
+		lobby:                                make(map[livekit.ParticipantIdentity]*lobbyEntry),
 		bufferFactory:                        buffer.NewFactoryOfBufferFactory(config.Receiver.PacketBufferSizeVideo, config.Receiver.PacketBufferSizeAudio),
 		batchedUpdates:                       make(map[livekit.ParticipantIdentity]*participantUpdate),
 		closed:                               make(chan struct{}),
@@ -342,8 +386,18 @@ func (r *Room) GetParticipants() []types.LocalParticipant {
 	return maps.Values(r.participants)
 }
 
+// GetLocalParticipants returns the participants taking part in the room, leaving out those waiting in the lobby
 func (r *Room) GetLocalParticipants() []types.LocalParticipant {
-	return r.GetParticipants()
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	participants := make([]types.LocalParticipant, 0, len(r.participants))
+	for identity, p := range r.participants {
+		if r.lobby[identity] == nil {
+			participants = append(participants, p)
+		}
+	}
+	return participants
 }
 
 func (r *Room) GetParticipantCount() int {
@@ -392,6 +446,12 @@ func (r *Room) LastLeftAt() int64 {
 	return r.leftAt.Load()
 }
 
+// RestartEmptyTimeout counts the empty timeout of a room nobody joined from now instead of its creation,
+// e.g. for a room restored after a restart
+func (r *Room) RestartEmptyTimeout() {
+	r.emptySince.Store(time.Now().Unix())
+}
+
 func (r *Room) Internal() *livekit.RoomInternal {
 	return r.internal
 }
@@ -413,6 +473,9 @@ func (r *Room) Release() {
 }
 
 func (r *Room) Join(participant types.LocalParticipant, requestSource routing.MessageSource, opts *ParticipantOptions, iceServers []*livekit.ICEServer) error {
+	// the participant replaces the bridged view of its tracks
+	r.closeIncomingBridges(participant.Identity())
+
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
@@ -420,13 +483,87 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 		return ErrRoomClosed
 	}
 
+	inLobby := r.needsAdmissionLocked(participant)
+	if inLobby {
+		if err := r.canEnterLobbyLocked(participant); err != nil {
+			return err
+		}
+	} else if err := r.canJoinLocked(participant); err != nil {
+		return err
+	}
+
+	if r.FirstJoinedAt() == 0 {
+		r.joinedAt.Store(time.Now().Unix())
+	}
+
+	if inLobby {
+		r.enterLobbyLocked(participant)
+	}
+	r.setParticipantCallbacks(participant)
+
+	r.Logger.Debugw("new participant joined",
+		"pID", participant.ID(),
+		"participant", participant.Identity(),
+		"clientInfo", logger.Proto(participant.GetClientInfo()),
+		"options", opts,
+		"numParticipants", len(r.participants),
+	)
+
+	r.addParticipantLocked(participant, requestSource, opts)
+
+	if r.onParticipantChanged != nil {
+		r.onParticipantChanged(participant)
+	}
+
+	time.AfterFunc(time.Minute, func() {
+		if !participant.Verify() {
+			r.RemoveParticipant(participant.Identity(), participant.ID(), types.ParticipantCloseReasonJoinTimeout)
+		}
+	})
+
+	joinResponse := r.createJoinResponseLocked(participant, iceServers)
+	if err := participant.SendJoinResponse(joinResponse); err != nil {
+		prometheus.ServiceOperationCounter.WithLabelValues("participant_join", "error", "send_response").Add(1)
+		return err
+	}
+	if inLobby {
+		go r.notifyLobbyHosts(LobbyParticipantInfo(participant))
+	}
+
+	participant.SetMigrateState(types.MigrateStateComplete)
+
+	if participant.SubscriberAsPrimary() {
+		// initiates sub connection as primary
+		if participant.ProtocolVersion().SupportFastStart() {
+			go func() {
+				r.subscribeToExistingTracks(participant)
+				participant.Negotiate(true)
+			}()
+		} else {
+			participant.Negotiate(true)
+		}
+	}
+
+	prometheus.ServiceOperationCounter.WithLabelValues("participant_join", "success", "").Add(1)
+
+	return nil
+}
+
+// canJoinLocked checks that participant can be added to the room, assumes lock is already acquired
+func (r *Room) canJoinLocked(participant types.LocalParticipant) error {
 	if r.participants[participant.Identity()] != nil {
 		return ErrAlreadyJoined
 	}
+	return r.checkMaxParticipantsLocked(participant)
+}
+
+// checkMaxParticipantsLocked checks that participant fits in the room, participants waiting in the lobby are not counted.
+// assumes lock is already acquired
+func (r *Room) checkMaxParticipantsLocked(participant types.LocalParticipant) error {
 	if r.protoRoom.MaxParticipants > 0 && !participant.IsDependent() {
 		numParticipants := uint32(0)
-		for _, p := range r.participants {
-			if !p.IsDependent() {
+		for identity, p := range r.participants {
+			if !p.IsDependent() && r.lobby[identity] == nil {
 				numParticipants++
 			}
 		}
@@ -434,11 +571,12 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 			return ErrMaxParticipantsExceeded
 		}
 	}
+	return nil
+}
 
-	if r.FirstJoinedAt() == 0 {
-		r.joinedAt.Store(time.Now().Unix())
-	}
-
+// setParticipantCallbacks routes the events of a participant to the room.
+// it's important to set these before connection, we don't want to miss out on any published tracks
+func (r *Room) setParticipantCallbacks(participant types.LocalParticipant) {
 	participant.OnStateChange(func(p types.LocalParticipant, state livekit.ParticipantInfo_State) {
 		if r.onParticipantChanged != nil {
 			r.onParticipantChanged(p)
@@ -448,6 +586,8 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 		if state == livekit.ParticipantInfo_ACTIVE {
 			// subscribe participant to existing published tracks
 			r.subscribeToExistingTracks(p)
+			r.replayChatHistory(p)
+			r.sendExpiry(p)
 
 			meta := &livekit.AnalyticsClientMeta{
 				ClientConnectTime: uint32(time.Since(p.ConnectedAt()).Milliseconds()),
@@ -473,7 +613,6 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 			go r.RemoveParticipant(p.Identity(), p.ID(), types.ParticipantCloseReasonNone)
 		}
 	})
-	// it's important to set this before connection, we don't want to miss out on any published tracks
 	participant.OnTrackPublished(r.onTrackPublished)
 	participant.OnTrackUpdated(r.onTrackUpdated)
 	participant.OnTrackUnpublished(r.onTrackUnpublished)
@@ -514,15 +653,21 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 			}, true)
 		}
 	})
+}
 
-	r.Logger.Debugw("new participant joined",
-		"pID", participant.ID(),
-		"participant", participant.Identity(),
-		"clientInfo", logger.Proto(participant.GetClientInfo()),
-		"options", opts,
-		"numParticipants", len(r.participants),
-	)
+func (r *Room) clearParticipantCallbacks(p types.LocalParticipant) {
+	p.OnTrackUpdated(nil)
+	p.OnTrackPublished(nil)
+	p.OnTrackUnpublished(nil)
+	p.OnStateChange(nil)
+	p.OnParticipantUpdate(nil)
+	p.OnDataPacket(nil)
+	p.OnMetrics(nil)
+	p.OnSubscribeStatusChanged(nil)
+}
 
+// addParticipantLocked assumes lock is already acquired
+func (r *Room) addParticipantLocked(participant types.LocalParticipant, requestSource routing.MessageSource, opts *ParticipantOptions) {
 	if participant.IsRecorder() && !r.protoRoom.ActiveRecording {
 		r.protoRoom.ActiveRecording = true
 		r.protoProxy.MarkDirty(true)
@@ -534,39 +679,43 @@ func (r *Room) Join(participant types.LocalParticipant, requestSource routing.Me
 	r.participantOpts[participant.Identity()] = opts
 	r.participantRequestSources[participant.Identity()] = requestSource
 
-	if r.onParticipantChanged != nil {
-		r.onParticipantChanged(participant)
+	if r.allocationPolicy != nil {
+		participant.SetSubscriberAllocationPolicy(r.allocationPolicy)
 	}
+}
 
-	time.AfterFunc(time.Minute, func() {
-		if !participant.Verify() {
-			r.RemoveParticipant(participant.Identity(), participant.ID(), types.ParticipantCloseReasonJoinTimeout)
-		}
-	})
-
-	joinResponse := r.createJoinResponseLocked(participant, iceServers)
-	if err := participant.SendJoinResponse(joinResponse); err != nil {
-		prometheus.ServiceOperationCounter.WithLabelValues("participant_join", "error", "send_response").Add(1)
-		return err
+// removeParticipantLocked assumes lock is already acquired, it returns whether the room changed in a way that needs an immediate update
+func (r *Room) removeParticipantLocked(p types.LocalParticipant) bool {
+	identity := p.Identity()
+	delete(r.participants, identity)
+	delete(r.participantOpts, identity)
+	delete(r.participantRequestSources, identity)
+	delete(r.hasPublished, identity)
+	delete(r.agentParticpants, identity)
+	delete(r.lobby, identity)
+	r.releasePublisherSlotLocked(identity)
+	r.lowerHandLocked(identity)
+	r.removeDataPacketFilterParticipantLocked(p.ID())
+	if !p.Hidden() {
+		r.protoRoom.NumParticipants--
 	}
 
-	participant.SetMigrateState(types.MigrateStateComplete)
+	immediateChange := false
+	if p.IsRecorder() {
+		activeRecording := false
+		for _, op := range r.participants {
+			if op.IsRecorder() {
+				activeRecording = true
+				break
+			}
+		}
 
-	if participant.SubscriberAsPrimary() {
-		// initiates sub connection as primary
-		if participant.ProtocolVersion().SupportFastStart() {
-			go func() {
-				r.subscribeToExistingTracks(participant)
-				participant.Negotiate(true)
-			}()
-		} else {
-			participant.Negotiate(true)
+		if r.protoRoom.ActiveRecording != activeRecording {
+			r.protoRoom.ActiveRecording = activeRecording
+			immediateChange = true
 		}
 	}
-
-	prometheus.ServiceOperationCounter.WithLabelValues("participant_join", "success", "").Add(1)
-
-	return nil
+	return immediateChange
 }
 
 func (r *Room) ReplaceParticipantRequestSource(identity livekit.ParticipantIdentity, reqSource routing.MessageSource) {
@@ -627,7 +776,12 @@ func (r *Room) ResumeParticipant(
 	}
 
 	// include the local participant's info as well, since metadata could have been changed
-	updates := r.getOtherParticipantInfo("")
+	var updates []*livekit.ParticipantInfo
+	if r.IsInLobby(p.Identity()) {
+		updates = []*livekit.ParticipantInfo{p.ToProto()}
+	} else {
+		updates = r.getOtherParticipantInfo("")
+	}
 	if err := p.SendParticipantUpdate(updates); err != nil {
 		return err
 	}
@@ -663,31 +817,9 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 	}
 
 	agentJob := r.agentParticpants[identity]
+	inLobby := r.lobby[identity] != nil
 
-	delete(r.participants, identity)
-	delete(r.participantOpts, identity)
-	delete(r.participantRequestSources, identity)
-	delete(r.hasPublished, identity)
-	delete(r.agentParticpants, identity)
-	if !p.Hidden() {
-		r.protoRoom.NumParticipants--
-	}
-
-	immediateChange := false
-	if p.IsRecorder() {
-		activeRecording := false
-		for _, op := range r.participants {
-			if op.IsRecorder() {
-				activeRecording = true
-				break
-			}
-		}
-
-		if r.protoRoom.ActiveRecording != activeRecording {
-			r.protoRoom.ActiveRecording = activeRecording
-			immediateChange = true
-		}
-	}
+	immediateChange := r.removeParticipantLocked(p)
 	r.lock.Unlock()
 	r.protoProxy.MarkDirty(immediateChange)
 
@@ -717,14 +849,7 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 		}()
 	}
 
-	p.OnTrackUpdated(nil)
-	p.OnTrackPublished(nil)
-	p.OnTrackUnpublished(nil)
-	p.OnStateChange(nil)
-	p.OnParticipantUpdate(nil)
-	p.OnDataPacket(nil)
-	p.OnMetrics(nil)
-	p.OnSubscribeStatusChanged(nil)
+	r.clearParticipantCallbacks(p)
 
 	// close participant as well
 	_ = p.Close(true, reason, false)
@@ -735,8 +860,123 @@ func (r *Room) RemoveParticipant(identity livekit.ParticipantIdentity, pID livek
 		if r.onParticipantChanged != nil {
 			r.onParticipantChanged(p)
 		}
-		r.broadcastParticipantState(p, broadcastOptions{skipSource: true})
+		if inLobby {
+			r.notifyLobbyHosts(LobbyParticipantInfo(p))
+		} else {
+			r.broadcastParticipantState(p, broadcastOptions{skipSource: true})
+		}
+	}
+}
+
+// DetachedParticipant is a live participant taken out of a room, to be attached to another room on this node
+type DetachedParticipant struct {
+	Participant   types.LocalParticipant
+	RequestSource routing.MessageSource
+	Options       *ParticipantOptions
+}
+
+// DetachParticipant takes a participant out of the room without closing its session, keeping its peer connections.
+// Its published tracks are withdrawn from the room and its subscriptions to tracks of the room are dropped,
+// the other participants see it leave.
+func (r *Room) DetachParticipant(identity livekit.ParticipantIdentity) (*DetachedParticipant, error) {
+	r.lock.Lock()
+	p, ok := r.participants[identity]
+	if !ok {
+		r.lock.Unlock()
+		return nil, ErrParticipantNotFound
+	}
+	if p.State() != livekit.ParticipantInfo_ACTIVE {
+		r.lock.Unlock()
+		return nil, ErrParticipantNotActive
+	}
+	if r.agentParticpants[identity] != nil {
+		r.lock.Unlock()
+		return nil, ErrCannotMoveAgent
+	}
+	if r.lobby[identity] != nil {
+		r.lock.Unlock()
+		return nil, ErrParticipantInLobby
+	}
+
+	d := &DetachedParticipant{
+		Participant:   p,
+		RequestSource: r.participantRequestSources[identity],
+		Options:       r.participantOpts[identity],
+	}
+	immediateChange := r.removeParticipantLocked(p)
+	r.lock.Unlock()
+	r.protoProxy.MarkDirty(immediateChange)
+
+	r.clearParticipantCallbacks(p)
+	r.closeOutgoingBridges(p.ID())
+
+	for _, t := range p.GetPublishedTracks() {
+		r.trackManager.RemoveTrack(t)
+		for _, subscriberID := range t.GetAllSubscribers() {
+			t.RemoveSubscriber(subscriberID, false)
+		}
+	}
+	p.UnsubscribeFromAllTracks()
+
+	// the participant is still active, the others need an explicit disconnect
+	pi := p.ToProto()
+	pi.State = livekit.ParticipantInfo_DISCONNECTED
+	if !p.Hidden() {
+		r.sendParticipantUpdates(r.pushAndDequeueUpdates(pi, types.ParticipantCloseReasonNone, true))
+	}
+
+	// and the participant needs to see the others leave
+	others := r.getOtherParticipantInfo(identity)
+	for _, opi := range others {
+		opi.State = livekit.ParticipantInfo_DISCONNECTED
 	}
+	if err := p.SendParticipantUpdate(others); err != nil {
+		p.GetLogger().Warnw("could not send update to participant", err)
+	}
+
+	r.leftAt.Store(time.Now().Unix())
+	return d, nil
+}
+
+// AttachParticipant adds a participant detached from another room, reusing its session.
+// Its tracks are republished in the room and it is subscribed to the tracks of the room as on join.
+func (r *Room) AttachParticipant(d *DetachedParticipant) error {
+	p := d.Participant
+	r.closeIncomingBridges(p.Identity())
+
+	r.lock.Lock()
+	if r.IsClosed() {
+		r.lock.Unlock()
+		return ErrRoomClosed
+	}
+	if err := r.canJoinLocked(p); err != nil {
+		r.lock.Unlock()
+		return err
+	}
+	if r.FirstJoinedAt() == 0 {
+		r.joinedAt.Store(time.Now().Unix())
+	}
+	r.setParticipantCallbacks(p)
+	r.addParticipantLocked(p, d.RequestSource, d.Options)
+	onParticipantChanged := r.onParticipantChanged
+	r.lock.Unlock()
+
+	_ = p.SendRoomUpdate(r.ToProto())
+	if err := p.SendParticipantUpdate(r.getOtherParticipantInfo(p.Identity())); err != nil {
+		p.GetLogger().Warnw("could not send update to participant", err)
+	}
+	r.broadcastParticipantState(p, broadcastOptions{skipSource: true, immediate: true})
+	if onParticipantChanged != nil {
+		onParticipantChanged(p)
+	}
+
+	for _, t := range p.GetPublishedTracks() {
+		r.onTrackPublished(p, t)
+	}
+	r.subscribeToExistingTracks(p)
+	r.replayChatHistory(p)
+	r.sendExpiry(p)
+	return nil
 }
 
 func (r *Room) UpdateSubscriptions(
@@ -804,6 +1044,10 @@ func (r *Room) SyncState(participant types.LocalParticipant, state *livekit.Sync
 		return nil
 	}
 
+	if lastSeq, ok := LastDataSequence(state); ok {
+		participant.ReplayReliableData(lastSeq)
+	}
+
 	// synthesize a track setting for each disabled track,
 	// can be set before addding subscriptions,
 	// in fact it is done before so that setting can be updated immediately upon subscription.
@@ -846,9 +1090,14 @@ func (r *Room) ResolveMediaTrackForSubscriber(sub types.LocalParticipant, trackI
 	res.PublisherID = info.PublisherID
 
 	pub := r.GetParticipantByID(info.PublisherID)
+	if pub == nil {
+		// bridged from another room, permissions of the publisher there apply
+		pub = r.getBridgedPublisher(trackID)
+	}
 	// when publisher is not found, we will assume it doesn't have permission to access
 	if pub != nil {
-		res.HasPermission = IsParticipantExemptFromTrackPermissionsRestrictions(sub) || pub.HasPermission(trackID, sub.Identity())
+		res.HasPermission = IsParticipantExemptFromTrackPermissionsRestrictions(sub) ||
+			(r.subscriptionPolicyAllows(pub, info.Track, sub) && pub.HasPermission(trackID, sub.Identity()))
 	}
 
 	return res
@@ -886,7 +1135,7 @@ func (r *Room) CloseIfEmpty() {
 		// need to give time in case participant is reconnecting
 		timeout = r.protoRoom.DepartureTimeout
 	} else {
-		elapsed = time.Now().Unix() - r.protoRoom.CreationTime
+		elapsed = time.Now().Unix() - max(r.protoRoom.CreationTime, r.emptySince.Load())
 		timeout = r.protoRoom.EmptyTimeout
 	}
 	r.lock.Unlock()
@@ -906,9 +1155,11 @@ func (r *Room) Close(reason types.ParticipantCloseReason) {
 		// fall through
 	}
 	close(r.closed)
+	r.stopExpiryTimersLocked()
 	r.lock.Unlock()
 
 	r.Logger.Infow("closing room")
+	r.closeAllBridges()
 	for _, p := range r.GetParticipants() {
 		_ = p.Close(true, reason, false)
 	}
@@ -1115,6 +1366,9 @@ func (r *Room) getOtherParticipantInfo(identity livekit.ParticipantIdentity) []*
 		}
 	}
 
+	r.lock.RLock()
+	pi = append(pi, r.bridgedParticipantInfosLocked()...)
+	r.lock.RUnlock()
 	return pi
 }
 
@@ -1129,11 +1383,17 @@ func (r *Room) autoSubscribe(participant types.LocalParticipant) bool {
 }
 
 func (r *Room) createJoinResponseLocked(participant types.LocalParticipant, iceServers []*livekit.ICEServer) *livekit.JoinResponse {
-	// gather other participants and send join response
+	// gather other participants and send join response, participants in the lobby get them once admitted
 	otherParticipants := make([]*livekit.ParticipantInfo, 0, len(r.participants))
-	for _, p := range r.participants {
-		if p.ID() != participant.ID() && !p.Hidden() {
-			otherParticipants = append(otherParticipants, p.ToProto())
+	if r.lobby[participant.Identity()] == nil {
+		for _, p := range r.participants {
+			if p.ID() != participant.ID() && !p.Hidden() {
+				otherParticipants = append(otherParticipants, p.ToProto())
+			}
+		}
+		otherParticipants = append(otherParticipants, r.bridgedParticipantInfosLocked()...)
+		if r.lobbyConfig != nil && isLobbyHost(participant) {
+			otherParticipants = append(otherParticipants, r.lobbyParticipantInfosLocked()...)
 		}
 	}
 
@@ -1264,10 +1524,31 @@ func (r *Room) onParticipantUpdate(p types.LocalParticipant) {
 	if r.onParticipantChanged != nil {
 		r.onParticipantChanged(p)
 	}
+	// attributes could have changed
+	r.reevaluateSubscriptionPolicy(p)
 }
 
 func (r *Room) onDataPacket(source types.LocalParticipant, kind livekit.DataPacket_Kind, dp *livekit.DataPacket) {
+	if source != nil {
+		if filter := r.getDataPacketFilter(); filter != nil {
+			if dp = filter.FilterDataPacket(source, dp); dp == nil {
+				return
+			}
+		}
+		// topics the server owns are never forwarded from participants, so they cannot pose as the server
+		switch dp.GetUser().GetTopic() {
+		case RoomExpiringTopic:
+			r.Logger.Debugw("dropping data packet on server topic", "participant", source.Identity(), "topic", RoomExpiringTopic)
+			return
+		case StageRaiseHandTopic:
+			r.handleStageRequest(source, dp)
+			return
+		}
+	}
 	BroadcastDataPacketForRoom(r, source, kind, dp, r.Logger)
+	if h := r.GetChatHistory(); h != nil {
+		h.Record(dp)
+	}
 }
 
 func (r *Room) onMetrics(source types.Participant, dp *livekit.DataPacket) {
@@ -1295,6 +1576,10 @@ func (r *Room) subscribeToExistingTracks(p types.LocalParticipant) {
 			p.SubscribeToTrack(track.ID())
 		}
 	}
+	for _, trackID := range r.getBridgedTrackIDs() {
+		trackIDs = append(trackIDs, trackID)
+		p.SubscribeToTrack(trackID)
+	}
 	if len(trackIDs) > 0 {
 		r.Logger.Debugw("subscribed participant to existing tracks", "trackID", trackIDs)
 	}
@@ -1302,6 +1587,19 @@ func (r *Room) subscribeToExistingTracks(p types.LocalParticipant) {
 
 // broadcast an update about participant p
 func (r *Room) broadcastParticipantState(p types.LocalParticipant, opts broadcastOptions) {
+	r.updateOutgoingBridges(p)
+
+	if r.IsInLobby(p.Identity()) {
+		if !opts.skipSource {
+			err := p.SendParticipantUpdate([]*livekit.ParticipantInfo{p.ToProto()})
+			if err != nil {
+				p.GetLogger().Errorw("could not send update to participant", err)
+			}
+		}
+		r.notifyLobbyHosts(LobbyParticipantInfo(p))
+		return
+	}
+
 	pi := p.ToProto()
 
 	if p.Hidden() {
@@ -1343,7 +1641,7 @@ func (r *Room) sendParticipantUpdates(updates []*participantUpdate) {
 		fullUpdates = append(fullUpdates, update.pi)
 	}
 
-	for _, op := range r.GetParticipants() {
+	for _, op := range r.GetLocalParticipants() {
 		var err error
 		if op.ProtocolVersion().SupportsIdentityBasedReconnection() {
 			err = op.SendParticipantUpdate(filteredUpdates)
@@ -1358,7 +1656,7 @@ func (r *Room) sendParticipantUpdates(updates []*participantUpdate) {
 
 // for protocol 3, send only changed updates
 func (r *Room) sendSpeakerChanges(speakers []*livekit.SpeakerInfo) {
-	for _, p := range r.GetParticipants() {
+	for _, p := range r.GetLocalParticipants() {
 		if p.ProtocolVersion().SupportsSpeakerChanged() {
 			_ = p.SendSpeakerUpdate(speakers, false)
 		}
@@ -1434,7 +1732,7 @@ func (r *Room) updateProto() *livekit.Room {
 
 	room.NumPublishers = 0
 	room.NumParticipants = 0
-	for _, p := range r.GetParticipants() {
+	for _, p := range r.GetLocalParticipants() {
 		if !p.IsDependent() {
 			room.NumParticipants++
 		}
@@ -1505,10 +1803,13 @@ func (r *Room) audioUpdateWorker() {
 		// see if an update is needed
 		if len(changedSpeakers) > 0 {
 			r.sendSpeakerChanges(changedSpeakers)
+			r.updateAllocationSpeakers(activeSpeakers)
 		}
 
 		lastActiveMap = nextActiveMap
 
+		r.updateLastNAudio()
+
 		time.Sleep(time.Duration(r.audioConfig.UpdateInterval) * time.Millisecond)
 	}
 }
@@ -1721,6 +2022,10 @@ func (r *Room) createAgentDispatchFromParams(agentName string, metadata string)
 }
 
 func (r *Room) createAgentDispatchesFromRoomAgent() {
+	if r.restoreAgentDispatches() {
+		return
+	}
+
 	if r.internal == nil {
 		return
 	}
@@ -1739,6 +2044,42 @@ func (r *Room) createAgentDispatchesFromRoomAgent() {
 	}
 }
 
+// restoreAgentDispatches picks up dispatches stored by a previous instance of the room, i.e. before a node restart,
+// instead of creating new ones. returns false when the room was not restored or there is nothing to restore.
+func (r *Room) restoreAgentDispatches() bool {
+	restorer, ok := r.agentStore.(AgentDispatchRestorer)
+	if !ok {
+		return false
+	}
+
+	dispatches, ok := restorer.TakeRestoredAgentDispatches(context.Background(), r.Name())
+	if !ok || len(dispatches) == 0 {
+		return false
+	}
+
+	for _, dispatch := range dispatches {
+		// jobs belonged to the previous instance and will be launched again
+		for _, job := range dispatch.GetState().GetJobs() {
+			job.Room = &livekit.Room{Name: r.protoRoom.Name}
+			if err := r.agentStore.DeleteAgentJob(context.Background(), job); err != nil {
+				r.Logger.Warnw("failed deleting stale agent job", err, "jobID", job.Id)
+			}
+		}
+		if dispatch.State == nil {
+			dispatch.State = &livekit.AgentDispatchState{
+				CreatedAt: time.Now().UnixNano(),
+			}
+		}
+		dispatch.State.Jobs = nil
+
+		r.lock.Lock()
+		r.agentDispatches[dispatch.Id] = newAgentDispatch(dispatch)
+		r.lock.Unlock()
+	}
+	r.Logger.Infow("restored agent dispatches", "count", len(dispatches))
+	return true
+}
+
 // ------------------------------------------------------------
 
 func BroadcastDataPacketForRoom(r types.Room, source types.LocalParticipant, kind livekit.DataPacket_Kind, dp *livekit.DataPacket, logger logger.Logger) {
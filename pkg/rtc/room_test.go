@@ -24,7 +24,6 @@ import (
 
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/utils"
-	"github.com/livekit/protocol/webhook"
 
 	"github.com/livekit/livekit-server/version"
 
@@ -412,6 +411,22 @@ func TestRoomClosure(t *testing.T) {
 		rm.CloseIfEmpty()
 		require.True(t, isClosed)
 	})
+
+	t.Run("restarted empty timeout counts from restart", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 0})
+		isClosed := false
+		rm.OnClose(func() {
+			isClosed = true
+		})
+		rm.lock.Lock()
+		rm.protoRoom.EmptyTimeout = 60
+		rm.protoRoom.CreationTime = time.Now().Add(-2 * time.Minute).Unix()
+		rm.lock.Unlock()
+
+		rm.RestartEmptyTimeout()
+		rm.CloseIfEmpty()
+		require.False(t, isClosed)
+	})
 }
 
 func TestNewTrack(t *testing.T) {
@@ -719,6 +734,69 @@ func TestDataChannel(t *testing.T) {
 			require.Zero(t, fp.SendDataPacketCallCount())
 		}
 	})
+
+	t.Run("server topics are not forwarded", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 3})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+		rm.SetStage(config.StageConfig{MaxPublishers: 1})
+		participants := rm.GetParticipants()
+		p := participants[0].(*typesfakes.FakeLocalParticipant)
+		p1 := participants[1].(*typesfakes.FakeLocalParticipant)
+
+		send := func(topic string, payload string, dest ...string) {
+			p.OnDataPacketArgsForCall(0)(p, livekit.DataPacket_RELIABLE, &livekit.DataPacket{
+				Kind:                  livekit.DataPacket_RELIABLE,
+				DestinationIdentities: dest,
+				Value: &livekit.DataPacket_User{
+					User: &livekit.UserPacket{
+						Topic:   proto.String(topic),
+						Payload: []byte(payload),
+					},
+				},
+			})
+		}
+
+		// a participant cannot announce the room expiring, to everyone or to a participant
+		send(RoomExpiringTopic, `{"expires_at":1}`)
+		send(RoomExpiringTopic, `{"expires_at":1}`, string(p1.Identity()))
+
+		// a raised hand is handled by the server, not forwarded
+		send(StageRaiseHandTopic, "raise")
+		require.Len(t, rm.GetStage().RaisedHands, 1)
+		send(StageRaiseHandTopic, "lower")
+		require.Empty(t, rm.GetStage().RaisedHands)
+
+		// and dropped when addressed to participants
+		send(StageRaiseHandTopic, "raise", string(p1.Identity()))
+		require.Empty(t, rm.GetStage().RaisedHands)
+
+		for _, op := range participants {
+			fp := op.(*typesfakes.FakeLocalParticipant)
+			require.Zero(t, fp.SendDataPacketCallCount())
+		}
+	})
+
+	t.Run("stage requests are dropped in rooms without a stage", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		defer rm.Close(types.ParticipantCloseReasonNone)
+		participants := rm.GetParticipants()
+		p := participants[0].(*typesfakes.FakeLocalParticipant)
+
+		p.OnDataPacketArgsForCall(0)(p, livekit.DataPacket_RELIABLE, &livekit.DataPacket{
+			Kind: livekit.DataPacket_RELIABLE,
+			Value: &livekit.DataPacket_User{
+				User: &livekit.UserPacket{
+					Topic:   proto.String(StageRaiseHandTopic),
+					Payload: []byte("raise"),
+				},
+			},
+		})
+
+		for _, op := range participants {
+			fp := op.(*typesfakes.FakeLocalParticipant)
+			require.Zero(t, fp.SendDataPacketCallCount())
+		}
+	})
 }
 
 func TestHiddenParticipants(t *testing.T) {
@@ -816,7 +894,7 @@ func newRoomWithParticipants(t *testing.T, opts testRoomOpts) *Room {
 			NodeId:   "testnode",
 			Region:   "testregion",
 		},
-		telemetry.NewTelemetryService(webhook.NewDefaultNotifier("", "", nil), &telemetryfakes.FakeAnalyticsService{}),
+		telemetry.NewTelemetryService(nil, &telemetryfakes.FakeAnalyticsService{}),
 		nil, nil, nil,
 	)
 	for i := 0; i < opts.num+opts.numHidden; i++ {
@@ -0,0 +1,168 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// data topic the server announces the expiry of a scheduled room on, over the reliable channel.
+// participants are sent it when they join a room with an expiry, when the expiry changes,
+// and at each of the warnings ahead of it
+const RoomExpiringTopic = "lk.room_expiring"
+
+// RoomExpiring is the JSON payload of packets on RoomExpiringTopic
+type RoomExpiring struct {
+	// unix seconds the room is closed at, zero when the expiry was cancelled
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+func roomExpiringPacket(expiresAt time.Time) (*livekit.DataPacket, error) {
+	var e RoomExpiring
+	if !expiresAt.IsZero() {
+		e.ExpiresAt = expiresAt.Unix()
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	topic := RoomExpiringTopic
+	return &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				Payload: payload,
+				Topic:   &topic,
+			},
+		},
+	}, nil
+}
+
+// SetExpiry closes the room at expiresAt with ParticipantCloseReasonRoomExpired, a zero time cancels it.
+// Participants are sent the expiry on RoomExpiringTopic when it changes, and again at each of the warnings ahead of it.
+// Warnings already past when the expiry is set are given once, right away.
+func (r *Room) SetExpiry(expiresAt time.Time, warnings []time.Duration) {
+	r.lock.Lock()
+	r.stopExpiryTimersLocked()
+	changed := !r.expiresAt.Equal(expiresAt)
+	r.expiresAt = expiresAt
+	if !expiresAt.IsZero() && !r.IsClosed() {
+		remaining := time.Until(expiresAt)
+		warnNow := false
+		for _, w := range warnings {
+			if w <= 0 {
+				continue
+			}
+			if w >= remaining {
+				warnNow = true
+				continue
+			}
+			r.expiryTimers = append(r.expiryTimers, time.AfterFunc(remaining-w, func() {
+				r.onExpiryTimer(expiresAt, w)
+			}))
+		}
+		if warnNow && remaining > 0 {
+			r.expiryTimers = append(r.expiryTimers, time.AfterFunc(0, func() {
+				r.onExpiryTimer(expiresAt, remaining)
+			}))
+		}
+		r.expiryTimers = append(r.expiryTimers, time.AfterFunc(remaining, func() {
+			r.onExpiryTimer(expiresAt, 0)
+		}))
+	}
+	r.lock.Unlock()
+
+	if changed && !r.IsClosed() {
+		r.broadcastExpiry(expiresAt)
+	}
+}
+
+// ExpiresAt returns when the room is closed, zero when it does not expire
+func (r *Room) ExpiresAt() time.Time {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.expiresAt
+}
+
+// OnExpiring is called at each of the expiry warnings with the time left
+func (r *Room) OnExpiring(f func(remaining time.Duration)) {
+	r.onExpiring = f
+}
+
+func (r *Room) onExpiryTimer(expiresAt time.Time, remaining time.Duration) {
+	r.lock.RLock()
+	current := r.expiresAt.Equal(expiresAt)
+	r.lock.RUnlock()
+	// the expiry was changed or cancelled after the timer fired
+	if !current || r.IsClosed() {
+		return
+	}
+
+	if remaining <= 0 {
+		r.Logger.Infow("room expired", "expiresAt", expiresAt)
+		r.Close(types.ParticipantCloseReasonRoomExpired)
+		return
+	}
+
+	r.Logger.Infow("room expiring", "expiresAt", expiresAt, "remaining", remaining)
+	r.broadcastExpiry(expiresAt)
+	if onExpiring := r.onExpiring; onExpiring != nil {
+		onExpiring(remaining)
+	}
+}
+
+func (r *Room) stopExpiryTimersLocked() {
+	for _, t := range r.expiryTimers {
+		t.Stop()
+	}
+	r.expiryTimers = nil
+}
+
+func (r *Room) broadcastExpiry(expiresAt time.Time) {
+	dp, err := roomExpiringPacket(expiresAt)
+	if err != nil {
+		r.Logger.Errorw("could not encode room expiry", err)
+		return
+	}
+	r.SendDataPacket(dp, livekit.DataPacket_RELIABLE)
+}
+
+// sendExpiry tells a participant joining when the room expires, for rooms with an expiry
+func (r *Room) sendExpiry(p types.LocalParticipant) {
+	expiresAt := r.ExpiresAt()
+	if expiresAt.IsZero() {
+		return
+	}
+	dp, err := roomExpiringPacket(expiresAt)
+	if err != nil {
+		r.Logger.Errorw("could not encode room expiry", err)
+		return
+	}
+	data, err := proto.Marshal(dp)
+	if err != nil {
+		r.Logger.Errorw("could not encode room expiry", err)
+		return
+	}
+	if err = p.SendDataPacket(livekit.DataPacket_RELIABLE, data); err != nil {
+		p.GetLogger().Warnw("could not send room expiry", err)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+// expiriesSent returns the expiries a participant was sent on RoomExpiringTopic, in order
+func expiriesSent(t *testing.T, p *typesfakes.FakeLocalParticipant) []int64 {
+	var expiries []int64
+	for i := 0; i < p.SendDataPacketCallCount(); i++ {
+		_, data := p.SendDataPacketArgsForCall(i)
+		dp := &livekit.DataPacket{}
+		require.NoError(t, proto.Unmarshal(data, dp))
+		if dp.GetUser().GetTopic() != RoomExpiringTopic {
+			continue
+		}
+		var e RoomExpiring
+		require.NoError(t, json.Unmarshal(dp.GetUser().Payload, &e))
+		expiries = append(expiries, e.ExpiresAt)
+	}
+	return expiries
+}
+
+func TestRoomExpiry(t *testing.T) {
+	t.Run("warns then closes", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		p := rm.GetParticipants()[0].(*typesfakes.FakeLocalParticipant)
+		p.IsReadyReturns(true)
+
+		var (
+			warnings atomic.Int32
+			last     atomic.Duration
+		)
+		rm.OnExpiring(func(remaining time.Duration) {
+			warnings.Inc()
+			last.Store(remaining)
+		})
+		// the hour long warning is already past, it is given right away
+		expiresAt := time.Now().Add(300 * time.Millisecond)
+		rm.SetExpiry(expiresAt, []time.Duration{time.Hour, 100 * time.Millisecond})
+
+		require.Eventually(t, rm.IsClosed, 2*time.Second, 10*time.Millisecond)
+		require.Equal(t, int32(2), warnings.Load())
+		require.Equal(t, 100*time.Millisecond, last.Load())
+		// once set, then at each of the two warnings
+		require.Equal(t, []int64{expiresAt.Unix(), expiresAt.Unix(), expiresAt.Unix()}, expiriesSent(t, p))
+
+		require.Equal(t, 1, p.CloseCallCount())
+		_, reason, _ := p.CloseArgsForCall(0)
+		require.Equal(t, types.ParticipantCloseReasonRoomExpired, reason)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		p := rm.GetParticipants()[0].(*typesfakes.FakeLocalParticipant)
+		rm.OnExpiring(func(time.Duration) {
+			t.Error("cancelled expiry warned")
+		})
+		rm.SetExpiry(time.Now().Add(100*time.Millisecond), []time.Duration{50 * time.Millisecond})
+		rm.SetExpiry(time.Time{}, nil)
+		require.True(t, rm.ExpiresAt().IsZero())
+
+		time.Sleep(200 * time.Millisecond)
+		require.False(t, rm.IsClosed())
+		expiries := expiriesSent(t, p)
+		require.Len(t, expiries, 2)
+		require.Zero(t, expiries[1])
+	})
+}
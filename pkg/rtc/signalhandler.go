@@ -15,6 +15,8 @@
 package rtc
 
 import (
+	"errors"
+
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 
@@ -106,11 +108,22 @@ func HandleParticipantSignal(room types.Room, participant types.LocalParticipant
 			Reason:    livekit.RequestResponse_OK,
 		}
 		if participant.ClaimGrants().Video.GetCanUpdateOwnMetadata() {
-			if err := participant.CheckMetadataLimits(
+			err := participant.CheckMetadataLimits(
 				msg.UpdateMetadata.Name,
 				msg.UpdateMetadata.Metadata,
 				msg.UpdateMetadata.Attributes,
-			); err == nil {
+			)
+			if err == nil {
+				err = participant.CheckMetadataSchema(
+					msg.UpdateMetadata.Metadata,
+					msg.UpdateMetadata.Attributes,
+					true,
+				)
+			}
+			if err == nil {
+				err = checkAudioPinSelfUpdate(participant.ClaimGrants().Attributes, msg.UpdateMetadata.Attributes)
+			}
+			if err == nil {
 				if msg.UpdateMetadata.Name != "" {
 					participant.SetName(msg.UpdateMetadata.Name)
 				}
@@ -135,6 +148,17 @@ func HandleParticipantSignal(room types.Room, participant types.LocalParticipant
 				case ErrAttributesExceedsLimits:
 					requestResponse.Reason = livekit.RequestResponse_LIMIT_EXCEEDED
 					requestResponse.Message = "exceeds attributes size limit"
+
+				case ErrAudioPinSelfUpdate:
+					requestResponse.Reason = livekit.RequestResponse_NOT_ALLOWED
+					requestResponse.Message = err.Error()
+
+				default:
+					var schemaErr *MetadataSchemaError
+					if errors.As(err, &schemaErr) {
+						requestResponse.Reason = livekit.RequestResponse_NOT_ALLOWED
+						requestResponse.Message = schemaErr.Error()
+					}
 				}
 
 			}
@@ -0,0 +1,204 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"slices"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// data topic participants raise and lower their hand on, with a "raise" or "lower" payload
+	StageRaiseHandTopic = "lk.stage.raise_hand"
+
+	stageLowerHand = "lower"
+)
+
+type RaisedHand struct {
+	Identity livekit.ParticipantIdentity
+	RaisedAt time.Time
+}
+
+// StageState is a snapshot of the stage of a room
+type StageState struct {
+	MaxPublishers uint32
+	// participants holding a publisher slot, in the order they took it
+	OnStage []livekit.ParticipantIdentity
+	// participants waiting to be promoted, in the order they raised their hand
+	RaisedHands []RaisedHand
+}
+
+// SetStage caps the number of participants publishing at once.
+// Participants already publishing take the free slots in the order they publish their next track.
+func (r *Room) SetStage(stage config.StageConfig) {
+	r.lock.Lock()
+	r.stageConfig = &stage
+	r.lock.Unlock()
+}
+
+func (r *Room) HasStage() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.stageConfig != nil
+}
+
+func (r *Room) GetStage() StageState {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var state StageState
+	if r.stageConfig != nil {
+		state.MaxPublishers = r.stageConfig.MaxPublishers
+	}
+	state.OnStage = slices.Clone(r.onStage)
+	state.RaisedHands = slices.Clone(r.raisedHands)
+	return state
+}
+
+// AcquirePublisherSlot is called before a participant publishes a track. It returns whether the participant
+// holds a publisher slot, taking a free one when it does not. Rooms without a stage have no limit.
+func (r *Room) AcquirePublisherSlot(p types.LocalParticipant) bool {
+	if p.Hidden() || p.IsDependent() {
+		return true
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.stageConfig == nil || slices.Contains(r.onStage, p.Identity()) {
+		return true
+	}
+	if !r.hasFreePublisherSlotLocked() {
+		p.GetLogger().Infow("no free publisher slot on stage", "onStage", r.onStage)
+		return false
+	}
+	r.takePublisherSlotLocked(p.Identity())
+	p.GetLogger().Infow("participant took publisher slot on stage")
+	return true
+}
+
+// PromoteParticipant gives a participant a publisher slot and the permission to publish.
+// Its raised hand, if any, is lowered.
+func (r *Room) PromoteParticipant(identity livekit.ParticipantIdentity) (types.LocalParticipant, error) {
+	r.lock.Lock()
+	p := r.participants[identity]
+	switch {
+	case p == nil:
+		r.lock.Unlock()
+		return nil, ErrParticipantNotFound
+	case r.lobby[identity] != nil:
+		r.lock.Unlock()
+		return nil, ErrParticipantInLobby
+	}
+	if !slices.Contains(r.onStage, identity) {
+		if !r.hasFreePublisherSlotLocked() {
+			r.lock.Unlock()
+			return nil, ErrStageFull
+		}
+		r.takePublisherSlotLocked(identity)
+	}
+	r.lock.Unlock()
+
+	p.GetLogger().Infow("participant promoted to stage")
+	permission := p.ClaimGrants().Video.ToPermission()
+	permission.CanPublish = true
+	p.SetPermission(permission)
+	return p, nil
+}
+
+// DemoteParticipant takes the publisher slot and the permission to publish away from a participant,
+// its published tracks are unpublished.
+func (r *Room) DemoteParticipant(identity livekit.ParticipantIdentity) (types.LocalParticipant, error) {
+	r.lock.Lock()
+	p := r.participants[identity]
+	if p == nil {
+		r.lock.Unlock()
+		return nil, ErrParticipantNotFound
+	}
+	if !slices.Contains(r.onStage, identity) {
+		r.lock.Unlock()
+		return nil, ErrParticipantNotOnStage
+	}
+	r.releasePublisherSlotLocked(identity)
+	r.lock.Unlock()
+
+	p.GetLogger().Infow("participant demoted from stage")
+	permission := p.ClaimGrants().Video.ToPermission()
+	permission.CanPublish = false
+	p.SetPermission(permission)
+	return p, nil
+}
+
+// RaiseHand queues a participant to be promoted, participants on stage or already queued keep their place
+func (r *Room) RaiseHand(identity livekit.ParticipantIdentity) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.participants[identity] == nil || slices.Contains(r.onStage, identity) {
+		return
+	}
+	if slices.ContainsFunc(r.raisedHands, func(h RaisedHand) bool { return h.Identity == identity }) {
+		return
+	}
+	r.raisedHands = append(r.raisedHands, RaisedHand{Identity: identity, RaisedAt: time.Now()})
+}
+
+func (r *Room) LowerHand(identity livekit.ParticipantIdentity) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.lowerHandLocked(identity)
+}
+
+func (r *Room) lowerHandLocked(identity livekit.ParticipantIdentity) {
+	r.raisedHands = slices.DeleteFunc(r.raisedHands, func(h RaisedHand) bool { return h.Identity == identity })
+}
+
+func (r *Room) hasFreePublisherSlotLocked() bool {
+	return r.stageConfig.MaxPublishers == 0 || uint32(len(r.onStage)) < r.stageConfig.MaxPublishers
+}
+
+func (r *Room) takePublisherSlotLocked(identity livekit.ParticipantIdentity) {
+	r.onStage = append(r.onStage, identity)
+	r.lowerHandLocked(identity)
+}
+
+// releasePublisherSlotLocked frees the slot of a participant leaving the stage or the room, assumes lock is already acquired
+func (r *Room) releasePublisherSlotLocked(identity livekit.ParticipantIdentity) {
+	r.onStage = slices.DeleteFunc(r.onStage, func(id livekit.ParticipantIdentity) bool { return id == identity })
+}
+
+// handleStageRequest handles a raised or lowered hand sent as data on StageRaiseHandTopic.
+// Requests are addressed to the server, those sent to participants or to a room without a stage are dropped.
+func (r *Room) handleStageRequest(source types.LocalParticipant, dp *livekit.DataPacket) {
+	user := dp.GetUser()
+	if len(dp.DestinationIdentities) != 0 || len(user.GetDestinationIdentities()) != 0 || len(user.GetDestinationSids()) != 0 {
+		r.Logger.Debugw("dropping stage request addressed to participants", "participant", source.Identity())
+		return
+	}
+	if !r.HasStage() {
+		return
+	}
+
+	if string(user.GetPayload()) == stageLowerHand {
+		r.LowerHand(source.Identity())
+	} else {
+		r.RaiseHand(source.Identity())
+	}
+}
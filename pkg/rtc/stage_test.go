@@ -0,0 +1,105 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestRoomStage(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 0})
+	rm.SetStage(config.StageConfig{MaxPublishers: 1})
+
+	join := func(identity livekit.ParticipantIdentity) *typesfakes.FakeLocalParticipant {
+		p := NewMockParticipant(identity, types.CurrentProtocol, false, false)
+		p.ClaimGrantsReturns(&auth.ClaimGrants{Video: &auth.VideoGrant{RoomJoin: true}})
+		require.NoError(t, rm.Join(p, nil, nil, iceServersForRoom))
+		return p
+	}
+	raiseHand := func(p types.LocalParticipant, payload string) {
+		rm.onDataPacket(p, livekit.DataPacket_RELIABLE, &livekit.DataPacket{
+			Value: &livekit.DataPacket_User{User: &livekit.UserPacket{
+				Topic:   proto.String(StageRaiseHandTopic),
+				Payload: []byte(payload),
+			}},
+		})
+	}
+	first := join("first")
+	second := join("second")
+	third := join("third")
+
+	// the first to publish takes the only slot
+	require.True(t, rm.AcquirePublisherSlot(first))
+	require.True(t, rm.AcquirePublisherSlot(first))
+	require.False(t, rm.AcquirePublisherSlot(second))
+	require.Equal(t, []livekit.ParticipantIdentity{"first"}, rm.GetStage().OnStage)
+
+	t.Run("raised hands are kept in order", func(t *testing.T) {
+		raiseHand(third, "raise")
+		raiseHand(second, "raise")
+		raiseHand(third, "raise")
+		raiseHand(first, "raise")
+		hands := rm.GetStage().RaisedHands
+		require.Len(t, hands, 2)
+		require.Equal(t, livekit.ParticipantIdentity("third"), hands[0].Identity)
+		require.Equal(t, livekit.ParticipantIdentity("second"), hands[1].Identity)
+
+		raiseHand(third, "lower")
+		hands = rm.GetStage().RaisedHands
+		require.Len(t, hands, 1)
+		require.Equal(t, livekit.ParticipantIdentity("second"), hands[0].Identity)
+	})
+
+	t.Run("stage is full", func(t *testing.T) {
+		_, err := rm.PromoteParticipant("second")
+		require.ErrorIs(t, err, ErrStageFull)
+		_, err = rm.DemoteParticipant("second")
+		require.ErrorIs(t, err, ErrParticipantNotOnStage)
+	})
+
+	t.Run("demote frees the slot", func(t *testing.T) {
+		p, err := rm.DemoteParticipant("first")
+		require.NoError(t, err)
+		require.Equal(t, first, p)
+		require.False(t, first.SetPermissionArgsForCall(first.SetPermissionCallCount()-1).CanPublish)
+		require.Empty(t, rm.GetStage().OnStage)
+	})
+
+	t.Run("promote lowers the hand", func(t *testing.T) {
+		p, err := rm.PromoteParticipant("second")
+		require.NoError(t, err)
+		require.Equal(t, second, p)
+		require.True(t, second.SetPermissionArgsForCall(second.SetPermissionCallCount()-1).CanPublish)
+		require.Equal(t, []livekit.ParticipantIdentity{"second"}, rm.GetStage().OnStage)
+		require.Empty(t, rm.GetStage().RaisedHands)
+		require.False(t, rm.AcquirePublisherSlot(first))
+	})
+
+	t.Run("leaving frees the slot", func(t *testing.T) {
+		rm.RemoveParticipant("second", "", types.ParticipantCloseReasonClientRequestLeave)
+		require.Empty(t, rm.GetStage().OnStage)
+		require.True(t, rm.AcquirePublisherSlot(third))
+	})
+}
@@ -57,6 +57,8 @@ type SubscribedTrack struct {
 	settingsLock     sync.Mutex
 	settings         *livekit.UpdateTrackSettings
 	settingsVersion  utils.TimedVersion
+	// paused by the room, independently of subscriber settings
+	forwardingPaused bool
 
 	bindLock        sync.Mutex
 	bound           bool
@@ -212,6 +214,20 @@ func (t *SubscribedTrack) SetPublisherMuted(muted bool) {
 	t.DownTrack().PubMute(muted)
 }
 
+// SetForwardingPaused stops or restarts forwarding while the track stays subscribed.
+// The track remains muted when the subscriber has disabled it. Returns true when it changed.
+func (t *SubscribedTrack) SetForwardingPaused(paused bool) bool {
+	t.settingsLock.Lock()
+	defer t.settingsLock.Unlock()
+
+	if t.forwardingPaused == paused {
+		return false
+	}
+	t.forwardingPaused = paused
+	t.DownTrack().Mute(paused || t.isMutedLocked())
+	return true
+}
+
 func (t *SubscribedTrack) UpdateSubscriberSettings(settings *livekit.UpdateTrackSettings, isImmediate bool) {
 	t.settingsLock.Lock()
 	if proto.Equal(t.settings, settings) {
@@ -270,7 +286,7 @@ func (t *SubscribedTrack) applySettings() {
 		return
 	}
 
-	if t.settings.Disabled {
+	if t.settings.Disabled || t.forwardingPaused {
 		dt.Mute(true)
 		t.settingsLock.Unlock()
 		return
@@ -181,6 +181,17 @@ func (m *SubscriptionManager) UnsubscribeFromTrack(trackID livekit.TrackID) {
 	m.queueReconcile(trackID)
 }
 
+// UnsubscribeFromAllTracks drops all subscriptions, including the ones that are not bound yet
+func (m *SubscriptionManager) UnsubscribeFromAllTracks() {
+	m.lock.RLock()
+	trackIDs := maps.Keys(m.subscriptions)
+	m.lock.RUnlock()
+
+	for _, trackID := range trackIDs {
+		m.UnsubscribeFromTrack(trackID)
+	}
+}
+
 func (m *SubscriptionManager) GetSubscribedTracks() []types.SubscribedTrack {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
@@ -634,6 +645,9 @@ func (m *SubscriptionManager) subscribeSynchronous(trackID livekit.TrackID) erro
 	if track == nil {
 		return ErrTrackNotFound
 	}
+	if !res.HasPermission {
+		return ErrNoTrackPermission
+	}
 
 	m.lock.Lock()
 	sub, ok := m.subscriptions[trackID]
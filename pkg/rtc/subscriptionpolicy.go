@@ -0,0 +1,137 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"strings"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// SubscriptionPolicyAllows evaluates room wide subscription rules for a track, the first rule matching it applies.
+// Tracks no rule matches are allowed, leaving it to the permissions of the publisher.
+func SubscriptionPolicyAllows(rules []config.SubscriptionRule, pub types.LocalParticipant, track types.MediaTrack, sub types.LocalParticipant) bool {
+	if len(rules) == 0 {
+		return true
+	}
+
+	pubAttributes := pub.ClaimGrants().Attributes
+	for _, rule := range rules {
+		if !ruleMatchesSource(rule, track) || !attributesMatch(pubAttributes, rule.Publisher) {
+			continue
+		}
+		return attributesMatch(sub.ClaimGrants().Attributes, rule.Subscriber)
+	}
+	return true
+}
+
+func ruleMatchesSource(rule config.SubscriptionRule, track types.MediaTrack) bool {
+	if len(rule.Sources) == 0 {
+		return true
+	}
+	source := track.Source().String()
+	for _, s := range rule.Sources {
+		if strings.EqualFold(s, source) {
+			return true
+		}
+	}
+	return false
+}
+
+func attributesMatch(attributes map[string]string, required map[string]string) bool {
+	for k, v := range required {
+		if attributes[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// SetSubscriptionPolicy replaces the subscription rules of the room, existing subscriptions are re-evaluated
+func (r *Room) SetSubscriptionPolicy(rules []config.SubscriptionRule) {
+	r.lock.Lock()
+	r.subscriptionRules = rules
+	r.lock.Unlock()
+
+	r.Logger.Infow("subscription policy updated", "rules", len(rules))
+	r.reevaluateSubscriptionPolicy(nil)
+}
+
+func (r *Room) GetSubscriptionPolicy() []config.SubscriptionRule {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.subscriptionRules
+}
+
+func (r *Room) subscriptionPolicyAllows(pub types.LocalParticipant, track types.MediaTrack, sub types.LocalParticipant) bool {
+	r.lock.RLock()
+	rules := r.subscriptionRules
+	r.lock.RUnlock()
+	return SubscriptionPolicyAllows(rules, pub, track, sub)
+}
+
+// reevaluateSubscriptionPolicy revokes subscriptions the policy no longer allows, and has pending
+// subscriptions resolved again in case they are now allowed. when changed is set, only subscriptions
+// it takes part in, as publisher or subscriber, are affected.
+func (r *Room) reevaluateSubscriptionPolicy(changed types.LocalParticipant) {
+	r.lock.RLock()
+	hasRules := len(r.subscriptionRules) != 0
+	r.lock.RUnlock()
+	if !hasRules && changed != nil {
+		return
+	}
+
+	participants := r.GetParticipants()
+	type publishedTrack struct {
+		pub   types.LocalParticipant
+		track types.MediaTrack
+	}
+	var tracks []publishedTrack
+	for _, p := range participants {
+		for _, t := range p.GetPublishedTracks() {
+			tracks = append(tracks, publishedTrack{pub: p, track: t})
+		}
+	}
+	r.lock.RLock()
+	for _, b := range r.incomingBridges {
+		tracks = append(tracks, publishedTrack{pub: b.publisher, track: b.track})
+	}
+	r.lock.RUnlock()
+
+	for _, pt := range tracks {
+		affected := false
+		for _, sub := range participants {
+			if sub == pt.pub || (changed != nil && changed != pt.pub && changed != sub) {
+				continue
+			}
+			affected = true
+			if !pt.track.IsSubscriber(sub.ID()) || IsParticipantExemptFromTrackPermissionsRestrictions(sub) {
+				continue
+			}
+			if !r.subscriptionPolicyAllows(pt.pub, pt.track, sub) {
+				r.Logger.Infow("revoking subscription by policy",
+					"trackID", pt.track.ID(),
+					"publisher", pt.pub.Identity(),
+					"subscriber", sub.Identity(),
+				)
+				pt.track.RemoveSubscriber(sub.ID(), false)
+			}
+		}
+		if affected {
+			r.trackManager.NotifyTrackChanged(pt.track.ID())
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+var testSubscriptionRules = []config.SubscriptionRule{
+	{
+		Sources:   []string{"screen_share"},
+		Publisher: map[string]string{"role": "presenter"},
+	},
+	{
+		Sources:    []string{"microphone"},
+		Publisher:  map[string]string{"team": "red"},
+		Subscriber: map[string]string{"team": "red"},
+	},
+}
+
+func newPolicyParticipant(identity livekit.ParticipantIdentity, attributes map[string]string) *typesfakes.FakeLocalParticipant {
+	p := NewMockParticipant(identity, 0, false, true)
+	p.ClaimGrantsReturns(&auth.ClaimGrants{Identity: string(identity), Attributes: attributes})
+	return p
+}
+
+func newPolicyTrack(source livekit.TrackSource) *typesfakes.FakeMediaTrack {
+	track := &typesfakes.FakeMediaTrack{}
+	track.IDReturns("TR_" + livekit.TrackID(source.String()))
+	track.SourceReturns(source)
+	track.IsOpenReturns(true)
+	return track
+}
+
+func TestSubscriptionPolicyAllows(t *testing.T) {
+	presenter := newPolicyParticipant("presenter", map[string]string{"role": "presenter", "team": "red"})
+	red := newPolicyParticipant("red", map[string]string{"team": "red"})
+	blue := newPolicyParticipant("blue", map[string]string{"team": "blue"})
+
+	screenShare := newPolicyTrack(livekit.TrackSource_SCREEN_SHARE)
+	microphone := newPolicyTrack(livekit.TrackSource_MICROPHONE)
+	camera := newPolicyTrack(livekit.TrackSource_CAMERA)
+
+	t.Run("screen share of presenter is visible to all", func(t *testing.T) {
+		require.True(t, SubscriptionPolicyAllows(testSubscriptionRules, presenter, screenShare, blue))
+		require.True(t, SubscriptionPolicyAllows(testSubscriptionRules, presenter, screenShare, red))
+	})
+
+	t.Run("microphone of team red only to team red", func(t *testing.T) {
+		require.True(t, SubscriptionPolicyAllows(testSubscriptionRules, red, microphone, presenter))
+		require.False(t, SubscriptionPolicyAllows(testSubscriptionRules, red, microphone, blue))
+		require.True(t, SubscriptionPolicyAllows(testSubscriptionRules, blue, microphone, red))
+	})
+
+	t.Run("tracks without a matching rule are allowed", func(t *testing.T) {
+		require.True(t, SubscriptionPolicyAllows(testSubscriptionRules, red, camera, blue))
+		require.True(t, SubscriptionPolicyAllows(nil, red, microphone, blue))
+	})
+}
+
+func TestSubscriptionPolicyRoom(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 0})
+	pub := newPolicyParticipant("pub", map[string]string{"team": "red"})
+	red := newPolicyParticipant("red", map[string]string{"team": "red"})
+	blue := newPolicyParticipant("blue", map[string]string{"team": "blue"})
+	for _, p := range []*typesfakes.FakeLocalParticipant{pub, red, blue} {
+		require.NoError(t, rm.Join(p, nil, &ParticipantOptions{AutoSubscribe: true}, iceServersForRoom))
+		p.StateReturns(livekit.ParticipantInfo_ACTIVE)
+	}
+	pub.HasPermissionReturns(true)
+
+	microphone := newPolicyTrack(livekit.TrackSource_MICROPHONE)
+	microphone.IsSubscriberReturns(true)
+	pub.GetPublishedTracksReturns([]types.MediaTrack{microphone})
+	rm.trackManager.AddTrack(microphone, pub.Identity(), pub.ID())
+
+	require.True(t, rm.ResolveMediaTrackForSubscriber(blue, microphone.ID()).HasPermission)
+
+	t.Run("revokes subscriptions the policy does not allow", func(t *testing.T) {
+		rm.SetSubscriptionPolicy(testSubscriptionRules)
+		require.True(t, rm.ResolveMediaTrackForSubscriber(red, microphone.ID()).HasPermission)
+		require.False(t, rm.ResolveMediaTrackForSubscriber(blue, microphone.ID()).HasPermission)
+		require.Equal(t, 1, microphone.RemoveSubscriberCallCount())
+		subID, _ := microphone.RemoveSubscriberArgsForCall(0)
+		require.Equal(t, blue.ID(), subID)
+	})
+
+	t.Run("re-evaluates when attributes change", func(t *testing.T) {
+		red.ClaimGrantsReturns(&auth.ClaimGrants{Identity: "red", Attributes: map[string]string{"team": "blue"}})
+		onUpdate := red.OnParticipantUpdateArgsForCall(red.OnParticipantUpdateCallCount() - 1)
+		onUpdate(red)
+		require.False(t, rm.ResolveMediaTrackForSubscriber(red, microphone.ID()).HasPermission)
+		require.Equal(t, 2, microphone.RemoveSubscriberCallCount())
+		subID, _ := microphone.RemoveSubscriberArgsForCall(1)
+		require.Equal(t, red.ID(), subID)
+	})
+
+	t.Run("publisher permissions still apply", func(t *testing.T) {
+		rm.SetSubscriptionPolicy(nil)
+		pub.HasPermissionReturns(false)
+		require.False(t, rm.ResolveMediaTrackForSubscriber(blue, microphone.ID()).HasPermission)
+	})
+}
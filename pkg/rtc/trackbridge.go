@@ -0,0 +1,412 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/frostbyte73/core"
+	"golang.org/x/exp/maps"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/protocol/utils/guid"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const trackBridgePrefix = "TB_"
+
+// bridgedTrack is the read-only view of a track published in another room.
+// Subscribers are added to the published track itself, so simulcast, dynacast and
+// stream allocation work as for any other track. Only the source room changes or closes it.
+type bridgedTrack struct {
+	types.MediaTrack
+}
+
+func (t *bridgedTrack) UpdateTrackInfo(_ *livekit.TrackInfo)              {}
+func (t *bridgedTrack) UpdateAudioTrack(_ *livekit.UpdateLocalAudioTrack) {}
+func (t *bridgedTrack) UpdateVideoTrack(_ *livekit.UpdateLocalVideoTrack) {}
+func (t *bridgedTrack) SetMuted(_ bool)                                   {}
+func (t *bridgedTrack) Close(_ bool)                                      {}
+func (t *bridgedTrack) ClearAllReceivers(_ bool)                          {}
+func (t *bridgedTrack) AddOnClose(_ func(isExpectedToResume bool))        {}
+
+// TrackBridge makes a track published in one room subscribable from another room on the same node.
+// The publisher does not join the destination room, participants there see it with the bridged tracks only.
+// The bridge closes when the track is unpublished, the publisher leaves or either room closes.
+type TrackBridge struct {
+	id        string
+	source    *Room
+	dest      *Room
+	publisher types.LocalParticipant
+	track     types.MediaTrack
+	proxy     *bridgedTrack
+	createdAt time.Time
+
+	lock    sync.Mutex
+	onClose []func(b *TrackBridge)
+	closed  core.Fuse
+}
+
+func (b *TrackBridge) ID() string {
+	return b.id
+}
+
+func (b *TrackBridge) SourceRoom() livekit.RoomName {
+	return b.source.Name()
+}
+
+func (b *TrackBridge) DestinationRoom() livekit.RoomName {
+	return b.dest.Name()
+}
+
+func (b *TrackBridge) TrackID() livekit.TrackID {
+	return b.track.ID()
+}
+
+func (b *TrackBridge) Publisher() types.LocalParticipant {
+	return b.publisher
+}
+
+func (b *TrackBridge) CreatedAt() time.Time {
+	return b.createdAt
+}
+
+func (b *TrackBridge) IsClosed() bool {
+	return b.closed.IsBroken()
+}
+
+// OnClose adds a callback run once the bridge is torn down, immediately when it already is
+func (b *TrackBridge) OnClose(f func(b *TrackBridge)) {
+	b.lock.Lock()
+	if !b.closed.IsBroken() {
+		b.onClose = append(b.onClose, f)
+		b.lock.Unlock()
+		return
+	}
+	b.lock.Unlock()
+	f(b)
+}
+
+// Close removes the track from the destination room, unsubscribing its participants there
+func (b *TrackBridge) Close() {
+	b.lock.Lock()
+	if !b.closed.Break() {
+		b.lock.Unlock()
+		return
+	}
+	onClose := b.onClose
+	b.onClose = nil
+	b.lock.Unlock()
+
+	b.source.removeOutgoingBridge(b)
+	b.dest.removeIncomingBridge(b)
+
+	b.source.Logger.Infow("track bridge closed",
+		"bridgeID", b.id,
+		"trackID", b.track.ID(),
+		"destinationRoom", b.dest.Name(),
+	)
+	for _, f := range onClose {
+		f(b)
+	}
+}
+
+// BridgeTrack makes a track published in this room subscribable by participants of dest.
+// Track permissions of the publisher apply to subscribers in dest as they do here.
+func (r *Room) BridgeTrack(trackID livekit.TrackID, dest *Room) (*TrackBridge, error) {
+	if dest == r {
+		return nil, ErrBridgeSameRoom
+	}
+	if r.IsClosed() || dest.IsClosed() {
+		return nil, ErrRoomClosed
+	}
+
+	info := r.trackManager.GetTrackInfo(trackID)
+	if info == nil {
+		return nil, ErrTrackNotFound
+	}
+	if _, ok := info.Track.(*bridgedTrack); ok {
+		return nil, ErrTrackIsBridged
+	}
+	pub := r.GetParticipantByID(info.PublisherID)
+	if pub == nil {
+		return nil, ErrTrackNotFound
+	}
+
+	b := &TrackBridge{
+		id:        guid.New(trackBridgePrefix),
+		source:    r,
+		dest:      dest,
+		publisher: pub,
+		track:     info.Track,
+		proxy:     &bridgedTrack{MediaTrack: info.Track},
+		createdAt: time.Now(),
+	}
+
+	r.lock.Lock()
+	if r.IsClosed() {
+		r.lock.Unlock()
+		return nil, ErrRoomClosed
+	}
+	r.outgoingBridges[b.id] = b
+	r.lock.Unlock()
+
+	if err := dest.addIncomingBridge(b); err != nil {
+		r.removeOutgoingBridge(b)
+		return nil, err
+	}
+
+	// unpublished, or the publisher left
+	info.Track.AddOnClose(func(_ bool) {
+		b.Close()
+	})
+	if !info.Track.IsOpen() {
+		b.Close()
+		return nil, ErrTrackNotFound
+	}
+
+	r.Logger.Infow("track bridged",
+		"bridgeID", b.id,
+		"trackID", trackID,
+		"participant", pub.Identity(),
+		"destinationRoom", dest.Name(),
+	)
+	return b, nil
+}
+
+// GetBridges returns the bridges of tracks published in this room
+func (r *Room) GetBridges() []*TrackBridge {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return maps.Values(r.outgoingBridges)
+}
+
+// GetIncomingBridges returns the bridges of tracks published in other rooms that are bridged to this room
+func (r *Room) GetIncomingBridges() []*TrackBridge {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return maps.Values(r.incomingBridges)
+}
+
+func (r *Room) removeOutgoingBridge(b *TrackBridge) {
+	r.lock.Lock()
+	delete(r.outgoingBridges, b.id)
+	r.lock.Unlock()
+}
+
+func (r *Room) addIncomingBridge(b *TrackBridge) error {
+	trackID := b.track.ID()
+	pub := b.publisher
+
+	r.lock.Lock()
+	if r.IsClosed() {
+		r.lock.Unlock()
+		return ErrRoomClosed
+	}
+	if r.participants[pub.Identity()] != nil {
+		r.lock.Unlock()
+		return ErrAlreadyJoined
+	}
+	for _, ib := range r.incomingBridges {
+		if ib.track.ID() == trackID {
+			r.lock.Unlock()
+			return ErrTrackAlreadyBridged
+		}
+	}
+	r.incomingBridges[b.id] = b
+
+	var subscribers []types.LocalParticipant
+	for _, p := range r.participants {
+		if p.State() == livekit.ParticipantInfo_ACTIVE && r.autoSubscribe(p) {
+			subscribers = append(subscribers, p)
+		}
+	}
+	r.lock.Unlock()
+
+	r.trackManager.AddTrack(b.proxy, pub.Identity(), pub.ID())
+	r.broadcastBridgedParticipant(pub)
+
+	for _, p := range subscribers {
+		p.SubscribeToTrack(trackID)
+	}
+	return nil
+}
+
+func (r *Room) removeIncomingBridge(b *TrackBridge) {
+	r.lock.Lock()
+	if _, ok := r.incomingBridges[b.id]; !ok {
+		r.lock.Unlock()
+		return
+	}
+	delete(r.incomingBridges, b.id)
+	r.lock.Unlock()
+
+	r.trackManager.RemoveTrack(b.proxy)
+	for _, p := range r.GetParticipants() {
+		if b.track.IsSubscriber(p.ID()) {
+			b.track.RemoveSubscriber(p.ID(), false)
+		}
+	}
+	r.broadcastBridgedParticipant(b.publisher)
+}
+
+// closeOutgoingBridges tears down the bridges of tracks published by a participant leaving the room
+func (r *Room) closeOutgoingBridges(pID livekit.ParticipantID) {
+	r.lock.RLock()
+	var bridges []*TrackBridge
+	for _, b := range r.outgoingBridges {
+		if b.publisher.ID() == pID {
+			bridges = append(bridges, b)
+		}
+	}
+	r.lock.RUnlock()
+
+	for _, b := range bridges {
+		b.Close()
+	}
+}
+
+// closeIncomingBridges tears down bridges of a publisher, when it joins this room itself
+func (r *Room) closeIncomingBridges(identity livekit.ParticipantIdentity) {
+	r.lock.RLock()
+	var bridges []*TrackBridge
+	for _, b := range r.incomingBridges {
+		if b.publisher.Identity() == identity {
+			bridges = append(bridges, b)
+		}
+	}
+	r.lock.RUnlock()
+
+	for _, b := range bridges {
+		b.Close()
+	}
+}
+
+func (r *Room) closeAllBridges() {
+	r.lock.RLock()
+	bridges := append(maps.Values(r.outgoingBridges), maps.Values(r.incomingBridges)...)
+	r.lock.RUnlock()
+
+	for _, b := range bridges {
+		b.Close()
+	}
+}
+
+// updateOutgoingBridges forwards a change of a publisher to the rooms its tracks are bridged to
+func (r *Room) updateOutgoingBridges(p types.LocalParticipant) {
+	r.lock.RLock()
+	var dests []*Room
+	for _, b := range r.outgoingBridges {
+		if b.publisher == p {
+			dests = append(dests, b.dest)
+		}
+	}
+	r.lock.RUnlock()
+
+	seen := make(map[*Room]bool, len(dests))
+	for _, dest := range dests {
+		if !seen[dest] {
+			seen[dest] = true
+			dest.broadcastBridgedParticipant(p)
+		}
+	}
+}
+
+// GetBridgedParticipantInfo returns the publisher of bridged tracks as seen by participants of this room
+func (r *Room) GetBridgedParticipantInfo(pID livekit.ParticipantID) *livekit.ParticipantInfo {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, b := range r.incomingBridges {
+		if b.publisher.ID() == pID {
+			return r.bridgedParticipantInfoLocked(b.publisher)
+		}
+	}
+	return nil
+}
+
+// bridgedParticipantInfoLocked builds the info of a publisher with only its tracks bridged to this room,
+// a publisher without any is reported as disconnected, assumes lock is already acquired
+func (r *Room) bridgedParticipantInfoLocked(pub types.LocalParticipant) *livekit.ParticipantInfo {
+	trackIDs := make(map[livekit.TrackID]bool)
+	for _, b := range r.incomingBridges {
+		if b.publisher == pub {
+			trackIDs[b.track.ID()] = true
+		}
+	}
+
+	pi := utils.CloneProto(pub.ToProto())
+	tracks := pi.Tracks[:0]
+	for _, ti := range pi.Tracks {
+		if trackIDs[livekit.TrackID(ti.Sid)] {
+			tracks = append(tracks, ti)
+		}
+	}
+	pi.Tracks = tracks
+	if len(trackIDs) == 0 {
+		pi.State = livekit.ParticipantInfo_DISCONNECTED
+	}
+	return pi
+}
+
+// bridgedParticipantInfosLocked returns the publishers of bridged tracks, assumes lock is already acquired
+func (r *Room) bridgedParticipantInfosLocked() []*livekit.ParticipantInfo {
+	pubs := make(map[livekit.ParticipantID]types.LocalParticipant)
+	for _, b := range r.incomingBridges {
+		pubs[b.publisher.ID()] = b.publisher
+	}
+
+	infos := make([]*livekit.ParticipantInfo, 0, len(pubs))
+	for _, pub := range pubs {
+		infos = append(infos, r.bridgedParticipantInfoLocked(pub))
+	}
+	return infos
+}
+
+func (r *Room) broadcastBridgedParticipant(pub types.LocalParticipant) {
+	r.lock.RLock()
+	pi := r.bridgedParticipantInfoLocked(pub)
+	r.lock.RUnlock()
+
+	r.sendParticipantUpdates(r.pushAndDequeueUpdates(pi, types.ParticipantCloseReasonNone, true))
+}
+
+// getBridgedPublisher returns the publisher of a track bridged to this room
+func (r *Room) getBridgedPublisher(trackID livekit.TrackID) types.LocalParticipant {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, b := range r.incomingBridges {
+		if b.track.ID() == trackID {
+			return b.publisher
+		}
+	}
+	return nil
+}
+
+// getBridgedTrackIDs returns the tracks bridged to this room
+func (r *Room) getBridgedTrackIDs() []livekit.TrackID {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	trackIDs := make([]livekit.TrackID, 0, len(r.incomingBridges))
+	for _, b := range r.incomingBridges {
+		trackIDs = append(trackIDs, b.track.ID())
+	}
+	return trackIDs
+}
@@ -1286,6 +1286,22 @@ func (t *PCTransport) SetAllowPauseOfStreamAllocator(allowPause bool) {
 	t.streamAllocator.SetAllowPause(allowPause)
 }
 
+func (t *PCTransport) SetAllocationPolicyOfStreamAllocator(policy streamallocator.AllocationPolicy) {
+	if t.streamAllocator == nil {
+		return
+	}
+
+	t.streamAllocator.SetAllocationPolicy(policy)
+}
+
+func (t *PCTransport) SetActiveSpeakersOfStreamAllocator(speakers []*livekit.SpeakerInfo) {
+	if t.streamAllocator == nil {
+		return
+	}
+
+	t.streamAllocator.SetActiveSpeakers(speakers)
+}
+
 func (t *PCTransport) SetChannelCapacityOfStreamAllocator(channelCapacity int64) {
 	if t.streamAllocator == nil {
 		return
@@ -40,7 +40,9 @@ import (
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/datachannel"
 	"github.com/livekit/livekit-server/pkg/sfu/pacer"
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
 	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
 )
 
 const (
@@ -102,12 +104,15 @@ type TransportManagerParams struct {
 	AllowPlayoutDelay            bool
 	DataChannelMaxBufferedAmount uint64
 	DatachannelSlowThreshold     int
+	ReliableDataReplay           config.ReliableDataReplayConfig
 	Logger                       logger.Logger
 	PublisherHandler             transport.Handler
 	SubscriberHandler            transport.Handler
 	DataChannelStats             *telemetry.BytesTrackStats
 	UseOneShotSignallingMode     bool
-	FireOnTrackBySdp             bool
+	// in one-shot signalling mode, the single peer connection sends subscribed tracks instead of receiving published ones
+	OneShotSendSide  bool
+	FireOnTrackBySdp bool
 }
 
 type TransportManager struct {
@@ -133,6 +138,9 @@ type TransportManager struct {
 	udpLossUnstableCount uint32
 	signalingRTT, udpRTT uint32
 
+	// reliable data sent downstream, kept to be replayed on resume
+	reliableData *reliableDataBuffer
+
 	onICEConfigChanged func(iceConfig *livekit.ICEConfig)
 }
 
@@ -145,10 +153,13 @@ func NewTransportManager(params TransportManagerParams) (*TransportManager, erro
 		mediaLossProxy: NewMediaLossProxy(MediaLossProxyParams{Logger: params.Logger}),
 		iceConfig:      &livekit.ICEConfig{},
 	}
+	if params.ReliableDataReplay.Enabled {
+		t.reliableData = newReliableDataBuffer(params.ReliableDataReplay)
+	}
 	t.mediaLossProxy.OnMediaLossUpdate(t.onMediaLossUpdate)
 
 	lgr := LoggerWithPCTarget(params.Logger, livekit.SignalTarget_PUBLISHER)
-	publisher, err := NewPCTransport(TransportParams{
+	publisherParams := TransportParams{
 		ParticipantID:                params.SID,
 		ParticipantIdentity:          params.Identity,
 		ProtocolVersion:              params.ProtocolVersion,
@@ -166,7 +177,16 @@ func NewTransportManager(params TransportManagerParams) (*TransportManager, erro
 		DataChannelMaxBufferedAmount: params.DataChannelMaxBufferedAmount,
 		DatachannelSlowThreshold:     params.DatachannelSlowThreshold,
 		FireOnTrackBySdp:             params.FireOnTrackBySdp,
-	})
+	}
+	if params.UseOneShotSignallingMode && params.OneShotSendSide {
+		// remote is still the offerer, but media flows like on a subscriber peer connection
+		publisherParams.DirectionConfig = params.Config.Subscriber
+		publisherParams.EnabledCodecs = params.EnabledSubscribeCodecs
+		publisherParams.AllowPlayoutDelay = params.AllowPlayoutDelay
+		publisherParams.IsSendSide = true
+		publisherParams.Twcc = nil
+	}
+	publisher, err := NewPCTransport(publisherParams)
 	if err != nil {
 		return nil, err
 	}
@@ -238,7 +258,11 @@ func (t *TransportManager) WritePublisherRTCP(pkts []rtcp.Packet) error {
 }
 
 func (t *TransportManager) GetSubscriberRTT() (float64, bool) {
-	return t.subscriber.GetRTT()
+	if t.params.UseOneShotSignallingMode {
+		return t.publisher.GetRTT()
+	} else {
+		return t.subscriber.GetRTT()
+	}
 }
 
 func (t *TransportManager) HasSubscriberEverConnected() bool {
@@ -284,18 +308,61 @@ func (t *TransportManager) WriteSubscriberRTCP(pkts []rtcp.Packet) error {
 }
 
 func (t *TransportManager) GetSubscriberPacer() pacer.Pacer {
-	return t.subscriber.GetPacer()
+	if t.params.UseOneShotSignallingMode {
+		return t.publisher.GetPacer()
+	} else {
+		return t.subscriber.GetPacer()
+	}
 }
 
 func (t *TransportManager) AddSubscribedTrack(subTrack types.SubscribedTrack) {
-	t.subscriber.AddTrackToStreamAllocator(subTrack)
+	if t.params.UseOneShotSignallingMode {
+		t.publisher.AddTrackToStreamAllocator(subTrack)
+	} else {
+		t.subscriber.AddTrackToStreamAllocator(subTrack)
+	}
 }
 
 func (t *TransportManager) RemoveSubscribedTrack(subTrack types.SubscribedTrack) {
-	t.subscriber.RemoveTrackFromStreamAllocator(subTrack)
+	if t.params.UseOneShotSignallingMode {
+		t.publisher.RemoveTrackFromStreamAllocator(subTrack)
+	} else {
+		t.subscriber.RemoveTrackFromStreamAllocator(subTrack)
+	}
 }
 
 func (t *TransportManager) SendDataPacket(kind livekit.DataPacket_Kind, encoded []byte) error {
+	if kind == livekit.DataPacket_RELIABLE && t.reliableData != nil {
+		encoded = t.reliableData.stamp(encoded)
+	}
+	return t.sendDataPacket(kind, encoded)
+}
+
+// ReplayReliableData sends again the reliable data packets kept that were sent after lastSeq
+func (t *TransportManager) ReplayReliableData(lastSeq uint32) {
+	if t.reliableData == nil {
+		return
+	}
+
+	packets, missed := t.reliableData.since(lastSeq)
+	if missed > 0 {
+		t.params.Logger.Infow("reliable data missed no longer kept", "lastSeq", lastSeq, "missed", missed)
+		prometheus.RecordReliableDataOverflowed(missed)
+	}
+	for i, encoded := range packets {
+		if err := t.sendDataPacket(livekit.DataPacket_RELIABLE, encoded); err != nil {
+			t.params.Logger.Infow("could not replay reliable data", "error", err, "replayed", i, "pending", len(packets)-i)
+			prometheus.RecordReliableDataReplayed(i)
+			return
+		}
+	}
+	if len(packets) > 0 {
+		t.params.Logger.Debugw("replayed reliable data", "lastSeq", lastSeq, "replayed", len(packets))
+		prometheus.RecordReliableDataReplayed(len(packets))
+	}
+}
+
+func (t *TransportManager) sendDataPacket(kind livekit.DataPacket_Kind, encoded []byte) error {
 	// downstream data is sent via primary peer connection
 	err := t.getTransport(true).SendDataPacket(kind, encoded)
 	if err != nil {
@@ -808,11 +875,35 @@ func (t *TransportManager) SetSignalSourceValid(valid bool) {
 }
 
 func (t *TransportManager) SetSubscriberAllowPause(allowPause bool) {
-	t.subscriber.SetAllowPauseOfStreamAllocator(allowPause)
+	if t.params.UseOneShotSignallingMode {
+		t.publisher.SetAllowPauseOfStreamAllocator(allowPause)
+	} else {
+		t.subscriber.SetAllowPauseOfStreamAllocator(allowPause)
+	}
+}
+
+func (t *TransportManager) SetSubscriberAllocationPolicy(policy streamallocator.AllocationPolicy) {
+	if t.params.UseOneShotSignallingMode {
+		t.publisher.SetAllocationPolicyOfStreamAllocator(policy)
+	} else {
+		t.subscriber.SetAllocationPolicyOfStreamAllocator(policy)
+	}
+}
+
+func (t *TransportManager) SetSubscriberActiveSpeakers(speakers []*livekit.SpeakerInfo) {
+	if t.params.UseOneShotSignallingMode {
+		t.publisher.SetActiveSpeakersOfStreamAllocator(speakers)
+	} else {
+		t.subscriber.SetActiveSpeakersOfStreamAllocator(speakers)
+	}
 }
 
 func (t *TransportManager) SetSubscriberChannelCapacity(channelCapacity int64) {
-	t.subscriber.SetChannelCapacityOfStreamAllocator(channelCapacity)
+	if t.params.UseOneShotSignallingMode {
+		t.publisher.SetChannelCapacityOfStreamAllocator(channelCapacity)
+	} else {
+		t.subscriber.SetChannelCapacityOfStreamAllocator(channelCapacity)
+	}
 }
 
 func (t *TransportManager) hasRecentSignalLocked() bool {
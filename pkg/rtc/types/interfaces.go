@@ -29,7 +29,9 @@ import (
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/sfu/capture"
 	"github.com/livekit/livekit-server/pkg/sfu/pacer"
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
@@ -110,6 +112,7 @@ const (
 	ParticipantCloseReasonRoomClosed
 	ParticipantCloseReasonUserUnavailable
 	ParticipantCloseReasonUserRejected
+	ParticipantCloseReasonRoomExpired
 )
 
 func (p ParticipantCloseReason) String() string {
@@ -168,6 +171,8 @@ func (p ParticipantCloseReason) String() string {
 		return "USER_UNAVAILABLE"
 	case ParticipantCloseReasonUserRejected:
 		return "USER_REJECTED"
+	case ParticipantCloseReasonRoomExpired:
+		return "ROOM_EXPIRED"
 	default:
 		return fmt.Sprintf("%d", int(p))
 	}
@@ -198,7 +203,7 @@ func (p ParticipantCloseReason) ToDisconnectReason() livekit.DisconnectReason {
 		return livekit.DisconnectReason_STATE_MISMATCH
 	case ParticipantCloseReasonSignalSourceClose:
 		return livekit.DisconnectReason_SIGNAL_CLOSE
-	case ParticipantCloseReasonRoomClosed:
+	case ParticipantCloseReasonRoomClosed, ParticipantCloseReasonRoomExpired:
 		return livekit.DisconnectReason_ROOM_CLOSED
 	case ParticipantCloseReasonUserUnavailable:
 		return livekit.DisconnectReason_USER_UNAVAILABLE
@@ -348,6 +353,7 @@ type LocalParticipant interface {
 
 	// updates
 	CheckMetadataLimits(name string, metadata string, attributes map[string]string) error
+	CheckMetadataSchema(metadata string, attributes map[string]string, selfUpdate bool) error
 	SetName(name string)
 	SetMetadata(metadata string)
 	SetAttributes(attributes map[string]string)
@@ -357,6 +363,7 @@ type LocalParticipant interface {
 	// permissions
 	ClaimGrants() *auth.ClaimGrants
 	SetPermission(permission *livekit.ParticipantPermission) bool
+	MoveToRoom(roomName livekit.RoomName)
 	CanPublish() bool
 	CanPublishSource(source livekit.TrackSource) bool
 	CanSubscribe() bool
@@ -381,6 +388,7 @@ type LocalParticipant interface {
 	// subscriptions
 	SubscribeToTrack(trackID livekit.TrackID)
 	UnsubscribeFromTrack(trackID livekit.TrackID)
+	UnsubscribeFromAllTracks()
 	UpdateSubscribedTrackSettings(trackID livekit.TrackID, settings *livekit.UpdateTrackSettings)
 	GetSubscribedTracks() []SubscribedTrack
 	IsTrackNameSubscribed(publisherIdentity livekit.ParticipantIdentity, trackName string) bool
@@ -402,8 +410,10 @@ type LocalParticipant interface {
 	SendParticipantUpdate(participants []*livekit.ParticipantInfo) error
 	SendSpeakerUpdate(speakers []*livekit.SpeakerInfo, force bool) error
 	SendDataPacket(kind livekit.DataPacket_Kind, encoded []byte) error
+	ReplayReliableData(lastSeq uint32)
 	SendRoomUpdate(room *livekit.Room) error
 	SendConnectionQualityUpdate(update *livekit.ConnectionQualityUpdate) error
+	SendStreamStateUpdate(update *livekit.StreamStateUpdate) error
 	SubscriptionPermissionUpdate(publisherID livekit.ParticipantID, trackID livekit.TrackID, allowed bool)
 	SendRefreshToken(token string) error
 	SendRequestResponse(requestResponse *livekit.RequestResponse) error
@@ -457,6 +467,8 @@ type LocalParticipant interface {
 	// down stream bandwidth management
 	SetSubscriberAllowPause(allowPause bool)
 	SetSubscriberChannelCapacity(channelCapacity int64)
+	SetSubscriberAllocationPolicy(policy streamallocator.AllocationPolicy)
+	SetSubscriberActiveSpeakers(speakers []*livekit.SpeakerInfo)
 
 	GetPacer() pacer.Pacer
 
@@ -549,6 +561,8 @@ type LocalMediaTrack interface {
 
 	NotifySubscriberNodeMaxQuality(nodeID livekit.NodeID, qualities []SubscribedCodecQuality)
 	NotifySubscriberNodeMediaLoss(nodeID livekit.NodeID, fractionalLoss uint8)
+
+	SetCapture(w *capture.Writer)
 }
 
 //counterfeiter:generate . SubscribedTrack
@@ -569,6 +583,7 @@ type SubscribedTrack interface {
 	RTPSender() *webrtc.RTPSender
 	IsMuted() bool
 	SetPublisherMuted(muted bool)
+	SetForwardingPaused(paused bool) bool
 	UpdateSubscriberSettings(settings *livekit.UpdateTrackSettings, isImmediate bool)
 	// selects appropriate video layer according to subscriber preferences
 	UpdateVideoLayer()
@@ -6,6 +6,7 @@ import (
 
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/capture"
 	"github.com/livekit/protocol/livekit"
 )
 
@@ -286,6 +287,11 @@ type FakeLocalMediaTrack struct {
 	revokeDisallowedSubscribersReturnsOnCall map[int]struct {
 		result1 []livekit.ParticipantIdentity
 	}
+	SetCaptureStub        func(*capture.Writer)
+	setCaptureMutex       sync.RWMutex
+	setCaptureArgsForCall []struct {
+		arg1 *capture.Writer
+	}
 	SetMutedStub        func(bool)
 	setMutedMutex       sync.RWMutex
 	setMutedArgsForCall []struct {
@@ -1834,6 +1840,38 @@ func (fake *FakeLocalMediaTrack) RevokeDisallowedSubscribersReturnsOnCall(i int,
 	}{result1}
 }
 
+func (fake *FakeLocalMediaTrack) SetCapture(arg1 *capture.Writer) {
+	fake.setCaptureMutex.Lock()
+	fake.setCaptureArgsForCall = append(fake.setCaptureArgsForCall, struct {
+		arg1 *capture.Writer
+	}{arg1})
+	stub := fake.SetCaptureStub
+	fake.recordInvocation("SetCapture", []interface{}{arg1})
+	fake.setCaptureMutex.Unlock()
+	if stub != nil {
+		fake.SetCaptureStub(arg1)
+	}
+}
+
+func (fake *FakeLocalMediaTrack) SetCaptureCallCount() int {
+	fake.setCaptureMutex.RLock()
+	defer fake.setCaptureMutex.RUnlock()
+	return len(fake.setCaptureArgsForCall)
+}
+
+func (fake *FakeLocalMediaTrack) SetCaptureCalls(stub func(*capture.Writer)) {
+	fake.setCaptureMutex.Lock()
+	defer fake.setCaptureMutex.Unlock()
+	fake.SetCaptureStub = stub
+}
+
+func (fake *FakeLocalMediaTrack) SetCaptureArgsForCall(i int) *capture.Writer {
+	fake.setCaptureMutex.RLock()
+	defer fake.setCaptureMutex.RUnlock()
+	argsForCall := fake.setCaptureArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalMediaTrack) SetMuted(arg1 bool) {
 	fake.setMutedMutex.Lock()
 	fake.setMutedArgsForCall = append(fake.setMutedArgsForCall, struct {
@@ -2269,6 +2307,8 @@ func (fake *FakeLocalMediaTrack) Invocations() map[string][][]interface{} {
 	defer fake.restartMutex.RUnlock()
 	fake.revokeDisallowedSubscribersMutex.RLock()
 	defer fake.revokeDisallowedSubscribersMutex.RUnlock()
+	fake.setCaptureMutex.RLock()
+	defer fake.setCaptureMutex.RUnlock()
 	fake.setMutedMutex.RLock()
 	defer fake.setMutedMutex.RUnlock()
 	fake.setRTTMutex.RLock()
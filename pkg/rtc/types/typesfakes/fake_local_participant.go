@@ -10,6 +10,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/sfu"
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 	"github.com/livekit/livekit-server/pkg/sfu/pacer"
+	"github.com/livekit/livekit-server/pkg/sfu/streamallocator"
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
@@ -133,6 +134,19 @@ type FakeLocalParticipant struct {
 	checkMetadataLimitsReturnsOnCall map[int]struct {
 		result1 error
 	}
+	CheckMetadataSchemaStub        func(string, map[string]string, bool) error
+	checkMetadataSchemaMutex       sync.RWMutex
+	checkMetadataSchemaArgsForCall []struct {
+		arg1 string
+		arg2 map[string]string
+		arg3 bool
+	}
+	checkMetadataSchemaReturns struct {
+		result1 error
+	}
+	checkMetadataSchemaReturnsOnCall map[int]struct {
+		result1 error
+	}
 	ClaimGrantsStub        func() *auth.ClaimGrants
 	claimGrantsMutex       sync.RWMutex
 	claimGrantsArgsForCall []struct {
@@ -677,6 +691,11 @@ type FakeLocalParticipant struct {
 	migrateStateReturnsOnCall map[int]struct {
 		result1 types.MigrateState
 	}
+	MoveToRoomStub        func(livekit.RoomName)
+	moveToRoomMutex       sync.RWMutex
+	moveToRoomArgsForCall []struct {
+		arg1 livekit.RoomName
+	}
 	NegotiateStub        func(bool)
 	negotiateMutex       sync.RWMutex
 	negotiateArgsForCall []struct {
@@ -774,6 +793,11 @@ type FakeLocalParticipant struct {
 	removeTrackLocalReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ReplayReliableDataStub        func(uint32)
+	replayReliableDataMutex       sync.RWMutex
+	replayReliableDataArgsForCall []struct {
+		arg1 uint32
+	}
 	SendConnectionQualityUpdateStub        func(*livekit.ConnectionQualityUpdate) error
 	sendConnectionQualityUpdateMutex       sync.RWMutex
 	sendConnectionQualityUpdateArgsForCall []struct {
@@ -864,6 +888,17 @@ type FakeLocalParticipant struct {
 	sendSpeakerUpdateReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SendStreamStateUpdateStub        func(*livekit.StreamStateUpdate) error
+	sendStreamStateUpdateMutex       sync.RWMutex
+	sendStreamStateUpdateArgsForCall []struct {
+		arg1 *livekit.StreamStateUpdate
+	}
+	sendStreamStateUpdateReturns struct {
+		result1 error
+	}
+	sendStreamStateUpdateReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SetAttributesStub        func(map[string]string)
 	setAttributesMutex       sync.RWMutex
 	setAttributesArgsForCall []struct {
@@ -918,6 +953,16 @@ type FakeLocalParticipant struct {
 	setSignalSourceValidArgsForCall []struct {
 		arg1 bool
 	}
+	SetSubscriberActiveSpeakersStub        func([]*livekit.SpeakerInfo)
+	setSubscriberActiveSpeakersMutex       sync.RWMutex
+	setSubscriberActiveSpeakersArgsForCall []struct {
+		arg1 []*livekit.SpeakerInfo
+	}
+	SetSubscriberAllocationPolicyStub        func(streamallocator.AllocationPolicy)
+	setSubscriberAllocationPolicyMutex       sync.RWMutex
+	setSubscriberAllocationPolicyArgsForCall []struct {
+		arg1 streamallocator.AllocationPolicy
+	}
 	SetSubscriberAllowPauseStub        func(bool)
 	setSubscriberAllowPauseMutex       sync.RWMutex
 	setSubscriberAllowPauseArgsForCall []struct {
@@ -1042,6 +1087,10 @@ type FakeLocalParticipant struct {
 	uncacheDownTrackArgsForCall []struct {
 		arg1 *webrtc.RTPTransceiver
 	}
+	UnsubscribeFromAllTracksStub        func()
+	unsubscribeFromAllTracksMutex       sync.RWMutex
+	unsubscribeFromAllTracksArgsForCall []struct {
+	}
 	UnsubscribeFromTrackStub        func(livekit.TrackID)
 	unsubscribeFromTrackMutex       sync.RWMutex
 	unsubscribeFromTrackArgsForCall []struct {
@@ -1751,6 +1800,69 @@ func (fake *FakeLocalParticipant) CheckMetadataLimitsReturnsOnCall(i int, result
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) CheckMetadataSchema(arg1 string, arg2 map[string]string, arg3 bool) error {
+	fake.checkMetadataSchemaMutex.Lock()
+	ret, specificReturn := fake.checkMetadataSchemaReturnsOnCall[len(fake.checkMetadataSchemaArgsForCall)]
+	fake.checkMetadataSchemaArgsForCall = append(fake.checkMetadataSchemaArgsForCall, struct {
+		arg1 string
+		arg2 map[string]string
+		arg3 bool
+	}{arg1, arg2, arg3})
+	stub := fake.CheckMetadataSchemaStub
+	fakeReturns := fake.checkMetadataSchemaReturns
+	fake.recordInvocation("CheckMetadataSchema", []interface{}{arg1, arg2, arg3})
+	fake.checkMetadataSchemaMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) CheckMetadataSchemaCallCount() int {
+	fake.checkMetadataSchemaMutex.RLock()
+	defer fake.checkMetadataSchemaMutex.RUnlock()
+	return len(fake.checkMetadataSchemaArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) CheckMetadataSchemaCalls(stub func(string, map[string]string, bool) error) {
+	fake.checkMetadataSchemaMutex.Lock()
+	defer fake.checkMetadataSchemaMutex.Unlock()
+	fake.CheckMetadataSchemaStub = stub
+}
+
+func (fake *FakeLocalParticipant) CheckMetadataSchemaArgsForCall(i int) (string, map[string]string, bool) {
+	fake.checkMetadataSchemaMutex.RLock()
+	defer fake.checkMetadataSchemaMutex.RUnlock()
+	argsForCall := fake.checkMetadataSchemaArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeLocalParticipant) CheckMetadataSchemaReturns(result1 error) {
+	fake.checkMetadataSchemaMutex.Lock()
+	defer fake.checkMetadataSchemaMutex.Unlock()
+	fake.CheckMetadataSchemaStub = nil
+	fake.checkMetadataSchemaReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) CheckMetadataSchemaReturnsOnCall(i int, result1 error) {
+	fake.checkMetadataSchemaMutex.Lock()
+	defer fake.checkMetadataSchemaMutex.Unlock()
+	fake.CheckMetadataSchemaStub = nil
+	if fake.checkMetadataSchemaReturnsOnCall == nil {
+		fake.checkMetadataSchemaReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.checkMetadataSchemaReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) ClaimGrants() *auth.ClaimGrants {
 	fake.claimGrantsMutex.Lock()
 	ret, specificReturn := fake.claimGrantsReturnsOnCall[len(fake.claimGrantsArgsForCall)]
@@ -4637,6 +4749,38 @@ func (fake *FakeLocalParticipant) MigrateStateReturnsOnCall(i int, result1 types
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) MoveToRoom(arg1 livekit.RoomName) {
+	fake.moveToRoomMutex.Lock()
+	fake.moveToRoomArgsForCall = append(fake.moveToRoomArgsForCall, struct {
+		arg1 livekit.RoomName
+	}{arg1})
+	stub := fake.MoveToRoomStub
+	fake.recordInvocation("MoveToRoom", []interface{}{arg1})
+	fake.moveToRoomMutex.Unlock()
+	if stub != nil {
+		fake.MoveToRoomStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) MoveToRoomCallCount() int {
+	fake.moveToRoomMutex.RLock()
+	defer fake.moveToRoomMutex.RUnlock()
+	return len(fake.moveToRoomArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) MoveToRoomCalls(stub func(livekit.RoomName)) {
+	fake.moveToRoomMutex.Lock()
+	defer fake.moveToRoomMutex.Unlock()
+	fake.MoveToRoomStub = stub
+}
+
+func (fake *FakeLocalParticipant) MoveToRoomArgsForCall(i int) livekit.RoomName {
+	fake.moveToRoomMutex.RLock()
+	defer fake.moveToRoomMutex.RUnlock()
+	argsForCall := fake.moveToRoomArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) Negotiate(arg1 bool) {
 	fake.negotiateMutex.Lock()
 	fake.negotiateArgsForCall = append(fake.negotiateArgsForCall, struct {
@@ -5225,6 +5369,38 @@ func (fake *FakeLocalParticipant) RemoveTrackLocalReturnsOnCall(i int, result1 e
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) ReplayReliableData(arg1 uint32) {
+	fake.replayReliableDataMutex.Lock()
+	fake.replayReliableDataArgsForCall = append(fake.replayReliableDataArgsForCall, struct {
+		arg1 uint32
+	}{arg1})
+	stub := fake.ReplayReliableDataStub
+	fake.recordInvocation("ReplayReliableData", []interface{}{arg1})
+	fake.replayReliableDataMutex.Unlock()
+	if stub != nil {
+		fake.ReplayReliableDataStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) ReplayReliableDataCallCount() int {
+	fake.replayReliableDataMutex.RLock()
+	defer fake.replayReliableDataMutex.RUnlock()
+	return len(fake.replayReliableDataArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) ReplayReliableDataCalls(stub func(uint32)) {
+	fake.replayReliableDataMutex.Lock()
+	defer fake.replayReliableDataMutex.Unlock()
+	fake.ReplayReliableDataStub = stub
+}
+
+func (fake *FakeLocalParticipant) ReplayReliableDataArgsForCall(i int) uint32 {
+	fake.replayReliableDataMutex.RLock()
+	defer fake.replayReliableDataMutex.RUnlock()
+	argsForCall := fake.replayReliableDataArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) SendConnectionQualityUpdate(arg1 *livekit.ConnectionQualityUpdate) error {
 	fake.sendConnectionQualityUpdateMutex.Lock()
 	ret, specificReturn := fake.sendConnectionQualityUpdateReturnsOnCall[len(fake.sendConnectionQualityUpdateArgsForCall)]
@@ -5730,6 +5906,67 @@ func (fake *FakeLocalParticipant) SendSpeakerUpdateReturnsOnCall(i int, result1
 	}{result1}
 }
 
+func (fake *FakeLocalParticipant) SendStreamStateUpdate(arg1 *livekit.StreamStateUpdate) error {
+	fake.sendStreamStateUpdateMutex.Lock()
+	ret, specificReturn := fake.sendStreamStateUpdateReturnsOnCall[len(fake.sendStreamStateUpdateArgsForCall)]
+	fake.sendStreamStateUpdateArgsForCall = append(fake.sendStreamStateUpdateArgsForCall, struct {
+		arg1 *livekit.StreamStateUpdate
+	}{arg1})
+	stub := fake.SendStreamStateUpdateStub
+	fakeReturns := fake.sendStreamStateUpdateReturns
+	fake.recordInvocation("SendStreamStateUpdate", []interface{}{arg1})
+	fake.sendStreamStateUpdateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLocalParticipant) SendStreamStateUpdateCallCount() int {
+	fake.sendStreamStateUpdateMutex.RLock()
+	defer fake.sendStreamStateUpdateMutex.RUnlock()
+	return len(fake.sendStreamStateUpdateArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) SendStreamStateUpdateCalls(stub func(*livekit.StreamStateUpdate) error) {
+	fake.sendStreamStateUpdateMutex.Lock()
+	defer fake.sendStreamStateUpdateMutex.Unlock()
+	fake.SendStreamStateUpdateStub = stub
+}
+
+func (fake *FakeLocalParticipant) SendStreamStateUpdateArgsForCall(i int) *livekit.StreamStateUpdate {
+	fake.sendStreamStateUpdateMutex.RLock()
+	defer fake.sendStreamStateUpdateMutex.RUnlock()
+	argsForCall := fake.sendStreamStateUpdateArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeLocalParticipant) SendStreamStateUpdateReturns(result1 error) {
+	fake.sendStreamStateUpdateMutex.Lock()
+	defer fake.sendStreamStateUpdateMutex.Unlock()
+	fake.SendStreamStateUpdateStub = nil
+	fake.sendStreamStateUpdateReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLocalParticipant) SendStreamStateUpdateReturnsOnCall(i int, result1 error) {
+	fake.sendStreamStateUpdateMutex.Lock()
+	defer fake.sendStreamStateUpdateMutex.Unlock()
+	fake.SendStreamStateUpdateStub = nil
+	if fake.sendStreamStateUpdateReturnsOnCall == nil {
+		fake.sendStreamStateUpdateReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.sendStreamStateUpdateReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeLocalParticipant) SetAttributes(arg1 map[string]string) {
 	fake.setAttributesMutex.Lock()
 	fake.setAttributesArgsForCall = append(fake.setAttributesArgsForCall, struct {
@@ -6060,6 +6297,75 @@ func (fake *FakeLocalParticipant) SetSignalSourceValidArgsForCall(i int) bool {
 	return argsForCall.arg1
 }
 
+func (fake *FakeLocalParticipant) SetSubscriberActiveSpeakers(arg1 []*livekit.SpeakerInfo) {
+	var arg1Copy []*livekit.SpeakerInfo
+	if arg1 != nil {
+		arg1Copy = make([]*livekit.SpeakerInfo, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.setSubscriberActiveSpeakersMutex.Lock()
+	fake.setSubscriberActiveSpeakersArgsForCall = append(fake.setSubscriberActiveSpeakersArgsForCall, struct {
+		arg1 []*livekit.SpeakerInfo
+	}{arg1Copy})
+	stub := fake.SetSubscriberActiveSpeakersStub
+	fake.recordInvocation("SetSubscriberActiveSpeakers", []interface{}{arg1Copy})
+	fake.setSubscriberActiveSpeakersMutex.Unlock()
+	if stub != nil {
+		fake.SetSubscriberActiveSpeakersStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) SetSubscriberActiveSpeakersCallCount() int {
+	fake.setSubscriberActiveSpeakersMutex.RLock()
+	defer fake.setSubscriberActiveSpeakersMutex.RUnlock()
+	return len(fake.setSubscriberActiveSpeakersArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) SetSubscriberActiveSpeakersCalls(stub func([]*livekit.SpeakerInfo)) {
+	fake.setSubscriberActiveSpeakersMutex.Lock()
+	defer fake.setSubscriberActiveSpeakersMutex.Unlock()
+	fake.SetSubscriberActiveSpeakersStub = stub
+}
+
+func (fake *FakeLocalParticipant) SetSubscriberActiveSpeakersArgsForCall(i int) []*livekit.SpeakerInfo {
+	fake.setSubscriberActiveSpeakersMutex.RLock()
+	defer fake.setSubscriberActiveSpeakersMutex.RUnlock()
+	argsForCall := fake.setSubscriberActiveSpeakersArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeLocalParticipant) SetSubscriberAllocationPolicy(arg1 streamallocator.AllocationPolicy) {
+	fake.setSubscriberAllocationPolicyMutex.Lock()
+	fake.setSubscriberAllocationPolicyArgsForCall = append(fake.setSubscriberAllocationPolicyArgsForCall, struct {
+		arg1 streamallocator.AllocationPolicy
+	}{arg1})
+	stub := fake.SetSubscriberAllocationPolicyStub
+	fake.recordInvocation("SetSubscriberAllocationPolicy", []interface{}{arg1})
+	fake.setSubscriberAllocationPolicyMutex.Unlock()
+	if stub != nil {
+		fake.SetSubscriberAllocationPolicyStub(arg1)
+	}
+}
+
+func (fake *FakeLocalParticipant) SetSubscriberAllocationPolicyCallCount() int {
+	fake.setSubscriberAllocationPolicyMutex.RLock()
+	defer fake.setSubscriberAllocationPolicyMutex.RUnlock()
+	return len(fake.setSubscriberAllocationPolicyArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) SetSubscriberAllocationPolicyCalls(stub func(streamallocator.AllocationPolicy)) {
+	fake.setSubscriberAllocationPolicyMutex.Lock()
+	defer fake.setSubscriberAllocationPolicyMutex.Unlock()
+	fake.SetSubscriberAllocationPolicyStub = stub
+}
+
+func (fake *FakeLocalParticipant) SetSubscriberAllocationPolicyArgsForCall(i int) streamallocator.AllocationPolicy {
+	fake.setSubscriberAllocationPolicyMutex.RLock()
+	defer fake.setSubscriberAllocationPolicyMutex.RUnlock()
+	argsForCall := fake.setSubscriberAllocationPolicyArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeLocalParticipant) SetSubscriberAllowPause(arg1 bool) {
 	fake.setSubscriberAllowPauseMutex.Lock()
 	fake.setSubscriberAllowPauseArgsForCall = append(fake.setSubscriberAllowPauseArgsForCall, struct {
@@ -6715,6 +7021,30 @@ func (fake *FakeLocalParticipant) UncacheDownTrackArgsForCall(i int) *webrtc.RTP
 	return argsForCall.arg1
 }
 
+func (fake *FakeLocalParticipant) UnsubscribeFromAllTracks() {
+	fake.unsubscribeFromAllTracksMutex.Lock()
+	fake.unsubscribeFromAllTracksArgsForCall = append(fake.unsubscribeFromAllTracksArgsForCall, struct {
+	}{})
+	stub := fake.UnsubscribeFromAllTracksStub
+	fake.recordInvocation("UnsubscribeFromAllTracks", []interface{}{})
+	fake.unsubscribeFromAllTracksMutex.Unlock()
+	if stub != nil {
+		fake.UnsubscribeFromAllTracksStub()
+	}
+}
+
+func (fake *FakeLocalParticipant) UnsubscribeFromAllTracksCallCount() int {
+	fake.unsubscribeFromAllTracksMutex.RLock()
+	defer fake.unsubscribeFromAllTracksMutex.RUnlock()
+	return len(fake.unsubscribeFromAllTracksArgsForCall)
+}
+
+func (fake *FakeLocalParticipant) UnsubscribeFromAllTracksCalls(stub func()) {
+	fake.unsubscribeFromAllTracksMutex.Lock()
+	defer fake.unsubscribeFromAllTracksMutex.Unlock()
+	fake.UnsubscribeFromAllTracksStub = stub
+}
+
 func (fake *FakeLocalParticipant) UnsubscribeFromTrack(arg1 livekit.TrackID) {
 	fake.unsubscribeFromTrackMutex.Lock()
 	fake.unsubscribeFromTrackArgsForCall = append(fake.unsubscribeFromTrackArgsForCall, struct {
@@ -7475,6 +7805,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.canSubscribeMutex.RUnlock()
 	fake.checkMetadataLimitsMutex.RLock()
 	defer fake.checkMetadataLimitsMutex.RUnlock()
+	fake.checkMetadataSchemaMutex.RLock()
+	defer fake.checkMetadataSchemaMutex.RUnlock()
 	fake.claimGrantsMutex.RLock()
 	defer fake.claimGrantsMutex.RUnlock()
 	fake.closeMutex.RLock()
@@ -7585,6 +7917,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.maybeStartMigrationMutex.RUnlock()
 	fake.migrateStateMutex.RLock()
 	defer fake.migrateStateMutex.RUnlock()
+	fake.moveToRoomMutex.RLock()
+	defer fake.moveToRoomMutex.RUnlock()
 	fake.negotiateMutex.RLock()
 	defer fake.negotiateMutex.RUnlock()
 	fake.notifyMigrationMutex.RLock()
@@ -7619,6 +7953,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.removePublishedTrackMutex.RUnlock()
 	fake.removeTrackLocalMutex.RLock()
 	defer fake.removeTrackLocalMutex.RUnlock()
+	fake.replayReliableDataMutex.RLock()
+	defer fake.replayReliableDataMutex.RUnlock()
 	fake.sendConnectionQualityUpdateMutex.RLock()
 	defer fake.sendConnectionQualityUpdateMutex.RUnlock()
 	fake.sendDataPacketMutex.RLock()
@@ -7635,6 +7971,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.sendRoomUpdateMutex.RUnlock()
 	fake.sendSpeakerUpdateMutex.RLock()
 	defer fake.sendSpeakerUpdateMutex.RUnlock()
+	fake.sendStreamStateUpdateMutex.RLock()
+	defer fake.sendStreamStateUpdateMutex.RUnlock()
 	fake.setAttributesMutex.RLock()
 	defer fake.setAttributesMutex.RUnlock()
 	fake.setICEConfigMutex.RLock()
@@ -7653,6 +7991,10 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.setResponseSinkMutex.RUnlock()
 	fake.setSignalSourceValidMutex.RLock()
 	defer fake.setSignalSourceValidMutex.RUnlock()
+	fake.setSubscriberActiveSpeakersMutex.RLock()
+	defer fake.setSubscriberActiveSpeakersMutex.RUnlock()
+	fake.setSubscriberAllocationPolicyMutex.RLock()
+	defer fake.setSubscriberAllocationPolicyMutex.RUnlock()
 	fake.setSubscriberAllowPauseMutex.RLock()
 	defer fake.setSubscriberAllowPauseMutex.RUnlock()
 	fake.setSubscriberChannelCapacityMutex.RLock()
@@ -7681,6 +8023,8 @@ func (fake *FakeLocalParticipant) Invocations() map[string][][]interface{} {
 	defer fake.toProtoWithVersionMutex.RUnlock()
 	fake.uncacheDownTrackMutex.RLock()
 	defer fake.uncacheDownTrackMutex.RUnlock()
+	fake.unsubscribeFromAllTracksMutex.RLock()
+	defer fake.unsubscribeFromAllTracksMutex.RUnlock()
 	fake.unsubscribeFromTrackMutex.RLock()
 	defer fake.unsubscribeFromTrackMutex.RUnlock()
 	fake.updateAudioTrackMutex.RLock()
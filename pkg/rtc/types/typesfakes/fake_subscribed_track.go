@@ -126,6 +126,17 @@ type FakeSubscribedTrack struct {
 	rTPSenderReturnsOnCall map[int]struct {
 		result1 *webrtc.RTPSender
 	}
+	SetForwardingPausedStub        func(bool) bool
+	setForwardingPausedMutex       sync.RWMutex
+	setForwardingPausedArgsForCall []struct {
+		arg1 bool
+	}
+	setForwardingPausedReturns struct {
+		result1 bool
+	}
+	setForwardingPausedReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	SetPublisherMutedStub        func(bool)
 	setPublisherMutedMutex       sync.RWMutex
 	setPublisherMutedArgsForCall []struct {
@@ -801,6 +812,67 @@ func (fake *FakeSubscribedTrack) RTPSenderReturnsOnCall(i int, result1 *webrtc.R
 	}{result1}
 }
 
+func (fake *FakeSubscribedTrack) SetForwardingPaused(arg1 bool) bool {
+	fake.setForwardingPausedMutex.Lock()
+	ret, specificReturn := fake.setForwardingPausedReturnsOnCall[len(fake.setForwardingPausedArgsForCall)]
+	fake.setForwardingPausedArgsForCall = append(fake.setForwardingPausedArgsForCall, struct {
+		arg1 bool
+	}{arg1})
+	stub := fake.SetForwardingPausedStub
+	fakeReturns := fake.setForwardingPausedReturns
+	fake.recordInvocation("SetForwardingPaused", []interface{}{arg1})
+	fake.setForwardingPausedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSubscribedTrack) SetForwardingPausedCallCount() int {
+	fake.setForwardingPausedMutex.RLock()
+	defer fake.setForwardingPausedMutex.RUnlock()
+	return len(fake.setForwardingPausedArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) SetForwardingPausedCalls(stub func(bool) bool) {
+	fake.setForwardingPausedMutex.Lock()
+	defer fake.setForwardingPausedMutex.Unlock()
+	fake.SetForwardingPausedStub = stub
+}
+
+func (fake *FakeSubscribedTrack) SetForwardingPausedArgsForCall(i int) bool {
+	fake.setForwardingPausedMutex.RLock()
+	defer fake.setForwardingPausedMutex.RUnlock()
+	argsForCall := fake.setForwardingPausedArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeSubscribedTrack) SetForwardingPausedReturns(result1 bool) {
+	fake.setForwardingPausedMutex.Lock()
+	defer fake.setForwardingPausedMutex.Unlock()
+	fake.SetForwardingPausedStub = nil
+	fake.setForwardingPausedReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeSubscribedTrack) SetForwardingPausedReturnsOnCall(i int, result1 bool) {
+	fake.setForwardingPausedMutex.Lock()
+	defer fake.setForwardingPausedMutex.Unlock()
+	fake.SetForwardingPausedStub = nil
+	if fake.setForwardingPausedReturnsOnCall == nil {
+		fake.setForwardingPausedReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.setForwardingPausedReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakeSubscribedTrack) SetPublisherMuted(arg1 bool) {
 	fake.setPublisherMutedMutex.Lock()
 	fake.setPublisherMutedArgsForCall = append(fake.setPublisherMutedArgsForCall, struct {
@@ -1078,6 +1150,8 @@ func (fake *FakeSubscribedTrack) Invocations() map[string][][]interface{} {
 	defer fake.publisherVersionMutex.RUnlock()
 	fake.rTPSenderMutex.RLock()
 	defer fake.rTPSenderMutex.RUnlock()
+	fake.setForwardingPausedMutex.RLock()
+	defer fake.setForwardingPausedMutex.RUnlock()
 	fake.setPublisherMutedMutex.RLock()
 	defer fake.setPublisherMutedMutex.RUnlock()
 	fake.subscriberMutex.RLock()
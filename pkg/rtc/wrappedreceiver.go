@@ -27,6 +27,7 @@ import (
 	"github.com/livekit/protocol/logger"
 
 	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
 )
 
 // wrapper around WebRTC receiver, overriding its ID
@@ -262,6 +263,13 @@ func (d *DummyReceiver) HeaderExtensions() []webrtc.RTPHeaderExtensionParameter
 	return d.headerExtensions
 }
 
+func (d *DummyReceiver) IsSVC() bool {
+	if r, ok := d.receiver.Load().(sfu.TrackReceiver); ok {
+		return r.IsSVC()
+	}
+	return buffer.IsSvcCodec(d.codec.MimeType)
+}
+
 func (d *DummyReceiver) ReadRTP(buf []byte, layer uint8, esn uint64) (int, error) {
 	if r, ok := d.receiver.Load().(sfu.TrackReceiver); ok {
 		return r.ReadRTP(buf, layer, esn)
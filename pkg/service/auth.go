@@ -17,13 +17,17 @@ package service
 import (
 	"context"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/twitchtv/twirp"
 
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/psrpc/pkg/metadata"
 )
 
 const (
@@ -44,16 +48,19 @@ var (
 	ErrMissingAuthorization      = errors.New("invalid authorization header. Must start with " + bearerPrefix)
 	ErrInvalidAuthorizationToken = errors.New("invalid authorization token")
 	ErrInvalidAPIKey             = errors.New("invalid API key")
+	ErrRateLimited               = errors.New("rate limit exceeded")
 )
 
-// authentication middleware
+// authentication middleware, also applies rate limits when a limiter is set
 type APIKeyAuthMiddleware struct {
 	provider auth.KeyProvider
+	limiter  *RateLimiter
 }
 
-func NewAPIKeyAuthMiddleware(provider auth.KeyProvider) *APIKeyAuthMiddleware {
+func NewAPIKeyAuthMiddleware(provider auth.KeyProvider, limiter *RateLimiter) *APIKeyAuthMiddleware {
 	return &APIKeyAuthMiddleware{
 		provider: provider,
+		limiter:  limiter,
 	}
 }
 
@@ -62,6 +69,12 @@ func (m *APIKeyAuthMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request,
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 	}
 
+	method := rateLimitMethod(r)
+	if ok, wait := m.limiter.AllowClientIP(GetClientIP(r), method); !ok {
+		handleRateLimited(w, r, method, wait, "clientIP", GetClientIP(r))
+		return
+	}
+
 	authHeader := r.Header.Get(authorizationHeader)
 	var authToken string
 
@@ -96,17 +109,34 @@ func (m *APIKeyAuthMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request,
 			return
 		}
 
+		if ok, wait := m.limiter.AllowAPIKey(v.APIKey(), method); !ok {
+			handleRateLimited(w, r, method, wait, "apiKey", v.APIKey())
+			return
+		}
+
 		// set grants in context
 		ctx := r.Context()
-		r = r.WithContext(context.WithValue(ctx, grantsKey{}, &grantsValue{
+		ctx = context.WithValue(ctx, grantsKey{}, &grantsValue{
 			claims: grants,
 			apiKey: v.APIKey(),
-		}))
+		})
+		// forwarded to the nodes handling the request, for quotas
+		ctx = metadata.AppendMetadataToOutgoingContext(ctx, apiKeyMetadataKey, v.APIKey())
+		r = r.WithContext(ctx)
 	}
 
 	next.ServeHTTP(w, r)
 }
 
+func handleRateLimited(w http.ResponseWriter, r *http.Request, method string, wait time.Duration, keysAndValues ...any) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	if strings.HasPrefix(r.URL.Path, twirpPathPrefix) {
+		_ = twirp.WriteError(w, twirp.NewError(twirp.ResourceExhausted, ErrRateLimited.Error()))
+		return
+	}
+	handleError(w, r, http.StatusTooManyRequests, ErrRateLimited, append(keysAndValues, "rateLimit", method)...)
+}
+
 func WithAPIKey(ctx context.Context, grants *auth.ClaimGrants, apiKey string) context.Context {
 	return context.WithValue(ctx, grantsKey{}, &grantsValue{
 		claims: grants,
@@ -171,6 +201,21 @@ func EnsureAdminPermission(ctx context.Context, room livekit.RoomName) error {
 	return nil
 }
 
+// EnsureDestinationRoomPermission checks the token administers room, and grants taking participants
+// or tracks from it to destination
+func EnsureDestinationRoomPermission(ctx context.Context, room livekit.RoomName, destination livekit.RoomName) error {
+	claims := GetGrants(ctx)
+	if claims == nil || claims.Video == nil {
+		return ErrPermissionDenied
+	}
+
+	if !claims.Video.RoomAdmin || room != livekit.RoomName(claims.Video.Room) || destination != livekit.RoomName(claims.Video.DestinationRoom) {
+		return ErrPermissionDenied
+	}
+
+	return nil
+}
+
 func EnsureCreatePermission(ctx context.Context) error {
 	claims := GetGrants(ctx)
 	if claims == nil || claims.Video == nil || !claims.Video.RoomCreate {
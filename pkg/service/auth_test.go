@@ -24,6 +24,7 @@ import (
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/auth/authfakes"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/service"
 )
 
@@ -33,7 +34,7 @@ func TestAuthMiddleware(t *testing.T) {
 	provider := &authfakes.FakeKeyProvider{}
 	provider.GetSecretReturns(secret)
 
-	m := service.NewAPIKeyAuthMiddleware(provider)
+	m := service.NewAPIKeyAuthMiddleware(provider, nil)
 	var grants *auth.ClaimGrants
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		grants = service.GetGrants(r.Context())
@@ -72,3 +73,62 @@ func TestAuthMiddleware(t *testing.T) {
 	require.Nil(t, grants)
 	require.Equal(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestAuthMiddlewareRateLimit(t *testing.T) {
+	api := "APIabcdefg"
+	secret := "somesecretencodedinbase62extendto32bytes"
+	provider := &authfakes.FakeKeyProvider{}
+	provider.GetSecretReturns(secret)
+
+	conf, err := config.NewConfig("", true, nil, nil)
+	require.NoError(t, err)
+	conf.RateLimit = config.RateLimitConfig{
+		APIKey: map[string]config.RateLimit{
+			"RoomService/*": {Rate: 0.01, Burst: 2},
+		},
+		ClientIP: map[string]config.RateLimit{
+			"join": {Rate: 0.01, Burst: 1},
+		},
+	}
+	limiter, err := service.NewRateLimiter(conf)
+	require.NoError(t, err)
+
+	m := service.NewAPIKeyAuthMiddleware(provider, limiter)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	token, err := auth.NewAccessToken(api, secret).
+		AddGrant(&auth.VideoGrant{RoomList: true}).
+		ToJWT()
+	require.NoError(t, err)
+
+	serve := func(path, remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, nil)
+		r.RemoteAddr = remoteAddr
+		service.SetAuthorizationToken(r, token)
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, handler)
+		return w
+	}
+
+	t.Run("api key", func(t *testing.T) {
+		// the burst is shared by all methods of the service
+		require.Equal(t, http.StatusOK, serve("/twirp/livekit.RoomService/ListRooms", "10.0.0.1:1000").Code)
+		require.Equal(t, http.StatusOK, serve("/twirp/livekit.RoomService/CreateRoom", "10.0.0.2:1000").Code)
+		w := serve("/twirp/livekit.RoomService/ListRooms", "10.0.0.3:1000")
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+		require.Contains(t, w.Body.String(), "resource_exhausted")
+		require.NotEmpty(t, w.Header().Get("Retry-After"))
+
+		// other services are not limited
+		require.Equal(t, http.StatusOK, serve("/twirp/livekit.Egress/ListEgress", "10.0.0.1:1000").Code)
+	})
+
+	t.Run("client ip", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, serve("/rtc", "10.0.1.1:1000").Code)
+		require.Equal(t, http.StatusTooManyRequests, serve("/rtc", "10.0.1.1:1001").Code)
+		// other clients have their own bucket
+		require.Equal(t, http.StatusOK, serve("/rtc", "10.0.1.2:1000").Code)
+	})
+}
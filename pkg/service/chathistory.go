@@ -0,0 +1,91 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"net/http"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+const chatHistoryPath = "/chat_history"
+
+type ChatHistoryRequest struct {
+	Room string `json:"room"`
+	// messages of all topics when empty
+	Topic string `json:"topic,omitempty"`
+}
+
+type ChatHistoryMessageInfo struct {
+	Topic               string `json:"topic"`
+	ID                  string `json:"id"`
+	ParticipantIdentity string `json:"participant_identity"`
+	ReceivedAt          int64  `json:"received_at"`
+	Message             string `json:"message"`
+}
+
+type ChatHistoryInfo struct {
+	Room     string                   `json:"room"`
+	Messages []ChatHistoryMessageInfo `json:"messages"`
+}
+
+func chatHistoryInfo(roomName livekit.RoomName, messages []*rtc.ChatHistoryMessage) *ChatHistoryInfo {
+	info := &ChatHistoryInfo{
+		Room:     string(roomName),
+		Messages: make([]ChatHistoryMessageInfo, 0, len(messages)),
+	}
+	for _, m := range messages {
+		info.Messages = append(info.Messages, ChatHistoryMessageInfo{
+			Topic:               m.Topic,
+			ID:                  m.ID,
+			ParticipantIdentity: string(m.ParticipantIdentity),
+			ReceivedAt:          m.ReceivedAt.UnixMilli(),
+			Message:             m.Text,
+		})
+	}
+	return info
+}
+
+// ChatHistoryService lets room admins read the chat messages a room keeps for participants joining later.
+// Rooms keep a history when they are created with a named room configuration that has one configured.
+// Requests are served by the RoomService, which routes them to the node hosting the room.
+type ChatHistoryService struct {
+	roomService *RoomService
+}
+
+func NewChatHistoryService(roomService *RoomService) *ChatHistoryService {
+	return &ChatHistoryService{
+		roomService: roomService,
+	}
+}
+
+func (s *ChatHistoryService) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET "+chatHistoryPath, s.handleGet)
+}
+
+func (s *ChatHistoryService) handleGet(w http.ResponseWriter, r *http.Request) {
+	req := &ChatHistoryRequest{
+		Room:  r.URL.Query().Get("room"),
+		Topic: r.URL.Query().Get("topic"),
+	}
+	info, err := s.roomService.GetChatHistory(r.Context(), req)
+	if err != nil {
+		handleError(w, r, statusForError(err), err, "room", req.Room)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
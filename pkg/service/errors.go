@@ -22,6 +22,7 @@ var (
 	ErrEgressNotFound                   = psrpc.NewErrorf(psrpc.NotFound, "egress does not exist")
 	ErrEgressNotConnected               = psrpc.NewErrorf(psrpc.Internal, "egress not connected (redis required)")
 	ErrIdentityEmpty                    = psrpc.NewErrorf(psrpc.InvalidArgument, "identity cannot be empty")
+	ErrRoomNameEmpty                    = psrpc.NewErrorf(psrpc.InvalidArgument, "room cannot be empty")
 	ErrIngressNotConnected              = psrpc.NewErrorf(psrpc.Internal, "ingress not connected (redis required)")
 	ErrIngressNotFound                  = psrpc.NewErrorf(psrpc.NotFound, "ingress does not exist")
 	ErrIngressNonReusable               = psrpc.NewErrorf(psrpc.InvalidArgument, "ingress is not reusable and cannot be modified")
@@ -42,4 +43,28 @@ var (
 	ErrSIPTrunkNotFound                 = psrpc.NewErrorf(psrpc.NotFound, "requested sip trunk does not exist")
 	ErrSIPDispatchRuleNotFound          = psrpc.NewErrorf(psrpc.NotFound, "requested sip dispatch rule does not exist")
 	ErrSIPParticipantNotFound           = psrpc.NewErrorf(psrpc.NotFound, "requested sip participant does not exist")
+	ErrRoomOnRemoteNode                 = psrpc.NewErrorf(psrpc.Unavailable, "room is hosted on another node")
+	ErrOneShotSessionNotFound           = psrpc.NewErrorf(psrpc.NotFound, "session does not exist")
+	ErrRTPCaptureDisabled               = psrpc.NewErrorf(psrpc.FailedPrecondition, "rtp capture is not enabled")
+	ErrRTPCaptureNotFound               = psrpc.NewErrorf(psrpc.NotFound, "rtp capture does not exist")
+	ErrRTPCaptureExists                 = psrpc.NewErrorf(psrpc.AlreadyExists, "track is already being captured")
+	ErrRTPCaptureLimitReached           = psrpc.NewErrorf(psrpc.ResourceExhausted, "too many active rtp captures")
+	ErrRTPCaptureDirFull                = psrpc.NewErrorf(psrpc.ResourceExhausted, "rtp capture directory is full")
+	ErrRoomQuotaExceeded                = psrpc.NewErrorf(psrpc.ResourceExhausted, "room quota of API key exceeded")
+	ErrParticipantQuotaExceeded         = psrpc.NewErrorf(psrpc.ResourceExhausted, "participant quota of API key exceeded")
+	ErrWebhookDeliveryNotFound          = psrpc.NewErrorf(psrpc.NotFound, "webhook delivery does not exist")
+	ErrWebhookEndpointNotFound          = psrpc.NewErrorf(psrpc.FailedPrecondition, "webhook endpoint is no longer configured")
+	ErrWebhookNotEnabled                = psrpc.NewErrorf(psrpc.FailedPrecondition, "webhooks are not enabled")
+	ErrDestinationRoomEmpty             = psrpc.NewErrorf(psrpc.InvalidArgument, "destination_room cannot be empty")
+	ErrBridgeToSameRoom                 = psrpc.NewErrorf(psrpc.InvalidArgument, "destination room must differ from the track's room")
+	ErrTrackBridgeNotFound              = psrpc.NewErrorf(psrpc.NotFound, "track bridge does not exist")
+	ErrBridgeAcrossNodes                = psrpc.NewErrorf(psrpc.FailedPrecondition, "destination room is hosted on another node than the track's room, tracks are only bridged between rooms of the same node")
+	ErrMoveToSameRoom                   = psrpc.NewErrorf(psrpc.InvalidArgument, "destination room must differ from the participant's room")
+	ErrRoomHasNoLobby                   = psrpc.NewErrorf(psrpc.FailedPrecondition, "room does not have a lobby")
+	ErrRoomHasNoStage                   = psrpc.NewErrorf(psrpc.FailedPrecondition, "room does not have a stage")
+	ErrRoomHasNoChatHistory             = psrpc.NewErrorf(psrpc.FailedPrecondition, "room does not keep a chat history")
+	ErrRoomScheduleNotFound             = psrpc.NewErrorf(psrpc.NotFound, "room does not have a schedule")
+	ErrInvalidRoomSchedule              = psrpc.NewErrorf(psrpc.InvalidArgument, "expires_at must be after not_before")
+	ErrRoomNotOpen                      = psrpc.NewErrorf(psrpc.FailedPrecondition, "room is not open yet")
+	ErrRoomExpired                      = psrpc.NewErrorf(psrpc.FailedPrecondition, "room has expired")
 )
@@ -38,6 +38,10 @@ type ObjectStore interface {
 
 	StoreParticipant(ctx context.Context, roomName livekit.RoomName, participant *livekit.ParticipantInfo) error
 	DeleteParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error
+
+	// schedules are kept apart from rooms, they can be stored before the room is created and outlive it
+	StoreRoomSchedule(ctx context.Context, schedule *RoomSchedule) error
+	DeleteRoomSchedule(ctx context.Context, roomName livekit.RoomName) error
 }
 
 //counterfeiter:generate . ServiceStore
@@ -50,6 +54,7 @@ type ServiceStore interface {
 	ListRooms(ctx context.Context, roomNames []livekit.RoomName) ([]*livekit.Room, error)
 	LoadParticipant(ctx context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error)
 	ListParticipants(ctx context.Context, roomName livekit.RoomName) ([]*livekit.ParticipantInfo, error)
+	LoadRoomSchedule(ctx context.Context, roomName livekit.RoomName) (*RoomSchedule, error)
 }
 
 //counterfeiter:generate . EgressStore
@@ -107,3 +112,31 @@ type AgentStore interface {
 	StoreAgentJob(ctx context.Context, job *livekit.Job) error
 	DeleteAgentJob(ctx context.Context, job *livekit.Job) error
 }
+
+// persists webhook events until their endpoint acknowledges them, and the ones that exhausted their retries
+//
+//counterfeiter:generate . WebhookStore
+type WebhookStore interface {
+	// StoreWebhookDelivery adds or updates a delivery, it is kept as a dead letter once DeadLetteredAt is set
+	StoreWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	// ClaimWebhookDelivery atomically stores a pending delivery as held by delivery.NodeID. It fails without storing
+	// when the delivery is no longer pending, or another node holds an unexpired lease on it
+	ClaimWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) (bool, error)
+	LoadWebhookDelivery(ctx context.Context, id string) (*WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, deadLettered bool) ([]*WebhookDelivery, error)
+	DeleteWebhookDelivery(ctx context.Context, id string) error
+}
+
+// counts what an API key is using across nodes, such as its rooms. members are held with a lease
+// the owning node keeps renewing, so the ones of a node that went away expire
+//
+//counterfeiter:generate . QuotaStore
+type QuotaStore interface {
+	// AcquireQuota adds member to set unless the set already holds limit members with unexpired leases.
+	// a member already in the set is renewed and always succeeds
+	AcquireQuota(ctx context.Context, set, member string, limit int, expiresAt time.Time) (bool, error)
+	// CheckQuota reports whether acquiring member would succeed, without adding it
+	CheckQuota(ctx context.Context, set, member string, limit int) (bool, error)
+	RenewQuota(ctx context.Context, set string, members []string, expiresAt time.Time) error
+	ReleaseQuota(ctx context.Context, set, member string) error
+}
@@ -65,13 +65,19 @@ func NewIOInfoService(
 }
 
 func (s *IOInfoService) Start() error {
-	if s.es != nil {
-		rs := s.es.(*RedisStore)
-		err := rs.Start()
+	switch store := s.es.(type) {
+	case *RedisStore:
+		err := store.Start()
 		if err != nil {
 			logger.Errorw("failed to start redis egress worker", err)
 			return err
 		}
+	case *SQLStore:
+		err := store.Start()
+		if err != nil {
+			logger.Errorw("failed to start sql egress worker", err)
+			return err
+		}
 	}
 
 	return nil
@@ -29,12 +29,28 @@ import (
 
 // matchSIPTrunk finds a SIP Trunk definition matching the request.
 // Returns nil if no rules matched or an error if there are conflicting definitions.
-func (s *IOInfoService) matchSIPTrunk(ctx context.Context, trunkID, calling, called string, srcIP netip.Addr) (*livekit.SIPInboundTrunkInfo, error) {
+func (s *IOInfoService) matchSIPTrunk(ctx context.Context, trunkID string, call *rpc.SIPCall) (*livekit.SIPInboundTrunkInfo, error) {
 	trunks, err := s.ss.ListSIPInboundTrunk(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return sip.MatchTrunk(trunks, srcIP, calling, called)
+	return sip.MatchTrunk(trunks, call)
+}
+
+// sipCallFromRequest returns the call description sent by the SIP service,
+// falling back to one built from the legacy request fields.
+func sipCallFromRequest(call *rpc.SIPCall, calling, called string, srcIP netip.Addr) *rpc.SIPCall {
+	if call != nil && call.From != nil && call.To != nil {
+		return call
+	}
+	c := &rpc.SIPCall{
+		From: &livekit.SIPUri{User: calling},
+		To:   &livekit.SIPUri{User: called},
+	}
+	if srcIP.IsValid() {
+		c.SourceIp = srcIP.String()
+	}
+	return c
 }
 
 // matchSIPDispatchRule finds the best dispatch rule matching the request parameters. Returns an error if no rule matched.
@@ -61,7 +77,7 @@ func (s *IOInfoService) EvaluateSIPDispatchRules(ctx context.Context, req *rpc.E
 		log.Errorw("cannot parse source IP", err)
 		return nil, twirp.WrapError(twirp.NewError(twirp.InvalidArgument, err.Error()), err)
 	}
-	trunk, err := s.matchSIPTrunk(ctx, req.SipTrunkId, req.CallingNumber, req.CalledNumber, srcIP)
+	trunk, err := s.matchSIPTrunk(ctx, req.SipTrunkId, sipCallFromRequest(req.Call, req.CallingNumber, req.CalledNumber, srcIP))
 	if err != nil {
 		return nil, err
 	}
@@ -106,7 +122,7 @@ func (s *IOInfoService) GetSIPTrunkAuthentication(ctx context.Context, req *rpc.
 		log.Errorw("cannot parse source IP", err)
 		return nil, twirp.WrapError(twirp.NewError(twirp.InvalidArgument, err.Error()), err)
 	}
-	trunk, err := s.matchSIPTrunk(ctx, "", req.From, req.To, srcIP)
+	trunk, err := s.matchSIPTrunk(ctx, "", sipCallFromRequest(req.Call, req.From, req.To, srcIP))
 	if err != nil {
 		return nil, err
 	}
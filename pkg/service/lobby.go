@@ -0,0 +1,92 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"net/http"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const lobbyPath = "/lobby"
+
+type LobbyRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+}
+
+// LobbyService lets room admins list the participants waiting in the lobby of a room, and admit or reject them.
+// Rooms get a lobby when they are created with a named room configuration that has one configured.
+// Requests are served by the RoomService, which routes them to the node hosting the room.
+type LobbyService struct {
+	roomService *RoomService
+}
+
+func NewLobbyService(roomService *RoomService) *LobbyService {
+	return &LobbyService{
+		roomService: roomService,
+	}
+}
+
+func (s *LobbyService) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET "+lobbyPath, s.handleList)
+	mux.HandleFunc("POST "+lobbyPath+"/admit", s.handleAdmit)
+	mux.HandleFunc("POST "+lobbyPath+"/reject", s.handleReject)
+}
+
+func (s *LobbyService) handleList(w http.ResponseWriter, r *http.Request) {
+	roomName := r.URL.Query().Get("room")
+	res, err := s.roomService.ListLobby(r.Context(), &livekit.ListParticipantsRequest{Room: roomName})
+	if err != nil {
+		handleError(w, r, statusForError(err), err, "room", roomName)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *LobbyService) handleAdmit(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.readRequest(w, r)
+	if !ok {
+		return
+	}
+
+	pi, err := s.roomService.AdmitParticipant(r.Context(), &livekit.RoomParticipantIdentity{Room: req.Room, Identity: req.Identity})
+	if err != nil {
+		handleError(w, r, statusForError(err), err, "room", req.Room, "participant", req.Identity)
+		return
+	}
+	writeJSON(w, http.StatusOK, pi)
+}
+
+func (s *LobbyService) handleReject(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.readRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := s.roomService.RejectParticipant(r.Context(), &livekit.RoomParticipantIdentity{Room: req.Room, Identity: req.Identity}); err != nil {
+		handleError(w, r, statusForError(err), err, "room", req.Room, "participant", req.Identity)
+		return
+	}
+	writeJSON(w, http.StatusOK, &req)
+}
+
+func (s *LobbyService) readRequest(w http.ResponseWriter, r *http.Request) (LobbyRequest, bool) {
+	var req LobbyRequest
+	if !readJSON(w, r, &req) {
+		return req, false
+	}
+	return req, true
+}
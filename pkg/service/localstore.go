@@ -16,11 +16,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
 	"github.com/thoas/go-funk"
 
+	"github.com/livekit/protocol/ingress"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/utils"
 )
@@ -30,12 +32,41 @@ type LocalStore struct {
 	// map of roomName => room
 	rooms        map[livekit.RoomName]*livekit.Room
 	roomInternal map[livekit.RoomName]*livekit.RoomInternal
+	// map of roomName => room configuration the room was created with, persisted with the room
+	roomPresets map[livekit.RoomName]string
+	// rooms restored from disk whose agent dispatches were not picked up yet
+	restoredRooms map[livekit.RoomName]struct{}
 	// map of roomName => { identity: participant }
 	participants map[livekit.RoomName]map[livekit.ParticipantIdentity]*livekit.ParticipantInfo
 
 	agentDispatches map[livekit.RoomName]map[string]*livekit.AgentDispatch
 	agentJobs       map[livekit.RoomName]map[string]*livekit.Job
 
+	// map of roomName => schedule, kept apart from rooms
+	roomSchedules map[livekit.RoomName]*RoomSchedule
+
+	// map of deliveryID => pending or dead-lettered webhook delivery
+	webhookDeliveries map[string]*WebhookDelivery
+
+	// map of quota set => { member: lease expiry }, not persisted
+	quotas map[string]map[string]time.Time
+
+	// map of egressID => egress info
+	egress map[string]*livekit.EgressInfo
+	// map of ingressID => ingress info, without state
+	ingress       map[string]*livekit.IngressInfo
+	ingressState  map[string]*livekit.IngressState
+	ingressStream map[string]string
+
+	sipTrunks         map[string]*livekit.SIPTrunkInfo
+	sipInboundTrunks  map[string]*livekit.SIPInboundTrunkInfo
+	sipOutboundTrunks map[string]*livekit.SIPOutboundTrunkInfo
+	sipDispatchRules  map[string]*livekit.SIPDispatchRuleInfo
+
+	// set when persisting to disk
+	wal  *localStoreWAL
+	done chan struct{}
+
 	lock       sync.RWMutex
 	globalLock sync.Mutex
 }
@@ -44,10 +75,27 @@ func NewLocalStore() *LocalStore {
 	return &LocalStore{
 		rooms:           make(map[livekit.RoomName]*livekit.Room),
 		roomInternal:    make(map[livekit.RoomName]*livekit.RoomInternal),
+		roomPresets:     make(map[livekit.RoomName]string),
+		restoredRooms:   make(map[livekit.RoomName]struct{}),
 		participants:    make(map[livekit.RoomName]map[livekit.ParticipantIdentity]*livekit.ParticipantInfo),
 		agentDispatches: make(map[livekit.RoomName]map[string]*livekit.AgentDispatch),
 		agentJobs:       make(map[livekit.RoomName]map[string]*livekit.Job),
-		lock:            sync.RWMutex{},
+
+		roomSchedules:     make(map[livekit.RoomName]*RoomSchedule),
+		webhookDeliveries: make(map[string]*WebhookDelivery),
+		quotas:            make(map[string]map[string]time.Time),
+
+		egress:        make(map[string]*livekit.EgressInfo),
+		ingress:       make(map[string]*livekit.IngressInfo),
+		ingressState:  make(map[string]*livekit.IngressState),
+		ingressStream: make(map[string]string),
+
+		sipTrunks:         make(map[string]*livekit.SIPTrunkInfo),
+		sipInboundTrunks:  make(map[string]*livekit.SIPInboundTrunkInfo),
+		sipOutboundTrunks: make(map[string]*livekit.SIPOutboundTrunkInfo),
+		sipDispatchRules:  make(map[string]*livekit.SIPDispatchRuleInfo),
+
+		lock: sync.RWMutex{},
 	}
 }
 
@@ -58,10 +106,20 @@ func (s *LocalStore) StoreRoom(_ context.Context, room *livekit.Room, internal *
 	roomName := livekit.RoomName(room.Name)
 
 	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.wal != nil {
+		rec, err := newStoreRoomRecord(room, internal, s.roomPresets[roomName])
+		if err != nil {
+			return err
+		}
+		if err = s.appendLocked(rec); err != nil {
+			return err
+		}
+	}
+
 	s.rooms[roomName] = room
 	s.roomInternal[roomName] = internal
-	s.lock.Unlock()
-
 	return nil
 }
 
@@ -105,14 +163,72 @@ func (s *LocalStore) DeleteRoom(ctx context.Context, roomName livekit.RoomName)
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	if err = s.appendLocked(&localStoreRecord{Op: localStoreOpDeleteRoom, Room: room.Name}); err != nil {
+		return err
+	}
+
 	delete(s.participants, livekit.RoomName(room.Name))
 	delete(s.rooms, livekit.RoomName(room.Name))
 	delete(s.roomInternal, livekit.RoomName(room.Name))
+	delete(s.roomPresets, livekit.RoomName(room.Name))
+	delete(s.restoredRooms, livekit.RoomName(room.Name))
 	delete(s.agentDispatches, livekit.RoomName(room.Name))
 	delete(s.agentJobs, livekit.RoomName(room.Name))
 	return nil
 }
 
+// StoreRoomPreset records the room configuration a room was created with, to create it alike when it is restored
+func (s *LocalStore) StoreRoomPreset(_ context.Context, roomName livekit.RoomName, preset string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	room := s.rooms[roomName]
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	if s.roomPresets[roomName] == preset {
+		return nil
+	}
+
+	if s.wal != nil {
+		rec, err := newStoreRoomRecord(room, s.roomInternal[roomName], preset)
+		if err != nil {
+			return err
+		}
+		if err = s.appendLocked(rec); err != nil {
+			return err
+		}
+	}
+
+	s.roomPresets[roomName] = preset
+	return nil
+}
+
+// LoadRoomPreset returns the room configuration a room was created with, empty when there was none
+func (s *LocalStore) LoadRoomPreset(_ context.Context, roomName livekit.RoomName) string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.roomPresets[roomName]
+}
+
+// TakeRestoredAgentDispatches returns the agent dispatches of a room restored from disk, the first time
+// the room is created after a restart only
+func (s *LocalStore) TakeRestoredAgentDispatches(ctx context.Context, roomName livekit.RoomName) ([]*livekit.AgentDispatch, bool) {
+	s.lock.Lock()
+	_, ok := s.restoredRooms[roomName]
+	delete(s.restoredRooms, roomName)
+	s.lock.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	dispatches, err := s.ListAgentDispatches(ctx, roomName)
+	if err != nil {
+		return nil, false
+	}
+	return dispatches, true
+}
+
 func (s *LocalStore) LockRoom(_ context.Context, _ livekit.RoomName, _ time.Duration) (string, error) {
 	// local rooms lock & unlock globally
 	s.globalLock.Lock()
@@ -180,6 +296,49 @@ func (s *LocalStore) DeleteParticipant(_ context.Context, roomName livekit.RoomN
 	return nil
 }
 
+func (s *LocalStore) StoreRoomSchedule(_ context.Context, schedule *RoomSchedule) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	clone := *schedule
+	if s.wal != nil {
+		rec, err := newStoreRoomScheduleRecord(&clone)
+		if err != nil {
+			return err
+		}
+		if err = s.appendLocked(rec); err != nil {
+			return err
+		}
+	}
+
+	s.roomSchedules[livekit.RoomName(clone.Room)] = &clone
+	return nil
+}
+
+func (s *LocalStore) LoadRoomSchedule(_ context.Context, roomName livekit.RoomName) (*RoomSchedule, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	schedule := s.roomSchedules[roomName]
+	if schedule == nil || !schedule.isRetained(time.Now()) {
+		return nil, ErrRoomScheduleNotFound
+	}
+	clone := *schedule
+	return &clone, nil
+}
+
+func (s *LocalStore) DeleteRoomSchedule(_ context.Context, roomName livekit.RoomName) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.appendLocked(&localStoreRecord{Op: localStoreOpDeleteRoomSchedule, Room: string(roomName)}); err != nil {
+		return err
+	}
+
+	delete(s.roomSchedules, roomName)
+	return nil
+}
+
 func (s *LocalStore) StoreAgentDispatch(ctx context.Context, dispatch *livekit.AgentDispatch) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -189,6 +348,16 @@ func (s *LocalStore) StoreAgentDispatch(ctx context.Context, dispatch *livekit.A
 		clone.State.Jobs = nil
 	}
 
+	if s.wal != nil {
+		rec, err := newStoreDispatchRecord(clone)
+		if err != nil {
+			return err
+		}
+		if err = s.appendLocked(rec); err != nil {
+			return err
+		}
+	}
+
 	roomDispatches := s.agentDispatches[livekit.RoomName(dispatch.Room)]
 	if roomDispatches == nil {
 		roomDispatches = make(map[string]*livekit.AgentDispatch)
@@ -203,6 +372,10 @@ func (s *LocalStore) DeleteAgentDispatch(ctx context.Context, dispatch *livekit.
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	if err := s.appendLocked(&localStoreRecord{Op: localStoreOpDeleteDispatch, Room: dispatch.Room, ID: dispatch.Id}); err != nil {
+		return err
+	}
+
 	roomDispatches := s.agentDispatches[livekit.RoomName(dispatch.Room)]
 	if roomDispatches != nil {
 		delete(roomDispatches, dispatch.Id)
@@ -279,3 +452,327 @@ func (s *LocalStore) DeleteAgentJob(ctx context.Context, job *livekit.Job) error
 
 	return nil
 }
+
+func (s *LocalStore) StoreWebhookDelivery(_ context.Context, delivery *WebhookDelivery) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.storeWebhookDeliveryLocked(delivery)
+}
+
+func (s *LocalStore) ClaimWebhookDelivery(_ context.Context, delivery *WebhookDelivery) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !canClaimWebhookDelivery(s.webhookDeliveries[delivery.ID], delivery.NodeID, time.Now()) {
+		return false, nil
+	}
+	return true, s.storeWebhookDeliveryLocked(delivery)
+}
+
+func (s *LocalStore) storeWebhookDeliveryLocked(delivery *WebhookDelivery) error {
+	clone := *delivery
+	if s.wal != nil {
+		rec, err := newStoreWebhookRecord(&clone)
+		if err != nil {
+			return err
+		}
+		if err = s.appendLocked(rec); err != nil {
+			return err
+		}
+	}
+
+	s.webhookDeliveries[clone.ID] = &clone
+	return nil
+}
+
+func (s *LocalStore) LoadWebhookDelivery(_ context.Context, id string) (*WebhookDelivery, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	delivery := s.webhookDeliveries[id]
+	if delivery == nil {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	clone := *delivery
+	return &clone, nil
+}
+
+func (s *LocalStore) ListWebhookDeliveries(_ context.Context, deadLettered bool) ([]*WebhookDelivery, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var deliveries []*WebhookDelivery
+	for _, delivery := range s.webhookDeliveries {
+		if (delivery.DeadLetteredAt != 0) == deadLettered {
+			clone := *delivery
+			deliveries = append(deliveries, &clone)
+		}
+	}
+	return deliveries, nil
+}
+
+func (s *LocalStore) DeleteWebhookDelivery(_ context.Context, id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.appendLocked(&localStoreRecord{Op: localStoreOpDeleteWebhook, ID: id}); err != nil {
+		return err
+	}
+
+	delete(s.webhookDeliveries, id)
+	return nil
+}
+
+func (s *LocalStore) AcquireQuota(_ context.Context, set, member string, limit int, expiresAt time.Time) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.checkQuotaLocked(set, member, limit) {
+		return false, nil
+	}
+	members := s.quotas[set]
+	if members == nil {
+		members = make(map[string]time.Time)
+		s.quotas[set] = members
+	}
+	members[member] = expiresAt
+	return true, nil
+}
+
+func (s *LocalStore) CheckQuota(_ context.Context, set, member string, limit int) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.checkQuotaLocked(set, member, limit), nil
+}
+
+func (s *LocalStore) checkQuotaLocked(set, member string, limit int) bool {
+	now := time.Now()
+	members := s.quotas[set]
+	count := 0
+	for m, expiresAt := range members {
+		if !expiresAt.After(now) {
+			delete(members, m)
+			continue
+		}
+		if m == member {
+			return true
+		}
+		count++
+	}
+	return count < limit
+}
+
+func (s *LocalStore) RenewQuota(_ context.Context, set string, members []string, expiresAt time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	held := s.quotas[set]
+	if held == nil {
+		held = make(map[string]time.Time)
+		s.quotas[set] = held
+	}
+	for _, m := range members {
+		held[m] = expiresAt
+	}
+	return nil
+}
+
+func (s *LocalStore) ReleaseQuota(_ context.Context, set, member string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if members := s.quotas[set]; members != nil {
+		delete(members, member)
+		if len(members) == 0 {
+			delete(s.quotas, set)
+		}
+	}
+	return nil
+}
+
+func (s *LocalStore) StoreEgress(_ context.Context, info *livekit.EgressInfo) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.egress[info.EgressId] = utils.CloneProto(info)
+	return nil
+}
+
+func (s *LocalStore) LoadEgress(_ context.Context, egressID string) (*livekit.EgressInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	info := s.egress[egressID]
+	if info == nil {
+		return nil, ErrEgressNotFound
+	}
+	return utils.CloneProto(info), nil
+}
+
+func (s *LocalStore) ListEgress(_ context.Context, roomName livekit.RoomName, active bool) ([]*livekit.EgressInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var infos []*livekit.EgressInfo
+	for _, info := range s.egress {
+		if roomName != "" && info.RoomName != string(roomName) {
+			continue
+		}
+		// if active, filter status starting, active, and ending
+		if !active || int32(info.Status) < int32(livekit.EgressStatus_EGRESS_COMPLETE) {
+			infos = append(infos, utils.CloneProto(info))
+		}
+	}
+	return infos, nil
+}
+
+func (s *LocalStore) UpdateEgress(_ context.Context, info *livekit.EgressInfo) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.egress[info.EgressId] = utils.CloneProto(info)
+	s.cleanEndedEgressLocked()
+	return nil
+}
+
+// deletes egress info 24h after the egress has ended
+func (s *LocalStore) cleanEndedEgressLocked() {
+	expiry := time.Now().Add(-24 * time.Hour).UnixNano()
+	for egressID, info := range s.egress {
+		if info.EndedAt != 0 && info.EndedAt < expiry {
+			delete(s.egress, egressID)
+		}
+	}
+}
+
+func (s *LocalStore) StoreIngress(_ context.Context, info *livekit.IngressInfo) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.storeIngressLocked(info); err != nil {
+		return err
+	}
+	return s.storeIngressStateLocked(info.IngressId, nil)
+}
+
+func (s *LocalStore) storeIngressLocked(info *livekit.IngressInfo) error {
+	if info.IngressId == "" {
+		return errors.New("Missing IngressId")
+	}
+	if info.StreamKey == "" && info.InputType != livekit.IngressInput_URL_INPUT {
+		return errors.New("Missing StreamKey")
+	}
+
+	// ignore state
+	infoCopy := utils.CloneProto(info)
+	infoCopy.State = nil
+
+	s.ingress[info.IngressId] = infoCopy
+	if info.StreamKey != "" {
+		s.ingressStream[info.StreamKey] = info.IngressId
+	}
+	return nil
+}
+
+func (s *LocalStore) storeIngressStateLocked(ingressId string, state *livekit.IngressState) error {
+	if ingressId == "" {
+		return errors.New("Missing IngressId")
+	}
+
+	if state == nil {
+		state = &livekit.IngressState{}
+	}
+
+	if oldState := s.ingressState[ingressId]; oldState != nil {
+		if state.StartedAt < oldState.StartedAt {
+			// Do not overwrite the info and state of a more recent session
+			return ingress.ErrIngressOutOfDate
+		}
+
+		if state.StartedAt == oldState.StartedAt && state.UpdatedAt < oldState.UpdatedAt {
+			// Do not overwrite with an old state in case RPCs were delivered out of order.
+			// All RPCs come from the same ingress server and should thus be on the same clock.
+			return nil
+		}
+	}
+
+	s.ingressState[ingressId] = utils.CloneProto(state)
+	return nil
+}
+
+func (s *LocalStore) loadIngressLocked(ingressId string) (*livekit.IngressInfo, error) {
+	info := s.ingress[ingressId]
+	if info == nil {
+		return nil, ErrIngressNotFound
+	}
+
+	info = utils.CloneProto(info)
+	if state := s.ingressState[ingressId]; state != nil {
+		info.State = utils.CloneProto(state)
+	}
+	return info, nil
+}
+
+func (s *LocalStore) LoadIngress(_ context.Context, ingressId string) (*livekit.IngressInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.loadIngressLocked(ingressId)
+}
+
+func (s *LocalStore) LoadIngressFromStreamKey(_ context.Context, streamKey string) (*livekit.IngressInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	ingressID, ok := s.ingressStream[streamKey]
+	if !ok {
+		return nil, ErrIngressNotFound
+	}
+	return s.loadIngressLocked(ingressID)
+}
+
+func (s *LocalStore) ListIngress(_ context.Context, roomName livekit.RoomName) ([]*livekit.IngressInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var infos []*livekit.IngressInfo
+	for ingressID, info := range s.ingress {
+		if roomName != "" && info.RoomName != string(roomName) {
+			continue
+		}
+		info, err := s.loadIngressLocked(ingressID)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (s *LocalStore) UpdateIngress(_ context.Context, info *livekit.IngressInfo) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.storeIngressLocked(info)
+}
+
+func (s *LocalStore) UpdateIngressState(_ context.Context, ingressId string, state *livekit.IngressState) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.storeIngressStateLocked(ingressId, state)
+}
+
+func (s *LocalStore) DeleteIngress(_ context.Context, info *livekit.IngressInfo) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if info.StreamKey != "" {
+		delete(s.ingressStream, info.StreamKey)
+	}
+	delete(s.ingress, info.IngressId)
+	delete(s.ingressState, info.IngressId)
+	return nil
+}
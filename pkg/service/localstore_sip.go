@@ -0,0 +1,162 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+)
+
+func (s *LocalStore) StoreSIPTrunk(_ context.Context, info *livekit.SIPTrunkInfo) error {
+	return localStoreOne(s, s.sipTrunks, info.SipTrunkId, info)
+}
+
+func (s *LocalStore) StoreSIPInboundTrunk(_ context.Context, info *livekit.SIPInboundTrunkInfo) error {
+	return localStoreOne(s, s.sipInboundTrunks, info.SipTrunkId, info)
+}
+
+func (s *LocalStore) StoreSIPOutboundTrunk(_ context.Context, info *livekit.SIPOutboundTrunkInfo) error {
+	return localStoreOne(s, s.sipOutboundTrunks, info.SipTrunkId, info)
+}
+
+func (s *LocalStore) LoadSIPTrunk(_ context.Context, id string) (*livekit.SIPTrunkInfo, error) {
+	if tr, err := localLoadOne(s, s.sipTrunks, id, ErrSIPTrunkNotFound); err == nil {
+		return tr, nil
+	}
+	if in, err := localLoadOne(s, s.sipInboundTrunks, id, ErrSIPTrunkNotFound); err == nil {
+		return in.AsTrunkInfo(), nil
+	}
+	if out, err := localLoadOne(s, s.sipOutboundTrunks, id, ErrSIPTrunkNotFound); err == nil {
+		return out.AsTrunkInfo(), nil
+	}
+	return nil, ErrSIPTrunkNotFound
+}
+
+func (s *LocalStore) LoadSIPInboundTrunk(_ context.Context, id string) (*livekit.SIPInboundTrunkInfo, error) {
+	if in, err := localLoadOne(s, s.sipInboundTrunks, id, ErrSIPTrunkNotFound); err == nil {
+		return in, nil
+	}
+	if tr, err := localLoadOne(s, s.sipTrunks, id, ErrSIPTrunkNotFound); err == nil {
+		return tr.AsInbound(), nil
+	}
+	return nil, ErrSIPTrunkNotFound
+}
+
+func (s *LocalStore) LoadSIPOutboundTrunk(_ context.Context, id string) (*livekit.SIPOutboundTrunkInfo, error) {
+	if out, err := localLoadOne(s, s.sipOutboundTrunks, id, ErrSIPTrunkNotFound); err == nil {
+		return out, nil
+	}
+	if tr, err := localLoadOne(s, s.sipTrunks, id, ErrSIPTrunkNotFound); err == nil {
+		return tr.AsOutbound(), nil
+	}
+	return nil, ErrSIPTrunkNotFound
+}
+
+func (s *LocalStore) DeleteSIPTrunk(_ context.Context, id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.sipTrunks, id)
+	delete(s.sipInboundTrunks, id)
+	delete(s.sipOutboundTrunks, id)
+	return nil
+}
+
+func (s *LocalStore) ListSIPTrunk(_ context.Context) ([]*livekit.SIPTrunkInfo, error) {
+	infos := localLoadMany(s, s.sipTrunks)
+	for _, t := range localLoadMany(s, s.sipInboundTrunks) {
+		infos = append(infos, t.AsTrunkInfo())
+	}
+	for _, t := range localLoadMany(s, s.sipOutboundTrunks) {
+		infos = append(infos, t.AsTrunkInfo())
+	}
+	return infos, nil
+}
+
+func (s *LocalStore) ListSIPInboundTrunk(_ context.Context) ([]*livekit.SIPInboundTrunkInfo, error) {
+	in := localLoadMany(s, s.sipInboundTrunks)
+	for _, t := range localLoadMany(s, s.sipTrunks) {
+		in = append(in, t.AsInbound())
+	}
+	return in, nil
+}
+
+func (s *LocalStore) ListSIPOutboundTrunk(_ context.Context) ([]*livekit.SIPOutboundTrunkInfo, error) {
+	out := localLoadMany(s, s.sipOutboundTrunks)
+	for _, t := range localLoadMany(s, s.sipTrunks) {
+		out = append(out, t.AsOutbound())
+	}
+	return out, nil
+}
+
+func (s *LocalStore) StoreSIPDispatchRule(_ context.Context, info *livekit.SIPDispatchRuleInfo) error {
+	return localStoreOne(s, s.sipDispatchRules, info.SipDispatchRuleId, info)
+}
+
+func (s *LocalStore) LoadSIPDispatchRule(_ context.Context, sipDispatchRuleId string) (*livekit.SIPDispatchRuleInfo, error) {
+	return localLoadOne(s, s.sipDispatchRules, sipDispatchRuleId, ErrSIPDispatchRuleNotFound)
+}
+
+func (s *LocalStore) DeleteSIPDispatchRule(_ context.Context, info *livekit.SIPDispatchRuleInfo) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.sipDispatchRules, info.SipDispatchRuleId)
+	return nil
+}
+
+func (s *LocalStore) ListSIPDispatchRule(_ context.Context) ([]*livekit.SIPDispatchRuleInfo, error) {
+	return localLoadMany(s, s.sipDispatchRules), nil
+}
+
+func localStoreOne[P proto.Message](s *LocalStore, m map[string]P, id string, p P) error {
+	if id == "" {
+		return errors.New("id is not set")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	m[id] = utils.CloneProto(p)
+	return nil
+}
+
+func localLoadOne[P proto.Message](s *LocalStore, m map[string]P, id string, notFoundErr error) (P, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	p, ok := m[id]
+	if !ok {
+		var zero P
+		return zero, notFoundErr
+	}
+	return utils.CloneProto(p), nil
+}
+
+func localLoadMany[P proto.Message](s *LocalStore, m map[string]P) []P {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	list := make([]P, 0, len(m))
+	for _, p := range m {
+		list = append(list, utils.CloneProto(p))
+	}
+	return list
+}
@@ -0,0 +1,478 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/ingress"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/protocol/utils/guid"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestLocalEgressStore(t *testing.T) {
+	ctx := context.Background()
+	s := service.NewLocalStore()
+
+	info := &livekit.EgressInfo{
+		EgressId: guid.New(utils.EgressPrefix),
+		RoomName: "egress-test",
+		Status:   livekit.EgressStatus_EGRESS_STARTING,
+	}
+	require.NoError(t, s.StoreEgress(ctx, info))
+
+	info2 := &livekit.EgressInfo{
+		EgressId: guid.New(utils.EgressPrefix),
+		RoomName: "another-egress-test",
+		Status:   livekit.EgressStatus_EGRESS_ACTIVE,
+	}
+	require.NoError(t, s.StoreEgress(ctx, info2))
+
+	res, err := s.LoadEgress(ctx, info.EgressId)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(info, res))
+
+	_, err = s.LoadEgress(ctx, "not-an-egress")
+	require.Equal(t, service.ErrEgressNotFound, err)
+
+	list, err := s.ListEgress(ctx, "", false)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+
+	list, err = s.ListEgress(ctx, "egress-test", false)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	// completed egress is filtered out of the active list
+	info2.Status = livekit.EgressStatus_EGRESS_COMPLETE
+	info2.EndedAt = time.Now().UnixNano()
+	require.NoError(t, s.UpdateEgress(ctx, info2))
+
+	list, err = s.ListEgress(ctx, "", true)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, info.EgressId, list[0].EgressId)
+
+	// egress that ended over a day ago is cleaned up
+	info.Status = livekit.EgressStatus_EGRESS_COMPLETE
+	info.EndedAt = time.Now().Add(-25 * time.Hour).UnixNano()
+	require.NoError(t, s.UpdateEgress(ctx, info))
+
+	list, err = s.ListEgress(ctx, "egress-test", false)
+	require.NoError(t, err)
+	require.Len(t, list, 0)
+}
+
+func TestLocalIngressStore(t *testing.T) {
+	ctx := context.Background()
+	s := service.NewLocalStore()
+
+	info := &livekit.IngressInfo{
+		IngressId: "ingressId",
+		StreamKey: "streamKey",
+		State: &livekit.IngressState{
+			StartedAt: 2,
+		},
+	}
+
+	require.Error(t, s.StoreIngress(ctx, &livekit.IngressInfo{IngressId: "noStreamKey"}))
+
+	require.NoError(t, s.StoreIngress(ctx, info))
+	require.NoError(t, s.UpdateIngressState(ctx, info.IngressId, info.State))
+
+	pulledInfo, err := s.LoadIngress(ctx, "ingressId")
+	require.NoError(t, err)
+	compareIngressInfo(t, pulledInfo, info)
+
+	pulledInfo, err = s.LoadIngressFromStreamKey(ctx, "streamKey")
+	require.NoError(t, err)
+	compareIngressInfo(t, pulledInfo, info)
+
+	_, err = s.LoadIngressFromStreamKey(ctx, "unknown")
+	require.Equal(t, service.ErrIngressNotFound, err)
+
+	infos, err := s.ListIngress(ctx, "room")
+	require.NoError(t, err)
+	require.Len(t, infos, 0)
+
+	info.RoomName = "room"
+	require.NoError(t, s.UpdateIngress(ctx, info))
+
+	infos, err = s.ListIngress(ctx, "room")
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	compareIngressInfo(t, infos[0], info)
+
+	info.State.StartedAt = 1
+	require.Equal(t, ingress.ErrIngressOutOfDate, s.UpdateIngressState(ctx, info.IngressId, info.State))
+
+	info.State.StartedAt = 3
+	require.NoError(t, s.UpdateIngressState(ctx, info.IngressId, info.State))
+
+	require.NoError(t, s.DeleteIngress(ctx, info))
+
+	_, err = s.LoadIngress(ctx, "ingressId")
+	require.Equal(t, service.ErrIngressNotFound, err)
+	_, err = s.LoadIngressFromStreamKey(ctx, "streamKey")
+	require.Equal(t, service.ErrIngressNotFound, err)
+}
+
+func TestLocalSIPStore(t *testing.T) {
+	ctx := context.Background()
+	s := service.NewLocalStore()
+
+	oldID := guid.New(utils.SIPTrunkPrefix)
+	inID := guid.New(utils.SIPTrunkPrefix)
+	ruleID := guid.New(utils.SIPDispatchRulePrefix)
+
+	_, err := s.LoadSIPTrunk(ctx, oldID)
+	require.Equal(t, service.ErrSIPTrunkNotFound, err)
+
+	require.Error(t, s.StoreSIPTrunk(ctx, &livekit.SIPTrunkInfo{Name: "Legacy"}))
+
+	oldT := &livekit.SIPTrunkInfo{SipTrunkId: oldID, Name: "Legacy"}
+	require.NoError(t, s.StoreSIPTrunk(ctx, oldT))
+	inT := &livekit.SIPInboundTrunkInfo{SipTrunkId: inID, Name: "Inbound"}
+	require.NoError(t, s.StoreSIPInboundTrunk(ctx, inT))
+
+	// legacy trunks are visible as both inbound and outbound
+	in, err := s.LoadSIPInboundTrunk(ctx, oldID)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(oldT.AsInbound(), in))
+
+	out, err := s.LoadSIPOutboundTrunk(ctx, oldID)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(oldT.AsOutbound(), out))
+
+	_, err = s.LoadSIPOutboundTrunk(ctx, inID)
+	require.Equal(t, service.ErrSIPTrunkNotFound, err)
+
+	list, err := s.ListSIPTrunk(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+
+	inList, err := s.ListSIPInboundTrunk(ctx)
+	require.NoError(t, err)
+	require.Len(t, inList, 2)
+
+	outList, err := s.ListSIPOutboundTrunk(ctx)
+	require.NoError(t, err)
+	require.Len(t, outList, 1)
+
+	require.NoError(t, s.DeleteSIPTrunk(ctx, oldID))
+	require.NoError(t, s.DeleteSIPTrunk(ctx, inID))
+
+	list, err = s.ListSIPTrunk(ctx)
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	rule := &livekit.SIPDispatchRuleInfo{
+		SipDispatchRuleId: ruleID,
+		TrunkIds:          []string{"trunk"},
+	}
+	require.NoError(t, s.StoreSIPDispatchRule(ctx, rule))
+
+	got, err := s.LoadSIPDispatchRule(ctx, ruleID)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(rule, got))
+
+	rules, err := s.ListSIPDispatchRule(ctx)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+
+	require.NoError(t, s.DeleteSIPDispatchRule(ctx, rule))
+
+	_, err = s.LoadSIPDispatchRule(ctx, ruleID)
+	require.Equal(t, service.ErrSIPDispatchRuleNotFound, err)
+}
+
+func TestLocalStorePersistence(t *testing.T) {
+	ctx := context.Background()
+	conf := config.LocalStoreConfig{
+		Dir:              t.TempDir(),
+		SnapshotInterval: time.Hour,
+	}
+
+	open := func() *service.LocalStore {
+		s, err := service.NewPersistentLocalStore(conf)
+		require.NoError(t, err)
+		return s
+	}
+
+	s := open()
+	require.True(t, s.IsPersistent())
+
+	room := &livekit.Room{Sid: "RM_1", Name: "room1", EmptyTimeout: 600, Metadata: "meta"}
+	internal := &livekit.RoomInternal{SyncStreams: true}
+	require.NoError(t, s.StoreRoom(ctx, room, internal))
+	require.NoError(t, s.StoreRoom(ctx, &livekit.Room{Sid: "RM_2", Name: "room2", EmptyTimeout: 600}, nil))
+	require.NoError(t, s.StoreRoom(ctx, &livekit.Room{Sid: "RM_3", Name: "deleted", EmptyTimeout: 600}, nil))
+	require.NoError(t, s.DeleteRoom(ctx, "deleted"))
+	// older than its empty timeout, which starts again once restored
+	require.NoError(t, s.StoreRoom(ctx, &livekit.Room{
+		Sid:          "RM_4",
+		Name:         "old",
+		EmptyTimeout: 60,
+		CreationTime: time.Now().Add(-2 * time.Minute).Unix(),
+	}, nil))
+	require.NoError(t, s.StoreParticipant(ctx, "room1", &livekit.ParticipantInfo{Identity: "p1"}))
+	require.NoError(t, s.StoreRoomPreset(ctx, "room1", "webinar"))
+	// only rooms restored from disk have their dispatches restored
+	_, ok := s.TakeRestoredAgentDispatches(ctx, "room1")
+	require.False(t, ok)
+
+	dispatch := &livekit.AgentDispatch{
+		Id:        "AD_1",
+		AgentName: "agent",
+		Room:      "room1",
+		State:     &livekit.AgentDispatchState{CreatedAt: 1},
+	}
+	require.NoError(t, s.StoreAgentDispatch(ctx, dispatch))
+	require.NoError(t, s.StoreAgentDispatch(ctx, &livekit.AgentDispatch{Id: "AD_2", Room: "room1"}))
+	require.NoError(t, s.DeleteAgentDispatch(ctx, &livekit.AgentDispatch{Id: "AD_2", Room: "room1"}))
+	require.NoError(t, s.StoreAgentDispatch(ctx, &livekit.AgentDispatch{Id: "AD_3", Room: "old"}))
+	require.NoError(t, s.StoreAgentDispatch(ctx, &livekit.AgentDispatch{Id: "AD_4", Room: "never_stored"}))
+
+	delivery := &service.WebhookDelivery{
+		ID:      "WH_1",
+		URL:     "http://localhost:7890",
+		Event:   "room_started",
+		Room:    "deleted",
+		Payload: []byte(`{"event":"room_started"}`),
+	}
+	require.NoError(t, s.StoreWebhookDelivery(ctx, delivery))
+	require.NoError(t, s.StoreWebhookDelivery(ctx, &service.WebhookDelivery{ID: "WH_2", Payload: []byte(`{}`)}))
+	require.NoError(t, s.DeleteWebhookDelivery(ctx, "WH_2"))
+
+	schedule := &service.RoomSchedule{Room: "upcoming", NotBefore: time.Now().Add(time.Hour).Unix()}
+	require.NoError(t, s.StoreRoomSchedule(ctx, schedule))
+	require.NoError(t, s.StoreRoomSchedule(ctx, &service.RoomSchedule{Room: "deleted", ExpiresAt: time.Now().Add(time.Hour).Unix()}))
+	require.NoError(t, s.DeleteRoomSchedule(ctx, "deleted"))
+	require.NoError(t, s.StoreRoomSchedule(ctx, &service.RoomSchedule{Room: "forgotten", ExpiresAt: time.Now().Add(-48 * time.Hour).Unix()}))
+
+	check := func(s *service.LocalStore) {
+		rooms, err := s.ListRooms(ctx, nil)
+		require.NoError(t, err)
+		require.Len(t, rooms, 3)
+
+		actual, actualInternal, err := s.LoadRoom(ctx, "room1", true)
+		require.NoError(t, err)
+		require.True(t, proto.Equal(room, actual))
+		require.True(t, proto.Equal(internal, actualInternal))
+
+		_, actualInternal, err = s.LoadRoom(ctx, "room2", true)
+		require.NoError(t, err)
+		require.Nil(t, actualInternal)
+
+		// rooms are created again with the room configuration they were created with
+		require.Equal(t, "webinar", s.LoadRoomPreset(ctx, "room1"))
+		require.Empty(t, s.LoadRoomPreset(ctx, "room2"))
+
+		_, _, err = s.LoadRoom(ctx, "old", false)
+		require.NoError(t, err)
+		_, _, err = s.LoadRoom(ctx, "deleted", false)
+		require.Equal(t, service.ErrRoomNotFound, err)
+
+		// participants belong to sessions that did not survive
+		participants, err := s.ListParticipants(ctx, "room1")
+		require.NoError(t, err)
+		require.Empty(t, participants)
+
+		dispatches, err := s.ListAgentDispatches(ctx, "room1")
+		require.NoError(t, err)
+		require.Len(t, dispatches, 1)
+		require.True(t, proto.Equal(dispatch, dispatches[0]))
+
+		dispatches, err = s.ListAgentDispatches(ctx, "old")
+		require.NoError(t, err)
+		require.Len(t, dispatches, 1)
+
+		dispatches, err = s.ListAgentDispatches(ctx, "never_stored")
+		require.NoError(t, err)
+		require.Empty(t, dispatches)
+
+		// dispatches of a restored room are handed over once, to its first instance after the restart
+		restored, ok := s.TakeRestoredAgentDispatches(ctx, "room1")
+		require.True(t, ok)
+		require.Len(t, restored, 1)
+		_, ok = s.TakeRestoredAgentDispatches(ctx, "room1")
+		require.False(t, ok)
+
+		// webhooks outlive their room
+		deliveries, err := s.ListWebhookDeliveries(ctx, false)
+		require.NoError(t, err)
+		require.Equal(t, []*service.WebhookDelivery{delivery}, deliveries)
+
+		// schedules are kept apart from rooms, until a day past their expiry
+		actualSchedule, err := s.LoadRoomSchedule(ctx, "upcoming")
+		require.NoError(t, err)
+		require.Equal(t, schedule, actualSchedule)
+		for _, roomName := range []livekit.RoomName{"deleted", "forgotten"} {
+			_, err = s.LoadRoomSchedule(ctx, roomName)
+			require.ErrorIs(t, err, service.ErrRoomScheduleNotFound)
+		}
+	}
+
+	t.Run("restores from write-ahead log after crash", func(t *testing.T) {
+		// the first store is never closed, as if the process was killed
+		s2 := open()
+		check(s2)
+		require.NoError(t, s2.Close())
+	})
+
+	t.Run("restores from snapshot after shutdown", func(t *testing.T) {
+		s3 := open()
+		require.NoError(t, s3.Close())
+
+		// changes after closing are not persisted
+		require.NoError(t, s3.DeleteRoom(ctx, "room1"))
+
+		s4 := open()
+		check(s4)
+
+		// crash with a partially written record at the end of the log
+		require.NoError(t, s4.StoreRoom(ctx, &livekit.Room{Sid: "RM_5", Name: "room5", EmptyTimeout: 600}, nil))
+		f, err := os.OpenFile(filepath.Join(conf.Dir, "localstore.wal"), os.O_APPEND|os.O_WRONLY, 0600)
+		require.NoError(t, err)
+		_, err = f.WriteString(`{"op":"delete_room","ro`)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		s5 := open()
+		_, _, err = s5.LoadRoom(ctx, "room5", false)
+		require.NoError(t, err)
+		require.NoError(t, s5.Close())
+	})
+
+	t.Run("refuses a log corrupted before its end", func(t *testing.T) {
+		s6 := open()
+		require.NoError(t, s6.StoreRoom(ctx, &livekit.Room{Sid: "RM_6", Name: "room6", EmptyTimeout: 600}, nil))
+
+		walPath := filepath.Join(conf.Dir, "localstore.wal")
+		wal, err := os.ReadFile(walPath)
+		require.NoError(t, err)
+		corrupted := append([]byte("{\"op\":\"delete_room\",\"ro\n"), wal...)
+		require.NoError(t, os.WriteFile(walPath, corrupted, 0600))
+
+		_, err = service.NewPersistentLocalStore(conf)
+		require.Error(t, err)
+
+		// the records after the corrupt one are kept
+		kept, err := os.ReadFile(walPath)
+		require.NoError(t, err)
+		require.Equal(t, corrupted, kept)
+	})
+}
+
+func TestLocalQuotaStore(t *testing.T) {
+	testQuotaStore(t, service.NewLocalStore())
+}
+
+func testQuotaStore(t *testing.T, s service.QuotaStore) {
+	ctx := context.Background()
+	set := "rooms:" + guid.New("key_")
+	expiresAt := time.Now().Add(time.Minute)
+
+	ok, err := s.AcquireQuota(ctx, set, "a", 2, expiresAt)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = s.AcquireQuota(ctx, set, "b", 2, expiresAt)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// full, members already counted are still allowed
+	ok, err = s.AcquireQuota(ctx, set, "c", 2, expiresAt)
+	require.NoError(t, err)
+	require.False(t, ok)
+	ok, err = s.CheckQuota(ctx, set, "", 2)
+	require.NoError(t, err)
+	require.False(t, ok)
+	ok, err = s.AcquireQuota(ctx, set, "a", 2, expiresAt)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.NoError(t, s.ReleaseQuota(ctx, set, "b"))
+	ok, err = s.CheckQuota(ctx, set, "", 2)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// expired leases are not counted, renewed ones are
+	ok, err = s.AcquireQuota(ctx, set, "c", 2, time.Now().Add(-time.Second))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, s.RenewQuota(ctx, set, []string{"a"}, expiresAt))
+	ok, err = s.AcquireQuota(ctx, set, "d", 2, expiresAt)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = s.CheckQuota(ctx, set, "", 2)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestLocalWebhookClaim(t *testing.T) {
+	testWebhookClaim(t, service.NewLocalStore())
+}
+
+func testWebhookClaim(t *testing.T, s service.WebhookStore) {
+	ctx := context.Background()
+	id := guid.New("WH_")
+
+	claim := func(nodeID string) bool {
+		ok, err := s.ClaimWebhookDelivery(ctx, &service.WebhookDelivery{
+			ID:             id,
+			Payload:        []byte(`{}`),
+			NodeID:         nodeID,
+			LeaseExpiresAt: time.Now().Add(time.Minute).UnixMilli(),
+		})
+		require.NoError(t, err)
+		return ok
+	}
+
+	// deliveries that are not stored cannot be claimed
+	require.False(t, claim("node1"))
+
+	// expired leases are taken over
+	require.NoError(t, s.StoreWebhookDelivery(ctx, &service.WebhookDelivery{
+		ID:             id,
+		Payload:        []byte(`{}`),
+		NodeID:         "node0",
+		LeaseExpiresAt: time.Now().Add(-time.Second).UnixMilli(),
+	}))
+	require.True(t, claim("node1"))
+	stored, err := s.LoadWebhookDelivery(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "node1", stored.NodeID)
+
+	// only by one node, the holder can renew its lease
+	require.False(t, claim("node2"))
+	require.True(t, claim("node1"))
+
+	// dead letters are not claimed
+	stored.DeadLetteredAt = time.Now().UnixMilli()
+	stored.LeaseExpiresAt = 0
+	require.NoError(t, s.StoreWebhookDelivery(ctx, stored))
+	require.False(t, claim("node2"))
+}
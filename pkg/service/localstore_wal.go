@@ -0,0 +1,441 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	localStoreWALFile      = "localstore.wal"
+	localStoreSnapshotFile = "localstore.snapshot"
+
+	localStoreOpStoreRoom      = "store_room"
+	localStoreOpDeleteRoom     = "delete_room"
+	localStoreOpStoreDispatch  = "store_dispatch"
+	localStoreOpDeleteDispatch = "delete_dispatch"
+	localStoreOpStoreWebhook   = "store_webhook"
+	localStoreOpDeleteWebhook  = "delete_webhook"
+
+	localStoreOpStoreRoomSchedule  = "store_room_schedule"
+	localStoreOpDeleteRoomSchedule = "delete_room_schedule"
+)
+
+// localStoreRecord is a single line of the write-ahead log or the snapshot.
+// only rooms, agent dispatches, webhook deliveries and room schedules are persisted, participants and jobs belong
+// to live sessions that do not survive a restart.
+type localStoreRecord struct {
+	Op          string `json:"op"`
+	Room        string `json:"room"`
+	Preset      string `json:"preset,omitempty"`
+	ID          string `json:"id,omitempty"`
+	Data        []byte `json:"data,omitempty"`
+	HasInternal bool   `json:"has_internal,omitempty"`
+	Internal    []byte `json:"internal,omitempty"`
+}
+
+type localStoreWAL struct {
+	dir  string
+	file *os.File
+	w    *bufio.Writer
+}
+
+// NewPersistentLocalStore creates a LocalStore that keeps a write-ahead log and periodic snapshots
+// in conf.Dir, restoring rooms, agent dispatches, webhook deliveries and room schedules that were stored before the last shutdown or crash
+func NewPersistentLocalStore(conf config.LocalStoreConfig) (*LocalStore, error) {
+	if err := os.MkdirAll(conf.Dir, 0700); err != nil {
+		return nil, err
+	}
+
+	s := NewLocalStore()
+	for _, name := range []string{localStoreSnapshotFile, localStoreWALFile} {
+		if err := s.replay(filepath.Join(conf.Dir, name)); err != nil {
+			return nil, err
+		}
+	}
+	s.dropOrphanedAgentDispatches()
+	for roomName := range s.rooms {
+		s.restoredRooms[roomName] = struct{}{}
+	}
+
+	s.wal = &localStoreWAL{dir: conf.Dir}
+	// compact whatever was recovered, this also discards a partially written tail of the log
+	if err := s.snapshot(); err != nil {
+		return nil, err
+	}
+
+	interval := conf.SnapshotInterval
+	if interval <= 0 {
+		interval = config.DefaultConfig.LocalStore.SnapshotInterval
+	}
+	s.done = make(chan struct{})
+	go s.snapshotWorker(interval)
+
+	return s, nil
+}
+
+// IsPersistent returns true when the store survives a restart
+func (s *LocalStore) IsPersistent() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.wal != nil
+}
+
+// Close writes a final snapshot and stops persisting, later changes are kept in memory only.
+func (s *LocalStore) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.wal == nil {
+		return nil
+	}
+	close(s.done)
+
+	err := s.snapshotLocked()
+	if s.wal.file != nil {
+		if cerr := s.wal.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	s.wal = nil
+	return err
+}
+
+func (s *LocalStore) snapshotWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if err := s.snapshot(); err != nil {
+				logger.Errorw("could not snapshot local store", err)
+			}
+		}
+	}
+}
+
+func (s *LocalStore) snapshot() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.wal == nil {
+		return nil
+	}
+	return s.snapshotLocked()
+}
+
+// snapshotLocked writes the full persisted state and truncates the write-ahead log.
+// replaying the log on top of a newer snapshot is harmless, every record overwrites or deletes by key.
+func (s *LocalStore) snapshotLocked() error {
+	tmp := filepath.Join(s.wal.dir, localStoreSnapshotFile+".tmp")
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	err = s.writeStateLocked(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not write snapshot")
+	}
+
+	if err = os.Rename(tmp, filepath.Join(s.wal.dir, localStoreSnapshotFile)); err != nil {
+		return err
+	}
+	if err = syncDir(s.wal.dir); err != nil {
+		return err
+	}
+
+	if s.wal.file != nil {
+		_ = s.wal.file.Close()
+	}
+	s.wal.file, err = os.OpenFile(filepath.Join(s.wal.dir, localStoreWALFile), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		s.wal.file = nil
+		return err
+	}
+	s.wal.w = bufio.NewWriter(s.wal.file)
+	return nil
+}
+
+func (s *LocalStore) writeStateLocked(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for roomName, room := range s.rooms {
+		rec, err := newStoreRoomRecord(room, s.roomInternal[roomName], s.roomPresets[roomName])
+		if err != nil {
+			return err
+		}
+		if err = enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	for _, roomDispatches := range s.agentDispatches {
+		for _, dispatch := range roomDispatches {
+			rec, err := newStoreDispatchRecord(dispatch)
+			if err != nil {
+				return err
+			}
+			if err = enc.Encode(rec); err != nil {
+				return err
+			}
+		}
+	}
+	for _, delivery := range s.webhookDeliveries {
+		rec, err := newStoreWebhookRecord(delivery)
+		if err != nil {
+			return err
+		}
+		if err = enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	for roomName, schedule := range s.roomSchedules {
+		// compact schedules past their retention away
+		if !schedule.isRetained(now) {
+			delete(s.roomSchedules, roomName)
+			continue
+		}
+		rec, err := newStoreRoomScheduleRecord(schedule)
+		if err != nil {
+			return err
+		}
+		if err = enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendLocked durably records a change before it is acknowledged
+func (s *LocalStore) appendLocked(rec *localStoreRecord) error {
+	if s.wal == nil {
+		return nil
+	}
+	if s.wal.file == nil {
+		return errors.New("local store write-ahead log is not open")
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err = s.wal.w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if err = s.wal.w.Flush(); err != nil {
+		return err
+	}
+	return s.wal.file.Sync()
+}
+
+func (s *LocalStore) replay(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		isLast := err == io.EOF
+		if !isLast {
+			_, perr := r.Peek(1)
+			isLast = perr == io.EOF
+		}
+
+		if len(bytes.TrimSpace(line)) != 0 {
+			rec := &localStoreRecord{}
+			if derr := json.Unmarshal(line, rec); derr != nil {
+				if !isLast {
+					// records after this one would be lost by the snapshot, leave the file for inspection
+					return errors.Wrapf(derr, "corrupt local store record in %s", path)
+				}
+				// a crash while appending leaves a partial record at the end of the log
+				logger.Warnw("discarding incomplete local store record", derr, "file", path)
+				return nil
+			}
+			if aerr := s.applyLocked(rec); aerr != nil {
+				logger.Warnw("discarding invalid local store record", aerr, "file", path, "op", rec.Op)
+			}
+		}
+
+		if isLast {
+			return nil
+		}
+	}
+}
+
+func (s *LocalStore) applyLocked(rec *localStoreRecord) error {
+	roomName := livekit.RoomName(rec.Room)
+	switch rec.Op {
+	case localStoreOpStoreRoom:
+		room := &livekit.Room{}
+		if err := proto.Unmarshal(rec.Data, room); err != nil {
+			return err
+		}
+		var internal *livekit.RoomInternal
+		if rec.HasInternal {
+			internal = &livekit.RoomInternal{}
+			if err := proto.Unmarshal(rec.Internal, internal); err != nil {
+				return err
+			}
+		}
+		s.rooms[roomName] = room
+		s.roomInternal[roomName] = internal
+		if rec.Preset != "" {
+			s.roomPresets[roomName] = rec.Preset
+		} else {
+			delete(s.roomPresets, roomName)
+		}
+
+	case localStoreOpDeleteRoom:
+		delete(s.rooms, roomName)
+		delete(s.roomInternal, roomName)
+		delete(s.roomPresets, roomName)
+		delete(s.agentDispatches, roomName)
+
+	case localStoreOpStoreDispatch:
+		dispatch := &livekit.AgentDispatch{}
+		if err := proto.Unmarshal(rec.Data, dispatch); err != nil {
+			return err
+		}
+		roomDispatches := s.agentDispatches[roomName]
+		if roomDispatches == nil {
+			roomDispatches = make(map[string]*livekit.AgentDispatch)
+			s.agentDispatches[roomName] = roomDispatches
+		}
+		roomDispatches[dispatch.Id] = dispatch
+
+	case localStoreOpDeleteDispatch:
+		if roomDispatches := s.agentDispatches[roomName]; roomDispatches != nil {
+			delete(roomDispatches, rec.ID)
+		}
+
+	case localStoreOpStoreWebhook:
+		delivery := &WebhookDelivery{}
+		if err := json.Unmarshal(rec.Data, delivery); err != nil {
+			return err
+		}
+		s.webhookDeliveries[delivery.ID] = delivery
+
+	case localStoreOpDeleteWebhook:
+		delete(s.webhookDeliveries, rec.ID)
+
+	case localStoreOpStoreRoomSchedule:
+		schedule := &RoomSchedule{}
+		if err := json.Unmarshal(rec.Data, schedule); err != nil {
+			return err
+		}
+		s.roomSchedules[roomName] = schedule
+
+	case localStoreOpDeleteRoomSchedule:
+		delete(s.roomSchedules, roomName)
+
+	default:
+		return errors.New("unknown op")
+	}
+	return nil
+}
+
+// dropOrphanedAgentDispatches removes restored agent dispatches of rooms that were not restored.
+// rooms are all restored, the RoomManager starts their empty timeout again as participants do not survive a restart.
+func (s *LocalStore) dropOrphanedAgentDispatches() {
+	for roomName := range s.agentDispatches {
+		if s.rooms[roomName] == nil {
+			delete(s.agentDispatches, roomName)
+		}
+	}
+}
+
+func newStoreRoomRecord(room *livekit.Room, internal *livekit.RoomInternal, preset string) (*localStoreRecord, error) {
+	data, err := proto.Marshal(room)
+	if err != nil {
+		return nil, err
+	}
+	rec := &localStoreRecord{Op: localStoreOpStoreRoom, Room: room.Name, Preset: preset, Data: data}
+	if internal != nil {
+		rec.HasInternal = true
+		if rec.Internal, err = proto.Marshal(internal); err != nil {
+			return nil, err
+		}
+	}
+	return rec, nil
+}
+
+func newStoreDispatchRecord(dispatch *livekit.AgentDispatch) (*localStoreRecord, error) {
+	data, err := proto.Marshal(dispatch)
+	if err != nil {
+		return nil, err
+	}
+	return &localStoreRecord{Op: localStoreOpStoreDispatch, Room: dispatch.Room, ID: dispatch.Id, Data: data}, nil
+}
+
+func newStoreWebhookRecord(delivery *WebhookDelivery) (*localStoreRecord, error) {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return nil, err
+	}
+	return &localStoreRecord{Op: localStoreOpStoreWebhook, ID: delivery.ID, Data: data}, nil
+}
+
+func newStoreRoomScheduleRecord(schedule *RoomSchedule) (*localStoreRecord, error) {
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return nil, err
+	}
+	return &localStoreRecord{Op: localStoreOpStoreRoomSchedule, Room: schedule.Room, Data: data}, nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,394 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/twitchtv/twirp"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils/guid"
+	"github.com/livekit/psrpc"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	mimeTypeSDP            = "application/sdp"
+	mimeTypeTrickleICEFrag = "application/trickle-ice-sdpfrag"
+
+	// offers are small, anything larger is not a WHIP/WHEP client
+	maxOneShotBodySize = 64 * 1024
+)
+
+type oneShotSession struct {
+	roomName livekit.RoomName
+	// identity of the token that created the session, required to manage it
+	owner         livekit.ParticipantIdentity
+	requestSource *routing.MessageChannel
+}
+
+// oneShotSessions runs HTTP signalled sessions (WHIP/WHEP) on this node.
+// the SDP offer is answered synchronously by the participant's single peer connection,
+// trickled candidates and teardown are relayed to the participant through its request source.
+type oneShotSessions struct {
+	config        *config.Config
+	router        routing.Router
+	roomAllocator RoomAllocator
+	roomManager   *RoomManager
+	currentNode   routing.LocalNode
+
+	mu       sync.Mutex
+	sessions map[livekit.ParticipantID]*oneShotSession
+}
+
+func newOneShotSessions(
+	conf *config.Config,
+	router routing.Router,
+	ra RoomAllocator,
+	roomManager *RoomManager,
+	currentNode routing.LocalNode,
+) *oneShotSessions {
+	return &oneShotSessions{
+		config:        conf,
+		router:        router,
+		roomAllocator: ra,
+		roomManager:   roomManager,
+		currentNode:   currentNode,
+		sessions:      make(map[livekit.ParticipantID]*oneShotSession),
+	}
+}
+
+// validate checks the join token for roomName, callers adjust the returned grants before building the ParticipantInit
+func (o *oneShotSessions) validate(r *http.Request, roomName livekit.RoomName) (*auth.ClaimGrants, int, error) {
+	claims := GetGrants(r.Context())
+	if claims == nil || claims.Video == nil {
+		return nil, http.StatusUnauthorized, rtc.ErrPermissionDenied
+	}
+
+	onlyName, err := EnsureJoinPermission(r.Context())
+	if err != nil {
+		return nil, http.StatusUnauthorized, err
+	}
+	if onlyName != "" && onlyName != roomName {
+		return nil, http.StatusUnauthorized, rtc.ErrPermissionDenied
+	}
+
+	if claims.Identity == "" {
+		return nil, http.StatusBadRequest, ErrIdentityEmpty
+	}
+	if limit := o.config.GetLimit().MaxParticipantIdentityLength; limit > 0 && len(claims.Identity) > limit {
+		return nil, http.StatusBadRequest, fmt.Errorf("%w: max length %d", ErrParticipantIdentityExceedsLimits, limit)
+	}
+	if limit := o.config.GetLimit().MaxRoomNameLength; limit > 0 && len(roomName) > limit {
+		return nil, http.StatusBadRequest, fmt.Errorf("%w: max length %d", ErrRoomNameExceedsLimits, limit)
+	}
+
+	if err = o.roomAllocator.ValidateCreateRoom(r.Context(), roomName); err != nil {
+		if errors.Is(err, ErrRoomNotFound) {
+			return nil, http.StatusNotFound, err
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+	return claims, http.StatusOK, nil
+}
+
+func (o *oneShotSessions) participantInit(
+	r *http.Request,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	claims *auth.ClaimGrants,
+) routing.ParticipantInit {
+	createRequest := &livekit.CreateRoomRequest{
+		Name:       string(roomName),
+		RoomPreset: claims.RoomPreset,
+	}
+	SetRoomConfiguration(createRequest, claims.GetRoomConfiguration())
+
+	return routing.ParticipantInit{
+		Identity: identity,
+		Name:     livekit.ParticipantName(claims.Name),
+		Client: &livekit.ClientInfo{
+			Protocol: types.CurrentProtocol,
+			Address:  GetClientIP(r),
+		},
+		Grants:     claims,
+		Region:     o.router.GetRegion(),
+		CreateRoom: createRequest,
+	}
+}
+
+// start starts a one-shot signalling session on this node, the room has to be hosted here
+// as the answer is produced synchronously by the participant's peer connection.
+func (o *oneShotSessions) start(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	pi routing.ParticipantInit,
+) (*rtc.Room, types.LocalParticipant, *routing.MessageChannel, error) {
+	if err := o.roomAllocator.SelectRoomNode(ctx, roomName, o.currentNode.NodeID()); err != nil {
+		return nil, nil, nil, err
+	}
+	node, err := o.router.GetNodeForRoom(ctx, roomName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if livekit.NodeID(node.Id) != o.currentNode.NodeID() {
+		return nil, nil, nil, ErrRoomOnRemoteNode
+	}
+
+	connID := livekit.ConnectionID(guid.New("CO_"))
+	requestSource := routing.NewDefaultMessageChannel(connID)
+	// the session worker closes the request source once the participant is gone
+	requestSource.OnClose(func() {
+		o.remove(requestSource)
+	})
+	// the session outlives the HTTP request, only the API key is kept for quotas
+	sessionCtx := WithAPIKey(context.Background(), pi.Grants, GetAPIKey(ctx))
+	err = o.roomManager.StartSession(sessionCtx, pi, requestSource, routing.NewNullMessageSink(connID), true)
+	if err != nil {
+		requestSource.Close()
+		return nil, nil, nil, err
+	}
+
+	room := o.roomManager.GetRoom(ctx, roomName)
+	if room == nil {
+		requestSource.Close()
+		return nil, nil, nil, ErrRoomNotFound
+	}
+	participant := room.GetParticipant(pi.Identity)
+	if participant == nil || room.GetParticipantRequestSource(pi.Identity) != requestSource {
+		requestSource.Close()
+		return nil, nil, nil, ErrParticipantNotFound
+	}
+	return room, participant, requestSource, nil
+}
+
+// negotiate answers the remote offer and registers the session so it can be addressed by its resource URL
+func (o *oneShotSessions) negotiate(
+	participant types.LocalParticipant,
+	requestSource *routing.MessageChannel,
+	owner livekit.ParticipantIdentity,
+	roomName livekit.RoomName,
+	offer string,
+) (webrtc.SessionDescription, error) {
+	err := participant.HandleOffer(webrtc.SessionDescription{
+		Type: webrtc.SDPTypeOffer,
+		SDP:  offer,
+	})
+	if err != nil {
+		return webrtc.SessionDescription{}, err
+	}
+	answer, err := participant.GetAnswer()
+	if err != nil {
+		return webrtc.SessionDescription{}, err
+	}
+
+	o.mu.Lock()
+	o.sessions[participant.ID()] = &oneShotSession{
+		roomName:      roomName,
+		owner:         owner,
+		requestSource: requestSource,
+	}
+	o.mu.Unlock()
+	if requestSource.IsClosed() {
+		// participant went away during negotiation
+		o.remove(requestSource)
+	}
+	return answer, nil
+}
+
+func (o *oneShotSessions) remove(requestSource *routing.MessageChannel) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for pID, session := range o.sessions {
+		if session.requestSource == requestSource {
+			delete(o.sessions, pID)
+		}
+	}
+}
+
+// get returns the session addressed by the resource URL, the token has to belong to the session owner or a room admin
+func (o *oneShotSessions) get(r *http.Request) (*oneShotSession, int, error) {
+	claims := GetGrants(r.Context())
+	if claims == nil || claims.Video == nil {
+		return nil, http.StatusUnauthorized, rtc.ErrPermissionDenied
+	}
+
+	o.mu.Lock()
+	session := o.sessions[livekit.ParticipantID(r.PathValue("participant"))]
+	o.mu.Unlock()
+	if session == nil || session.roomName != livekit.RoomName(r.PathValue("room")) {
+		return nil, http.StatusNotFound, ErrOneShotSessionNotFound
+	}
+	if !canManageOneShotSession(claims, session) {
+		return nil, http.StatusUnauthorized, rtc.ErrPermissionDenied
+	}
+	return session, http.StatusOK, nil
+}
+
+func (o *oneShotSessions) handleTrickle(w http.ResponseWriter, r *http.Request) {
+	if !hasContentType(r, mimeTypeTrickleICEFrag) {
+		handleError(w, r, http.StatusUnsupportedMediaType, fmt.Errorf("content type must be %s", mimeTypeTrickleICEFrag))
+		return
+	}
+
+	session, code, err := o.get(r)
+	if err != nil {
+		handleError(w, r, code, err)
+		return
+	}
+
+	frag, ok := readOneShotBody(w, r)
+	if !ok {
+		return
+	}
+
+	for _, candidate := range parseTrickleICEFragment(string(frag)) {
+		err = session.requestSource.WriteMessage(&livekit.SignalRequest{
+			Message: &livekit.SignalRequest_Trickle{
+				Trickle: rtc.ToProtoTrickle(candidate, livekit.SignalTarget_PUBLISHER, false),
+			},
+		})
+		if err != nil {
+			handleError(w, r, http.StatusNotFound, ErrOneShotSessionNotFound)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (o *oneShotSessions) handleDelete(w http.ResponseWriter, r *http.Request) {
+	session, code, err := o.get(r)
+	if err != nil {
+		handleError(w, r, code, err)
+		return
+	}
+
+	err = session.requestSource.WriteMessage(&livekit.SignalRequest{
+		Message: &livekit.SignalRequest_Leave{
+			Leave: &livekit.LeaveRequest{
+				Reason: livekit.DisconnectReason_CLIENT_INITIATED,
+			},
+		},
+	})
+	if err != nil {
+		handleError(w, r, http.StatusNotFound, ErrOneShotSessionNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func canManageOneShotSession(claims *auth.ClaimGrants, session *oneShotSession) bool {
+	if claims.Video.Room != "" && livekit.RoomName(claims.Video.Room) != session.roomName {
+		return false
+	}
+	return claims.Video.RoomAdmin || livekit.ParticipantIdentity(claims.Identity) == session.owner
+}
+
+func readSDPOffer(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if !hasContentType(r, mimeTypeSDP) {
+		handleError(w, r, http.StatusUnsupportedMediaType, fmt.Errorf("content type must be %s", mimeTypeSDP))
+		return "", false
+	}
+	offer, ok := readOneShotBody(w, r)
+	if !ok {
+		return "", false
+	}
+	return string(offer), true
+}
+
+// readOneShotBody reads a request body of at most maxOneShotBodySize bytes, responding with 413 when it is larger.
+func readOneShotBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxOneShotBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			handleError(w, r, http.StatusRequestEntityTooLarge, err)
+		} else {
+			handleError(w, r, http.StatusBadRequest, err)
+		}
+		return nil, false
+	}
+	return body, true
+}
+
+func writeSDPAnswer(w http.ResponseWriter, location string, answer webrtc.SessionDescription) {
+	w.Header().Set("Content-Type", mimeTypeSDP)
+	w.Header().Set("Location", location)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write([]byte(answer.SDP))
+}
+
+func statusForError(err error) int {
+	var psrpcErr psrpc.Error
+	if errors.As(err, &psrpcErr) {
+		return psrpcErr.ToHttp()
+	}
+	var twErr twirp.Error
+	if errors.As(err, &twErr) {
+		return twirp.ServerHTTPStatusFromErrorCode(twErr.Code())
+	}
+	return http.StatusInternalServerError
+}
+
+// parseTrickleICEFragment extracts candidates from an application/trickle-ice-sdpfrag body (RFC 8840)
+func parseTrickleICEFragment(frag string) []webrtc.ICECandidateInit {
+	var (
+		candidates []webrtc.ICECandidateInit
+		mid        *string
+		mLineIndex *uint16
+		mLines     uint16
+	)
+	for _, line := range strings.Split(frag, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "m="):
+			idx := mLines
+			mLineIndex = &idx
+			mLines++
+			mid = nil
+
+		case strings.HasPrefix(line, "a=mid:"):
+			m := strings.TrimPrefix(line, "a=mid:")
+			mid = &m
+
+		case strings.HasPrefix(line, "a=candidate:"):
+			candidates = append(candidates, webrtc.ICECandidateInit{
+				Candidate:     strings.TrimPrefix(line, "a="),
+				SDPMid:        mid,
+				SDPMLineIndex: mLineIndex,
+			})
+		}
+	}
+	return candidates
+}
+
+func hasContentType(r *http.Request, expected string) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == expected
+}
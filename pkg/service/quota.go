@@ -0,0 +1,223 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/frostbyte73/core"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/psrpc/pkg/metadata"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	// psrpc metadata carrying the API key a request was authenticated with to the node handling it
+	apiKeyMetadataKey = "lkApiKey"
+
+	quotaLeaseDuration = time.Minute
+	quotaRenewInterval = quotaLeaseDuration / 3
+)
+
+// APIKeyQuotas limits the concurrent rooms and participants of each API key.
+// Rooms and participants are counted by the node hosting them, which holds a lease on each one in the QuotaStore.
+// With redis the counts are shared by all nodes, leases of a node that went away expire on their own.
+//
+// Quotas fail open, when the store cannot be reached requests are let through.
+type APIKeyQuotas struct {
+	conf  *config.Config
+	store QuotaStore
+
+	// keeps a renewal from adding back a lease released while it runs
+	renewLock sync.Mutex
+
+	lock sync.Mutex
+	// apiKey of each room and participant hosted by this node, that a lease is held for
+	rooms        map[livekit.RoomName]string
+	participants map[livekit.ParticipantID]string
+	stopped      core.Fuse
+}
+
+func NewAPIKeyQuotas(conf *config.Config, store QuotaStore) *APIKeyQuotas {
+	q := &APIKeyQuotas{
+		conf:         conf,
+		store:        store,
+		rooms:        make(map[livekit.RoomName]string),
+		participants: make(map[livekit.ParticipantID]string),
+	}
+	go q.renewWorker()
+	return q
+}
+
+func (q *APIKeyQuotas) Stop() {
+	q.stopped.Break()
+}
+
+// CheckJoin verifies a participant could join a room, before the session is started on the node hosting it
+func (q *APIKeyQuotas) CheckJoin(ctx context.Context, apiKey string, roomName livekit.RoomName) error {
+	if apiKey == "" {
+		return nil
+	}
+	limits := q.conf.GetLimit()
+	if limits.MaxRoomsPerAPIKey > 0 {
+		if ok, err := q.store.CheckQuota(ctx, roomQuotaSet(apiKey), string(roomName), limits.MaxRoomsPerAPIKey); err != nil {
+			logger.Warnw("could not check room quota", err, "apiKey", apiKey)
+		} else if !ok {
+			return ErrRoomQuotaExceeded
+		}
+	}
+	if limits.MaxParticipantsPerAPIKey > 0 {
+		if ok, err := q.store.CheckQuota(ctx, participantQuotaSet(apiKey), "", limits.MaxParticipantsPerAPIKey); err != nil {
+			logger.Warnw("could not check participant quota", err, "apiKey", apiKey)
+		} else if !ok {
+			return ErrParticipantQuotaExceeded
+		}
+	}
+	return nil
+}
+
+// AcquireRoom counts a room this node is about to host against the quota of the API key creating it
+func (q *APIKeyQuotas) AcquireRoom(ctx context.Context, apiKey string, roomName livekit.RoomName) error {
+	limit := q.conf.GetLimit().MaxRoomsPerAPIKey
+	if apiKey == "" || limit <= 0 {
+		return nil
+	}
+	ok, err := q.store.AcquireQuota(ctx, roomQuotaSet(apiKey), string(roomName), limit, time.Now().Add(quotaLeaseDuration))
+	if err != nil {
+		logger.Warnw("could not acquire room quota", err, "apiKey", apiKey, "room", roomName)
+		return nil
+	}
+	if !ok {
+		return ErrRoomQuotaExceeded
+	}
+
+	q.lock.Lock()
+	q.rooms[roomName] = apiKey
+	q.lock.Unlock()
+	return nil
+}
+
+func (q *APIKeyQuotas) ReleaseRoom(ctx context.Context, roomName livekit.RoomName) {
+	q.lock.Lock()
+	apiKey, ok := q.rooms[roomName]
+	delete(q.rooms, roomName)
+	q.lock.Unlock()
+
+	if ok {
+		q.renewLock.Lock()
+		defer q.renewLock.Unlock()
+		if err := q.store.ReleaseQuota(ctx, roomQuotaSet(apiKey), string(roomName)); err != nil {
+			logger.Warnw("could not release room quota", err, "apiKey", apiKey, "room", roomName)
+		}
+	}
+}
+
+// AcquireParticipant counts a participant joining on this node against the quota of the API key that signed its token
+func (q *APIKeyQuotas) AcquireParticipant(ctx context.Context, apiKey string, pID livekit.ParticipantID) error {
+	limit := q.conf.GetLimit().MaxParticipantsPerAPIKey
+	if apiKey == "" || limit <= 0 {
+		return nil
+	}
+	ok, err := q.store.AcquireQuota(ctx, participantQuotaSet(apiKey), string(pID), limit, time.Now().Add(quotaLeaseDuration))
+	if err != nil {
+		logger.Warnw("could not acquire participant quota", err, "apiKey", apiKey, "pID", pID)
+		return nil
+	}
+	if !ok {
+		return ErrParticipantQuotaExceeded
+	}
+
+	q.lock.Lock()
+	q.participants[pID] = apiKey
+	q.lock.Unlock()
+	return nil
+}
+
+func (q *APIKeyQuotas) ReleaseParticipant(ctx context.Context, pID livekit.ParticipantID) {
+	q.lock.Lock()
+	apiKey, ok := q.participants[pID]
+	delete(q.participants, pID)
+	q.lock.Unlock()
+
+	if ok {
+		q.renewLock.Lock()
+		defer q.renewLock.Unlock()
+		if err := q.store.ReleaseQuota(ctx, participantQuotaSet(apiKey), string(pID)); err != nil {
+			logger.Warnw("could not release participant quota", err, "apiKey", apiKey, "pID", pID)
+		}
+	}
+}
+
+func (q *APIKeyQuotas) renewWorker() {
+	ticker := time.NewTicker(quotaRenewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.stopped.Watch():
+			return
+		case <-ticker.C:
+			q.renew()
+		}
+	}
+}
+
+func (q *APIKeyQuotas) renew() {
+	q.renewLock.Lock()
+	defer q.renewLock.Unlock()
+
+	sets := make(map[string][]string)
+	q.lock.Lock()
+	for roomName, apiKey := range q.rooms {
+		set := roomQuotaSet(apiKey)
+		sets[set] = append(sets[set], string(roomName))
+	}
+	for pID, apiKey := range q.participants {
+		set := participantQuotaSet(apiKey)
+		sets[set] = append(sets[set], string(pID))
+	}
+	q.lock.Unlock()
+
+	expiresAt := time.Now().Add(quotaLeaseDuration)
+	for set, members := range sets {
+		if err := q.store.RenewQuota(context.Background(), set, members, expiresAt); err != nil {
+			logger.Warnw("could not renew quota leases", err, "set", set)
+		}
+	}
+}
+
+func roomQuotaSet(apiKey string) string {
+	return "rooms:" + apiKey
+}
+
+func participantQuotaSet(apiKey string) string {
+	return "participants:" + apiKey
+}
+
+// quotaAPIKey returns the API key a request was authenticated with, also when it was forwarded by another node
+func quotaAPIKey(ctx context.Context) string {
+	if apiKey := GetAPIKey(ctx); apiKey != "" {
+		return apiKey
+	}
+	if head := metadata.IncomingHeader(ctx); head != nil {
+		return head.Metadata[apiKeyMetadataKey]
+	}
+	return ""
+}
@@ -0,0 +1,188 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	rateLimitJoin    = "join"
+	rateLimitDefault = "*"
+
+	rateLimitScopeAPIKey   = "api_key"
+	rateLimitScopeClientIP = "client_ip"
+
+	twirpPathPrefix = "/twirp/"
+
+	// how often buckets that have refilled completely are dropped
+	rateLimitPruneInterval = time.Minute
+)
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type rateLimitRule struct {
+	name  string
+	rate  float64
+	burst float64
+}
+
+type rateLimitBucketKey struct {
+	scope string
+	id    string
+	rule  string
+}
+
+// RateLimiter throttles requests with token buckets for each API key and client IP.
+// Buckets are local to the node, so the effective limit across a cluster scales with the number of nodes.
+type RateLimiter struct {
+	rules map[string]map[string]*rateLimitRule
+
+	lock      sync.Mutex
+	buckets   map[rateLimitBucketKey]*tokenBucket
+	lastPrune time.Time
+}
+
+func NewRateLimiter(conf *config.Config) (*RateLimiter, error) {
+	if !conf.RateLimit.IsEnabled() {
+		return nil, nil
+	}
+
+	l := &RateLimiter{
+		rules:     make(map[string]map[string]*rateLimitRule),
+		buckets:   make(map[rateLimitBucketKey]*tokenBucket),
+		lastPrune: time.Now(),
+	}
+	for scope, limits := range map[string]map[string]config.RateLimit{
+		rateLimitScopeAPIKey:   conf.RateLimit.APIKey,
+		rateLimitScopeClientIP: conf.RateLimit.ClientIP,
+	} {
+		rules := make(map[string]*rateLimitRule, len(limits))
+		for name, limit := range limits {
+			if limit.Rate <= 0 {
+				return nil, fmt.Errorf("rate_limit.%s.%s: rate must be positive", scope, name)
+			}
+			burst := float64(limit.Burst)
+			if burst <= 0 {
+				burst = math.Ceil(limit.Rate)
+			}
+			rules[name] = &rateLimitRule{name: name, rate: limit.Rate, burst: burst}
+		}
+		l.rules[scope] = rules
+	}
+	return l, nil
+}
+
+// AllowAPIKey takes a token for an authenticated request, when denied it returns how long to wait for the next one
+func (l *RateLimiter) AllowAPIKey(apiKey, method string) (bool, time.Duration) {
+	return l.allow(rateLimitScopeAPIKey, apiKey, method)
+}
+
+// AllowClientIP takes a token for a request from a client IP, before it is authenticated
+func (l *RateLimiter) AllowClientIP(ip, method string) (bool, time.Duration) {
+	return l.allow(rateLimitScopeClientIP, ip, method)
+}
+
+func (l *RateLimiter) allow(scope, id, method string) (bool, time.Duration) {
+	if l == nil || method == "" {
+		return true, 0
+	}
+	rule := l.ruleFor(scope, method)
+	if rule == nil {
+		return true, 0
+	}
+
+	now := time.Now()
+	key := rateLimitBucketKey{scope: scope, id: id, rule: rule.name}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if now.Sub(l.lastPrune) > rateLimitPruneInterval {
+		l.pruneLocked(now)
+	}
+
+	b := l.buckets[key]
+	if b == nil {
+		b = &tokenBucket{tokens: rule.burst, last: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(rule.burst, b.tokens+now.Sub(b.last).Seconds()*rule.rate)
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rule.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// ruleFor picks the most specific rule configured for a method
+func (l *RateLimiter) ruleFor(scope, method string) *rateLimitRule {
+	rules := l.rules[scope]
+	if r := rules[method]; r != nil {
+		return r
+	}
+	if service, _, ok := strings.Cut(method, "/"); ok {
+		if r := rules[service+"/*"]; r != nil {
+			return r
+		}
+	}
+	return rules[rateLimitDefault]
+}
+
+// pruneLocked drops buckets that are full again, they are recreated as full when needed
+func (l *RateLimiter) pruneLocked(now time.Time) {
+	l.lastPrune = now
+	for key, b := range l.buckets {
+		rule := l.rules[key.scope][key.rule]
+		if b.tokens+now.Sub(b.last).Seconds()*rule.rate >= rule.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimitMethod names the rate limit a request falls under, or returns "" when it is not limited
+func rateLimitMethod(r *http.Request) string {
+	if r.URL == nil {
+		return ""
+	}
+	path := r.URL.Path
+	switch {
+	case strings.HasPrefix(path, twirpPathPrefix):
+		// /twirp/livekit.RoomService/CreateRoom
+		method := strings.TrimPrefix(path, twirpPathPrefix)
+		if i := strings.Index(method, "."); i >= 0 {
+			method = method[i+1:]
+		}
+		return method
+	case path == "/rtc":
+		return rateLimitJoin
+	case r.Method == http.MethodPost && (strings.HasPrefix(path, whipPathPrefix) || strings.HasPrefix(path, whepPathPrefix)):
+		return rateLimitJoin
+	}
+	return ""
+}
@@ -16,6 +16,7 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -60,20 +61,68 @@ const (
 	// RoomLockPrefix is a simple key containing a provided lock uid
 	RoomLockPrefix = "room_lock:"
 
+	// RoomSchedulePrefix is a simple key containing the room's schedule as JSON
+	RoomSchedulePrefix = "room_schedule:"
+
 	// Agents
 	AgentDispatchPrefix = "agent_dispatch:"
 	AgentJobPrefix      = "agent_job:"
 
+	// WebhookDeliveriesKey is a hash of deliveryID => pending webhook delivery
+	WebhookDeliveriesKey  = "webhook_deliveries"
+	WebhookDeadLettersKey = "webhook_dead_letters"
+
+	// QuotaPrefix is a hash of member => lease expiry in unix ms, for each quota set
+	QuotaPrefix = "quota:"
+
 	maxRetries = 5
 )
 
 type RedisStore struct {
-	rc           redis.UniversalClient
-	unlockScript *redis.Script
-	ctx          context.Context
-	done         chan struct{}
+	rc                 redis.UniversalClient
+	unlockScript       *redis.Script
+	quotaScript        *redis.Script
+	webhookClaimScript *redis.Script
+	ctx                context.Context
+	done               chan struct{}
 }
 
+// drops expired members, then adds or renews ARGV[1] when ARGV[5] is set, unless that would exceed the limit.
+// ARGV: member, now, expiresAt, limit, acquire
+const quotaScript = `local now = tonumber(ARGV[2])
+local count = 0
+local held = false
+local entries = redis.call("hgetall", KEYS[1])
+for i = 1, #entries, 2 do
+	if tonumber(entries[i+1]) <= now then
+		redis.call("hdel", KEYS[1], entries[i])
+	elseif entries[i] == ARGV[1] then
+		held = true
+	else
+		count = count + 1
+	end
+end
+if not held and count >= tonumber(ARGV[4]) then
+	return 0
+end
+if ARGV[5] == "1" then
+	redis.call("hset", KEYS[1], ARGV[1], ARGV[3])
+end
+return 1`
+
+// stores ARGV[4] as pending delivery ARGV[1] when it is still pending, and not leased to another node than ARGV[2].
+// ARGV: id, nodeID, now, delivery
+const webhookClaimScript = `local data = redis.call("hget", KEYS[1], ARGV[1])
+if not data then
+	return 0
+end
+local delivery = cjson.decode(data)
+if delivery.node_id ~= ARGV[2] and (tonumber(delivery.lease_expires_at) or 0) > tonumber(ARGV[3]) then
+	return 0
+end
+redis.call("hset", KEYS[1], ARGV[1], ARGV[4])
+return 1`
+
 func NewRedisStore(rc redis.UniversalClient) *RedisStore {
 	unlockScript := `if redis.call("get", KEYS[1]) == ARGV[1] then
 						return redis.call("del", KEYS[1])
@@ -81,9 +130,11 @@ func NewRedisStore(rc redis.UniversalClient) *RedisStore {
 					 end`
 
 	return &RedisStore{
-		ctx:          context.Background(),
-		rc:           rc,
-		unlockScript: redis.NewScript(unlockScript),
+		ctx:                context.Background(),
+		rc:                 rc,
+		unlockScript:       redis.NewScript(unlockScript),
+		quotaScript:        redis.NewScript(quotaScript),
+		webhookClaimScript: redis.NewScript(webhookClaimScript),
 	}
 }
 
@@ -337,6 +388,40 @@ func (s *RedisStore) DeleteParticipant(_ context.Context, roomName livekit.RoomN
 	return s.rc.HDel(s.ctx, key, string(identity)).Err()
 }
 
+func (s *RedisStore) StoreRoomSchedule(_ context.Context, schedule *RoomSchedule) error {
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return err
+	}
+
+	var ttl time.Duration
+	if until := schedule.retainedUntil(); !until.IsZero() {
+		if ttl = time.Until(until); ttl <= 0 {
+			return s.rc.Del(s.ctx, RoomSchedulePrefix+schedule.Room).Err()
+		}
+	}
+	return s.rc.Set(s.ctx, RoomSchedulePrefix+schedule.Room, data, ttl).Err()
+}
+
+func (s *RedisStore) LoadRoomSchedule(_ context.Context, roomName livekit.RoomName) (*RoomSchedule, error) {
+	data, err := s.rc.Get(s.ctx, RoomSchedulePrefix+string(roomName)).Result()
+	if err == redis.Nil {
+		return nil, ErrRoomScheduleNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	schedule := &RoomSchedule{}
+	if err = json.Unmarshal([]byte(data), schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *RedisStore) DeleteRoomSchedule(_ context.Context, roomName livekit.RoomName) error {
+	return s.rc.Del(s.ctx, RoomSchedulePrefix+string(roomName)).Err()
+}
+
 func (s *RedisStore) StoreEgress(_ context.Context, info *livekit.EgressInfo) error {
 	data, err := proto.Marshal(info)
 	if err != nil {
@@ -923,6 +1008,125 @@ func (s *RedisStore) DeleteAgentJob(_ context.Context, job *livekit.Job) error {
 	return s.rc.HDel(s.ctx, key, job.Id).Err()
 }
 
+func (s *RedisStore) StoreWebhookDelivery(_ context.Context, delivery *WebhookDelivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+
+	key, otherKey := WebhookDeliveriesKey, WebhookDeadLettersKey
+	if delivery.DeadLetteredAt != 0 {
+		key, otherKey = otherKey, key
+	}
+	_, err = s.rc.TxPipelined(s.ctx, func(p redis.Pipeliner) error {
+		p.HSet(s.ctx, key, delivery.ID, data)
+		p.HDel(s.ctx, otherKey, delivery.ID)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) ClaimWebhookDelivery(_ context.Context, delivery *WebhookDelivery) (bool, error) {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := s.webhookClaimScript.Run(s.ctx, s.rc, []string{WebhookDeliveriesKey},
+		delivery.ID, delivery.NodeID, time.Now().UnixMilli(), data,
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (s *RedisStore) LoadWebhookDelivery(_ context.Context, id string) (*WebhookDelivery, error) {
+	for _, key := range []string{WebhookDeliveriesKey, WebhookDeadLettersKey} {
+		data, err := s.rc.HGet(s.ctx, key, id).Result()
+		if err == redis.Nil {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		delivery := &WebhookDelivery{}
+		if err = json.Unmarshal([]byte(data), delivery); err != nil {
+			return nil, err
+		}
+		return delivery, nil
+	}
+	return nil, ErrWebhookDeliveryNotFound
+}
+
+func (s *RedisStore) ListWebhookDeliveries(_ context.Context, deadLettered bool) ([]*WebhookDelivery, error) {
+	key := WebhookDeliveriesKey
+	if deadLettered {
+		key = WebhookDeadLettersKey
+	}
+	data, err := s.rc.HGetAll(s.ctx, key).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*WebhookDelivery, 0, len(data))
+	for _, d := range data {
+		delivery := &WebhookDelivery{}
+		if err = json.Unmarshal([]byte(d), delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+func (s *RedisStore) DeleteWebhookDelivery(_ context.Context, id string) error {
+	_, err := s.rc.TxPipelined(s.ctx, func(p redis.Pipeliner) error {
+		p.HDel(s.ctx, WebhookDeliveriesKey, id)
+		p.HDel(s.ctx, WebhookDeadLettersKey, id)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) AcquireQuota(_ context.Context, set, member string, limit int, expiresAt time.Time) (bool, error) {
+	return s.runQuotaScript(set, member, limit, expiresAt, true)
+}
+
+func (s *RedisStore) CheckQuota(_ context.Context, set, member string, limit int) (bool, error) {
+	return s.runQuotaScript(set, member, limit, time.Time{}, false)
+}
+
+func (s *RedisStore) runQuotaScript(set, member string, limit int, expiresAt time.Time, acquire bool) (bool, error) {
+	flag := "0"
+	if acquire {
+		flag = "1"
+	}
+	res, err := s.quotaScript.Run(s.ctx, s.rc, []string{QuotaPrefix + set},
+		member, time.Now().UnixMilli(), expiresAt.UnixMilli(), limit, flag,
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (s *RedisStore) RenewQuota(_ context.Context, set string, members []string, expiresAt time.Time) error {
+	if len(members) == 0 {
+		return nil
+	}
+	values := make([]interface{}, 0, 2*len(members))
+	for _, m := range members {
+		values = append(values, m, expiresAt.UnixMilli())
+	}
+	return s.rc.HSet(s.ctx, QuotaPrefix+set, values...).Err()
+}
+
+func (s *RedisStore) ReleaseQuota(_ context.Context, set, member string) error {
+	return s.rc.HDel(s.ctx, QuotaPrefix+set, member).Err()
+}
+
 func redisStoreOne(ctx context.Context, s *RedisStore, key, id string, p proto.Message) error {
 	if id == "" {
 		return errors.New("id is not set")
@@ -294,6 +294,14 @@ func TestIngressStore(t *testing.T) {
 	require.Equal(t, "", infos[0].RoomName)
 }
 
+func TestQuotaStore(t *testing.T) {
+	testQuotaStore(t, redisStore(t))
+}
+
+func TestWebhookClaim(t *testing.T) {
+	testWebhookClaim(t, redisStore(t))
+}
+
 func TestAgentStore(t *testing.T) {
 	ctx := context.Background()
 	rs := redisStore(t)
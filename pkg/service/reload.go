@@ -0,0 +1,102 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// ReloadableKeyProvider looks up secrets in the most recently loaded set of API keys
+type ReloadableKeyProvider struct {
+	provider atomic.Pointer[auth.KeyProvider]
+}
+
+func NewReloadableKeyProvider(provider auth.KeyProvider) *ReloadableKeyProvider {
+	p := &ReloadableKeyProvider{}
+	p.Swap(provider)
+	return p
+}
+
+func (p *ReloadableKeyProvider) Swap(provider auth.KeyProvider) {
+	p.provider.Store(&provider)
+}
+
+func (p *ReloadableKeyProvider) GetSecret(key string) string {
+	return (*p.provider.Load()).GetSecret(key)
+}
+
+func (p *ReloadableKeyProvider) NumKeys() int {
+	return (*p.provider.Load()).NumKeys()
+}
+
+// Reload applies the API keys, limits, named room configurations and webhook endpoints of next
+// to the running server. next is validated as a whole first, when any part of it is invalid
+// nothing is applied. Other settings that differ are logged, they take effect after a restart.
+func (s *LivekitServer) Reload(next *config.Config) error {
+	s.reloadLock.Lock()
+	defer s.reloadLock.Unlock()
+
+	if err := next.ValidateKeys(); err != nil {
+		return fmt.Errorf("invalid keys: %w", err)
+	}
+	keyProvider, err := newKeyProvider(next)
+	if err != nil {
+		return fmt.Errorf("invalid keys: %w", err)
+	}
+	var notifier *WebhookNotifier
+	var endpoints []*webhookEndpoint
+	if s.webhookService != nil {
+		notifier = s.webhookService.notifier
+	}
+	if notifier != nil {
+		if endpoints, err = newWebhookEndpoints(next.WebHook, keyProvider); err != nil {
+			return fmt.Errorf("invalid webhook config: %w", err)
+		}
+	} else if len(next.WebHook.URLs) != 0 || len(next.WebHook.Endpoints) != 0 {
+		return fmt.Errorf("invalid webhook config: %w", ErrWebhookNotEnabled)
+	}
+	diff, err := s.appliedConfig.Diff(next)
+	if err != nil {
+		return err
+	}
+
+	if err = s.config.Reload(next); err != nil {
+		return err
+	}
+	if s.keyProvider != nil {
+		s.keyProvider.Swap(keyProvider)
+	}
+	if notifier != nil {
+		notifier.setEndpoints(endpoints)
+	}
+	s.appliedConfig = next
+
+	if diff.IsEmpty() {
+		logger.Infow("config reloaded, no changes")
+		return nil
+	}
+	logger.Infow("config reloaded", "changes", diff.Reloadable)
+	if len(diff.RestartRequired) != 0 {
+		logger.Warnw("config changes ignored until restart", nil, "changes", diff.RestartRequired)
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+	"github.com/livekit/psrpc"
+	"github.com/livekit/psrpc/pkg/client"
+	"github.com/livekit/psrpc/pkg/info"
+	"github.com/livekit/psrpc/pkg/rand"
+	"github.com/livekit/psrpc/pkg/server"
+)
+
+// RoomAdmin carries the room admin requests livekit/protocol has no RPC for to the node hosting the room,
+// which serves them on the topic of the room like the Room service.
+// Requests and responses are livekit messages where one fits, JSON encoded in a BytesValue otherwise.
+const roomAdminServiceName = "RoomAdmin"
+
+const (
+	roomAdminListLobby         = "ListLobby"
+	roomAdminAdmitParticipant  = "AdmitParticipant"
+	roomAdminRejectParticipant = "RejectParticipant"
+	roomAdminGetStage          = "GetStage"
+	roomAdminPromoteToStage    = "PromoteParticipant"
+	roomAdminDemoteFromStage   = "DemoteParticipant"
+	roomAdminGetChatHistory    = "GetChatHistory"
+	roomAdminCreateTrackBridge = "CreateTrackBridge"
+	roomAdminListTrackBridges  = "ListTrackBridges"
+	roomAdminDeleteTrackBridge = "DeleteTrackBridge"
+)
+
+var roomAdminMethods = []string{
+	roomAdminListLobby,
+	roomAdminAdmitParticipant,
+	roomAdminRejectParticipant,
+	roomAdminGetStage,
+	roomAdminPromoteToStage,
+	roomAdminDemoteFromStage,
+	roomAdminGetChatHistory,
+	roomAdminCreateTrackBridge,
+	roomAdminListTrackBridges,
+	roomAdminDeleteTrackBridge,
+}
+
+type RoomAdminClient interface {
+	ListLobby(ctx context.Context, room rpc.RoomTopic, req *livekit.ListParticipantsRequest, opts ...psrpc.RequestOption) (*livekit.ListParticipantsResponse, error)
+	AdmitParticipant(ctx context.Context, room rpc.RoomTopic, req *livekit.RoomParticipantIdentity, opts ...psrpc.RequestOption) (*livekit.ParticipantInfo, error)
+	RejectParticipant(ctx context.Context, room rpc.RoomTopic, req *livekit.RoomParticipantIdentity, opts ...psrpc.RequestOption) (*livekit.RemoveParticipantResponse, error)
+	GetStage(ctx context.Context, room rpc.RoomTopic, req *StageRequest, opts ...psrpc.RequestOption) (*StageInfo, error)
+	PromoteParticipant(ctx context.Context, room rpc.RoomTopic, req *livekit.RoomParticipantIdentity, opts ...psrpc.RequestOption) (*livekit.ParticipantInfo, error)
+	DemoteParticipant(ctx context.Context, room rpc.RoomTopic, req *livekit.RoomParticipantIdentity, opts ...psrpc.RequestOption) (*livekit.ParticipantInfo, error)
+	GetChatHistory(ctx context.Context, room rpc.RoomTopic, req *ChatHistoryRequest, opts ...psrpc.RequestOption) (*ChatHistoryInfo, error)
+	CreateTrackBridge(ctx context.Context, room rpc.RoomTopic, req *CreateTrackBridgeRequest, opts ...psrpc.RequestOption) (*TrackBridgeInfo, error)
+	ListTrackBridges(ctx context.Context, room rpc.RoomTopic, req *ListTrackBridgesRequest, opts ...psrpc.RequestOption) (*ListTrackBridgesResponse, error)
+	DeleteTrackBridge(ctx context.Context, room rpc.RoomTopic, req *DeleteTrackBridgeRequest, opts ...psrpc.RequestOption) (*TrackBridgeInfo, error)
+
+	Close()
+}
+
+type roomAdminServerImpl interface {
+	ListLobby(context.Context, *livekit.ListParticipantsRequest) (*livekit.ListParticipantsResponse, error)
+	AdmitParticipant(context.Context, *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error)
+	RejectParticipant(context.Context, *livekit.RoomParticipantIdentity) (*livekit.RemoveParticipantResponse, error)
+	GetStage(context.Context, *StageRequest) (*StageInfo, error)
+	PromoteParticipant(context.Context, *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error)
+	DemoteParticipant(context.Context, *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error)
+	GetChatHistory(context.Context, *ChatHistoryRequest) (*ChatHistoryInfo, error)
+	CreateTrackBridge(context.Context, *CreateTrackBridgeRequest) (*TrackBridgeInfo, error)
+	ListTrackBridges(context.Context, *ListTrackBridgesRequest) (*ListTrackBridgesResponse, error)
+	DeleteTrackBridge(context.Context, *DeleteTrackBridgeRequest) (*TrackBridgeInfo, error)
+}
+
+func newRoomAdminServiceDefinition(id string) *info.ServiceDefinition {
+	sd := &info.ServiceDefinition{
+		Name: roomAdminServiceName,
+		ID:   id,
+	}
+	for _, method := range roomAdminMethods {
+		sd.RegisterMethod(method, false, false, true, true)
+	}
+	return sd
+}
+
+// ---------------------------------------------------------------
+
+type roomAdminClient struct {
+	client *client.RPCClient
+}
+
+func NewRoomAdminClient(params rpc.ClientParams) (RoomAdminClient, error) {
+	bus, opts := params.Args()
+	rpcClient, err := client.NewRPCClient(newRoomAdminServiceDefinition(rand.NewClientID()), bus, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &roomAdminClient{
+		client: rpcClient,
+	}, nil
+}
+
+func (c *roomAdminClient) ListLobby(ctx context.Context, room rpc.RoomTopic, req *livekit.ListParticipantsRequest, opts ...psrpc.RequestOption) (*livekit.ListParticipantsResponse, error) {
+	return client.RequestSingle[*livekit.ListParticipantsResponse](ctx, c.client, roomAdminListLobby, []string{string(room)}, req, opts...)
+}
+
+func (c *roomAdminClient) AdmitParticipant(ctx context.Context, room rpc.RoomTopic, req *livekit.RoomParticipantIdentity, opts ...psrpc.RequestOption) (*livekit.ParticipantInfo, error) {
+	return client.RequestSingle[*livekit.ParticipantInfo](ctx, c.client, roomAdminAdmitParticipant, []string{string(room)}, req, opts...)
+}
+
+func (c *roomAdminClient) RejectParticipant(ctx context.Context, room rpc.RoomTopic, req *livekit.RoomParticipantIdentity, opts ...psrpc.RequestOption) (*livekit.RemoveParticipantResponse, error) {
+	return client.RequestSingle[*livekit.RemoveParticipantResponse](ctx, c.client, roomAdminRejectParticipant, []string{string(room)}, req, opts...)
+}
+
+func (c *roomAdminClient) GetStage(ctx context.Context, room rpc.RoomTopic, req *StageRequest, opts ...psrpc.RequestOption) (*StageInfo, error) {
+	return requestRoomAdminJSON[StageInfo](ctx, c.client, roomAdminGetStage, room, req, opts...)
+}
+
+func (c *roomAdminClient) PromoteParticipant(ctx context.Context, room rpc.RoomTopic, req *livekit.RoomParticipantIdentity, opts ...psrpc.RequestOption) (*livekit.ParticipantInfo, error) {
+	return client.RequestSingle[*livekit.ParticipantInfo](ctx, c.client, roomAdminPromoteToStage, []string{string(room)}, req, opts...)
+}
+
+func (c *roomAdminClient) DemoteParticipant(ctx context.Context, room rpc.RoomTopic, req *livekit.RoomParticipantIdentity, opts ...psrpc.RequestOption) (*livekit.ParticipantInfo, error) {
+	return client.RequestSingle[*livekit.ParticipantInfo](ctx, c.client, roomAdminDemoteFromStage, []string{string(room)}, req, opts...)
+}
+
+func (c *roomAdminClient) GetChatHistory(ctx context.Context, room rpc.RoomTopic, req *ChatHistoryRequest, opts ...psrpc.RequestOption) (*ChatHistoryInfo, error) {
+	return requestRoomAdminJSON[ChatHistoryInfo](ctx, c.client, roomAdminGetChatHistory, room, req, opts...)
+}
+
+func (c *roomAdminClient) CreateTrackBridge(ctx context.Context, room rpc.RoomTopic, req *CreateTrackBridgeRequest, opts ...psrpc.RequestOption) (*TrackBridgeInfo, error) {
+	return requestRoomAdminJSON[TrackBridgeInfo](ctx, c.client, roomAdminCreateTrackBridge, room, req, opts...)
+}
+
+func (c *roomAdminClient) ListTrackBridges(ctx context.Context, room rpc.RoomTopic, req *ListTrackBridgesRequest, opts ...psrpc.RequestOption) (*ListTrackBridgesResponse, error) {
+	return requestRoomAdminJSON[ListTrackBridgesResponse](ctx, c.client, roomAdminListTrackBridges, room, req, opts...)
+}
+
+func (c *roomAdminClient) DeleteTrackBridge(ctx context.Context, room rpc.RoomTopic, req *DeleteTrackBridgeRequest, opts ...psrpc.RequestOption) (*TrackBridgeInfo, error) {
+	return requestRoomAdminJSON[TrackBridgeInfo](ctx, c.client, roomAdminDeleteTrackBridge, room, req, opts...)
+}
+
+func (c *roomAdminClient) Close() {
+	c.client.Close()
+}
+
+func requestRoomAdminJSON[ResponseType any](
+	ctx context.Context,
+	c *client.RPCClient,
+	method string,
+	room rpc.RoomTopic,
+	req any,
+	opts ...psrpc.RequestOption,
+) (*ResponseType, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, psrpc.NewError(psrpc.MalformedRequest, err)
+	}
+	res, err := client.RequestSingle[*wrapperspb.BytesValue](ctx, c, method, []string{string(room)}, wrapperspb.Bytes(b), opts...)
+	if err != nil {
+		return nil, err
+	}
+	var v ResponseType
+	if err := json.Unmarshal(res.Value, &v); err != nil {
+		return nil, psrpc.NewError(psrpc.MalformedResponse, err)
+	}
+	return &v, nil
+}
+
+// ---------------------------------------------------------------
+
+type roomAdminServer struct {
+	rpc         *server.RPCServer
+	registerers server.RegistererSlice
+}
+
+func newRoomAdminServer(svc roomAdminServerImpl, bus psrpc.MessageBus, opts ...psrpc.ServerOption) *roomAdminServer {
+	s := server.NewRPCServer(newRoomAdminServiceDefinition(rand.NewServerID()), bus, opts...)
+	return &roomAdminServer{
+		rpc: s,
+		registerers: server.RegistererSlice{
+			roomAdminRegisterer(s, roomAdminListLobby, svc.ListLobby),
+			roomAdminRegisterer(s, roomAdminAdmitParticipant, svc.AdmitParticipant),
+			roomAdminRegisterer(s, roomAdminRejectParticipant, svc.RejectParticipant),
+			roomAdminRegisterer(s, roomAdminGetStage, roomAdminJSONHandler(svc.GetStage)),
+			roomAdminRegisterer(s, roomAdminPromoteToStage, svc.PromoteParticipant),
+			roomAdminRegisterer(s, roomAdminDemoteFromStage, svc.DemoteParticipant),
+			roomAdminRegisterer(s, roomAdminGetChatHistory, roomAdminJSONHandler(svc.GetChatHistory)),
+			roomAdminRegisterer(s, roomAdminCreateTrackBridge, roomAdminJSONHandler(svc.CreateTrackBridge)),
+			roomAdminRegisterer(s, roomAdminListTrackBridges, roomAdminJSONHandler(svc.ListTrackBridges)),
+			roomAdminRegisterer(s, roomAdminDeleteTrackBridge, roomAdminJSONHandler(svc.DeleteTrackBridge)),
+		},
+	}
+}
+
+func roomAdminRegisterer[RequestType, ResponseType proto.Message](
+	s *server.RPCServer,
+	method string,
+	handler func(context.Context, RequestType) (ResponseType, error),
+) server.Registerer {
+	return server.NewRegisterer(
+		func(room rpc.RoomTopic) error {
+			return server.RegisterHandler(s, method, []string{string(room)}, handler, nil)
+		},
+		func(room rpc.RoomTopic) {
+			s.DeregisterHandler(method, []string{string(room)})
+		},
+	)
+}
+
+func roomAdminJSONHandler[RequestType, ResponseType any](
+	handler func(context.Context, *RequestType) (*ResponseType, error),
+) func(context.Context, *wrapperspb.BytesValue) (*wrapperspb.BytesValue, error) {
+	return func(ctx context.Context, req *wrapperspb.BytesValue) (*wrapperspb.BytesValue, error) {
+		var v RequestType
+		if err := json.Unmarshal(req.Value, &v); err != nil {
+			return nil, psrpc.NewError(psrpc.MalformedRequest, err)
+		}
+		res, err := handler(ctx, &v)
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(res)
+		if err != nil {
+			return nil, psrpc.NewError(psrpc.MalformedResponse, err)
+		}
+		return wrapperspb.Bytes(b), nil
+	}
+}
+
+func (s *roomAdminServer) RegisterAllRoomTopics(room rpc.RoomTopic) error {
+	return s.registerers.Register(room)
+}
+
+func (s *roomAdminServer) Kill() {
+	s.rpc.Close(true)
+}
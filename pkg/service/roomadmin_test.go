@@ -0,0 +1,131 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/rpc"
+	"github.com/livekit/psrpc"
+)
+
+type testRoomAdmin struct {
+	room livekit.RoomName
+}
+
+func (a *testRoomAdmin) ListLobby(_ context.Context, req *livekit.ListParticipantsRequest) (*livekit.ListParticipantsResponse, error) {
+	return &livekit.ListParticipantsResponse{
+		Participants: []*livekit.ParticipantInfo{{Identity: string(a.room) + "_waiting"}},
+	}, nil
+}
+
+func (a *testRoomAdmin) AdmitParticipant(_ context.Context, req *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	return &livekit.ParticipantInfo{Identity: req.Identity}, nil
+}
+
+func (a *testRoomAdmin) RejectParticipant(_ context.Context, req *livekit.RoomParticipantIdentity) (*livekit.RemoveParticipantResponse, error) {
+	return nil, ErrParticipantNotFound
+}
+
+func (a *testRoomAdmin) GetStage(_ context.Context, req *StageRequest) (*StageInfo, error) {
+	return &StageInfo{Room: req.Room, MaxPublishers: 2, OnStage: []string{"host"}}, nil
+}
+
+func (a *testRoomAdmin) PromoteParticipant(_ context.Context, req *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	return &livekit.ParticipantInfo{Identity: req.Identity, Permission: &livekit.ParticipantPermission{CanPublish: true}}, nil
+}
+
+func (a *testRoomAdmin) DemoteParticipant(_ context.Context, req *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	return &livekit.ParticipantInfo{Identity: req.Identity, Permission: &livekit.ParticipantPermission{}}, nil
+}
+
+func (a *testRoomAdmin) GetChatHistory(_ context.Context, req *ChatHistoryRequest) (*ChatHistoryInfo, error) {
+	return &ChatHistoryInfo{Room: req.Room, Messages: []ChatHistoryMessageInfo{{Topic: req.Topic, Message: "hi"}}}, nil
+}
+
+func (a *testRoomAdmin) CreateTrackBridge(_ context.Context, req *CreateTrackBridgeRequest) (*TrackBridgeInfo, error) {
+	return nil, ErrBridgeAcrossNodes
+}
+
+func (a *testRoomAdmin) ListTrackBridges(_ context.Context, req *ListTrackBridgesRequest) (*ListTrackBridgesResponse, error) {
+	return &ListTrackBridgesResponse{Bridges: []*TrackBridgeInfo{{BridgeID: "TB_1", Room: req.Room}}}, nil
+}
+
+func (a *testRoomAdmin) DeleteTrackBridge(_ context.Context, req *DeleteTrackBridgeRequest) (*TrackBridgeInfo, error) {
+	return &TrackBridgeInfo{BridgeID: req.BridgeID, Room: req.Room}, nil
+}
+
+func TestRoomAdmin(t *testing.T) {
+	bus := psrpc.NewLocalMessageBus()
+	c, err := NewRoomAdminClient(rpc.ClientParams{Bus: bus})
+	require.NoError(t, err)
+	defer c.Close()
+
+	// each room is served by its own server, as it would be by the node hosting it
+	for _, room := range []livekit.RoomName{"room1", "room2"} {
+		s := newRoomAdminServer(&testRoomAdmin{room: room}, bus)
+		defer s.Kill()
+		require.NoError(t, s.RegisterAllRoomTopics(rpc.FormatRoomTopic(room)))
+	}
+
+	ctx := context.Background()
+	res, err := c.ListLobby(ctx, rpc.FormatRoomTopic("room2"), &livekit.ListParticipantsRequest{Room: "room2"})
+	require.NoError(t, err)
+	require.Len(t, res.Participants, 1)
+	require.Equal(t, "room2_waiting", res.Participants[0].Identity)
+
+	pi, err := c.AdmitParticipant(ctx, rpc.FormatRoomTopic("room1"), &livekit.RoomParticipantIdentity{Room: "room1", Identity: "p1"})
+	require.NoError(t, err)
+	require.Equal(t, "p1", pi.Identity)
+
+	// JSON payloads
+	stage, err := c.GetStage(ctx, rpc.FormatRoomTopic("room2"), &StageRequest{Room: "room2"})
+	require.NoError(t, err)
+	require.Equal(t, &StageInfo{Room: "room2", MaxPublishers: 2, OnStage: []string{"host"}}, stage)
+
+	history, err := c.GetChatHistory(ctx, rpc.FormatRoomTopic("room1"), &ChatHistoryRequest{Room: "room1", Topic: "lk.chat"})
+	require.NoError(t, err)
+	require.Equal(t, []ChatHistoryMessageInfo{{Topic: "lk.chat", Message: "hi"}}, history.Messages)
+
+	pi, err = c.PromoteParticipant(ctx, rpc.FormatRoomTopic("room1"), &livekit.RoomParticipantIdentity{Room: "room1", Identity: "p1"})
+	require.NoError(t, err)
+	require.True(t, pi.Permission.CanPublish)
+
+	// errors keep their code across nodes
+	_, err = c.RejectParticipant(ctx, rpc.FormatRoomTopic("room1"), &livekit.RoomParticipantIdentity{Room: "room1", Identity: "p1"})
+	var psrpcErr psrpc.Error
+	require.True(t, errors.As(err, &psrpcErr))
+	require.Equal(t, psrpc.NotFound, psrpcErr.Code())
+
+	bridges, err := c.ListTrackBridges(ctx, rpc.FormatRoomTopic("room2"), &ListTrackBridgesRequest{Room: "room2"})
+	require.NoError(t, err)
+	require.Equal(t, []*TrackBridgeInfo{{BridgeID: "TB_1", Room: "room2"}}, bridges.Bridges)
+
+	_, err = c.CreateTrackBridge(ctx, rpc.FormatRoomTopic("room1"), &CreateTrackBridgeRequest{Room: "room1", DestinationRoom: "room2"})
+	require.True(t, errors.As(err, &psrpcErr))
+	require.Equal(t, psrpc.FailedPrecondition, psrpcErr.Code())
+	require.Equal(t, http.StatusPreconditionFailed, statusForError(err))
+
+	// nobody serves rooms that are not hosted
+	_, err = c.ListLobby(ctx, rpc.FormatRoomTopic("room3"), &livekit.ListParticipantsRequest{Room: "room3"}, psrpc.WithRequestTimeout(100*time.Millisecond))
+	require.Error(t, err)
+}
@@ -35,9 +35,10 @@ type StandardRoomAllocator struct {
 	router    routing.Router
 	selector  selector.NodeSelector
 	roomStore ObjectStore
+	quotas    *APIKeyQuotas
 }
 
-func NewRoomAllocator(conf *config.Config, router routing.Router, rs ObjectStore) (RoomAllocator, error) {
+func NewRoomAllocator(conf *config.Config, router routing.Router, rs ObjectStore, quotas *APIKeyQuotas) (RoomAllocator, error) {
 	ns, err := selector.CreateNodeSelector(conf)
 	if err != nil {
 		return nil, err
@@ -48,6 +49,7 @@ func NewRoomAllocator(conf *config.Config, router routing.Router, rs ObjectStore
 		router:    router,
 		selector:  ns,
 		roomStore: rs,
+		quotas:    quotas,
 	}, nil
 }
 
@@ -122,7 +124,14 @@ func (r *StandardRoomAllocator) CreateRoom(ctx context.Context, req *livekit.Cre
 		internal.SyncStreams = true
 	}
 
+	// counted by the node hosting the room, acquiring again only renews the lease
+	roomName := livekit.RoomName(req.Name)
+	if err = r.quotas.AcquireRoom(ctx, quotaAPIKey(ctx), roomName); err != nil {
+		return nil, nil, false, err
+	}
+
 	if err = r.roomStore.StoreRoom(ctx, rm, internal); err != nil {
+		r.quotas.ReleaseRoom(ctx, roomName)
 		return nil, nil, false, err
 	}
 
@@ -139,7 +148,7 @@ func (r *StandardRoomAllocator) SelectRoomNode(ctx context.Context, roomName liv
 	// if already assigned and still available, keep it on that node
 	if err == nil && selector.IsAvailable(existing) {
 		// if node hosting the room is full, deny entry
-		if selector.LimitsReached(r.config.Limit, existing.Stats) {
+		if selector.LimitsReached(r.config.GetLimit(), existing.Stats) {
 			return routing.ErrNodeLimitReached
 		}
 
@@ -204,7 +213,7 @@ func (r *StandardRoomAllocator) applyNamedRoomConfiguration(req *livekit.CreateR
 		return req, nil
 	}
 
-	conf, ok := r.config.Room.RoomConfigurations[req.RoomPreset]
+	conf, ok := r.config.GetRoomConfiguration(req.RoomPreset)
 	if !ok {
 		return req, psrpc.NewErrorf(psrpc.InvalidArgument, "unknown room confguration in create room request")
 	}
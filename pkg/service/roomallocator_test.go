@@ -92,7 +92,7 @@ func newTestRoomAllocator(t *testing.T, conf *config.Config, node *livekit.Node)
 
 	router.GetNodeForRoomReturns(node, nil)
 
-	ra, err := service.NewRoomAllocator(conf, router, store)
+	ra, err := service.NewRoomAllocator(conf, router, store, service.NewAPIKeyQuotas(conf, service.NewLocalStore()))
 	require.NoError(t, err)
 	return ra, conf
 }
@@ -23,6 +23,7 @@ import (
 
 	"github.com/pkg/errors"
 	"golang.org/x/exp/maps"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/livekit/livekit-server/pkg/agent"
 	"github.com/livekit/livekit-server/pkg/sfu"
@@ -60,6 +61,47 @@ type iceConfigCacheKey struct {
 	participantIdentity livekit.ParticipantIdentity
 }
 
+// participantSession follows a participant hosted on this node across the rooms it is moved between
+type participantSession struct {
+	// held while a signal message is handled, and while the participant is being moved
+	signalLock sync.Mutex
+
+	lock                  sync.Mutex
+	room                  *rtc.Room
+	killParticipantServer func()
+}
+
+func newParticipantSession(room *rtc.Room) *participantSession {
+	return &participantSession{room: room}
+}
+
+func (s *participantSession) Room() *rtc.Room {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.room
+}
+
+func (s *participantSession) setRoom(room *rtc.Room) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.room = room
+}
+
+func (s *participantSession) setParticipantServer(kill func()) {
+	s.lock.Lock()
+	prev := s.killParticipantServer
+	s.killParticipantServer = kill
+	s.lock.Unlock()
+
+	if prev != nil {
+		prev()
+	}
+}
+
+func (s *participantSession) close() {
+	s.setParticipantServer(nil)
+}
+
 // RoomManager manages rooms and its interaction with participants.
 // It's responsible for creating, deleting rooms, as well as running sessions for participants
 type RoomManager struct {
@@ -81,11 +123,15 @@ type RoomManager struct {
 	versionGenerator  utils.TimedVersionGenerator
 	turnAuthHandler   *TURNAuthHandler
 	bus               psrpc.MessageBus
+	quotas            *APIKeyQuotas
 
 	rooms map[livekit.RoomName]*rtc.Room
+	// sessions of participants on this node, by participant ID
+	sessions map[livekit.ParticipantID]*participantSession
 
 	roomServers          utils.MultitonService[rpc.RoomTopic]
 	agentDispatchServers utils.MultitonService[rpc.RoomTopic]
+	roomAdminServers     utils.MultitonService[rpc.RoomTopic]
 	participantServers   utils.MultitonService[rpc.ParticipantTopic]
 
 	iceConfigCache *sutils.IceConfigCache[iceConfigCacheKey]
@@ -108,6 +154,7 @@ func NewLocalRoomManager(
 	turnAuthHandler *TURNAuthHandler,
 	bus psrpc.MessageBus,
 	forwardStats *sfu.ForwardStats,
+	quotas *APIKeyQuotas,
 ) (*RoomManager, error) {
 	rtcConf, err := rtc.NewWebRTCConfig(conf)
 	if err != nil {
@@ -130,8 +177,10 @@ func NewLocalRoomManager(
 		turnAuthHandler:   turnAuthHandler,
 		bus:               bus,
 		forwardStats:      forwardStats,
+		quotas:            quotas,
 
-		rooms: make(map[livekit.RoomName]*rtc.Room),
+		rooms:    make(map[livekit.RoomName]*rtc.Room),
+		sessions: make(map[livekit.ParticipantID]*participantSession),
 
 		iceConfigCache: sutils.NewIceConfigCache[iceConfigCacheKey](0),
 
@@ -169,6 +218,9 @@ func (r *RoomManager) deleteRoom(ctx context.Context, roomName livekit.RoomName)
 	delete(r.rooms, roomName)
 	r.lock.Unlock()
 
+	// called when the room closes, the context of the request that created it may be done
+	r.quotas.ReleaseRoom(context.Background(), roomName)
+
 	var err, err2 error
 	wg := sync.WaitGroup{}
 	wg.Add(2)
@@ -213,7 +265,71 @@ func (r *RoomManager) HasParticipants() bool {
 	return false
 }
 
+// RestoreRooms recreates rooms persisted by the local store before the node restarted
+func (r *RoomManager) RestoreRooms(ctx context.Context) error {
+	ls, ok := r.roomStore.(*LocalStore)
+	if !ok || !ls.IsPersistent() {
+		return nil
+	}
+
+	rooms, err := ls.ListRooms(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, ri := range rooms {
+		_, internal, err := ls.LoadRoom(ctx, livekit.RoomName(ri.Name), true)
+		if err != nil {
+			logger.Errorw("could not restore room", err, "room", ri.Name)
+			continue
+		}
+		room, err := r.getOrCreateRoom(ctx, restoreRoomRequest(ri, internal, ls.LoadRoomPreset(ctx, livekit.RoomName(ri.Name))))
+		if err != nil {
+			logger.Errorw("could not restore room", err, "room", ri.Name)
+			continue
+		}
+		// nobody could join while the node was down
+		room.RestartEmptyTimeout()
+		room.Release()
+		room.Logger.Infow("restored room")
+	}
+	return nil
+}
+
+// restoreRoomRequest creates a room again with the room configuration it was created with,
+// keeping the settings it was stored with over the defaults of the room configuration
+func restoreRoomRequest(ri *livekit.Room, internal *livekit.RoomInternal, preset string) *livekit.CreateRoomRequest {
+	req := &livekit.CreateRoomRequest{
+		Name:             ri.Name,
+		RoomPreset:       preset,
+		EmptyTimeout:     ri.EmptyTimeout,
+		DepartureTimeout: ri.DepartureTimeout,
+		MaxParticipants:  ri.MaxParticipants,
+	}
+	if internal != nil {
+		if internal.ParticipantEgress != nil || internal.TrackEgress != nil {
+			req.Egress = &livekit.RoomEgress{
+				Participant: internal.ParticipantEgress,
+				Tracks:      internal.TrackEgress,
+			}
+		}
+		req.Agents = internal.AgentDispatches
+		if pd := internal.PlayoutDelay; pd != nil && pd.Enabled {
+			req.MinPlayoutDelay = pd.Min
+			req.MaxPlayoutDelay = pd.Max
+		}
+		req.SyncStreams = internal.SyncStreams
+	}
+	return req
+}
+
 func (r *RoomManager) Stop() {
+	// keep persisted rooms for the next start, closing rooms below would otherwise delete them
+	if ls, ok := r.roomStore.(*LocalStore); ok {
+		if err := ls.Close(); err != nil {
+			logger.Errorw("could not close local store", err)
+		}
+	}
+
 	// disconnect all clients
 	r.lock.RLock()
 	rooms := maps.Values(r.rooms)
@@ -226,6 +342,7 @@ func (r *RoomManager) Stop() {
 	r.roomManagerServer.Kill()
 	r.roomServers.Kill()
 	r.agentDispatchServers.Kill()
+	r.roomAdminServers.Kill()
 	r.participantServers.Kill()
 
 	if r.rtcConfig != nil {
@@ -265,6 +382,12 @@ func (r *RoomManager) StartSession(
 	sessionStartTime := time.Now()
 
 	createRoom := pi.CreateRoom
+	// sessions are also started without going through RTCService, by WHIP and WHEP
+	if pi.Identity != "" {
+		if err := checkRoomSchedule(ctx, r.roomStore, livekit.RoomName(createRoom.Name)); err != nil {
+			return err
+		}
+	}
 	room, err := r.getOrCreateRoom(ctx, createRoom)
 	if err != nil {
 		return err
@@ -343,7 +466,7 @@ func (r *RoomManager) StartSession(
 				return err
 			}
 			r.telemetry.ParticipantResumed(ctx, room.ToProto(), participant.ToProto(), r.currentNode.NodeID(), pi.ReconnectReason)
-			go r.rtcSessionWorker(room, participant, requestSource)
+			go r.rtcSessionWorker(r.getSession(room, participant), participant, requestSource)
 			return nil
 		}
 
@@ -375,6 +498,9 @@ func (r *RoomManager) StartSession(
 	}
 
 	sid := livekit.ParticipantID(guid.New(utils.ParticipantPrefix))
+	if err = r.quotas.AcquireParticipant(ctx, quotaAPIKey(ctx), sid); err != nil {
+		return err
+	}
 	pLogger := rtc.LoggerWithParticipant(
 		rtc.LoggerWithRoom(logger.GetLogger(), room.Name(), room.ID()),
 		pi.Identity,
@@ -420,6 +546,8 @@ func (r *RoomManager) StartSession(
 	if pi.SubscriberAllowPause != nil {
 		subscriberAllowPause = *pi.SubscriberAllowPause
 	}
+	limits := r.config.GetLimit()
+	session := newParticipantSession(room)
 	participant, err = rtc.NewParticipant(rtc.ParticipantParams{
 		Identity:                pi.Identity,
 		Name:                    pi.Name,
@@ -428,7 +556,8 @@ func (r *RoomManager) StartSession(
 		Sink:                    responseSink,
 		AudioConfig:             r.config.Audio,
 		VideoConfig:             r.config.Video,
-		LimitConfig:             r.config.Limit,
+		LimitConfig:             limits,
+		MetadataSchema:          room.GetMetadataSchema(),
 		ProtocolVersion:         pv,
 		SessionStartTime:        sessionStartTime,
 		Telemetry:               r.telemetry,
@@ -447,19 +576,25 @@ func (r *RoomManager) StartSession(
 		AllowTCPFallback:        allowFallback,
 		TURNSEnabled:            r.config.IsTURNSEnabled(),
 		GetParticipantInfo: func(pID livekit.ParticipantID) *livekit.ParticipantInfo {
+			room := session.Room()
 			if p := room.GetParticipantByID(pID); p != nil {
 				return p.ToProto()
 			}
-			return nil
+			return room.GetBridgedParticipantInfo(pID)
 		},
 		ReconnectOnPublicationError:  reconnectOnPublicationError,
 		ReconnectOnSubscriptionError: reconnectOnSubscriptionError,
 		ReconnectOnDataChannelError:  reconnectOnDataChannelError,
 		VersionGenerator:             r.versionGenerator,
-		TrackResolver:                room.ResolveMediaTrackForSubscriber,
+		TrackResolver: func(sub types.LocalParticipant, trackID livekit.TrackID) types.MediaResolverResult {
+			return session.Room().ResolveMediaTrackForSubscriber(sub, trackID)
+		},
+		AcquirePublisherSlot: func(p types.LocalParticipant) bool {
+			return session.Room().AcquirePublisherSlot(p)
+		},
 		SubscriberAllowPause:         subscriberAllowPause,
-		SubscriptionLimitAudio:       r.config.Limit.SubscriptionLimitAudio,
-		SubscriptionLimitVideo:       r.config.Limit.SubscriptionLimitVideo,
+		SubscriptionLimitAudio:       limits.SubscriptionLimitAudio,
+		SubscriptionLimitVideo:       limits.SubscriptionLimitVideo,
 		PlayoutDelay:                 roomInternal.GetPlayoutDelay(),
 		SyncStreams:                  roomInternal.GetSyncStreams(),
 		ForwardStats:                 r.forwardStats,
@@ -467,9 +602,11 @@ func (r *RoomManager) StartSession(
 		UseOneShotSignallingMode:     useOneShotSignallingMode,
 		DataChannelMaxBufferedAmount: r.config.RTC.DataChannelMaxBufferedAmount,
 		DatachannelSlowThreshold:     r.config.RTC.DatachannelSlowThreshold,
+		ReliableDataReplay:           r.config.RTC.ReliableDataReplay,
 		FireOnTrackBySdp:             true,
 	})
 	if err != nil {
+		r.quotas.ReleaseParticipant(ctx, sid)
 		return err
 	}
 	iceConfig := r.setIceConfig(room.Name(), participant)
@@ -482,16 +619,14 @@ func (r *RoomManager) StartSession(
 	if err = room.Join(participant, requestSource, &opts, iceServers); err != nil {
 		pLogger.Errorw("could not join room", err)
 		_ = participant.Close(true, types.ParticipantCloseReasonJoinFailed, false)
+		r.quotas.ReleaseParticipant(ctx, sid)
 		return err
 	}
 
-	participantTopic := rpc.FormatParticipantTopic(room.Name(), participant.Identity())
-	participantServer := must.Get(rpc.NewTypedParticipantServer(r, r.bus))
-	killParticipantServer := r.participantServers.Replace(participantTopic, participantServer)
-	if err := participantServer.RegisterAllParticipantTopics(participantTopic); err != nil {
-		killParticipantServer()
+	if err := r.registerParticipantServer(session, participant); err != nil {
 		pLogger.Errorw("could not join register participant topic", err)
 		_ = participant.Close(true, types.ParticipantCloseReasonMessageBusFailed, false)
+		r.quotas.ReleaseParticipant(ctx, sid)
 		return err
 	}
 
@@ -499,31 +634,31 @@ func (r *RoomManager) StartSession(
 		pLogger.Errorw("could not store participant", err)
 	}
 
-	persistRoomForParticipantCount := func(proto *livekit.Room) {
-		if !participant.Hidden() && !room.IsClosed() {
-			err = r.roomStore.StoreRoom(ctx, proto, room.Internal())
-			if err != nil {
-				logger.Errorw("could not store room", err)
-			}
-		}
-	}
+	r.lock.Lock()
+	r.sessions[participant.ID()] = session
+	r.lock.Unlock()
 
 	// update room store with new numParticipants
-	persistRoomForParticipantCount(room.ToProto())
+	r.persistRoomForParticipantCount(ctx, room, participant)
 
 	clientMeta := &livekit.AnalyticsClientMeta{Region: r.currentNode.Region(), Node: string(r.currentNode.NodeID())}
 	r.telemetry.ParticipantJoined(ctx, protoRoom, participant.ToProto(), pi.Client, clientMeta, true)
 	participant.OnClose(func(p types.LocalParticipant) {
-		killParticipantServer()
+		session.close()
+		r.lock.Lock()
+		delete(r.sessions, p.ID())
+		r.lock.Unlock()
+		// the context of the session may be done by the time the participant closes
+		r.quotas.ReleaseParticipant(context.Background(), p.ID())
 
+		room := session.Room()
 		if err := r.roomStore.DeleteParticipant(ctx, room.Name(), p.Identity()); err != nil {
 			pLogger.Errorw("could not delete participant", err)
 		}
 
 		// update room store with new numParticipants
-		proto := room.ToProto()
-		persistRoomForParticipantCount(proto)
-		r.telemetry.ParticipantLeft(ctx, proto, p.ToProto(), true)
+		r.persistRoomForParticipantCount(ctx, room, p)
+		r.telemetry.ParticipantLeft(ctx, room.ToProto(), p.ToProto(), true)
 	})
 	participant.OnClaimsChanged(func(participant types.LocalParticipant) {
 		pLogger.Debugw("refreshing client token after claims change")
@@ -532,10 +667,10 @@ func (r *RoomManager) StartSession(
 		}
 	})
 	participant.OnICEConfigChanged(func(participant types.LocalParticipant, iceConfig *livekit.ICEConfig) {
-		r.iceConfigCache.Put(iceConfigCacheKey{room.Name(), participant.Identity()}, iceConfig)
+		r.iceConfigCache.Put(iceConfigCacheKey{session.Room().Name(), participant.Identity()}, iceConfig)
 	})
 
-	go r.rtcSessionWorker(room, participant, requestSource)
+	go r.rtcSessionWorker(session, participant, requestSource)
 	return nil
 }
 
@@ -556,6 +691,10 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, createRoom *livekit.C
 	if err != nil {
 		return nil, err
 	}
+	schedule, err := r.roomStore.LoadRoomSchedule(ctx, roomName)
+	if err != nil && !errors.Is(err, ErrRoomScheduleNotFound) {
+		logger.Warnw("could not load room schedule", err, "room", roomName)
+	}
 
 	r.lock.Lock()
 
@@ -573,6 +712,42 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, createRoom *livekit.C
 
 	// construct ice servers
 	newRoom := rtc.NewRoom(ri, internal, *r.rtcConfig, r.config.Room, &r.config.Audio, r.serverInfo, r.telemetry, r.agentClient, r.agentStore, r.egressLauncher)
+	if rules, ok := r.config.GetSubscriptionPolicy(createRoom.RoomPreset); ok {
+		newRoom.SetSubscriptionPolicy(rules)
+	}
+	if lobby, ok := r.config.GetLobby(createRoom.RoomPreset); ok {
+		newRoom.SetLobby(lobby)
+	}
+	if stage, ok := r.config.GetStage(createRoom.RoomPreset); ok {
+		newRoom.SetStage(stage)
+	}
+	if filter, ok := r.config.GetDataFilter(createRoom.RoomPreset); ok {
+		if err := newRoom.SetDataFilter(filter); err != nil {
+			newRoom.Logger.Warnw("could not set data filter", err)
+		}
+	}
+	if topics, ok := r.config.GetChatHistory(createRoom.RoomPreset); ok {
+		newRoom.SetChatHistory(topics)
+	}
+	if schema, ok := r.config.GetMetadataSchema(createRoom.RoomPreset); ok {
+		if err := newRoom.SetMetadataSchema(schema); err != nil {
+			newRoom.Logger.Warnw("could not set metadata schema", err)
+		}
+	}
+	if lastN, ok := r.config.GetLastNAudio(createRoom.RoomPreset); ok {
+		newRoom.SetLastNAudio(lastN)
+	}
+	if policy, ok := r.config.GetAllocationPolicy(createRoom.RoomPreset); ok {
+		if err := newRoom.SetAllocationPolicy(policy); err != nil {
+			newRoom.Logger.Warnw("could not set allocation policy", err)
+		}
+	}
+	newRoom.OnExpiring(func(_ time.Duration) {
+		r.telemetry.RoomExpiring(ctx, newRoom.ToProto())
+	})
+	if schedule != nil && schedule.ExpiresAt != 0 {
+		newRoom.SetExpiry(schedule.Expiry(), r.config.Room.ExpiryWarnings)
+	}
 
 	roomTopic := rpc.FormatRoomTopic(roomName)
 	roomServer := must.Get(rpc.NewTypedRoomServer(r, r.bus))
@@ -580,6 +755,7 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, createRoom *livekit.C
 	if err := roomServer.RegisterAllRoomTopics(roomTopic); err != nil {
 		killRoomServer()
 		r.lock.Unlock()
+		r.quotas.ReleaseRoom(ctx, roomName)
 		return nil, err
 	}
 	agentDispatchServer := must.Get(rpc.NewTypedAgentDispatchInternalServer(r, r.bus))
@@ -588,12 +764,24 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, createRoom *livekit.C
 		killRoomServer()
 		killDispServer()
 		r.lock.Unlock()
+		r.quotas.ReleaseRoom(ctx, roomName)
+		return nil, err
+	}
+	roomAdminServer := newRoomAdminServer(r, r.bus)
+	killRoomAdminServer := r.roomAdminServers.Replace(roomTopic, roomAdminServer)
+	if err := roomAdminServer.RegisterAllRoomTopics(roomTopic); err != nil {
+		killRoomServer()
+		killDispServer()
+		killRoomAdminServer()
+		r.lock.Unlock()
+		r.quotas.ReleaseRoom(ctx, roomName)
 		return nil, err
 	}
 
 	newRoom.OnClose(func() {
 		killRoomServer()
 		killDispServer()
+		killRoomAdminServer()
 
 		roomInfo := newRoom.ToProto()
 		r.telemetry.RoomEnded(ctx, roomInfo)
@@ -625,6 +813,12 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, createRoom *livekit.C
 
 	newRoom.Hold()
 
+	if ls, ok := r.roomStore.(*LocalStore); ok && ls.IsPersistent() && createRoom.RoomPreset != "" {
+		if err := ls.StoreRoomPreset(ctx, roomName, createRoom.RoomPreset); err != nil {
+			newRoom.Logger.Warnw("could not store room configuration", err)
+		}
+	}
+
 	r.telemetry.RoomStarted(ctx, newRoom.ToProto())
 	prometheus.RoomStarted()
 
@@ -647,7 +841,8 @@ func (r *RoomManager) getOrCreateRoom(ctx context.Context, createRoom *livekit.C
 }
 
 // manages an RTC session for a participant, runs on the RTC node
-func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.LocalParticipant, requestSource routing.MessageSource) {
+func (r *RoomManager) rtcSessionWorker(session *participantSession, participant types.LocalParticipant, requestSource routing.MessageSource) {
+	room := session.Room()
 	pLogger := rtc.LoggerWithParticipant(
 		rtc.LoggerWithRoom(logger.GetLogger(), room.Name(), room.ID()),
 		participant.Identity(),
@@ -679,19 +874,71 @@ func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.LocalPa
 				pLogger.Errorw("could not refresh token", err, "connID", requestSource.ConnectionID())
 			}
 		case obj := <-requestSource.ReadChan():
-			if obj == nil {
-				if room.GetParticipantRequestSource(participant.Identity()) == requestSource {
-					participant.HandleSignalSourceClose()
-				}
+			if !r.handleSessionSignal(session, participant, requestSource, obj, pLogger) {
 				return
 			}
+		}
+	}
+}
 
-			req := obj.(*livekit.SignalRequest)
-			if err := rtc.HandleParticipantSignal(room, participant, req, pLogger); err != nil {
-				// more specific errors are already logged
-				// treat errors returned as fatal
-				return
-			}
+// handleSessionSignal returns false once the session should end
+func (r *RoomManager) handleSessionSignal(
+	session *participantSession,
+	participant types.LocalParticipant,
+	requestSource routing.MessageSource,
+	obj proto.Message,
+	pLogger logger.Logger,
+) bool {
+	session.signalLock.Lock()
+	defer session.signalLock.Unlock()
+
+	// the participant could have been moved to another room
+	room := session.Room()
+	if obj == nil {
+		if room.GetParticipantRequestSource(participant.Identity()) == requestSource {
+			participant.HandleSignalSourceClose()
+		}
+		return false
+	}
+
+	req := obj.(*livekit.SignalRequest)
+	if err := rtc.HandleParticipantSignal(room, participant, req, pLogger); err != nil {
+		// more specific errors are already logged
+		// treat errors returned as fatal
+		return false
+	}
+	return true
+}
+
+func (r *RoomManager) getSession(room *rtc.Room, participant types.LocalParticipant) *participantSession {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	session := r.sessions[participant.ID()]
+	if session == nil {
+		session = newParticipantSession(room)
+		r.sessions[participant.ID()] = session
+	}
+	return session
+}
+
+// registerParticipantServer serves participant requests for the room the session is in, replacing the server of its previous room
+func (r *RoomManager) registerParticipantServer(session *participantSession, participant types.LocalParticipant) error {
+	participantTopic := rpc.FormatParticipantTopic(session.Room().Name(), participant.Identity())
+	participantServer := must.Get(rpc.NewTypedParticipantServer(r, r.bus))
+	killParticipantServer := r.participantServers.Replace(participantTopic, participantServer)
+	if err := participantServer.RegisterAllParticipantTopics(participantTopic); err != nil {
+		killParticipantServer()
+		return err
+	}
+	session.setParticipantServer(killParticipantServer)
+	return nil
+}
+
+func (r *RoomManager) persistRoomForParticipantCount(ctx context.Context, room *rtc.Room, participant types.LocalParticipant) {
+	if !participant.Hidden() && !room.IsClosed() {
+		if err := r.roomStore.StoreRoom(ctx, room.ToProto(), room.Internal()); err != nil {
+			logger.Errorw("could not store room", err)
 		}
 	}
 }
@@ -743,10 +990,14 @@ func (r *RoomManager) MutePublishedTrack(ctx context.Context, req *livekit.MuteR
 }
 
 func (r *RoomManager) UpdateParticipant(ctx context.Context, req *livekit.UpdateParticipantRequest) (*livekit.ParticipantInfo, error) {
-	_, participant, err := r.roomAndParticipantForReq(ctx, req)
+	room, participant, err := r.roomAndParticipantForReq(ctx, req)
 	if err != nil {
 		return nil, err
 	}
+	if req.Permission != nil && room.IsInLobby(participant.Identity()) {
+		// permissions are granted on admission
+		return nil, psrpc.NewError(psrpc.FailedPrecondition, rtc.ErrParticipantInLobby)
+	}
 
 	participant.GetLogger().Debugw("updating participant",
 		"metadata", req.Metadata,
@@ -756,6 +1007,9 @@ func (r *RoomManager) UpdateParticipant(ctx context.Context, req *livekit.Update
 	if err = participant.CheckMetadataLimits(req.Name, req.Metadata, req.Attributes); err != nil {
 		return nil, err
 	}
+	if err = participant.CheckMetadataSchema(req.Metadata, req.Attributes, false); err != nil {
+		return nil, psrpc.NewError(psrpc.InvalidArgument, err)
+	}
 
 	if req.Name != "" {
 		participant.SetName(req.Name)
@@ -834,6 +1088,10 @@ func (r *RoomManager) UpdateRoomMetadata(ctx context.Context, req *livekit.Updat
 		return nil, ErrRoomNotFound
 	}
 
+	if err := room.CheckMetadata(req.Metadata); err != nil {
+		return nil, psrpc.NewError(psrpc.InvalidArgument, err)
+	}
+
 	room.Logger.Debugw("updating room")
 	done := room.SetMetadata(req.Metadata)
 	// wait till the update is applied
@@ -887,6 +1145,409 @@ func (r *RoomManager) DeleteDispatch(ctx context.Context, req *livekit.DeleteAge
 	return disp, nil
 }
 
+func (r *RoomManager) ForwardParticipant(ctx context.Context, req *livekit.ForwardParticipantRequest) (*livekit.ForwardParticipantResponse, error) {
+	return nil, psrpc.NewErrorf(psrpc.Unimplemented, "forwarding participants is not supported")
+}
+
+func (r *RoomManager) MoveParticipant(ctx context.Context, req *livekit.MoveParticipantRequest) (*livekit.MoveParticipantResponse, error) {
+	if _, err := r.moveParticipant(
+		ctx,
+		livekit.RoomName(req.Room),
+		livekit.ParticipantIdentity(req.Identity),
+		livekit.RoomName(req.DestinationRoom),
+	); err != nil {
+		return nil, err
+	}
+	return &livekit.MoveParticipantResponse{}, nil
+}
+
+// moveParticipant transfers a participant to another room hosted on this node, keeping its connection and published tracks.
+// The destination room is created when it does not exist yet.
+func (r *RoomManager) moveParticipant(
+	ctx context.Context,
+	roomName livekit.RoomName,
+	identity livekit.ParticipantIdentity,
+	destRoomName livekit.RoomName,
+) (*livekit.ParticipantInfo, error) {
+	if roomName == destRoomName {
+		return nil, ErrMoveToSameRoom
+	}
+	if err := checkRoomSchedule(ctx, r.roomStore, destRoomName); err != nil {
+		return nil, err
+	}
+
+	room, err := r.getLocalRoom(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return nil, ErrParticipantNotFound
+	}
+	r.lock.RLock()
+	session := r.sessions[participant.ID()]
+	r.lock.RUnlock()
+	if session == nil {
+		return nil, ErrParticipantNotFound
+	}
+
+	destRoom, err := r.holdLocalRoom(ctx, destRoomName)
+	if err != nil {
+		return nil, err
+	}
+	defer destRoom.Release()
+
+	session.signalLock.Lock()
+	defer session.signalLock.Unlock()
+	if session.Room() != room {
+		// moved concurrently
+		return nil, ErrParticipantNotFound
+	}
+
+	detached, err := room.DetachParticipant(identity)
+	if err != nil {
+		return nil, moveParticipantError(err)
+	}
+	if err = destRoom.AttachParticipant(detached); err != nil {
+		participant.GetLogger().Warnw("could not move participant", err, "destinationRoom", destRoomName)
+		if rerr := room.AttachParticipant(detached); rerr != nil {
+			participant.GetLogger().Errorw("could not return participant to its room", rerr)
+			_ = participant.Close(true, types.ParticipantCloseReasonJoinFailed, false)
+		}
+		return nil, moveParticipantError(err)
+	}
+	session.setRoom(destRoom)
+
+	// the client resumes with the token it holds, which has to name the room it was moved to
+	participant.MoveToRoom(destRoomName)
+	if err = r.refreshToken(participant); err != nil {
+		participant.GetLogger().Errorw("could not refresh token after move", err)
+	}
+
+	if err = r.registerParticipantServer(session, participant); err != nil {
+		participant.GetLogger().Errorw("could not register participant topic", err)
+	}
+	if iceConfig := r.getIceConfig(roomName, participant); iceConfig != nil {
+		r.iceConfigCache.Put(iceConfigCacheKey{destRoomName, identity}, iceConfig)
+	}
+
+	if err = r.roomStore.DeleteParticipant(ctx, roomName, identity); err != nil {
+		participant.GetLogger().Errorw("could not delete participant", err)
+	}
+	if err = r.roomStore.StoreParticipant(ctx, destRoomName, participant.ToProto()); err != nil {
+		participant.GetLogger().Errorw("could not store participant", err)
+	}
+	r.persistRoomForParticipantCount(ctx, room, participant)
+	r.persistRoomForParticipantCount(ctx, destRoom, participant)
+
+	pID := participant.ID()
+	tracks := participant.GetPublishedTracks()
+	for _, t := range tracks {
+		r.telemetry.TrackUnpublished(ctx, pID, identity, t.ToProto(), true)
+	}
+	r.telemetry.ParticipantLeft(ctx, room.ToProto(), participant.ToProto(), true)
+
+	clientMeta := &livekit.AnalyticsClientMeta{Region: r.currentNode.Region(), Node: string(r.currentNode.NodeID())}
+	destProto := destRoom.ToProto()
+	r.telemetry.ParticipantJoined(ctx, destProto, participant.ToProto(), participant.GetClientInfo(), clientMeta, true)
+	r.telemetry.ParticipantActive(ctx, destProto, participant.ToProto(), clientMeta, false)
+	for _, t := range tracks {
+		r.telemetry.TrackPublished(ctx, pID, identity, t.ToProto())
+	}
+
+	participant.GetLogger().Infow("participant moved", "room", roomName, "destinationRoom", destRoomName)
+	return participant.ToProto(), nil
+}
+
+// CreateTrackBridge makes a track of a room hosted on this node subscribable from another room on this node.
+// The destination room is created when it does not exist yet, bridges to rooms hosted on other nodes are rejected.
+func (r *RoomManager) CreateTrackBridge(ctx context.Context, req *CreateTrackBridgeRequest) (*TrackBridgeInfo, error) {
+	roomName := livekit.RoomName(req.Room)
+	destRoomName := livekit.RoomName(req.DestinationRoom)
+	if roomName == destRoomName {
+		return nil, ErrBridgeToSameRoom
+	}
+
+	room, err := r.getLocalRoom(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+
+	destRoom, err := r.holdLocalRoom(ctx, destRoomName)
+	if errors.Is(err, ErrRoomOnRemoteNode) {
+		return nil, ErrBridgeAcrossNodes
+	} else if err != nil {
+		return nil, err
+	}
+	defer destRoom.Release()
+
+	b, err := room.BridgeTrack(livekit.TrackID(req.TrackSid), destRoom)
+	switch {
+	case errors.Is(err, rtc.ErrTrackNotFound):
+		return nil, ErrTrackNotFound
+	case errors.Is(err, rtc.ErrAlreadyJoined), errors.Is(err, rtc.ErrTrackAlreadyBridged):
+		return nil, psrpc.NewError(psrpc.AlreadyExists, err)
+	case errors.Is(err, rtc.ErrTrackIsBridged):
+		return nil, psrpc.NewError(psrpc.FailedPrecondition, err)
+	case errors.Is(err, rtc.ErrRoomClosed):
+		return nil, psrpc.NewError(psrpc.Unavailable, err)
+	case err != nil:
+		return nil, err
+	}
+	return trackBridgeInfo(b), nil
+}
+
+// ListTrackBridges returns the bridges from and to a room hosted on this node
+func (r *RoomManager) ListTrackBridges(ctx context.Context, req *ListTrackBridgesRequest) (*ListTrackBridgesResponse, error) {
+	room, err := r.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ListTrackBridgesResponse{
+		Bridges: make([]*TrackBridgeInfo, 0),
+	}
+	for _, b := range append(room.GetBridges(), room.GetIncomingBridges()...) {
+		res.Bridges = append(res.Bridges, trackBridgeInfo(b))
+	}
+	return res, nil
+}
+
+// DeleteTrackBridge closes a bridge of a track published in a room hosted on this node
+func (r *RoomManager) DeleteTrackBridge(ctx context.Context, req *DeleteTrackBridgeRequest) (*TrackBridgeInfo, error) {
+	room, err := r.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range room.GetBridges() {
+		if b.ID() == req.BridgeID {
+			b.Close()
+			return trackBridgeInfo(b), nil
+		}
+	}
+	return nil, ErrTrackBridgeNotFound
+}
+
+// ListLobby returns the participants waiting in the lobby of a room hosted on this node
+func (r *RoomManager) ListLobby(ctx context.Context, req *livekit.ListParticipantsRequest) (*livekit.ListParticipantsResponse, error) {
+	room, err := r.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+	if !room.HasLobby() {
+		return nil, ErrRoomHasNoLobby
+	}
+
+	participants := room.GetLobbyParticipants()
+	res := &livekit.ListParticipantsResponse{
+		Participants: make([]*livekit.ParticipantInfo, 0, len(participants)),
+	}
+	for _, p := range participants {
+		res.Participants = append(res.Participants, rtc.LobbyParticipantInfo(p))
+	}
+	return res, nil
+}
+
+// AdmitParticipant lets a participant waiting in the lobby of a room hosted on this node into the room
+func (r *RoomManager) AdmitParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	room, err := r.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+	participant, err := room.AdmitParticipant(livekit.ParticipantIdentity(req.Identity))
+	if err != nil {
+		return nil, lobbyError(err)
+	}
+	r.persistRoomForParticipantCount(ctx, room, participant)
+	return participant.ToProto(), nil
+}
+
+// RejectParticipant disconnects a participant waiting in the lobby of a room hosted on this node
+func (r *RoomManager) RejectParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.RemoveParticipantResponse, error) {
+	room, err := r.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+	if err := room.RejectParticipant(livekit.ParticipantIdentity(req.Identity)); err != nil {
+		return nil, lobbyError(err)
+	}
+	return &livekit.RemoveParticipantResponse{}, nil
+}
+
+// GetStage returns the publisher slots and raised hands of a room hosted on this node
+func (r *RoomManager) GetStage(ctx context.Context, req *StageRequest) (*StageInfo, error) {
+	roomName := livekit.RoomName(req.Room)
+	room, err := r.getLocalRoom(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	if !room.HasStage() {
+		return nil, ErrRoomHasNoStage
+	}
+	return stageInfo(roomName, room.GetStage()), nil
+}
+
+// PromoteParticipant brings a participant of a room hosted on this node on stage, allowing it to publish
+func (r *RoomManager) PromoteParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	room, err := r.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+	if !room.HasStage() {
+		return nil, ErrRoomHasNoStage
+	}
+	participant, err := room.PromoteParticipant(livekit.ParticipantIdentity(req.Identity))
+	if err != nil {
+		return nil, stageError(err)
+	}
+	return participant.ToProto(), nil
+}
+
+// DemoteParticipant takes a participant of a room hosted on this node off stage, unpublishing its tracks
+func (r *RoomManager) DemoteParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	room, err := r.getLocalRoom(ctx, livekit.RoomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+	if !room.HasStage() {
+		return nil, ErrRoomHasNoStage
+	}
+	participant, err := room.DemoteParticipant(livekit.ParticipantIdentity(req.Identity))
+	if err != nil {
+		return nil, stageError(err)
+	}
+	return participant.ToProto(), nil
+}
+
+// GetChatHistory returns the chat messages kept for a topic of a room hosted on this node, or for all topics when empty
+func (r *RoomManager) GetChatHistory(ctx context.Context, req *ChatHistoryRequest) (*ChatHistoryInfo, error) {
+	roomName := livekit.RoomName(req.Room)
+	room, err := r.getLocalRoom(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	h := room.GetChatHistory()
+	if h == nil {
+		return nil, ErrRoomHasNoChatHistory
+	}
+	return chatHistoryInfo(roomName, h.Messages(req.Topic)), nil
+}
+
+func (r *RoomManager) GetRoomSchedule(ctx context.Context, roomName livekit.RoomName) (*RoomSchedule, error) {
+	return r.roomStore.LoadRoomSchedule(ctx, roomName)
+}
+
+// SetRoomSchedule stores the schedule of a room, and applies its expiry right away when the room is already open.
+// it fails when the room is hosted on another node, which would not learn about the expiry.
+func (r *RoomManager) SetRoomSchedule(ctx context.Context, schedule *RoomSchedule) error {
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	roomName := livekit.RoomName(schedule.Room)
+	room, err := r.getLocalRoom(ctx, roomName)
+	if err != nil && !errors.Is(err, ErrRoomNotFound) {
+		return err
+	}
+	if err = r.roomStore.StoreRoomSchedule(ctx, schedule); err != nil {
+		return err
+	}
+	if room != nil {
+		room.SetExpiry(schedule.Expiry(), r.config.Room.ExpiryWarnings)
+	}
+	return nil
+}
+
+// DeleteRoomSchedule removes the schedule of a room, an open room no longer expires
+func (r *RoomManager) DeleteRoomSchedule(ctx context.Context, roomName livekit.RoomName) (*RoomSchedule, error) {
+	schedule, err := r.roomStore.LoadRoomSchedule(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	room, err := r.getLocalRoom(ctx, roomName)
+	if err != nil && !errors.Is(err, ErrRoomNotFound) {
+		return nil, err
+	}
+	if err = r.roomStore.DeleteRoomSchedule(ctx, roomName); err != nil {
+		return nil, err
+	}
+	if room != nil {
+		room.SetExpiry(time.Time{}, nil)
+	}
+	return schedule, nil
+}
+
+func stageError(err error) error {
+	switch {
+	case errors.Is(err, rtc.ErrParticipantNotFound):
+		return ErrParticipantNotFound
+	case errors.Is(err, rtc.ErrStageFull):
+		return psrpc.NewError(psrpc.ResourceExhausted, err)
+	case errors.Is(err, rtc.ErrParticipantNotOnStage), errors.Is(err, rtc.ErrParticipantInLobby):
+		return psrpc.NewError(psrpc.FailedPrecondition, err)
+	}
+	return err
+}
+
+func lobbyError(err error) error {
+	switch {
+	case errors.Is(err, rtc.ErrParticipantNotInLobby):
+		return psrpc.NewError(psrpc.NotFound, err)
+	case errors.Is(err, rtc.ErrMaxParticipantsExceeded):
+		return psrpc.NewError(psrpc.ResourceExhausted, err)
+	}
+	return err
+}
+
+// getLocalRoom returns a room hosted on this node, telling apart rooms hosted elsewhere
+func (r *RoomManager) getLocalRoom(ctx context.Context, roomName livekit.RoomName) (*rtc.Room, error) {
+	if room := r.GetRoom(ctx, roomName); room != nil {
+		return room, nil
+	}
+	if node, err := r.router.GetNodeForRoom(ctx, roomName); err == nil && livekit.NodeID(node.Id) != r.currentNode.NodeID() {
+		return nil, ErrRoomOnRemoteNode
+	}
+	return nil, ErrRoomNotFound
+}
+
+// holdLocalRoom holds a room that has to be hosted on this node, creating it when needed
+func (r *RoomManager) holdLocalRoom(ctx context.Context, roomName livekit.RoomName) (*rtc.Room, error) {
+	r.lock.RLock()
+	room := r.rooms[roomName]
+	r.lock.RUnlock()
+	if room != nil && room.Hold() {
+		return room, nil
+	}
+
+	if err := r.roomAllocator.SelectRoomNode(ctx, roomName, r.currentNode.NodeID()); err != nil {
+		return nil, err
+	}
+	node, err := r.router.GetNodeForRoom(ctx, roomName)
+	if err != nil {
+		return nil, err
+	}
+	if livekit.NodeID(node.Id) != r.currentNode.NodeID() {
+		return nil, ErrRoomOnRemoteNode
+	}
+	return r.getOrCreateRoom(ctx, &livekit.CreateRoomRequest{Name: string(roomName)})
+}
+
+func moveParticipantError(err error) error {
+	switch {
+	case errors.Is(err, rtc.ErrParticipantNotFound):
+		return ErrParticipantNotFound
+	case errors.Is(err, rtc.ErrAlreadyJoined):
+		return psrpc.NewError(psrpc.AlreadyExists, err)
+	case errors.Is(err, rtc.ErrMaxParticipantsExceeded):
+		return psrpc.NewError(psrpc.ResourceExhausted, err)
+	case errors.Is(err, rtc.ErrParticipantNotActive), errors.Is(err, rtc.ErrCannotMoveAgent), errors.Is(err, rtc.ErrParticipantInLobby):
+		return psrpc.NewError(psrpc.FailedPrecondition, err)
+	case errors.Is(err, rtc.ErrRoomClosed):
+		return psrpc.NewError(psrpc.Unavailable, err)
+	}
+	return err
+}
+
 func (r *RoomManager) iceServersForParticipant(apiKey string, participant types.LocalParticipant, tlsOnly bool) []*livekit.ICEServer {
 	var iceServers []*livekit.ICEServer
 	rtcConf := r.config.RTC
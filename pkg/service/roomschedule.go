@@ -0,0 +1,167 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+const (
+	roomSchedulePath = "/room_schedule"
+
+	// schedules are kept this long past their expiry, so participants joining late are told the room expired
+	roomScheduleRetention = 24 * time.Hour
+)
+
+// RoomSchedule is the window participants can join a room in. Times are unix seconds, and not enforced when 0.
+// The room is closed at ExpiresAt.
+type RoomSchedule struct {
+	Room      string `json:"room"`
+	NotBefore int64  `json:"not_before,omitempty"`
+	ExpiresAt int64  `json:"expires_at,omitempty"`
+}
+
+// Validate checks the schedule is for a room, and does not end before it starts
+func (s *RoomSchedule) Validate() error {
+	if s.Room == "" {
+		return ErrRoomNameEmpty
+	}
+	if s.NotBefore != 0 && s.ExpiresAt != 0 && s.ExpiresAt <= s.NotBefore {
+		return ErrInvalidRoomSchedule
+	}
+	return nil
+}
+
+// CheckJoin returns an error telling why participants cannot join at now
+func (s *RoomSchedule) CheckJoin(now time.Time) error {
+	if s.NotBefore != 0 && now.Unix() < s.NotBefore {
+		return fmt.Errorf("%w: opens at %s", ErrRoomNotOpen, time.Unix(s.NotBefore, 0).UTC().Format(time.RFC3339))
+	}
+	if s.ExpiresAt != 0 && now.Unix() >= s.ExpiresAt {
+		return fmt.Errorf("%w: expired at %s", ErrRoomExpired, time.Unix(s.ExpiresAt, 0).UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// checkRoomSchedule returns why participants cannot join a room now, nil when the room has no schedule.
+// it is checked on every path participants join or move into a room through
+func checkRoomSchedule(ctx context.Context, store ServiceStore, roomName livekit.RoomName) error {
+	schedule, err := store.LoadRoomSchedule(ctx, roomName)
+	if errors.Is(err, ErrRoomScheduleNotFound) {
+		return nil
+	}
+	if err != nil || schedule == nil {
+		return err
+	}
+	return schedule.CheckJoin(time.Now())
+}
+
+// Expiry returns when the room is closed, zero when it does not expire
+func (s *RoomSchedule) Expiry() time.Time {
+	if s.ExpiresAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(s.ExpiresAt, 0)
+}
+
+// retainedUntil returns when stores can forget the schedule, zero when it is kept until deleted
+func (s *RoomSchedule) retainedUntil() time.Time {
+	if s.ExpiresAt == 0 {
+		return time.Time{}
+	}
+	return s.Expiry().Add(roomScheduleRetention)
+}
+
+func (s *RoomSchedule) isRetained(now time.Time) bool {
+	until := s.retainedUntil()
+	return until.IsZero() || now.Before(until)
+}
+
+// RoomScheduleService lets room admins schedule when participants can join a room, and when it closes.
+// A schedule can be set before the room is created.
+type RoomScheduleService struct {
+	roomManager *RoomManager
+}
+
+func NewRoomScheduleService(roomManager *RoomManager) *RoomScheduleService {
+	return &RoomScheduleService{
+		roomManager: roomManager,
+	}
+}
+
+func (s *RoomScheduleService) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET "+roomSchedulePath, s.handleGet)
+	mux.HandleFunc("PUT "+roomSchedulePath, s.handlePut)
+	mux.HandleFunc("DELETE "+roomSchedulePath, s.handleDelete)
+}
+
+func (s *RoomScheduleService) handleGet(w http.ResponseWriter, r *http.Request) {
+	roomName := livekit.RoomName(r.URL.Query().Get("room"))
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	schedule, err := s.roomManager.GetRoomSchedule(r.Context(), roomName)
+	if err != nil {
+		handleError(w, r, statusForError(err), err, "room", roomName)
+		return
+	}
+	writeJSON(w, http.StatusOK, schedule)
+}
+
+func (s *RoomScheduleService) handlePut(w http.ResponseWriter, r *http.Request) {
+	schedule := &RoomSchedule{}
+	if !readJSON(w, r, schedule) {
+		return
+	}
+	if err := schedule.Validate(); err != nil {
+		handleError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	roomName := livekit.RoomName(schedule.Room)
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	if err := s.roomManager.SetRoomSchedule(r.Context(), schedule); err != nil {
+		handleError(w, r, statusForError(err), err, "room", roomName)
+		return
+	}
+	writeJSON(w, http.StatusOK, schedule)
+}
+
+func (s *RoomScheduleService) handleDelete(w http.ResponseWriter, r *http.Request) {
+	roomName := livekit.RoomName(r.URL.Query().Get("room"))
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	schedule, err := s.roomManager.DeleteRoomSchedule(r.Context(), roomName)
+	if err != nil {
+		handleError(w, r, statusForError(err), err, "room", roomName)
+		return
+	}
+	writeJSON(w, http.StatusOK, schedule)
+}
@@ -32,7 +32,7 @@ import (
 )
 
 type RoomService struct {
-	limitConf         config.LimitConfig
+	conf              *config.Config
 	apiConf           config.APIConfig
 	router            routing.MessageRouter
 	roomAllocator     RoomAllocator
@@ -41,10 +41,11 @@ type RoomService struct {
 	topicFormatter    rpc.TopicFormatter
 	roomClient        rpc.TypedRoomClient
 	participantClient rpc.TypedParticipantClient
+	roomAdminClient   RoomAdminClient
 }
 
 func NewRoomService(
-	limitConf config.LimitConfig,
+	conf *config.Config,
 	apiConf config.APIConfig,
 	router routing.MessageRouter,
 	roomAllocator RoomAllocator,
@@ -53,9 +54,10 @@ func NewRoomService(
 	topicFormatter rpc.TopicFormatter,
 	roomClient rpc.TypedRoomClient,
 	participantClient rpc.TypedParticipantClient,
+	roomAdminClient RoomAdminClient,
 ) (svc *RoomService, err error) {
 	svc = &RoomService{
-		limitConf:         limitConf,
+		conf:              conf,
 		apiConf:           apiConf,
 		router:            router,
 		roomAllocator:     roomAllocator,
@@ -64,6 +66,7 @@ func NewRoomService(
 		topicFormatter:    topicFormatter,
 		roomClient:        roomClient,
 		participantClient: participantClient,
+		roomAdminClient:   roomAdminClient,
 	}
 	return
 }
@@ -76,8 +79,8 @@ func (s *RoomService) CreateRoom(ctx context.Context, req *livekit.CreateRoomReq
 		return nil, ErrEgressNotConnected
 	}
 
-	if !s.limitConf.CheckRoomNameLength(req.Name) {
-		return nil, fmt.Errorf("%w: max length %d", ErrRoomNameExceedsLimits, s.limitConf.MaxRoomNameLength)
+	if limits := s.conf.GetLimit(); !limits.CheckRoomNameLength(req.Name) {
+		return nil, fmt.Errorf("%w: max length %d", ErrRoomNameExceedsLimits, limits.MaxRoomNameLength)
 	}
 
 	err := s.roomAllocator.SelectRoomNode(ctx, livekit.RoomName(req.Name), livekit.NodeID(req.NodeId))
@@ -194,16 +197,17 @@ func (s *RoomService) MutePublishedTrack(ctx context.Context, req *livekit.MuteR
 func (s *RoomService) UpdateParticipant(ctx context.Context, req *livekit.UpdateParticipantRequest) (*livekit.ParticipantInfo, error) {
 	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
 
-	if !s.limitConf.CheckParticipantNameLength(req.Name) {
-		return nil, twirp.InvalidArgumentError(ErrNameExceedsLimits.Error(), strconv.Itoa(s.limitConf.MaxParticipantNameLength))
+	limits := s.conf.GetLimit()
+	if !limits.CheckParticipantNameLength(req.Name) {
+		return nil, twirp.InvalidArgumentError(ErrNameExceedsLimits.Error(), strconv.Itoa(limits.MaxParticipantNameLength))
 	}
 
-	if !s.limitConf.CheckMetadataSize(req.Metadata) {
-		return nil, twirp.InvalidArgumentError(ErrMetadataExceedsLimits.Error(), strconv.Itoa(int(s.limitConf.MaxMetadataSize)))
+	if !limits.CheckMetadataSize(req.Metadata) {
+		return nil, twirp.InvalidArgumentError(ErrMetadataExceedsLimits.Error(), strconv.Itoa(int(limits.MaxMetadataSize)))
 	}
 
-	if !s.limitConf.CheckAttributesSize(req.Attributes) {
-		return nil, twirp.InvalidArgumentError(ErrAttributeExceedsLimits.Error(), strconv.Itoa(int(s.limitConf.MaxAttributesSize)))
+	if !limits.CheckAttributesSize(req.Attributes) {
+		return nil, twirp.InvalidArgumentError(ErrAttributeExceedsLimits.Error(), strconv.Itoa(int(limits.MaxAttributesSize)))
 	}
 
 	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
@@ -237,9 +241,28 @@ func (s *RoomService) SendData(ctx context.Context, req *livekit.SendDataRequest
 	return s.roomClient.SendData(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
 }
 
+func (s *RoomService) ForwardParticipant(ctx context.Context, req *livekit.ForwardParticipantRequest) (*livekit.ForwardParticipantResponse, error) {
+	return nil, twirp.NewError(twirp.Unimplemented, "forwarding participants is not supported")
+}
+
+// MoveParticipant is routed to the node hosting the participant, which has to host the destination room too
+func (s *RoomService) MoveParticipant(ctx context.Context, req *livekit.MoveParticipantRequest) (*livekit.MoveParticipantResponse, error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity, "destinationRoom", req.DestinationRoom)
+
+	if err := EnsureDestinationRoomPermission(ctx, livekit.RoomName(req.Room), livekit.RoomName(req.DestinationRoom)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, err := s.roomStore.LoadParticipant(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)); err == ErrParticipantNotFound {
+		return nil, twirp.NotFoundError("participant not found")
+	}
+
+	return s.participantClient.MoveParticipant(ctx, s.topicFormatter.ParticipantTopic(ctx, livekit.RoomName(req.Room), livekit.ParticipantIdentity(req.Identity)), req)
+}
+
 func (s *RoomService) UpdateRoomMetadata(ctx context.Context, req *livekit.UpdateRoomMetadataRequest) (*livekit.Room, error) {
 	AppendLogFields(ctx, "room", req.Room, "size", len(req.Metadata))
-	maxMetadataSize := int(s.limitConf.MaxMetadataSize)
+	maxMetadataSize := int(s.conf.GetLimit().MaxMetadataSize)
 	if maxMetadataSize > 0 && len(req.Metadata) > maxMetadataSize {
 		return nil, twirp.InvalidArgumentError(ErrMetadataExceedsLimits.Error(), strconv.Itoa(maxMetadataSize))
 	}
@@ -261,6 +284,166 @@ func (s *RoomService) UpdateRoomMetadata(ctx context.Context, req *livekit.Updat
 	return room, nil
 }
 
+// ListLobby returns the participants waiting in the lobby of a room, from the node hosting it
+func (s *RoomService) ListLobby(ctx context.Context, req *livekit.ListParticipantsRequest) (*livekit.ListParticipantsResponse, error) {
+	AppendLogFields(ctx, "room", req.Room)
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err != nil {
+		return nil, err
+	}
+
+	return s.roomAdminClient.ListLobby(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// AdmitParticipant lets a participant waiting in the lobby of a room into the room
+func (s *RoomService) AdmitParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+	if req.Identity == "" {
+		return nil, ErrIdentityEmpty
+	}
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err != nil {
+		return nil, err
+	}
+
+	return s.roomAdminClient.AdmitParticipant(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// RejectParticipant disconnects a participant waiting in the lobby of a room
+func (s *RoomService) RejectParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.RemoveParticipantResponse, error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+	if req.Identity == "" {
+		return nil, ErrIdentityEmpty
+	}
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err != nil {
+		return nil, err
+	}
+
+	return s.roomAdminClient.RejectParticipant(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// GetStage returns the publisher slots and raised hands of a room, from the node hosting it
+func (s *RoomService) GetStage(ctx context.Context, req *StageRequest) (*StageInfo, error) {
+	AppendLogFields(ctx, "room", req.Room)
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err != nil {
+		return nil, err
+	}
+
+	return s.roomAdminClient.GetStage(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// PromoteParticipant brings a participant of a room on stage, allowing it to publish
+func (s *RoomService) PromoteParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+	if req.Identity == "" {
+		return nil, ErrIdentityEmpty
+	}
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err != nil {
+		return nil, err
+	}
+
+	return s.roomAdminClient.PromoteParticipant(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// DemoteParticipant takes a participant of a room off stage, unpublishing its tracks
+func (s *RoomService) DemoteParticipant(ctx context.Context, req *livekit.RoomParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	AppendLogFields(ctx, "room", req.Room, "participant", req.Identity)
+	if req.Identity == "" {
+		return nil, ErrIdentityEmpty
+	}
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err != nil {
+		return nil, err
+	}
+
+	return s.roomAdminClient.DemoteParticipant(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// GetChatHistory returns the chat messages a room keeps, from the node hosting it
+func (s *RoomService) GetChatHistory(ctx context.Context, req *ChatHistoryRequest) (*ChatHistoryInfo, error) {
+	AppendLogFields(ctx, "room", req.Room, "topic", req.Topic)
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err != nil {
+		return nil, err
+	}
+
+	return s.roomAdminClient.GetChatHistory(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// CreateTrackBridge makes a track of a room subscribable from another room, on the node hosting the track's room.
+// Both rooms have to be hosted on the same node.
+func (s *RoomService) CreateTrackBridge(ctx context.Context, req *CreateTrackBridgeRequest) (*TrackBridgeInfo, error) {
+	AppendLogFields(ctx, "room", req.Room, "trackID", req.TrackSid, "destinationRoom", req.DestinationRoom)
+	if req.DestinationRoom == "" {
+		return nil, ErrDestinationRoomEmpty
+	}
+	// admin of the room the track is published in, granted the one it is bridged to, and allowed to create it
+	if err := EnsureDestinationRoomPermission(ctx, livekit.RoomName(req.Room), livekit.RoomName(req.DestinationRoom)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if err := EnsureCreatePermission(ctx); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err != nil {
+		return nil, err
+	}
+
+	return s.roomAdminClient.CreateTrackBridge(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// ListTrackBridges returns the bridges from and to a room, from the node hosting it
+func (s *RoomService) ListTrackBridges(ctx context.Context, req *ListTrackBridgesRequest) (*ListTrackBridgesResponse, error) {
+	AppendLogFields(ctx, "room", req.Room)
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err != nil {
+		return nil, err
+	}
+
+	return s.roomAdminClient.ListTrackBridges(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
+// DeleteTrackBridge closes a bridge of a track published in a room, on the node hosting it
+func (s *RoomService) DeleteTrackBridge(ctx context.Context, req *DeleteTrackBridgeRequest) (*TrackBridgeInfo, error) {
+	AppendLogFields(ctx, "room", req.Room, "bridgeID", req.BridgeID)
+	if err := EnsureAdminPermission(ctx, livekit.RoomName(req.Room)); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, _, err := s.roomStore.LoadRoom(ctx, livekit.RoomName(req.Room), false); err != nil {
+		return nil, err
+	}
+
+	return s.roomAdminClient.DeleteTrackBridge(ctx, s.topicFormatter.RoomTopic(ctx, livekit.RoomName(req.Room)), req)
+}
+
 func redactCreateRoomRequest(req *livekit.CreateRoomRequest) *livekit.CreateRoomRequest {
 	if req.Egress == nil {
 		// nothing to redact
@@ -109,7 +109,7 @@ func newTestRoomService(limitConf config.LimitConfig) *TestRoomService {
 	allocator := &servicefakes.FakeRoomAllocator{}
 	store := &servicefakes.FakeServiceStore{}
 	svc, err := service.NewRoomService(
-		limitConf,
+		&config.Config{Limit: limitConf},
 		config.APIConfig{ExecutionTimeout: 2},
 		router,
 		allocator,
@@ -118,6 +118,7 @@ func newTestRoomService(limitConf config.LimitConfig) *TestRoomService {
 		rpc.NewTopicFormatter(),
 		&rpcfakes.FakeTypedRoomClient{},
 		&rpcfakes.FakeTypedParticipantClient{},
+		nil,
 	)
 	if err != nil {
 		panic(err)
@@ -49,9 +49,9 @@ type RTCService struct {
 	currentNode   routing.LocalNode
 	config        *config.Config
 	isDev         bool
-	limits        config.LimitConfig
 	parser        *uaparser.Parser
 	telemetry     telemetry.TelemetryService
+	quotas        *APIKeyQuotas
 
 	mu          sync.Mutex
 	connections map[*websocket.Conn]struct{}
@@ -64,6 +64,7 @@ func NewRTCService(
 	router routing.MessageRouter,
 	currentNode routing.LocalNode,
 	telemetry telemetry.TelemetryService,
+	quotas *APIKeyQuotas,
 ) *RTCService {
 	s := &RTCService{
 		router:        router,
@@ -72,9 +73,9 @@ func NewRTCService(
 		currentNode:   currentNode,
 		config:        conf,
 		isDev:         conf.Development,
-		limits:        conf.Limit,
 		parser:        uaparser.NewFromSaved(),
 		telemetry:     telemetry,
+		quotas:        quotas,
 		connections:   map[*websocket.Conn]struct{}{},
 	}
 
@@ -117,7 +118,7 @@ func (s *RTCService) validate(r *http.Request) (livekit.RoomName, routing.Partic
 	if claims.Identity == "" {
 		return "", pi, http.StatusBadRequest, ErrIdentityEmpty
 	}
-	if limit := s.config.Limit.MaxParticipantIdentityLength; limit > 0 && len(claims.Identity) > limit {
+	if limit := s.config.GetLimit().MaxParticipantIdentityLength; limit > 0 && len(claims.Identity) > limit {
 		return "", pi, http.StatusBadRequest, fmt.Errorf("%w: max length %d", ErrParticipantIdentityExceedsLimits, limit)
 	}
 
@@ -134,10 +135,18 @@ func (s *RTCService) validate(r *http.Request) (livekit.RoomName, routing.Partic
 	if onlyName != "" {
 		roomName = onlyName
 	}
-	if limit := s.config.Limit.MaxRoomNameLength; limit > 0 && len(roomName) > limit {
+	if limit := s.config.GetLimit().MaxRoomNameLength; limit > 0 && len(roomName) > limit {
 		return "", pi, http.StatusBadRequest, fmt.Errorf("%w: max length %d", ErrRoomNameExceedsLimits, limit)
 	}
 
+	// checked again when the session starts, failing early gives the client the reason
+	if err := checkRoomSchedule(r.Context(), s.store, roomName); err != nil {
+		if errors.Is(err, ErrRoomNotOpen) || errors.Is(err, ErrRoomExpired) {
+			return "", pi, http.StatusForbidden, err
+		}
+		return "", pi, http.StatusInternalServerError, err
+	}
+
 	// this is new connection for existing participant -  with publish only permissions
 	if publishParam != "" {
 		// Make sure grant has GetCanPublish set,
@@ -159,11 +168,18 @@ func (s *RTCService) validate(r *http.Request) (livekit.RoomName, routing.Partic
 		}
 	}
 
+	// resuming participants are already counted
+	if !boolValue(reconnectParam) {
+		if err = s.quotas.CheckJoin(r.Context(), GetAPIKey(r.Context()), roomName); err != nil {
+			return "", pi, http.StatusTooManyRequests, err
+		}
+	}
+
 	region := ""
 	if router, ok := s.router.(routing.Router); ok {
 		region = router.GetRegion()
 		if foundNode, err := router.GetNodeForRoom(r.Context(), roomName); err == nil {
-			if selector.LimitsReached(s.limits, foundNode.Stats) {
+			if selector.LimitsReached(s.config.GetLimit(), foundNode.Stats) {
 				return "", pi, http.StatusServiceUnavailable, rtc.ErrLimitExceeded
 			}
 		}
@@ -0,0 +1,390 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils/guid"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/sfu/capture"
+)
+
+const (
+	rtpCapturePath = "/rtp_capture"
+
+	rtpCaptureDirectionIngress = "ingress"
+	rtpCaptureDirectionEgress  = "egress"
+
+	rtpCaptureFileExt = ".rtpdump"
+
+	// finished captures are listed for this long after they end
+	rtpCaptureRetention = time.Hour
+)
+
+type StartRTPCaptureRequest struct {
+	Room     string `json:"room"`
+	TrackSid string `json:"track_sid"`
+	// when set, packets forwarded to this subscriber are captured instead of packets received from the publisher
+	SubscriberIdentity string `json:"subscriber_identity,omitempty"`
+	// optional, lower limits than the configured maximum
+	MaxBytes           int64  `json:"max_bytes,omitempty"`
+	MaxDurationSeconds uint32 `json:"max_duration_seconds,omitempty"`
+}
+
+type RTPCaptureInfo struct {
+	CaptureID          string `json:"capture_id"`
+	Room               string `json:"room"`
+	TrackSid           string `json:"track_sid"`
+	SubscriberIdentity string `json:"subscriber_identity,omitempty"`
+	Direction          string `json:"direction"`
+	File               string `json:"file"`
+	Active             bool   `json:"active"`
+	Bytes              int64  `json:"bytes"`
+	Packets            int64  `json:"packets"`
+	DroppedPackets     int64  `json:"dropped_packets"`
+	StartedAt          int64  `json:"started_at"`
+	EndedAt            int64  `json:"ended_at,omitempty"`
+}
+
+type rtpCapture struct {
+	id                 string
+	roomName           livekit.RoomName
+	trackID            livekit.TrackID
+	subscriberIdentity livekit.ParticipantIdentity
+	writer             *capture.Writer
+	// set once the capture is detached from the media path, protected by service lock
+	done bool
+}
+
+func (c *rtpCapture) direction() string {
+	if c.subscriberIdentity != "" {
+		return rtpCaptureDirectionEgress
+	}
+	return rtpCaptureDirectionIngress
+}
+
+func (c *rtpCapture) ToInfo() *RTPCaptureInfo {
+	stats := c.writer.Stats()
+	info := &RTPCaptureInfo{
+		CaptureID:          c.id,
+		Room:               string(c.roomName),
+		TrackSid:           string(c.trackID),
+		SubscriberIdentity: string(c.subscriberIdentity),
+		Direction:          c.direction(),
+		File:               c.writer.Path(),
+		Active:             stats.EndedAt.IsZero(),
+		Bytes:              stats.Bytes,
+		Packets:            stats.Packets,
+		DroppedPackets:     stats.Dropped,
+		StartedAt:          stats.StartedAt.UnixMilli(),
+	}
+	if !info.Active {
+		info.EndedAt = stats.EndedAt.UnixMilli()
+	}
+	return info
+}
+
+// RTPCaptureService records the RTP and RTCP of a single track to disk for debugging,
+// either as received from the publisher or as forwarded to one subscriber.
+// It is an admin API next to RoomService, captures are bounded in size and duration by config.
+type RTPCaptureService struct {
+	config      config.RTPCaptureConfig
+	roomManager *RoomManager
+
+	lock     sync.Mutex
+	captures map[string]*rtpCapture
+}
+
+func NewRTPCaptureService(conf *config.Config, roomManager *RoomManager) *RTPCaptureService {
+	return &RTPCaptureService{
+		config:      conf.RTPCapture,
+		roomManager: roomManager,
+		captures:    make(map[string]*rtpCapture),
+	}
+}
+
+func (s *RTPCaptureService) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST "+rtpCapturePath, s.handleStart)
+	mux.HandleFunc("GET "+rtpCapturePath, s.handleList)
+	mux.HandleFunc("DELETE "+rtpCapturePath+"/{capture}", s.handleStop)
+}
+
+// Stop finishes all running captures, so their files are complete
+func (s *RTPCaptureService) Stop() {
+	s.lock.Lock()
+	captures := make([]*rtpCapture, 0, len(s.captures))
+	for _, c := range s.captures {
+		captures = append(captures, c)
+	}
+	s.lock.Unlock()
+
+	for _, c := range captures {
+		_ = c.writer.Close()
+	}
+}
+
+func (s *RTPCaptureService) handleStart(w http.ResponseWriter, r *http.Request) {
+	var req StartRTPCaptureRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	roomName := livekit.RoomName(req.Room)
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	c, err := s.start(r, &req)
+	if err != nil {
+		handleError(w, r, statusForError(err), err, "room", req.Room, "trackID", req.TrackSid)
+		return
+	}
+
+	logger.Infow("RTP capture started",
+		"captureID", c.id,
+		"room", c.roomName,
+		"trackID", c.trackID,
+		"subscriber", c.subscriberIdentity,
+		"file", c.writer.Path(),
+	)
+	writeJSON(w, http.StatusCreated, c.ToInfo())
+}
+
+func (s *RTPCaptureService) handleList(w http.ResponseWriter, r *http.Request) {
+	roomName := livekit.RoomName(r.URL.Query().Get("room"))
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	s.lock.Lock()
+	s.pruneLocked()
+	infos := make([]*RTPCaptureInfo, 0, len(s.captures))
+	for _, c := range s.captures {
+		if c.roomName == roomName {
+			infos = append(infos, c.ToInfo())
+		}
+	}
+	s.lock.Unlock()
+
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func (s *RTPCaptureService) handleStop(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	c := s.captures[r.PathValue("capture")]
+	s.lock.Unlock()
+	if c == nil {
+		handleError(w, r, http.StatusNotFound, ErrRTPCaptureNotFound)
+		return
+	}
+	if err := EnsureAdminPermission(r.Context(), c.roomName); err != nil {
+		handleError(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	// waits for the file to be completely written
+	_ = c.writer.Close()
+	writeJSON(w, http.StatusOK, c.ToInfo())
+}
+
+func (s *RTPCaptureService) start(r *http.Request, req *StartRTPCaptureRequest) (*rtpCapture, error) {
+	if !s.config.IsEnabled() {
+		return nil, ErrRTPCaptureDisabled
+	}
+
+	room := s.roomManager.GetRoom(r.Context(), livekit.RoomName(req.Room))
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	c := &rtpCapture{
+		id:                 guid.New("RC_"),
+		roomName:           room.Name(),
+		trackID:            livekit.TrackID(req.TrackSid),
+		subscriberIdentity: livekit.ParticipantIdentity(req.SubscriberIdentity),
+	}
+
+	var attach func(w *capture.Writer)
+	if c.subscriberIdentity == "" {
+		track := findLocalMediaTrack(room.GetParticipants(), c.trackID)
+		if track == nil {
+			return nil, ErrTrackNotFound
+		}
+		attach = track.SetCapture
+	} else {
+		subscriber := room.GetParticipant(c.subscriberIdentity)
+		if subscriber == nil {
+			return nil, ErrParticipantNotFound
+		}
+		var subTrack types.SubscribedTrack
+		for _, st := range subscriber.GetSubscribedTracks() {
+			if st.ID() == c.trackID {
+				subTrack = st
+				break
+			}
+		}
+		if subTrack == nil {
+			return nil, ErrTrackNotFound
+		}
+		attach = subTrack.DownTrack().SetCapture
+	}
+
+	maxBytes := s.config.MaxBytes
+	if req.MaxBytes > 0 && req.MaxBytes < maxBytes {
+		maxBytes = req.MaxBytes
+	}
+	maxDuration := s.config.MaxDuration
+	if d := time.Duration(req.MaxDurationSeconds) * time.Second; d > 0 && d < maxDuration {
+		maxDuration = d
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.pruneLocked()
+	activeFiles := make(map[string]bool)
+	for _, existing := range s.captures {
+		if existing.done {
+			continue
+		}
+		if existing.roomName == c.roomName && existing.trackID == c.trackID && existing.subscriberIdentity == c.subscriberIdentity {
+			return nil, ErrRTPCaptureExists
+		}
+		activeFiles[existing.writer.Path()] = true
+	}
+	if len(activeFiles) >= s.config.MaxActive {
+		return nil, ErrRTPCaptureLimitReached
+	}
+
+	if err := os.MkdirAll(s.config.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	// make room for the new capture growing to its full size
+	limit := int64(-1)
+	if s.config.MaxTotalBytes > 0 {
+		limit = max(s.config.MaxTotalBytes-maxBytes, 0)
+	}
+	total, err := pruneRTPCaptureFiles(s.config.Dir, activeFiles, s.config.MaxAge, limit)
+	if err != nil {
+		return nil, err
+	}
+	if limit >= 0 && total > limit {
+		return nil, ErrRTPCaptureDirFull
+	}
+
+	writer, err := capture.NewWriter(capture.WriterParams{
+		Path:        filepath.Join(s.config.Dir, fmt.Sprintf("%s_%s_%s%s", c.id, c.trackID, c.direction(), rtpCaptureFileExt)),
+		MaxBytes:    maxBytes,
+		MaxDuration: maxDuration,
+		Logger:      logger.GetLogger(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.writer = writer
+	writer.OnDone(func() {
+		attach(nil)
+
+		s.lock.Lock()
+		c.done = true
+		s.lock.Unlock()
+		logger.Infow("RTP capture ended", "captureID", c.id, "room", c.roomName, "trackID", c.trackID)
+	})
+	attach(writer)
+
+	s.captures[c.id] = c
+	return c, nil
+}
+
+func (s *RTPCaptureService) pruneLocked() {
+	for id, c := range s.captures {
+		if endedAt := c.writer.Stats().EndedAt; !endedAt.IsZero() && time.Since(endedAt) > rtpCaptureRetention {
+			delete(s.captures, id)
+		}
+	}
+}
+
+// pruneRTPCaptureFiles deletes the capture files in dir older than maxAge, then the oldest ones until their total size
+// is at most maxTotalBytes, skipping the files of running captures. A negative maxTotalBytes disables the size limit.
+// It returns the size of the files left.
+func pruneRTPCaptureFiles(dir string, active map[string]bool, maxAge time.Duration, maxTotalBytes int64) (int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	type captureFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var (
+		files []captureFile
+		total int64
+	)
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != rtpCaptureFileExt {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		total += fi.Size()
+		path := filepath.Join(dir, e.Name())
+		if !active[path] {
+			files = append(files, captureFile{path: path, size: fi.Size(), modTime: fi.ModTime()})
+		}
+	}
+	slices.SortFunc(files, func(a, b captureFile) int {
+		return a.modTime.Compare(b.modTime)
+	})
+
+	for _, f := range files {
+		expired := maxAge > 0 && time.Since(f.modTime) > maxAge
+		if !expired && (maxTotalBytes < 0 || total <= maxTotalBytes) {
+			break
+		}
+		if err := os.Remove(f.path); err != nil {
+			logger.Warnw("could not delete RTP capture file", err, "file", f.path)
+			continue
+		}
+		logger.Infow("deleted RTP capture file", "file", f.path, "bytes", f.size, "expired", expired)
+		total -= f.size
+	}
+	return total, nil
+}
+
+func findLocalMediaTrack(participants []types.LocalParticipant, trackID livekit.TrackID) types.LocalMediaTrack {
+	for _, p := range participants {
+		if track, ok := p.GetPublishedTrack(trackID).(types.LocalMediaTrack); ok {
+			return track
+		}
+	}
+	return nil
+}
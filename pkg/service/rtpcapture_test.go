@@ -0,0 +1,79 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneRTPCaptureFiles(t *testing.T) {
+	writeFiles := func(t *testing.T, dir string) {
+		now := time.Now()
+		for i, name := range []string{"a.rtpdump", "b.rtpdump", "c.rtpdump"} {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(path, make([]byte, 100), 0o644))
+			// a is the oldest
+			modTime := now.Add(time.Duration(i-3) * time.Hour)
+			require.NoError(t, os.Chtimes(path, modTime, modTime))
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), make([]byte, 1000), 0o644))
+	}
+	exists := func(dir, name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	t.Run("deletes oldest files over the size limit", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir)
+
+		total, err := pruneRTPCaptureFiles(dir, nil, 0, 150)
+		require.NoError(t, err)
+		require.Equal(t, int64(100), total)
+		require.False(t, exists(dir, "a.rtpdump"))
+		require.False(t, exists(dir, "b.rtpdump"))
+		require.True(t, exists(dir, "c.rtpdump"))
+		// other files are left alone
+		require.True(t, exists(dir, "notes.txt"))
+	})
+
+	t.Run("keeps files of running captures", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir)
+
+		total, err := pruneRTPCaptureFiles(dir, map[string]bool{filepath.Join(dir, "a.rtpdump"): true}, 0, 0)
+		require.NoError(t, err)
+		require.Equal(t, int64(100), total)
+		require.True(t, exists(dir, "a.rtpdump"))
+		require.False(t, exists(dir, "b.rtpdump"))
+		require.False(t, exists(dir, "c.rtpdump"))
+	})
+
+	t.Run("deletes expired files", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir)
+
+		total, err := pruneRTPCaptureFiles(dir, nil, 150*time.Minute, -1)
+		require.NoError(t, err)
+		require.Equal(t, int64(200), total)
+		require.False(t, exists(dir, "a.rtpdump"))
+		require.True(t, exists(dir, "b.rtpdump"))
+	})
+}
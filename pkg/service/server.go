@@ -25,6 +25,7 @@ import (
 	"runtime"
 	"runtime/pprof"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/pion/turn/v4"
@@ -38,26 +39,39 @@ import (
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
 	"github.com/livekit/livekit-server/version"
-	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 )
 
 type LivekitServer struct {
-	config       *config.Config
-	ioService    *IOInfoService
-	rtcService   *RTCService
-	agentService *AgentService
-	httpServer   *http.Server
-	promServer   *http.Server
-	router       routing.Router
-	roomManager  *RoomManager
-	signalServer *SignalServer
-	turnServer   *turn.Server
-	currentNode  routing.LocalNode
-	running      atomic.Bool
-	doneChan     chan struct{}
-	closedChan   chan struct{}
+	config              *config.Config
+	ioService           *IOInfoService
+	rtcService          *RTCService
+	agentService        *AgentService
+	rtpCaptureService   *RTPCaptureService
+	bridgeService       *TrackBridgeService
+	policyService       *SubscriptionPolicyService
+	lobbyService        *LobbyService
+	stageService        *StageService
+	chatHistoryService  *ChatHistoryService
+	roomScheduleService *RoomScheduleService
+	webhookService      *WebhookService
+	httpServer          *http.Server
+	promServer          *http.Server
+	router              routing.Router
+	roomManager         *RoomManager
+	signalServer        *SignalServer
+	turnServer          *turn.Server
+	currentNode         routing.LocalNode
+	running             atomic.Bool
+	doneChan            chan struct{}
+	closedChan          chan struct{}
+
+	keyProvider *ReloadableKeyProvider
+	quotas      *APIKeyQuotas
+	reloadLock  sync.Mutex
+	// config the last reload was compared against
+	appliedConfig *config.Config
 }
 
 func NewLivekitServer(conf *config.Config,
@@ -68,8 +82,20 @@ func NewLivekitServer(conf *config.Config,
 	sipService *SIPService,
 	ioService *IOInfoService,
 	rtcService *RTCService,
+	whipService *WHIPService,
+	whepService *WHEPService,
+	rtpCaptureService *RTPCaptureService,
+	bridgeService *TrackBridgeService,
+	policyService *SubscriptionPolicyService,
+	lobbyService *LobbyService,
+	stageService *StageService,
+	chatHistoryService *ChatHistoryService,
+	roomScheduleService *RoomScheduleService,
+	webhookService *WebhookService,
 	agentService *AgentService,
-	keyProvider auth.KeyProvider,
+	keyProvider *ReloadableKeyProvider,
+	rateLimiter *RateLimiter,
+	quotas *APIKeyQuotas,
 	router routing.Router,
 	roomManager *RoomManager,
 	signalServer *SignalServer,
@@ -77,17 +103,29 @@ func NewLivekitServer(conf *config.Config,
 	currentNode routing.LocalNode,
 ) (s *LivekitServer, err error) {
 	s = &LivekitServer{
-		config:       conf,
-		ioService:    ioService,
-		rtcService:   rtcService,
-		agentService: agentService,
-		router:       router,
-		roomManager:  roomManager,
-		signalServer: signalServer,
+		config:              conf,
+		ioService:           ioService,
+		rtcService:          rtcService,
+		agentService:        agentService,
+		rtpCaptureService:   rtpCaptureService,
+		bridgeService:       bridgeService,
+		policyService:       policyService,
+		lobbyService:        lobbyService,
+		stageService:        stageService,
+		chatHistoryService:  chatHistoryService,
+		roomScheduleService: roomScheduleService,
+		webhookService:      webhookService,
+		router:              router,
+		roomManager:         roomManager,
+		signalServer:        signalServer,
 		// turn server starts automatically
 		turnServer:  turnServer,
 		currentNode: currentNode,
 		closedChan:  make(chan struct{}),
+
+		keyProvider:   keyProvider,
+		quotas:        quotas,
+		appliedConfig: conf,
 	}
 
 	middlewares := []negroni.Handler{
@@ -99,13 +137,16 @@ func NewLivekitServer(conf *config.Config,
 				return true
 			},
 			AllowedHeaders: []string{"*"},
+			AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodHead, http.MethodPatch, http.MethodDelete},
+			// WHIP clients need the session resource URL
+			ExposedHeaders: []string{"Location"},
 			// allow preflight to be cached for a day
 			MaxAge: 86400,
 		}),
 		negroni.HandlerFunc(RemoveDoubleSlashes),
 	}
 	if keyProvider != nil {
-		middlewares = append(middlewares, NewAPIKeyAuthMiddleware(keyProvider))
+		middlewares = append(middlewares, NewAPIKeyAuthMiddleware(keyProvider, rateLimiter))
 	}
 
 	serverOptions := []interface{}{
@@ -136,6 +177,16 @@ func NewLivekitServer(conf *config.Config,
 	mux.Handle("/rtc", rtcService)
 	mux.Handle("/agent", agentService)
 	mux.HandleFunc("/rtc/validate", rtcService.Validate)
+	whipService.SetupRoutes(mux)
+	whepService.SetupRoutes(mux)
+	rtpCaptureService.SetupRoutes(mux)
+	bridgeService.SetupRoutes(mux)
+	policyService.SetupRoutes(mux)
+	lobbyService.SetupRoutes(mux)
+	stageService.SetupRoutes(mux)
+	chatHistoryService.SetupRoutes(mux)
+	roomScheduleService.SetupRoutes(mux)
+	webhookService.SetupRoutes(mux)
 	mux.HandleFunc("/", s.defaultHandler)
 
 	s.httpServer = &http.Server{
@@ -202,6 +253,10 @@ func (s *LivekitServer) Start() error {
 		return err
 	}
 
+	if err := s.roomManager.RestoreRooms(context.Background()); err != nil {
+		return err
+	}
+
 	addresses := s.config.BindAddresses
 	if addresses == nil {
 		addresses = []string{""}
@@ -299,6 +354,8 @@ func (s *LivekitServer) Start() error {
 	s.roomManager.Stop()
 	s.signalServer.Stop()
 	s.ioService.Stop()
+	s.rtpCaptureService.Stop()
+	s.webhookService.Stop()
 
 	close(s.closedChan)
 	return nil
@@ -321,6 +378,7 @@ func (s *LivekitServer) Stop(force bool) {
 	}
 
 	s.router.Stop()
+	s.quotas.Stop()
 	close(s.doneChan)
 
 	// wait for fully closed
@@ -36,6 +36,18 @@ type FakeObjectStore struct {
 	deleteRoomReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DeleteRoomScheduleStub        func(context.Context, livekit.RoomName) error
+	deleteRoomScheduleMutex       sync.RWMutex
+	deleteRoomScheduleArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	deleteRoomScheduleReturns struct {
+		result1 error
+	}
+	deleteRoomScheduleReturnsOnCall map[int]struct {
+		result1 error
+	}
 	ListParticipantsStub        func(context.Context, livekit.RoomName) ([]*livekit.ParticipantInfo, error)
 	listParticipantsMutex       sync.RWMutex
 	listParticipantsArgsForCall []struct {
@@ -96,6 +108,20 @@ type FakeObjectStore struct {
 		result2 *livekit.RoomInternal
 		result3 error
 	}
+	LoadRoomScheduleStub        func(context.Context, livekit.RoomName) (*service.RoomSchedule, error)
+	loadRoomScheduleMutex       sync.RWMutex
+	loadRoomScheduleArgsForCall []struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}
+	loadRoomScheduleReturns struct {
+		result1 *service.RoomSchedule
+		result2 error
+	}
+	loadRoomScheduleReturnsOnCall map[int]struct {
+		result1 *service.RoomSchedule
+		result2 error
+	}
 	LockRoomStub        func(context.Context, livekit.RoomName, time.Duration) (string, error)
 	lockRoomMutex       sync.RWMutex
 	lockRoomArgsForCall []struct {
@@ -137,6 +163,18 @@ type FakeObjectStore struct {
 	storeRoomReturnsOnCall map[int]struct {
 		result1 error
 	}
+	StoreRoomScheduleStub        func(context.Context, *service.RoomSchedule) error
+	storeRoomScheduleMutex       sync.RWMutex
+	storeRoomScheduleArgsForCall []struct {
+		arg1 context.Context
+		arg2 *service.RoomSchedule
+	}
+	storeRoomScheduleReturns struct {
+		result1 error
+	}
+	storeRoomScheduleReturnsOnCall map[int]struct {
+		result1 error
+	}
 	UnlockRoomStub        func(context.Context, livekit.RoomName, string) error
 	unlockRoomMutex       sync.RWMutex
 	unlockRoomArgsForCall []struct {
@@ -279,6 +317,68 @@ func (fake *FakeObjectStore) DeleteRoomReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeObjectStore) DeleteRoomSchedule(arg1 context.Context, arg2 livekit.RoomName) error {
+	fake.deleteRoomScheduleMutex.Lock()
+	ret, specificReturn := fake.deleteRoomScheduleReturnsOnCall[len(fake.deleteRoomScheduleArgsForCall)]
+	fake.deleteRoomScheduleArgsForCall = append(fake.deleteRoomScheduleArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.DeleteRoomScheduleStub
+	fakeReturns := fake.deleteRoomScheduleReturns
+	fake.recordInvocation("DeleteRoomSchedule", []interface{}{arg1, arg2})
+	fake.deleteRoomScheduleMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) DeleteRoomScheduleCallCount() int {
+	fake.deleteRoomScheduleMutex.RLock()
+	defer fake.deleteRoomScheduleMutex.RUnlock()
+	return len(fake.deleteRoomScheduleArgsForCall)
+}
+
+func (fake *FakeObjectStore) DeleteRoomScheduleCalls(stub func(context.Context, livekit.RoomName) error) {
+	fake.deleteRoomScheduleMutex.Lock()
+	defer fake.deleteRoomScheduleMutex.Unlock()
+	fake.DeleteRoomScheduleStub = stub
+}
+
+func (fake *FakeObjectStore) DeleteRoomScheduleArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.deleteRoomScheduleMutex.RLock()
+	defer fake.deleteRoomScheduleMutex.RUnlock()
+	argsForCall := fake.deleteRoomScheduleArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) DeleteRoomScheduleReturns(result1 error) {
+	fake.deleteRoomScheduleMutex.Lock()
+	defer fake.deleteRoomScheduleMutex.Unlock()
+	fake.DeleteRoomScheduleStub = nil
+	fake.deleteRoomScheduleReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) DeleteRoomScheduleReturnsOnCall(i int, result1 error) {
+	fake.deleteRoomScheduleMutex.Lock()
+	defer fake.deleteRoomScheduleMutex.Unlock()
+	fake.DeleteRoomScheduleStub = nil
+	if fake.deleteRoomScheduleReturnsOnCall == nil {
+		fake.deleteRoomScheduleReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteRoomScheduleReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeObjectStore) ListParticipants(arg1 context.Context, arg2 livekit.RoomName) ([]*livekit.ParticipantInfo, error) {
 	fake.listParticipantsMutex.Lock()
 	ret, specificReturn := fake.listParticipantsReturnsOnCall[len(fake.listParticipantsArgsForCall)]
@@ -549,6 +649,71 @@ func (fake *FakeObjectStore) LoadRoomReturnsOnCall(i int, result1 *livekit.Room,
 	}{result1, result2, result3}
 }
 
+func (fake *FakeObjectStore) LoadRoomSchedule(arg1 context.Context, arg2 livekit.RoomName) (*service.RoomSchedule, error) {
+	fake.loadRoomScheduleMutex.Lock()
+	ret, specificReturn := fake.loadRoomScheduleReturnsOnCall[len(fake.loadRoomScheduleArgsForCall)]
+	fake.loadRoomScheduleArgsForCall = append(fake.loadRoomScheduleArgsForCall, struct {
+		arg1 context.Context
+		arg2 livekit.RoomName
+	}{arg1, arg2})
+	stub := fake.LoadRoomScheduleStub
+	fakeReturns := fake.loadRoomScheduleReturns
+	fake.recordInvocation("LoadRoomSchedule", []interface{}{arg1, arg2})
+	fake.loadRoomScheduleMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeObjectStore) LoadRoomScheduleCallCount() int {
+	fake.loadRoomScheduleMutex.RLock()
+	defer fake.loadRoomScheduleMutex.RUnlock()
+	return len(fake.loadRoomScheduleArgsForCall)
+}
+
+func (fake *FakeObjectStore) LoadRoomScheduleCalls(stub func(context.Context, livekit.RoomName) (*service.RoomSchedule, error)) {
+	fake.loadRoomScheduleMutex.Lock()
+	defer fake.loadRoomScheduleMutex.Unlock()
+	fake.LoadRoomScheduleStub = stub
+}
+
+func (fake *FakeObjectStore) LoadRoomScheduleArgsForCall(i int) (context.Context, livekit.RoomName) {
+	fake.loadRoomScheduleMutex.RLock()
+	defer fake.loadRoomScheduleMutex.RUnlock()
+	argsForCall := fake.loadRoomScheduleArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) LoadRoomScheduleReturns(result1 *service.RoomSchedule, result2 error) {
+	fake.loadRoomScheduleMutex.Lock()
+	defer fake.loadRoomScheduleMutex.Unlock()
+	fake.LoadRoomScheduleStub = nil
+	fake.loadRoomScheduleReturns = struct {
+		result1 *service.RoomSchedule
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeObjectStore) LoadRoomScheduleReturnsOnCall(i int, result1 *service.RoomSchedule, result2 error) {
+	fake.loadRoomScheduleMutex.Lock()
+	defer fake.loadRoomScheduleMutex.Unlock()
+	fake.LoadRoomScheduleStub = nil
+	if fake.loadRoomScheduleReturnsOnCall == nil {
+		fake.loadRoomScheduleReturnsOnCall = make(map[int]struct {
+			result1 *service.RoomSchedule
+			result2 error
+		})
+	}
+	fake.loadRoomScheduleReturnsOnCall[i] = struct {
+		result1 *service.RoomSchedule
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeObjectStore) LockRoom(arg1 context.Context, arg2 livekit.RoomName, arg3 time.Duration) (string, error) {
 	fake.lockRoomMutex.Lock()
 	ret, specificReturn := fake.lockRoomReturnsOnCall[len(fake.lockRoomArgsForCall)]
@@ -741,6 +906,68 @@ func (fake *FakeObjectStore) StoreRoomReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeObjectStore) StoreRoomSchedule(arg1 context.Context, arg2 *service.RoomSchedule) error {
+	fake.storeRoomScheduleMutex.Lock()
+	ret, specificReturn := fake.storeRoomScheduleReturnsOnCall[len(fake.storeRoomScheduleArgsForCall)]
+	fake.storeRoomScheduleArgsForCall = append(fake.storeRoomScheduleArgsForCall, struct {
+		arg1 context.Context
+		arg2 *service.RoomSchedule
+	}{arg1, arg2})
+	stub := fake.StoreRoomScheduleStub
+	fakeReturns := fake.storeRoomScheduleReturns
+	fake.recordInvocation("StoreRoomSchedule", []interface{}{arg1, arg2})
+	fake.storeRoomScheduleMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeObjectStore) StoreRoomScheduleCallCount() int {
+	fake.storeRoomScheduleMutex.RLock()
+	defer fake.storeRoomScheduleMutex.RUnlock()
+	return len(fake.storeRoomScheduleArgsForCall)
+}
+
+func (fake *FakeObjectStore) StoreRoomScheduleCalls(stub func(context.Context, *service.RoomSchedule) error) {
+	fake.storeRoomScheduleMutex.Lock()
+	defer fake.storeRoomScheduleMutex.Unlock()
+	fake.StoreRoomScheduleStub = stub
+}
+
+func (fake *FakeObjectStore) StoreRoomScheduleArgsForCall(i int) (context.Context, *service.RoomSchedule) {
+	fake.storeRoomScheduleMutex.RLock()
+	defer fake.storeRoomScheduleMutex.RUnlock()
+	argsForCall := fake.storeRoomScheduleArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObjectStore) StoreRoomScheduleReturns(result1 error) {
+	fake.storeRoomScheduleMutex.Lock()
+	defer fake.storeRoomScheduleMutex.Unlock()
+	fake.StoreRoomScheduleStub = nil
+	fake.storeRoomScheduleReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeObjectStore) StoreRoomScheduleReturnsOnCall(i int, result1 error) {
+	fake.storeRoomScheduleMutex.Lock()
+	defer fake.storeRoomScheduleMutex.Unlock()
+	fake.StoreRoomScheduleStub = nil
+	if fake.storeRoomScheduleReturnsOnCall == nil {
+		fake.storeRoomScheduleReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeRoomScheduleReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeObjectStore) UnlockRoom(arg1 context.Context, arg2 livekit.RoomName, arg3 string) error {
 	fake.unlockRoomMutex.Lock()
 	ret, specificReturn := fake.unlockRoomReturnsOnCall[len(fake.unlockRoomArgsForCall)]
@@ -811,6 +1038,8 @@ func (fake *FakeObjectStore) Invocations() map[string][][]interface{} {
 	defer fake.deleteParticipantMutex.RUnlock()
 	fake.deleteRoomMutex.RLock()
 	defer fake.deleteRoomMutex.RUnlock()
+	fake.deleteRoomScheduleMutex.RLock()
+	defer fake.deleteRoomScheduleMutex.RUnlock()
 	fake.listParticipantsMutex.RLock()
 	defer fake.listParticipantsMutex.RUnlock()
 	fake.listRoomsMutex.RLock()
@@ -819,12 +1048,16 @@ func (fake *FakeObjectStore) Invocations() map[string][][]interface{} {
 	defer fake.loadParticipantMutex.RUnlock()
 	fake.loadRoomMutex.RLock()
 	defer fake.loadRoomMutex.RUnlock()
+	fake.loadRoomScheduleMutex.RLock()
+	defer fake.loadRoomScheduleMutex.RUnlock()
 	fake.lockRoomMutex.RLock()
 	defer fake.lockRoomMutex.RUnlock()
 	fake.storeParticipantMutex.RLock()
 	defer fake.storeParticipantMutex.RUnlock()
 	fake.storeRoomMutex.RLock()
 	defer fake.storeRoomMutex.RUnlock()
+	fake.storeRoomScheduleMutex.RLock()
+	defer fake.storeRoomScheduleMutex.RUnlock()
 	fake.unlockRoomMutex.RLock()
 	defer fake.unlockRoomMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
@@ -0,0 +1,374 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package servicefakes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+type FakeQuotaStore struct {
+	AcquireQuotaStub        func(context.Context, string, string, int, time.Time) (bool, error)
+	acquireQuotaMutex       sync.RWMutex
+	acquireQuotaArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 int
+		arg5 time.Time
+	}
+	acquireQuotaReturns struct {
+		result1 bool
+		result2 error
+	}
+	acquireQuotaReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	CheckQuotaStub        func(context.Context, string, string, int) (bool, error)
+	checkQuotaMutex       sync.RWMutex
+	checkQuotaArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 int
+	}
+	checkQuotaReturns struct {
+		result1 bool
+		result2 error
+	}
+	checkQuotaReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	ReleaseQuotaStub        func(context.Context, string, string) error
+	releaseQuotaMutex       sync.RWMutex
+	releaseQuotaArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	releaseQuotaReturns struct {
+		result1 error
+	}
+	releaseQuotaReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RenewQuotaStub        func(context.Context, string, []string, time.Time) error
+	renewQuotaMutex       sync.RWMutex
+	renewQuotaArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 []string
+		arg4 time.Time
+	}
+	renewQuotaReturns struct {
+		result1 error
+	}
+	renewQuotaReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeQuotaStore) AcquireQuota(arg1 context.Context, arg2 string, arg3 string, arg4 int, arg5 time.Time) (bool, error) {
+	fake.acquireQuotaMutex.Lock()
+	ret, specificReturn := fake.acquireQuotaReturnsOnCall[len(fake.acquireQuotaArgsForCall)]
+	fake.acquireQuotaArgsForCall = append(fake.acquireQuotaArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 int
+		arg5 time.Time
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.AcquireQuotaStub
+	fakeReturns := fake.acquireQuotaReturns
+	fake.recordInvocation("AcquireQuota", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.acquireQuotaMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeQuotaStore) AcquireQuotaCallCount() int {
+	fake.acquireQuotaMutex.RLock()
+	defer fake.acquireQuotaMutex.RUnlock()
+	return len(fake.acquireQuotaArgsForCall)
+}
+
+func (fake *FakeQuotaStore) AcquireQuotaCalls(stub func(context.Context, string, string, int, time.Time) (bool, error)) {
+	fake.acquireQuotaMutex.Lock()
+	defer fake.acquireQuotaMutex.Unlock()
+	fake.AcquireQuotaStub = stub
+}
+
+func (fake *FakeQuotaStore) AcquireQuotaArgsForCall(i int) (context.Context, string, string, int, time.Time) {
+	fake.acquireQuotaMutex.RLock()
+	defer fake.acquireQuotaMutex.RUnlock()
+	argsForCall := fake.acquireQuotaArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeQuotaStore) AcquireQuotaReturns(result1 bool, result2 error) {
+	fake.acquireQuotaMutex.Lock()
+	defer fake.acquireQuotaMutex.Unlock()
+	fake.AcquireQuotaStub = nil
+	fake.acquireQuotaReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeQuotaStore) AcquireQuotaReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.acquireQuotaMutex.Lock()
+	defer fake.acquireQuotaMutex.Unlock()
+	fake.AcquireQuotaStub = nil
+	if fake.acquireQuotaReturnsOnCall == nil {
+		fake.acquireQuotaReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.acquireQuotaReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeQuotaStore) CheckQuota(arg1 context.Context, arg2 string, arg3 string, arg4 int) (bool, error) {
+	fake.checkQuotaMutex.Lock()
+	ret, specificReturn := fake.checkQuotaReturnsOnCall[len(fake.checkQuotaArgsForCall)]
+	fake.checkQuotaArgsForCall = append(fake.checkQuotaArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 int
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.CheckQuotaStub
+	fakeReturns := fake.checkQuotaReturns
+	fake.recordInvocation("CheckQuota", []interface{}{arg1, arg2, arg3, arg4})
+	fake.checkQuotaMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeQuotaStore) CheckQuotaCallCount() int {
+	fake.checkQuotaMutex.RLock()
+	defer fake.checkQuotaMutex.RUnlock()
+	return len(fake.checkQuotaArgsForCall)
+}
+
+func (fake *FakeQuotaStore) CheckQuotaCalls(stub func(context.Context, string, string, int) (bool, error)) {
+	fake.checkQuotaMutex.Lock()
+	defer fake.checkQuotaMutex.Unlock()
+	fake.CheckQuotaStub = stub
+}
+
+func (fake *FakeQuotaStore) CheckQuotaArgsForCall(i int) (context.Context, string, string, int) {
+	fake.checkQuotaMutex.RLock()
+	defer fake.checkQuotaMutex.RUnlock()
+	argsForCall := fake.checkQuotaArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeQuotaStore) CheckQuotaReturns(result1 bool, result2 error) {
+	fake.checkQuotaMutex.Lock()
+	defer fake.checkQuotaMutex.Unlock()
+	fake.CheckQuotaStub = nil
+	fake.checkQuotaReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeQuotaStore) CheckQuotaReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.checkQuotaMutex.Lock()
+	defer fake.checkQuotaMutex.Unlock()
+	fake.CheckQuotaStub = nil
+	if fake.checkQuotaReturnsOnCall == nil {
+		fake.checkQuotaReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.checkQuotaReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeQuotaStore) ReleaseQuota(arg1 context.Context, arg2 string, arg3 string) error {
+	fake.releaseQuotaMutex.Lock()
+	ret, specificReturn := fake.releaseQuotaReturnsOnCall[len(fake.releaseQuotaArgsForCall)]
+	fake.releaseQuotaArgsForCall = append(fake.releaseQuotaArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.ReleaseQuotaStub
+	fakeReturns := fake.releaseQuotaReturns
+	fake.recordInvocation("ReleaseQuota", []interface{}{arg1, arg2, arg3})
+	fake.releaseQuotaMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeQuotaStore) ReleaseQuotaCallCount() int {
+	fake.releaseQuotaMutex.RLock()
+	defer fake.releaseQuotaMutex.RUnlock()
+	return len(fake.releaseQuotaArgsForCall)
+}
+
+func (fake *FakeQuotaStore) ReleaseQuotaCalls(stub func(context.Context, string, string) error) {
+	fake.releaseQuotaMutex.Lock()
+	defer fake.releaseQuotaMutex.Unlock()
+	fake.ReleaseQuotaStub = stub
+}
+
+func (fake *FakeQuotaStore) ReleaseQuotaArgsForCall(i int) (context.Context, string, string) {
+	fake.releaseQuotaMutex.RLock()
+	defer fake.releaseQuotaMutex.RUnlock()
+	argsForCall := fake.releaseQuotaArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeQuotaStore) ReleaseQuotaReturns(result1 error) {
+	fake.releaseQuotaMutex.Lock()
+	defer fake.releaseQuotaMutex.Unlock()
+	fake.ReleaseQuotaStub = nil
+	fake.releaseQuotaReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeQuotaStore) ReleaseQuotaReturnsOnCall(i int, result1 error) {
+	fake.releaseQuotaMutex.Lock()
+	defer fake.releaseQuotaMutex.Unlock()
+	fake.ReleaseQuotaStub = nil
+	if fake.releaseQuotaReturnsOnCall == nil {
+		fake.releaseQuotaReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.releaseQuotaReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeQuotaStore) RenewQuota(arg1 context.Context, arg2 string, arg3 []string, arg4 time.Time) error {
+	var arg3Copy []string
+	if arg3 != nil {
+		arg3Copy = make([]string, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.renewQuotaMutex.Lock()
+	ret, specificReturn := fake.renewQuotaReturnsOnCall[len(fake.renewQuotaArgsForCall)]
+	fake.renewQuotaArgsForCall = append(fake.renewQuotaArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 []string
+		arg4 time.Time
+	}{arg1, arg2, arg3Copy, arg4})
+	stub := fake.RenewQuotaStub
+	fakeReturns := fake.renewQuotaReturns
+	fake.recordInvocation("RenewQuota", []interface{}{arg1, arg2, arg3Copy, arg4})
+	fake.renewQuotaMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeQuotaStore) RenewQuotaCallCount() int {
+	fake.renewQuotaMutex.RLock()
+	defer fake.renewQuotaMutex.RUnlock()
+	return len(fake.renewQuotaArgsForCall)
+}
+
+func (fake *FakeQuotaStore) RenewQuotaCalls(stub func(context.Context, string, []string, time.Time) error) {
+	fake.renewQuotaMutex.Lock()
+	defer fake.renewQuotaMutex.Unlock()
+	fake.RenewQuotaStub = stub
+}
+
+func (fake *FakeQuotaStore) RenewQuotaArgsForCall(i int) (context.Context, string, []string, time.Time) {
+	fake.renewQuotaMutex.RLock()
+	defer fake.renewQuotaMutex.RUnlock()
+	argsForCall := fake.renewQuotaArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeQuotaStore) RenewQuotaReturns(result1 error) {
+	fake.renewQuotaMutex.Lock()
+	defer fake.renewQuotaMutex.Unlock()
+	fake.RenewQuotaStub = nil
+	fake.renewQuotaReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeQuotaStore) RenewQuotaReturnsOnCall(i int, result1 error) {
+	fake.renewQuotaMutex.Lock()
+	defer fake.renewQuotaMutex.Unlock()
+	fake.RenewQuotaStub = nil
+	if fake.renewQuotaReturnsOnCall == nil {
+		fake.renewQuotaReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.renewQuotaReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeQuotaStore) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.acquireQuotaMutex.RLock()
+	defer fake.acquireQuotaMutex.RUnlock()
+	fake.checkQuotaMutex.RLock()
+	defer fake.checkQuotaMutex.RUnlock()
+	fake.releaseQuotaMutex.RLock()
+	defer fake.releaseQuotaMutex.RUnlock()
+	fake.renewQuotaMutex.RLock()
+	defer fake.renewQuotaMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeQuotaStore) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ service.QuotaStore = new(FakeQuotaStore)
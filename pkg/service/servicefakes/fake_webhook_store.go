@@ -0,0 +1,433 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package servicefakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+type FakeWebhookStore struct {
+	ClaimWebhookDeliveryStub        func(context.Context, *service.WebhookDelivery) (bool, error)
+	claimWebhookDeliveryMutex       sync.RWMutex
+	claimWebhookDeliveryArgsForCall []struct {
+		arg1 context.Context
+		arg2 *service.WebhookDelivery
+	}
+	claimWebhookDeliveryReturns struct {
+		result1 bool
+		result2 error
+	}
+	claimWebhookDeliveryReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	DeleteWebhookDeliveryStub        func(context.Context, string) error
+	deleteWebhookDeliveryMutex       sync.RWMutex
+	deleteWebhookDeliveryArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	deleteWebhookDeliveryReturns struct {
+		result1 error
+	}
+	deleteWebhookDeliveryReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ListWebhookDeliveriesStub        func(context.Context, bool) ([]*service.WebhookDelivery, error)
+	listWebhookDeliveriesMutex       sync.RWMutex
+	listWebhookDeliveriesArgsForCall []struct {
+		arg1 context.Context
+		arg2 bool
+	}
+	listWebhookDeliveriesReturns struct {
+		result1 []*service.WebhookDelivery
+		result2 error
+	}
+	listWebhookDeliveriesReturnsOnCall map[int]struct {
+		result1 []*service.WebhookDelivery
+		result2 error
+	}
+	LoadWebhookDeliveryStub        func(context.Context, string) (*service.WebhookDelivery, error)
+	loadWebhookDeliveryMutex       sync.RWMutex
+	loadWebhookDeliveryArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	loadWebhookDeliveryReturns struct {
+		result1 *service.WebhookDelivery
+		result2 error
+	}
+	loadWebhookDeliveryReturnsOnCall map[int]struct {
+		result1 *service.WebhookDelivery
+		result2 error
+	}
+	StoreWebhookDeliveryStub        func(context.Context, *service.WebhookDelivery) error
+	storeWebhookDeliveryMutex       sync.RWMutex
+	storeWebhookDeliveryArgsForCall []struct {
+		arg1 context.Context
+		arg2 *service.WebhookDelivery
+	}
+	storeWebhookDeliveryReturns struct {
+		result1 error
+	}
+	storeWebhookDeliveryReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeWebhookStore) ClaimWebhookDelivery(arg1 context.Context, arg2 *service.WebhookDelivery) (bool, error) {
+	fake.claimWebhookDeliveryMutex.Lock()
+	ret, specificReturn := fake.claimWebhookDeliveryReturnsOnCall[len(fake.claimWebhookDeliveryArgsForCall)]
+	fake.claimWebhookDeliveryArgsForCall = append(fake.claimWebhookDeliveryArgsForCall, struct {
+		arg1 context.Context
+		arg2 *service.WebhookDelivery
+	}{arg1, arg2})
+	stub := fake.ClaimWebhookDeliveryStub
+	fakeReturns := fake.claimWebhookDeliveryReturns
+	fake.recordInvocation("ClaimWebhookDelivery", []interface{}{arg1, arg2})
+	fake.claimWebhookDeliveryMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeWebhookStore) ClaimWebhookDeliveryCallCount() int {
+	fake.claimWebhookDeliveryMutex.RLock()
+	defer fake.claimWebhookDeliveryMutex.RUnlock()
+	return len(fake.claimWebhookDeliveryArgsForCall)
+}
+
+func (fake *FakeWebhookStore) ClaimWebhookDeliveryCalls(stub func(context.Context, *service.WebhookDelivery) (bool, error)) {
+	fake.claimWebhookDeliveryMutex.Lock()
+	defer fake.claimWebhookDeliveryMutex.Unlock()
+	fake.ClaimWebhookDeliveryStub = stub
+}
+
+func (fake *FakeWebhookStore) ClaimWebhookDeliveryArgsForCall(i int) (context.Context, *service.WebhookDelivery) {
+	fake.claimWebhookDeliveryMutex.RLock()
+	defer fake.claimWebhookDeliveryMutex.RUnlock()
+	argsForCall := fake.claimWebhookDeliveryArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeWebhookStore) ClaimWebhookDeliveryReturns(result1 bool, result2 error) {
+	fake.claimWebhookDeliveryMutex.Lock()
+	defer fake.claimWebhookDeliveryMutex.Unlock()
+	fake.ClaimWebhookDeliveryStub = nil
+	fake.claimWebhookDeliveryReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeWebhookStore) ClaimWebhookDeliveryReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.claimWebhookDeliveryMutex.Lock()
+	defer fake.claimWebhookDeliveryMutex.Unlock()
+	fake.ClaimWebhookDeliveryStub = nil
+	if fake.claimWebhookDeliveryReturnsOnCall == nil {
+		fake.claimWebhookDeliveryReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.claimWebhookDeliveryReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeWebhookStore) DeleteWebhookDelivery(arg1 context.Context, arg2 string) error {
+	fake.deleteWebhookDeliveryMutex.Lock()
+	ret, specificReturn := fake.deleteWebhookDeliveryReturnsOnCall[len(fake.deleteWebhookDeliveryArgsForCall)]
+	fake.deleteWebhookDeliveryArgsForCall = append(fake.deleteWebhookDeliveryArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DeleteWebhookDeliveryStub
+	fakeReturns := fake.deleteWebhookDeliveryReturns
+	fake.recordInvocation("DeleteWebhookDelivery", []interface{}{arg1, arg2})
+	fake.deleteWebhookDeliveryMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeWebhookStore) DeleteWebhookDeliveryCallCount() int {
+	fake.deleteWebhookDeliveryMutex.RLock()
+	defer fake.deleteWebhookDeliveryMutex.RUnlock()
+	return len(fake.deleteWebhookDeliveryArgsForCall)
+}
+
+func (fake *FakeWebhookStore) DeleteWebhookDeliveryCalls(stub func(context.Context, string) error) {
+	fake.deleteWebhookDeliveryMutex.Lock()
+	defer fake.deleteWebhookDeliveryMutex.Unlock()
+	fake.DeleteWebhookDeliveryStub = stub
+}
+
+func (fake *FakeWebhookStore) DeleteWebhookDeliveryArgsForCall(i int) (context.Context, string) {
+	fake.deleteWebhookDeliveryMutex.RLock()
+	defer fake.deleteWebhookDeliveryMutex.RUnlock()
+	argsForCall := fake.deleteWebhookDeliveryArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeWebhookStore) DeleteWebhookDeliveryReturns(result1 error) {
+	fake.deleteWebhookDeliveryMutex.Lock()
+	defer fake.deleteWebhookDeliveryMutex.Unlock()
+	fake.DeleteWebhookDeliveryStub = nil
+	fake.deleteWebhookDeliveryReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeWebhookStore) DeleteWebhookDeliveryReturnsOnCall(i int, result1 error) {
+	fake.deleteWebhookDeliveryMutex.Lock()
+	defer fake.deleteWebhookDeliveryMutex.Unlock()
+	fake.DeleteWebhookDeliveryStub = nil
+	if fake.deleteWebhookDeliveryReturnsOnCall == nil {
+		fake.deleteWebhookDeliveryReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteWebhookDeliveryReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeWebhookStore) ListWebhookDeliveries(arg1 context.Context, arg2 bool) ([]*service.WebhookDelivery, error) {
+	fake.listWebhookDeliveriesMutex.Lock()
+	ret, specificReturn := fake.listWebhookDeliveriesReturnsOnCall[len(fake.listWebhookDeliveriesArgsForCall)]
+	fake.listWebhookDeliveriesArgsForCall = append(fake.listWebhookDeliveriesArgsForCall, struct {
+		arg1 context.Context
+		arg2 bool
+	}{arg1, arg2})
+	stub := fake.ListWebhookDeliveriesStub
+	fakeReturns := fake.listWebhookDeliveriesReturns
+	fake.recordInvocation("ListWebhookDeliveries", []interface{}{arg1, arg2})
+	fake.listWebhookDeliveriesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeWebhookStore) ListWebhookDeliveriesCallCount() int {
+	fake.listWebhookDeliveriesMutex.RLock()
+	defer fake.listWebhookDeliveriesMutex.RUnlock()
+	return len(fake.listWebhookDeliveriesArgsForCall)
+}
+
+func (fake *FakeWebhookStore) ListWebhookDeliveriesCalls(stub func(context.Context, bool) ([]*service.WebhookDelivery, error)) {
+	fake.listWebhookDeliveriesMutex.Lock()
+	defer fake.listWebhookDeliveriesMutex.Unlock()
+	fake.ListWebhookDeliveriesStub = stub
+}
+
+func (fake *FakeWebhookStore) ListWebhookDeliveriesArgsForCall(i int) (context.Context, bool) {
+	fake.listWebhookDeliveriesMutex.RLock()
+	defer fake.listWebhookDeliveriesMutex.RUnlock()
+	argsForCall := fake.listWebhookDeliveriesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeWebhookStore) ListWebhookDeliveriesReturns(result1 []*service.WebhookDelivery, result2 error) {
+	fake.listWebhookDeliveriesMutex.Lock()
+	defer fake.listWebhookDeliveriesMutex.Unlock()
+	fake.ListWebhookDeliveriesStub = nil
+	fake.listWebhookDeliveriesReturns = struct {
+		result1 []*service.WebhookDelivery
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeWebhookStore) ListWebhookDeliveriesReturnsOnCall(i int, result1 []*service.WebhookDelivery, result2 error) {
+	fake.listWebhookDeliveriesMutex.Lock()
+	defer fake.listWebhookDeliveriesMutex.Unlock()
+	fake.ListWebhookDeliveriesStub = nil
+	if fake.listWebhookDeliveriesReturnsOnCall == nil {
+		fake.listWebhookDeliveriesReturnsOnCall = make(map[int]struct {
+			result1 []*service.WebhookDelivery
+			result2 error
+		})
+	}
+	fake.listWebhookDeliveriesReturnsOnCall[i] = struct {
+		result1 []*service.WebhookDelivery
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeWebhookStore) LoadWebhookDelivery(arg1 context.Context, arg2 string) (*service.WebhookDelivery, error) {
+	fake.loadWebhookDeliveryMutex.Lock()
+	ret, specificReturn := fake.loadWebhookDeliveryReturnsOnCall[len(fake.loadWebhookDeliveryArgsForCall)]
+	fake.loadWebhookDeliveryArgsForCall = append(fake.loadWebhookDeliveryArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.LoadWebhookDeliveryStub
+	fakeReturns := fake.loadWebhookDeliveryReturns
+	fake.recordInvocation("LoadWebhookDelivery", []interface{}{arg1, arg2})
+	fake.loadWebhookDeliveryMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeWebhookStore) LoadWebhookDeliveryCallCount() int {
+	fake.loadWebhookDeliveryMutex.RLock()
+	defer fake.loadWebhookDeliveryMutex.RUnlock()
+	return len(fake.loadWebhookDeliveryArgsForCall)
+}
+
+func (fake *FakeWebhookStore) LoadWebhookDeliveryCalls(stub func(context.Context, string) (*service.WebhookDelivery, error)) {
+	fake.loadWebhookDeliveryMutex.Lock()
+	defer fake.loadWebhookDeliveryMutex.Unlock()
+	fake.LoadWebhookDeliveryStub = stub
+}
+
+func (fake *FakeWebhookStore) LoadWebhookDeliveryArgsForCall(i int) (context.Context, string) {
+	fake.loadWebhookDeliveryMutex.RLock()
+	defer fake.loadWebhookDeliveryMutex.RUnlock()
+	argsForCall := fake.loadWebhookDeliveryArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeWebhookStore) LoadWebhookDeliveryReturns(result1 *service.WebhookDelivery, result2 error) {
+	fake.loadWebhookDeliveryMutex.Lock()
+	defer fake.loadWebhookDeliveryMutex.Unlock()
+	fake.LoadWebhookDeliveryStub = nil
+	fake.loadWebhookDeliveryReturns = struct {
+		result1 *service.WebhookDelivery
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeWebhookStore) LoadWebhookDeliveryReturnsOnCall(i int, result1 *service.WebhookDelivery, result2 error) {
+	fake.loadWebhookDeliveryMutex.Lock()
+	defer fake.loadWebhookDeliveryMutex.Unlock()
+	fake.LoadWebhookDeliveryStub = nil
+	if fake.loadWebhookDeliveryReturnsOnCall == nil {
+		fake.loadWebhookDeliveryReturnsOnCall = make(map[int]struct {
+			result1 *service.WebhookDelivery
+			result2 error
+		})
+	}
+	fake.loadWebhookDeliveryReturnsOnCall[i] = struct {
+		result1 *service.WebhookDelivery
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeWebhookStore) StoreWebhookDelivery(arg1 context.Context, arg2 *service.WebhookDelivery) error {
+	fake.storeWebhookDeliveryMutex.Lock()
+	ret, specificReturn := fake.storeWebhookDeliveryReturnsOnCall[len(fake.storeWebhookDeliveryArgsForCall)]
+	fake.storeWebhookDeliveryArgsForCall = append(fake.storeWebhookDeliveryArgsForCall, struct {
+		arg1 context.Context
+		arg2 *service.WebhookDelivery
+	}{arg1, arg2})
+	stub := fake.StoreWebhookDeliveryStub
+	fakeReturns := fake.storeWebhookDeliveryReturns
+	fake.recordInvocation("StoreWebhookDelivery", []interface{}{arg1, arg2})
+	fake.storeWebhookDeliveryMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeWebhookStore) StoreWebhookDeliveryCallCount() int {
+	fake.storeWebhookDeliveryMutex.RLock()
+	defer fake.storeWebhookDeliveryMutex.RUnlock()
+	return len(fake.storeWebhookDeliveryArgsForCall)
+}
+
+func (fake *FakeWebhookStore) StoreWebhookDeliveryCalls(stub func(context.Context, *service.WebhookDelivery) error) {
+	fake.storeWebhookDeliveryMutex.Lock()
+	defer fake.storeWebhookDeliveryMutex.Unlock()
+	fake.StoreWebhookDeliveryStub = stub
+}
+
+func (fake *FakeWebhookStore) StoreWebhookDeliveryArgsForCall(i int) (context.Context, *service.WebhookDelivery) {
+	fake.storeWebhookDeliveryMutex.RLock()
+	defer fake.storeWebhookDeliveryMutex.RUnlock()
+	argsForCall := fake.storeWebhookDeliveryArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeWebhookStore) StoreWebhookDeliveryReturns(result1 error) {
+	fake.storeWebhookDeliveryMutex.Lock()
+	defer fake.storeWebhookDeliveryMutex.Unlock()
+	fake.StoreWebhookDeliveryStub = nil
+	fake.storeWebhookDeliveryReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeWebhookStore) StoreWebhookDeliveryReturnsOnCall(i int, result1 error) {
+	fake.storeWebhookDeliveryMutex.Lock()
+	defer fake.storeWebhookDeliveryMutex.Unlock()
+	fake.StoreWebhookDeliveryStub = nil
+	if fake.storeWebhookDeliveryReturnsOnCall == nil {
+		fake.storeWebhookDeliveryReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.storeWebhookDeliveryReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeWebhookStore) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.claimWebhookDeliveryMutex.RLock()
+	defer fake.claimWebhookDeliveryMutex.RUnlock()
+	fake.deleteWebhookDeliveryMutex.RLock()
+	defer fake.deleteWebhookDeliveryMutex.RUnlock()
+	fake.listWebhookDeliveriesMutex.RLock()
+	defer fake.listWebhookDeliveriesMutex.RUnlock()
+	fake.loadWebhookDeliveryMutex.RLock()
+	defer fake.loadWebhookDeliveryMutex.RUnlock()
+	fake.storeWebhookDeliveryMutex.RLock()
+	defer fake.storeWebhookDeliveryMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeWebhookStore) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ service.WebhookStore = new(FakeWebhookStore)
@@ -167,6 +167,95 @@ func (s *SIPService) CreateSIPOutboundTrunk(ctx context.Context, req *livekit.Cr
 	return info, nil
 }
 
+func (s *SIPService) UpdateSIPInboundTrunk(ctx context.Context, req *livekit.UpdateSIPInboundTrunkRequest) (*livekit.SIPInboundTrunkInfo, error) {
+	if err := EnsureSIPAdminPermission(ctx); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if s.store == nil {
+		return nil, ErrSIPNotConnected
+	}
+	if err := req.Validate(); err != nil {
+		return nil, twirp.WrapError(twirp.NewError(twirp.InvalidArgument, err.Error()), err)
+	}
+
+	AppendLogFields(ctx,
+		"request", logger.Proto(req),
+		"trunkID", req.SipTrunkId,
+	)
+	info, err := s.store.LoadSIPInboundTrunk(ctx, req.SipTrunkId)
+	if err != nil {
+		return nil, err
+	}
+	switch a := req.Action.(type) {
+	default:
+		return nil, twirp.NewError(twirp.InvalidArgument, "missing or unsupported action")
+	case *livekit.UpdateSIPInboundTrunkRequest_Replace:
+		info = a.Replace
+		info.SipTrunkId = req.SipTrunkId
+	case *livekit.UpdateSIPInboundTrunkRequest_Update:
+		if err = a.Update.Apply(info); err != nil {
+			return nil, twirp.WrapError(twirp.NewError(twirp.InvalidArgument, err.Error()), err)
+		}
+	}
+
+	// Validate all trunks including the updated one first.
+	list, err := s.store.ListSIPInboundTrunk(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, t := range list {
+		if t.SipTrunkId == info.SipTrunkId {
+			list[i] = info
+		}
+	}
+	if err = sip.ValidateTrunks(list); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.StoreSIPInboundTrunk(ctx, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (s *SIPService) UpdateSIPOutboundTrunk(ctx context.Context, req *livekit.UpdateSIPOutboundTrunkRequest) (*livekit.SIPOutboundTrunkInfo, error) {
+	if err := EnsureSIPAdminPermission(ctx); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if s.store == nil {
+		return nil, ErrSIPNotConnected
+	}
+	if err := req.Validate(); err != nil {
+		return nil, twirp.WrapError(twirp.NewError(twirp.InvalidArgument, err.Error()), err)
+	}
+
+	AppendLogFields(ctx,
+		"request", logger.Proto(req),
+		"trunkID", req.SipTrunkId,
+	)
+	info, err := s.store.LoadSIPOutboundTrunk(ctx, req.SipTrunkId)
+	if err != nil {
+		return nil, err
+	}
+	switch a := req.Action.(type) {
+	default:
+		return nil, twirp.NewError(twirp.InvalidArgument, "missing or unsupported action")
+	case *livekit.UpdateSIPOutboundTrunkRequest_Replace:
+		info = a.Replace
+		info.SipTrunkId = req.SipTrunkId
+	case *livekit.UpdateSIPOutboundTrunkRequest_Update:
+		if err = a.Update.Apply(info); err != nil {
+			return nil, twirp.WrapError(twirp.NewError(twirp.InvalidArgument, err.Error()), err)
+		}
+	}
+
+	// No additional validation needed for outbound.
+	if err := s.store.StoreSIPOutboundTrunk(ctx, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
 func (s *SIPService) GetSIPInboundTrunk(ctx context.Context, req *livekit.GetSIPInboundTrunkRequest) (*livekit.GetSIPInboundTrunkResponse, error) {
 	if err := EnsureSIPAdminPermission(ctx); err != nil {
 		return nil, twirpAuthError(err)
@@ -351,6 +440,57 @@ func (s *SIPService) CreateSIPDispatchRule(ctx context.Context, req *livekit.Cre
 	return info, nil
 }
 
+func (s *SIPService) UpdateSIPDispatchRule(ctx context.Context, req *livekit.UpdateSIPDispatchRuleRequest) (*livekit.SIPDispatchRuleInfo, error) {
+	if err := EnsureSIPAdminPermission(ctx); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if s.store == nil {
+		return nil, ErrSIPNotConnected
+	}
+	if err := req.Validate(); err != nil {
+		return nil, twirp.WrapError(twirp.NewError(twirp.InvalidArgument, err.Error()), err)
+	}
+
+	AppendLogFields(ctx,
+		"request", logger.Proto(req),
+		"ruleID", req.SipDispatchRuleId,
+	)
+	info, err := s.store.LoadSIPDispatchRule(ctx, req.SipDispatchRuleId)
+	if err != nil {
+		return nil, err
+	}
+	switch a := req.Action.(type) {
+	default:
+		return nil, twirp.NewError(twirp.InvalidArgument, "missing or unsupported action")
+	case *livekit.UpdateSIPDispatchRuleRequest_Replace:
+		info = a.Replace
+		info.SipDispatchRuleId = req.SipDispatchRuleId
+	case *livekit.UpdateSIPDispatchRuleRequest_Update:
+		if err = a.Update.Apply(info); err != nil {
+			return nil, twirp.WrapError(twirp.NewError(twirp.InvalidArgument, err.Error()), err)
+		}
+	}
+
+	// Validate all rules including the updated one first.
+	list, err := s.store.ListSIPDispatchRule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range list {
+		if r.SipDispatchRuleId == info.SipDispatchRuleId {
+			list[i] = info
+		}
+	}
+	if err = sip.ValidateDispatchRules(list); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.StoreSIPDispatchRule(ctx, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
 func (s *SIPService) ListSIPDispatchRule(ctx context.Context, req *livekit.ListSIPDispatchRuleRequest) (*livekit.ListSIPDispatchRuleResponse, error) {
 	if err := EnsureSIPAdminPermission(ctx); err != nil {
 		return nil, twirpAuthError(err)
@@ -0,0 +1,1020 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	_ "modernc.org/sqlite"
+
+	"github.com/livekit/protocol/ingress"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+	"github.com/livekit/protocol/utils/guid"
+	"github.com/livekit/psrpc"
+)
+
+const (
+	sqlSIPTrunkTable         = "sip_trunk"
+	sqlSIPInboundTrunkTable  = "sip_inbound_trunk"
+	sqlSIPOutboundTrunkTable = "sip_outbound_trunk"
+	sqlSIPDispatchRuleTable  = "sip_dispatch_rule"
+
+	sqlLockRetryInterval      = 100 * time.Millisecond
+	sqlEgressCleanupInterval  = 30 * time.Minute
+	sqlEndedEgressRetainedFor = 24 * time.Hour
+)
+
+var sqlSchema = []string{
+	`CREATE TABLE IF NOT EXISTS rooms (
+		name TEXT PRIMARY KEY,
+		data BLOB NOT NULL,
+		has_internal INTEGER NOT NULL DEFAULT 0,
+		internal BLOB
+	)`,
+	`CREATE TABLE IF NOT EXISTS participants (
+		room_name TEXT NOT NULL,
+		identity TEXT NOT NULL,
+		data BLOB NOT NULL,
+		PRIMARY KEY (room_name, identity)
+	)`,
+	`CREATE TABLE IF NOT EXISTS room_locks (
+		room_name TEXT PRIMARY KEY,
+		uid TEXT NOT NULL,
+		expires_at INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS egress (
+		id TEXT PRIMARY KEY,
+		room_name TEXT NOT NULL,
+		ended_at INTEGER NOT NULL DEFAULT 0,
+		data BLOB NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS egress_room_name ON egress (room_name)`,
+	`CREATE TABLE IF NOT EXISTS ingress (
+		id TEXT PRIMARY KEY,
+		room_name TEXT NOT NULL,
+		stream_key TEXT NOT NULL,
+		data BLOB NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS ingress_room_name ON ingress (room_name)`,
+	`CREATE INDEX IF NOT EXISTS ingress_stream_key ON ingress (stream_key)`,
+	`CREATE TABLE IF NOT EXISTS ingress_state (
+		id TEXT PRIMARY KEY,
+		started_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL,
+		data BLOB NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS sip_trunk (id TEXT PRIMARY KEY, data BLOB NOT NULL)`,
+	`CREATE TABLE IF NOT EXISTS sip_inbound_trunk (id TEXT PRIMARY KEY, data BLOB NOT NULL)`,
+	`CREATE TABLE IF NOT EXISTS sip_outbound_trunk (id TEXT PRIMARY KEY, data BLOB NOT NULL)`,
+	`CREATE TABLE IF NOT EXISTS sip_dispatch_rule (id TEXT PRIMARY KEY, data BLOB NOT NULL)`,
+	`CREATE TABLE IF NOT EXISTS agent_dispatch (
+		room_name TEXT NOT NULL,
+		id TEXT NOT NULL,
+		data BLOB NOT NULL,
+		PRIMARY KEY (room_name, id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS agent_job (
+		room_name TEXT NOT NULL,
+		id TEXT NOT NULL,
+		data BLOB NOT NULL,
+		PRIMARY KEY (room_name, id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS webhook_delivery (
+		id TEXT PRIMARY KEY,
+		dead_lettered INTEGER NOT NULL DEFAULT 0,
+		data BLOB NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS webhook_delivery_dead_lettered ON webhook_delivery (dead_lettered)`,
+	`CREATE TABLE IF NOT EXISTS room_schedule (
+		room_name TEXT PRIMARY KEY,
+		retained_until INTEGER NOT NULL DEFAULT 0,
+		data BLOB NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS quota (
+		set_name TEXT NOT NULL,
+		member TEXT NOT NULL,
+		expires_at INTEGER NOT NULL,
+		PRIMARY KEY (set_name, member)
+	)`,
+}
+
+// SQLStore persists state in a SQLite database
+type SQLStore struct {
+	db   *sql.DB
+	ctx  context.Context
+	done chan struct{}
+}
+
+func NewSQLStore(dsn string) (*SQLStore, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, serialize access instead of failing with SQLITE_BUSY.
+	// this also keeps in-memory databases on a single connection.
+	db.SetMaxOpenConns(1)
+
+	s := &SQLStore{
+		db:  db,
+		ctx: context.Background(),
+	}
+	for _, stmt := range sqlSchema {
+		if _, err = db.ExecContext(s.ctx, stmt); err != nil {
+			_ = db.Close()
+			return nil, errors.Wrap(err, "could not create sql schema")
+		}
+	}
+	return s, nil
+}
+
+func (s *SQLStore) Start() error {
+	if s.done != nil {
+		return nil
+	}
+
+	s.done = make(chan struct{}, 1)
+	go s.egressWorker()
+	return nil
+}
+
+func (s *SQLStore) Stop() {
+	if s.done == nil {
+		return
+	}
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+}
+
+func (s *SQLStore) Close() error {
+	s.Stop()
+	return s.db.Close()
+}
+
+func (s *SQLStore) StoreRoom(_ context.Context, room *livekit.Room, internal *livekit.RoomInternal) error {
+	if room.CreationTime == 0 {
+		room.CreationTime = time.Now().Unix()
+	}
+
+	roomData, err := proto.Marshal(room)
+	if err != nil {
+		return err
+	}
+
+	var internalData []byte
+	if internal != nil {
+		internalData, err = proto.Marshal(internal)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.db.ExecContext(s.ctx,
+		`INSERT INTO rooms (name, data, has_internal, internal) VALUES (?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET data = excluded.data, has_internal = excluded.has_internal, internal = excluded.internal`,
+		room.Name, roomData, internal != nil, internalData,
+	)
+	if err != nil {
+		return errors.Wrap(err, "could not create room")
+	}
+	return nil
+}
+
+func (s *SQLStore) LoadRoom(_ context.Context, roomName livekit.RoomName, includeInternal bool) (*livekit.Room, *livekit.RoomInternal, error) {
+	var roomData, internalData []byte
+	var hasInternal bool
+	err := s.db.QueryRowContext(s.ctx, `SELECT data, has_internal, internal FROM rooms WHERE name = ?`, string(roomName)).Scan(&roomData, &hasInternal, &internalData)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrRoomNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	room := &livekit.Room{}
+	if err = proto.Unmarshal(roomData, room); err != nil {
+		return nil, nil, err
+	}
+
+	var internal *livekit.RoomInternal
+	if includeInternal && hasInternal {
+		internal = &livekit.RoomInternal{}
+		if err = proto.Unmarshal(internalData, internal); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return room, internal, nil
+}
+
+func (s *SQLStore) ListRooms(_ context.Context, roomNames []livekit.RoomName) ([]*livekit.Room, error) {
+	var rows *sql.Rows
+	var err error
+	if roomNames == nil {
+		rows, err = s.db.QueryContext(s.ctx, `SELECT data FROM rooms`)
+	} else {
+		if len(roomNames) == 0 {
+			return []*livekit.Room{}, nil
+		}
+		query, args := sqlInQuery(`SELECT data FROM rooms WHERE name IN `, livekit.IDsAsStrings(roomNames))
+		rows, err = s.db.QueryContext(s.ctx, query, args...)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get rooms")
+	}
+
+	rooms, err := sqlScanMany[livekit.Room](rows)
+	if err != nil {
+		return nil, err
+	}
+	if rooms == nil {
+		rooms = []*livekit.Room{}
+	}
+	return rooms, nil
+}
+
+func (s *SQLStore) DeleteRoom(_ context.Context, roomName livekit.RoomName) error {
+	tx, err := s.db.BeginTx(s.ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range []string{
+		`DELETE FROM rooms WHERE name = ?`,
+		`DELETE FROM participants WHERE room_name = ?`,
+		`DELETE FROM agent_dispatch WHERE room_name = ?`,
+		`DELETE FROM agent_job WHERE room_name = ?`,
+	} {
+		if _, err = tx.ExecContext(s.ctx, stmt, string(roomName)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LockRoom acquires a lease on the room that expires after duration, so that a crashed holder cannot keep the room locked
+func (s *SQLStore) LockRoom(_ context.Context, roomName livekit.RoomName, duration time.Duration) (string, error) {
+	token := guid.New("LOCK")
+
+	startTime := time.Now()
+	for {
+		locked, err := s.tryLockRoom(roomName, token, duration)
+		if err != nil {
+			return "", err
+		}
+		if locked {
+			return token, nil
+		}
+
+		// stop waiting past lock duration, the last attempt being made once the lock of
+		// the previous holder has expired
+		remaining := duration - time.Since(startTime)
+		if remaining < 0 {
+			break
+		}
+
+		time.Sleep(min(sqlLockRetryInterval, remaining))
+	}
+
+	return "", ErrRoomLockFailed
+}
+
+func (s *SQLStore) tryLockRoom(roomName livekit.RoomName, token string, duration time.Duration) (bool, error) {
+	now := time.Now()
+	res, err := s.db.ExecContext(s.ctx,
+		`INSERT INTO room_locks (room_name, uid, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (room_name) DO UPDATE SET uid = excluded.uid, expires_at = excluded.expires_at
+		WHERE room_locks.expires_at <= ?`,
+		string(roomName), token, now.Add(duration).UnixNano(), now.UnixNano(),
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (s *SQLStore) UnlockRoom(_ context.Context, roomName livekit.RoomName, uid string) error {
+	res, err := s.db.ExecContext(s.ctx, `DELETE FROM room_locks WHERE room_name = ? AND uid = ?`, string(roomName), uid)
+	if err != nil {
+		return err
+	}
+
+	// uid does not match
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return ErrRoomUnlockFailed
+	}
+	return nil
+}
+
+func (s *SQLStore) StoreParticipant(_ context.Context, roomName livekit.RoomName, participant *livekit.ParticipantInfo) error {
+	data, err := proto.Marshal(participant)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(s.ctx,
+		`INSERT INTO participants (room_name, identity, data) VALUES (?, ?, ?)
+		ON CONFLICT (room_name, identity) DO UPDATE SET data = excluded.data`,
+		string(roomName), participant.Identity, data,
+	)
+	return err
+}
+
+func (s *SQLStore) LoadParticipant(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) (*livekit.ParticipantInfo, error) {
+	row := s.db.QueryRowContext(s.ctx, `SELECT data FROM participants WHERE room_name = ? AND identity = ?`, string(roomName), string(identity))
+	return sqlScanOne[livekit.ParticipantInfo](row, ErrParticipantNotFound)
+}
+
+func (s *SQLStore) ListParticipants(_ context.Context, roomName livekit.RoomName) ([]*livekit.ParticipantInfo, error) {
+	rows, err := s.db.QueryContext(s.ctx, `SELECT data FROM participants WHERE room_name = ?`, string(roomName))
+	if err != nil {
+		return nil, err
+	}
+	return sqlScanMany[livekit.ParticipantInfo](rows)
+}
+
+func (s *SQLStore) DeleteParticipant(_ context.Context, roomName livekit.RoomName, identity livekit.ParticipantIdentity) error {
+	_, err := s.db.ExecContext(s.ctx, `DELETE FROM participants WHERE room_name = ? AND identity = ?`, string(roomName), string(identity))
+	return err
+}
+
+func (s *SQLStore) StoreRoomSchedule(_ context.Context, schedule *RoomSchedule) error {
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return err
+	}
+
+	var retainedUntil int64
+	if until := schedule.retainedUntil(); !until.IsZero() {
+		retainedUntil = until.Unix()
+	}
+	tx, err := s.db.BeginTx(s.ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// forget schedules past their retention while here
+	if _, err = tx.ExecContext(s.ctx, `DELETE FROM room_schedule WHERE retained_until != 0 AND retained_until <= ?`, time.Now().Unix()); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(s.ctx,
+		`INSERT INTO room_schedule (room_name, retained_until, data) VALUES (?, ?, ?)
+		ON CONFLICT (room_name) DO UPDATE SET retained_until = excluded.retained_until, data = excluded.data`,
+		schedule.Room, retainedUntil, data,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) LoadRoomSchedule(_ context.Context, roomName livekit.RoomName) (*RoomSchedule, error) {
+	var data []byte
+	err := s.db.QueryRowContext(s.ctx,
+		`SELECT data FROM room_schedule WHERE room_name = ? AND (retained_until = 0 OR retained_until > ?)`,
+		string(roomName), time.Now().Unix(),
+	).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrRoomScheduleNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	schedule := &RoomSchedule{}
+	if err = json.Unmarshal(data, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *SQLStore) DeleteRoomSchedule(_ context.Context, roomName livekit.RoomName) error {
+	_, err := s.db.ExecContext(s.ctx, `DELETE FROM room_schedule WHERE room_name = ?`, string(roomName))
+	return err
+}
+
+func (s *SQLStore) StoreEgress(_ context.Context, info *livekit.EgressInfo) error {
+	return s.storeEgress(info, "could not store egress info")
+}
+
+func (s *SQLStore) storeEgress(info *livekit.EgressInfo, errMsg string) error {
+	data, err := proto.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(s.ctx,
+		`INSERT INTO egress (id, room_name, ended_at, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET room_name = excluded.room_name, ended_at = excluded.ended_at, data = excluded.data`,
+		info.EgressId, info.RoomName, info.EndedAt, data,
+	)
+	if err != nil {
+		return errors.Wrap(err, errMsg)
+	}
+	return nil
+}
+
+func (s *SQLStore) LoadEgress(_ context.Context, egressID string) (*livekit.EgressInfo, error) {
+	row := s.db.QueryRowContext(s.ctx, `SELECT data FROM egress WHERE id = ?`, egressID)
+	return sqlScanOne[livekit.EgressInfo](row, ErrEgressNotFound)
+}
+
+func (s *SQLStore) ListEgress(_ context.Context, roomName livekit.RoomName, active bool) ([]*livekit.EgressInfo, error) {
+	var rows *sql.Rows
+	var err error
+	if roomName == "" {
+		rows, err = s.db.QueryContext(s.ctx, `SELECT data FROM egress`)
+	} else {
+		rows, err = s.db.QueryContext(s.ctx, `SELECT data FROM egress WHERE room_name = ?`, string(roomName))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := sqlScanMany[livekit.EgressInfo](rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []*livekit.EgressInfo
+	for _, info := range all {
+		// if active, filter status starting, active, and ending
+		if !active || int32(info.Status) < int32(livekit.EgressStatus_EGRESS_COMPLETE) {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+// UpdateEgress also stores egress infos that were not stored before, like RedisStore does
+func (s *SQLStore) UpdateEgress(_ context.Context, info *livekit.EgressInfo) error {
+	return s.storeEgress(info, "could not update egress info")
+}
+
+// Deletes egress info 24h after the egress has ended
+func (s *SQLStore) egressWorker() {
+	ticker := time.NewTicker(sqlEgressCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			err := s.CleanEndedEgress()
+			if err != nil {
+				logger.Errorw("could not clean egress info", err)
+			}
+		}
+	}
+}
+
+func (s *SQLStore) CleanEndedEgress() error {
+	expiry := time.Now().Add(-sqlEndedEgressRetainedFor).UnixNano()
+	_, err := s.db.ExecContext(s.ctx, `DELETE FROM egress WHERE ended_at != 0 AND ended_at < ?`, expiry)
+	return err
+}
+
+func (s *SQLStore) StoreIngress(ctx context.Context, info *livekit.IngressInfo) error {
+	err := s.storeIngress(ctx, info)
+	if err != nil {
+		return err
+	}
+
+	return s.storeIngressState(ctx, info.IngressId, nil)
+}
+
+func (s *SQLStore) storeIngress(_ context.Context, info *livekit.IngressInfo) error {
+	if info.IngressId == "" {
+		return errors.New("Missing IngressId")
+	}
+	if info.StreamKey == "" && info.InputType != livekit.IngressInput_URL_INPUT {
+		return errors.New("Missing StreamKey")
+	}
+
+	// ignore state
+	infoCopy := utils.CloneProto(info)
+	infoCopy.State = nil
+
+	data, err := proto.Marshal(infoCopy)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(s.ctx,
+		`INSERT INTO ingress (id, room_name, stream_key, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET room_name = excluded.room_name, stream_key = excluded.stream_key, data = excluded.data`,
+		info.IngressId, info.RoomName, info.StreamKey, data,
+	)
+	return err
+}
+
+func (s *SQLStore) storeIngressState(_ context.Context, ingressId string, state *livekit.IngressState) error {
+	if ingressId == "" {
+		return errors.New("Missing IngressId")
+	}
+
+	if state == nil {
+		state = &livekit.IngressState{}
+	}
+
+	data, err := proto.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(s.ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldStartedAt, oldUpdatedAt int64
+	err = tx.QueryRowContext(s.ctx, `SELECT started_at, updated_at FROM ingress_state WHERE id = ?`, ingressId).Scan(&oldStartedAt, &oldUpdatedAt)
+	switch err {
+	case sql.ErrNoRows:
+		// Ingress state doesn't exist yet
+	case nil:
+		if state.StartedAt < oldStartedAt {
+			// Do not overwrite the info and state of a more recent session
+			return ingress.ErrIngressOutOfDate
+		}
+
+		if state.StartedAt == oldStartedAt && state.UpdatedAt < oldUpdatedAt {
+			// Do not overwrite with an old state in case RPCs were delivered out of order.
+			// All RPCs come from the same ingress server and should thus be on the same clock.
+			return nil
+		}
+	default:
+		return err
+	}
+
+	_, err = tx.ExecContext(s.ctx,
+		`INSERT INTO ingress_state (id, started_at, updated_at, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET started_at = excluded.started_at, updated_at = excluded.updated_at, data = excluded.data`,
+		ingressId, state.StartedAt, state.UpdatedAt, data,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) loadIngressState(ingressId string) (*livekit.IngressState, error) {
+	row := s.db.QueryRowContext(s.ctx, `SELECT data FROM ingress_state WHERE id = ?`, ingressId)
+	return sqlScanOne[livekit.IngressState](row, ErrIngressNotFound)
+}
+
+func (s *SQLStore) withIngressState(info *livekit.IngressInfo) (*livekit.IngressInfo, error) {
+	state, err := s.loadIngressState(info.IngressId)
+	switch err {
+	case nil:
+		info.State = state
+	case ErrIngressNotFound:
+		// No state for this ingress
+	default:
+		return nil, err
+	}
+	return info, nil
+}
+
+func (s *SQLStore) LoadIngress(_ context.Context, ingressId string) (*livekit.IngressInfo, error) {
+	row := s.db.QueryRowContext(s.ctx, `SELECT data FROM ingress WHERE id = ?`, ingressId)
+	info, err := sqlScanOne[livekit.IngressInfo](row, ErrIngressNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return s.withIngressState(info)
+}
+
+func (s *SQLStore) LoadIngressFromStreamKey(_ context.Context, streamKey string) (*livekit.IngressInfo, error) {
+	row := s.db.QueryRowContext(s.ctx, `SELECT data FROM ingress WHERE stream_key = ? AND stream_key != ''`, streamKey)
+	info, err := sqlScanOne[livekit.IngressInfo](row, ErrIngressNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return s.withIngressState(info)
+}
+
+func (s *SQLStore) ListIngress(_ context.Context, roomName livekit.RoomName) ([]*livekit.IngressInfo, error) {
+	var rows *sql.Rows
+	var err error
+	if roomName == "" {
+		rows, err = s.db.QueryContext(s.ctx, `SELECT data FROM ingress`)
+	} else {
+		rows, err = s.db.QueryContext(s.ctx, `SELECT data FROM ingress WHERE room_name = ?`, string(roomName))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := sqlScanMany[livekit.IngressInfo](rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if _, err = s.withIngressState(info); err != nil {
+			return nil, err
+		}
+	}
+	return infos, nil
+}
+
+func (s *SQLStore) UpdateIngress(ctx context.Context, info *livekit.IngressInfo) error {
+	return s.storeIngress(ctx, info)
+}
+
+func (s *SQLStore) UpdateIngressState(ctx context.Context, ingressId string, state *livekit.IngressState) error {
+	return s.storeIngressState(ctx, ingressId, state)
+}
+
+func (s *SQLStore) DeleteIngress(_ context.Context, info *livekit.IngressInfo) error {
+	tx, err := s.db.BeginTx(s.ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(s.ctx, `DELETE FROM ingress WHERE id = ?`, info.IngressId); err != nil {
+		return errors.Wrap(err, "could not delete ingress info")
+	}
+	if _, err = tx.ExecContext(s.ctx, `DELETE FROM ingress_state WHERE id = ?`, info.IngressId); err != nil {
+		return errors.Wrap(err, "could not delete ingress info")
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) StoreAgentDispatch(_ context.Context, dispatch *livekit.AgentDispatch) error {
+	di := utils.CloneProto(dispatch)
+
+	// Do not store jobs with the dispatch
+	if di.State != nil {
+		di.State.Jobs = nil
+	}
+
+	data, err := proto.Marshal(di)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(s.ctx,
+		`INSERT INTO agent_dispatch (room_name, id, data) VALUES (?, ?, ?)
+		ON CONFLICT (room_name, id) DO UPDATE SET data = excluded.data`,
+		dispatch.Room, di.Id, data,
+	)
+	return err
+}
+
+// This will not delete the jobs created by the dispatch
+func (s *SQLStore) DeleteAgentDispatch(_ context.Context, dispatch *livekit.AgentDispatch) error {
+	_, err := s.db.ExecContext(s.ctx, `DELETE FROM agent_dispatch WHERE room_name = ? AND id = ?`, dispatch.Room, dispatch.Id)
+	return err
+}
+
+func (s *SQLStore) ListAgentDispatches(_ context.Context, roomName livekit.RoomName) ([]*livekit.AgentDispatch, error) {
+	rows, err := s.db.QueryContext(s.ctx, `SELECT data FROM agent_dispatch WHERE room_name = ?`, string(roomName))
+	if err != nil {
+		return nil, err
+	}
+	dispatches, err := sqlScanMany[livekit.AgentDispatch](rows)
+	if err != nil {
+		return nil, err
+	}
+
+	dMap := make(map[string]*livekit.AgentDispatch)
+	for _, di := range dispatches {
+		dMap[di.Id] = di
+	}
+
+	rows, err = s.db.QueryContext(s.ctx, `SELECT data FROM agent_job WHERE room_name = ?`, string(roomName))
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := sqlScanMany[livekit.Job](rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Associate job to dispatch
+	for _, jb := range jobs {
+		di := dMap[jb.DispatchId]
+		if di == nil {
+			continue
+		}
+		if di.State == nil {
+			di.State = &livekit.AgentDispatchState{}
+		}
+		di.State.Jobs = append(di.State.Jobs, jb)
+	}
+
+	return dispatches, nil
+}
+
+func (s *SQLStore) StoreAgentJob(_ context.Context, job *livekit.Job) error {
+	if job.Room == nil {
+		return psrpc.NewErrorf(psrpc.InvalidArgument, "job doesn't have a valid Room field")
+	}
+
+	jb := utils.CloneProto(job)
+
+	// Do not store room with the job
+	jb.Room = nil
+
+	// Only store the participant identity
+	if jb.Participant != nil {
+		jb.Participant = &livekit.ParticipantInfo{
+			Identity: jb.Participant.Identity,
+		}
+	}
+
+	data, err := proto.Marshal(jb)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(s.ctx,
+		`INSERT INTO agent_job (room_name, id, data) VALUES (?, ?, ?)
+		ON CONFLICT (room_name, id) DO UPDATE SET data = excluded.data`,
+		job.Room.Name, jb.Id, data,
+	)
+	return err
+}
+
+func (s *SQLStore) DeleteAgentJob(_ context.Context, job *livekit.Job) error {
+	if job.Room == nil {
+		return psrpc.NewErrorf(psrpc.InvalidArgument, "job doesn't have a valid Room field")
+	}
+
+	_, err := s.db.ExecContext(s.ctx, `DELETE FROM agent_job WHERE room_name = ? AND id = ?`, job.Room.Name, job.Id)
+	return err
+}
+
+func (s *SQLStore) StoreWebhookDelivery(_ context.Context, delivery *WebhookDelivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(s.ctx,
+		`INSERT INTO webhook_delivery (id, dead_lettered, data) VALUES (?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET dead_lettered = excluded.dead_lettered, data = excluded.data`,
+		delivery.ID, delivery.DeadLetteredAt != 0, data,
+	)
+	return err
+}
+
+func (s *SQLStore) ClaimWebhookDelivery(_ context.Context, delivery *WebhookDelivery) (bool, error) {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := s.db.BeginTx(s.ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var storedData []byte
+	err = tx.QueryRowContext(s.ctx, `SELECT data FROM webhook_delivery WHERE id = ? AND dead_lettered = 0`, delivery.ID).Scan(&storedData)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	stored := &WebhookDelivery{}
+	if err = json.Unmarshal(storedData, stored); err != nil {
+		return false, err
+	}
+	if !canClaimWebhookDelivery(stored, delivery.NodeID, time.Now()) {
+		return false, nil
+	}
+
+	if _, err = tx.ExecContext(s.ctx, `UPDATE webhook_delivery SET data = ? WHERE id = ?`, data, delivery.ID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (s *SQLStore) LoadWebhookDelivery(_ context.Context, id string) (*WebhookDelivery, error) {
+	var data []byte
+	err := s.db.QueryRowContext(s.ctx, `SELECT data FROM webhook_delivery WHERE id = ?`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookDeliveryNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	delivery := &WebhookDelivery{}
+	if err = json.Unmarshal(data, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (s *SQLStore) ListWebhookDeliveries(_ context.Context, deadLettered bool) ([]*WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(s.ctx, `SELECT data FROM webhook_delivery WHERE dead_lettered = ?`, deadLettered)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*WebhookDelivery
+	for rows.Next() {
+		var data []byte
+		if err = rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		delivery := &WebhookDelivery{}
+		if err = json.Unmarshal(data, delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (s *SQLStore) DeleteWebhookDelivery(_ context.Context, id string) error {
+	_, err := s.db.ExecContext(s.ctx, `DELETE FROM webhook_delivery WHERE id = ?`, id)
+	return err
+}
+
+func (s *SQLStore) AcquireQuota(_ context.Context, set, member string, limit int, expiresAt time.Time) (bool, error) {
+	return s.updateQuota(set, member, limit, expiresAt, true)
+}
+
+func (s *SQLStore) CheckQuota(_ context.Context, set, member string, limit int) (bool, error) {
+	return s.updateQuota(set, member, limit, time.Time{}, false)
+}
+
+// updateQuota drops expired members, then adds or renews member when acquire is set, unless that would exceed the limit
+func (s *SQLStore) updateQuota(set, member string, limit int, expiresAt time.Time, acquire bool) (bool, error) {
+	tx, err := s.db.BeginTx(s.ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(s.ctx, `DELETE FROM quota WHERE set_name = ? AND expires_at <= ?`, set, time.Now().UnixMilli()); err != nil {
+		return false, err
+	}
+	var count, held int
+	err = tx.QueryRowContext(s.ctx,
+		`SELECT COUNT(*), COUNT(CASE WHEN member = ? THEN 1 END) FROM quota WHERE set_name = ?`,
+		member, set,
+	).Scan(&count, &held)
+	if err != nil {
+		return false, err
+	}
+	if held == 0 && count >= limit {
+		return false, nil
+	}
+	if !acquire {
+		return true, nil
+	}
+
+	_, err = tx.ExecContext(s.ctx,
+		`INSERT INTO quota (set_name, member, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (set_name, member) DO UPDATE SET expires_at = excluded.expires_at`,
+		set, member, expiresAt.UnixMilli(),
+	)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (s *SQLStore) RenewQuota(_ context.Context, set string, members []string, expiresAt time.Time) error {
+	if len(members) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(s.ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, m := range members {
+		_, err = tx.ExecContext(s.ctx,
+			`INSERT INTO quota (set_name, member, expires_at) VALUES (?, ?, ?)
+			ON CONFLICT (set_name, member) DO UPDATE SET expires_at = excluded.expires_at`,
+			set, m, expiresAt.UnixMilli(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) ReleaseQuota(_ context.Context, set, member string) error {
+	_, err := s.db.ExecContext(s.ctx, `DELETE FROM quota WHERE set_name = ? AND member = ?`, set, member)
+	return err
+}
+
+func sqlStoreOne(s *SQLStore, table, id string, p proto.Message) error {
+	if id == "" {
+		return errors.New("id is not set")
+	}
+	data, err := proto.Marshal(p)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(s.ctx,
+		`INSERT INTO `+table+` (id, data) VALUES (?, ?) ON CONFLICT (id) DO UPDATE SET data = excluded.data`,
+		id, data,
+	)
+	return err
+}
+
+func sqlLoadOne[T any, P interface {
+	*T
+	proto.Message
+}](s *SQLStore, table, id string, notFoundErr error) (P, error) {
+	row := s.db.QueryRowContext(s.ctx, `SELECT data FROM `+table+` WHERE id = ?`, id)
+	return sqlScanOne[T, P](row, notFoundErr)
+}
+
+func sqlLoadMany[T any, P interface {
+	*T
+	proto.Message
+}](s *SQLStore, table string) ([]P, error) {
+	rows, err := s.db.QueryContext(s.ctx, `SELECT data FROM `+table)
+	if err != nil {
+		return nil, err
+	}
+	return sqlScanMany[T, P](rows)
+}
+
+func sqlScanOne[T any, P interface {
+	*T
+	proto.Message
+}](row *sql.Row, notFoundErr error) (P, error) {
+	var data []byte
+	err := row.Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, notFoundErr
+	} else if err != nil {
+		return nil, err
+	}
+	var p P = new(T)
+	if err = proto.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func sqlScanMany[T any, P interface {
+	*T
+	proto.Message
+}](rows *sql.Rows) ([]P, error) {
+	defer rows.Close()
+
+	var list []P
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var p P = new(T)
+		if err := proto.Unmarshal(data, p); err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+func sqlInQuery(prefix string, values []string) (string, []any) {
+	query := prefix + "("
+	args := make([]any, 0, len(values))
+	for i, v := range values {
+		if i > 0 {
+			query += ", "
+		}
+		query += "?"
+		args = append(args, v)
+	}
+	return query + ")", args
+}
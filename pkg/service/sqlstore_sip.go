@@ -0,0 +1,195 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func (s *SQLStore) StoreSIPTrunk(ctx context.Context, info *livekit.SIPTrunkInfo) error {
+	return sqlStoreOne(s, sqlSIPTrunkTable, info.SipTrunkId, info)
+}
+
+func (s *SQLStore) StoreSIPInboundTrunk(ctx context.Context, info *livekit.SIPInboundTrunkInfo) error {
+	return sqlStoreOne(s, sqlSIPInboundTrunkTable, info.SipTrunkId, info)
+}
+
+func (s *SQLStore) StoreSIPOutboundTrunk(ctx context.Context, info *livekit.SIPOutboundTrunkInfo) error {
+	return sqlStoreOne(s, sqlSIPOutboundTrunkTable, info.SipTrunkId, info)
+}
+
+func (s *SQLStore) loadSIPLegacyTrunk(ctx context.Context, id string) (*livekit.SIPTrunkInfo, error) {
+	return sqlLoadOne[livekit.SIPTrunkInfo](s, sqlSIPTrunkTable, id, ErrSIPTrunkNotFound)
+}
+
+func (s *SQLStore) loadSIPInboundTrunk(ctx context.Context, id string) (*livekit.SIPInboundTrunkInfo, error) {
+	return sqlLoadOne[livekit.SIPInboundTrunkInfo](s, sqlSIPInboundTrunkTable, id, ErrSIPTrunkNotFound)
+}
+
+func (s *SQLStore) loadSIPOutboundTrunk(ctx context.Context, id string) (*livekit.SIPOutboundTrunkInfo, error) {
+	return sqlLoadOne[livekit.SIPOutboundTrunkInfo](s, sqlSIPOutboundTrunkTable, id, ErrSIPTrunkNotFound)
+}
+
+func (s *SQLStore) LoadSIPTrunk(ctx context.Context, id string) (*livekit.SIPTrunkInfo, error) {
+	tr, err := s.loadSIPLegacyTrunk(ctx, id)
+	if err == nil {
+		return tr, nil
+	} else if err != ErrSIPTrunkNotFound {
+		return nil, err
+	}
+	in, err := s.loadSIPInboundTrunk(ctx, id)
+	if err == nil {
+		return in.AsTrunkInfo(), nil
+	} else if err != ErrSIPTrunkNotFound {
+		return nil, err
+	}
+	out, err := s.loadSIPOutboundTrunk(ctx, id)
+	if err == nil {
+		return out.AsTrunkInfo(), nil
+	} else if err != ErrSIPTrunkNotFound {
+		return nil, err
+	}
+	return nil, ErrSIPTrunkNotFound
+}
+
+func (s *SQLStore) LoadSIPInboundTrunk(ctx context.Context, id string) (*livekit.SIPInboundTrunkInfo, error) {
+	in, err := s.loadSIPInboundTrunk(ctx, id)
+	if err == nil {
+		return in, nil
+	} else if err != ErrSIPTrunkNotFound {
+		return nil, err
+	}
+	tr, err := s.loadSIPLegacyTrunk(ctx, id)
+	if err == nil {
+		return tr.AsInbound(), nil
+	} else if err != ErrSIPTrunkNotFound {
+		return nil, err
+	}
+	return nil, ErrSIPTrunkNotFound
+}
+
+func (s *SQLStore) LoadSIPOutboundTrunk(ctx context.Context, id string) (*livekit.SIPOutboundTrunkInfo, error) {
+	in, err := s.loadSIPOutboundTrunk(ctx, id)
+	if err == nil {
+		return in, nil
+	} else if err != ErrSIPTrunkNotFound {
+		return nil, err
+	}
+	tr, err := s.loadSIPLegacyTrunk(ctx, id)
+	if err == nil {
+		return tr.AsOutbound(), nil
+	} else if err != ErrSIPTrunkNotFound {
+		return nil, err
+	}
+	return nil, ErrSIPTrunkNotFound
+}
+
+func (s *SQLStore) DeleteSIPTrunk(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(s.ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{sqlSIPTrunkTable, sqlSIPInboundTrunkTable, sqlSIPOutboundTrunkTable} {
+		if _, err = tx.ExecContext(s.ctx, `DELETE FROM `+table+` WHERE id = ?`, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) listSIPLegacyTrunk(ctx context.Context) ([]*livekit.SIPTrunkInfo, error) {
+	return sqlLoadMany[livekit.SIPTrunkInfo](s, sqlSIPTrunkTable)
+}
+
+func (s *SQLStore) listSIPInboundTrunk(ctx context.Context) ([]*livekit.SIPInboundTrunkInfo, error) {
+	return sqlLoadMany[livekit.SIPInboundTrunkInfo](s, sqlSIPInboundTrunkTable)
+}
+
+func (s *SQLStore) listSIPOutboundTrunk(ctx context.Context) ([]*livekit.SIPOutboundTrunkInfo, error) {
+	return sqlLoadMany[livekit.SIPOutboundTrunkInfo](s, sqlSIPOutboundTrunkTable)
+}
+
+func (s *SQLStore) ListSIPTrunk(ctx context.Context) ([]*livekit.SIPTrunkInfo, error) {
+	infos, err := s.listSIPLegacyTrunk(ctx)
+	if err != nil {
+		return nil, err
+	}
+	in, err := s.listSIPInboundTrunk(ctx)
+	if err != nil {
+		return infos, err
+	}
+	for _, t := range in {
+		infos = append(infos, t.AsTrunkInfo())
+	}
+	out, err := s.listSIPOutboundTrunk(ctx)
+	if err != nil {
+		return infos, err
+	}
+	for _, t := range out {
+		infos = append(infos, t.AsTrunkInfo())
+	}
+	return infos, nil
+}
+
+func (s *SQLStore) ListSIPInboundTrunk(ctx context.Context) (infos []*livekit.SIPInboundTrunkInfo, err error) {
+	in, err := s.listSIPInboundTrunk(ctx)
+	if err != nil {
+		return in, err
+	}
+	old, err := s.listSIPLegacyTrunk(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range old {
+		in = append(in, t.AsInbound())
+	}
+	return in, nil
+}
+
+func (s *SQLStore) ListSIPOutboundTrunk(ctx context.Context) (infos []*livekit.SIPOutboundTrunkInfo, err error) {
+	out, err := s.listSIPOutboundTrunk(ctx)
+	if err != nil {
+		return out, err
+	}
+	old, err := s.listSIPLegacyTrunk(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range old {
+		out = append(out, t.AsOutbound())
+	}
+	return out, nil
+}
+
+func (s *SQLStore) StoreSIPDispatchRule(ctx context.Context, info *livekit.SIPDispatchRuleInfo) error {
+	return sqlStoreOne(s, sqlSIPDispatchRuleTable, info.SipDispatchRuleId, info)
+}
+
+func (s *SQLStore) LoadSIPDispatchRule(ctx context.Context, sipDispatchRuleId string) (*livekit.SIPDispatchRuleInfo, error) {
+	return sqlLoadOne[livekit.SIPDispatchRuleInfo](s, sqlSIPDispatchRuleTable, sipDispatchRuleId, ErrSIPDispatchRuleNotFound)
+}
+
+func (s *SQLStore) DeleteSIPDispatchRule(ctx context.Context, info *livekit.SIPDispatchRuleInfo) error {
+	_, err := s.db.ExecContext(s.ctx, `DELETE FROM `+sqlSIPDispatchRuleTable+` WHERE id = ?`, info.SipDispatchRuleId)
+	return err
+}
+
+func (s *SQLStore) ListSIPDispatchRule(ctx context.Context) (infos []*livekit.SIPDispatchRuleInfo, err error) {
+	return sqlLoadMany[livekit.SIPDispatchRuleInfo](s, sqlSIPDispatchRuleTable)
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"net/http"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+const stagePath = "/stage"
+
+type StageRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+}
+
+type RaisedHandInfo struct {
+	Identity string `json:"identity"`
+	RaisedAt int64  `json:"raised_at"`
+}
+
+type StageInfo struct {
+	Room          string           `json:"room"`
+	MaxPublishers uint32           `json:"max_publishers"`
+	OnStage       []string         `json:"on_stage"`
+	RaisedHands   []RaisedHandInfo `json:"raised_hands"`
+}
+
+func stageInfo(roomName livekit.RoomName, state rtc.StageState) *StageInfo {
+	info := &StageInfo{
+		Room:          string(roomName),
+		MaxPublishers: state.MaxPublishers,
+		OnStage:       make([]string, 0, len(state.OnStage)),
+		RaisedHands:   make([]RaisedHandInfo, 0, len(state.RaisedHands)),
+	}
+	for _, identity := range state.OnStage {
+		info.OnStage = append(info.OnStage, string(identity))
+	}
+	for _, h := range state.RaisedHands {
+		info.RaisedHands = append(info.RaisedHands, RaisedHandInfo{
+			Identity: string(h.Identity),
+			RaisedAt: h.RaisedAt.UnixMilli(),
+		})
+	}
+	return info
+}
+
+// StageService lets room admins see who holds the publisher slots of a room and who raised their hand,
+// and promote or demote participants. Rooms get a stage when they are created with a named room configuration
+// that has one configured.
+// Requests are served by the RoomService, which routes them to the node hosting the room.
+type StageService struct {
+	roomService *RoomService
+}
+
+func NewStageService(roomService *RoomService) *StageService {
+	return &StageService{
+		roomService: roomService,
+	}
+}
+
+func (s *StageService) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET "+stagePath, s.handleGet)
+	mux.HandleFunc("POST "+stagePath+"/promote", s.handlePromote)
+	mux.HandleFunc("POST "+stagePath+"/demote", s.handleDemote)
+}
+
+func (s *StageService) handleGet(w http.ResponseWriter, r *http.Request) {
+	roomName := r.URL.Query().Get("room")
+	info, err := s.roomService.GetStage(r.Context(), &StageRequest{Room: roomName})
+	if err != nil {
+		handleError(w, r, statusForError(err), err, "room", roomName)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *StageService) handlePromote(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.readRequest(w, r)
+	if !ok {
+		return
+	}
+
+	pi, err := s.roomService.PromoteParticipant(r.Context(), &livekit.RoomParticipantIdentity{Room: req.Room, Identity: req.Identity})
+	if err != nil {
+		handleError(w, r, statusForError(err), err, "room", req.Room, "participant", req.Identity)
+		return
+	}
+	writeJSON(w, http.StatusOK, pi)
+}
+
+func (s *StageService) handleDemote(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.readRequest(w, r)
+	if !ok {
+		return
+	}
+
+	pi, err := s.roomService.DemoteParticipant(r.Context(), &livekit.RoomParticipantIdentity{Room: req.Room, Identity: req.Identity})
+	if err != nil {
+		handleError(w, r, statusForError(err), err, "room", req.Room, "participant", req.Identity)
+		return
+	}
+	writeJSON(w, http.StatusOK, pi)
+}
+
+func (s *StageService) readRequest(w http.ResponseWriter, r *http.Request) (StageRequest, bool) {
+	var req StageRequest
+	if !readJSON(w, r, &req) {
+		return req, false
+	}
+	return req, true
+}
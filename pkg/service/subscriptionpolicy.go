@@ -0,0 +1,91 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"net/http"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const subscriptionPolicyPath = "/subscription_policy"
+
+type SubscriptionPolicy struct {
+	Room  string                    `json:"room"`
+	Rules []config.SubscriptionRule `json:"rules"`
+}
+
+// SubscriptionPolicyService sets the subscription rules of a room, on top of the permissions each publisher grants.
+// Rooms created with a named room configuration start with the policy configured for it, if any.
+type SubscriptionPolicyService struct {
+	roomManager *RoomManager
+}
+
+func NewSubscriptionPolicyService(roomManager *RoomManager) *SubscriptionPolicyService {
+	return &SubscriptionPolicyService{
+		roomManager: roomManager,
+	}
+}
+
+func (s *SubscriptionPolicyService) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("PUT "+subscriptionPolicyPath, s.handleSet)
+	mux.HandleFunc("GET "+subscriptionPolicyPath, s.handleGet)
+}
+
+func (s *SubscriptionPolicyService) handleSet(w http.ResponseWriter, r *http.Request) {
+	var req SubscriptionPolicy
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if err := config.ValidateSubscriptionRules(req.Rules); err != nil {
+		handleError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	roomName := livekit.RoomName(req.Room)
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	room, err := s.roomManager.getLocalRoom(r.Context(), roomName)
+	if err != nil {
+		handleError(w, r, statusForError(err), err, "room", req.Room)
+		return
+	}
+	room.SetSubscriptionPolicy(req.Rules)
+	writeJSON(w, http.StatusOK, &req)
+}
+
+func (s *SubscriptionPolicyService) handleGet(w http.ResponseWriter, r *http.Request) {
+	roomName := livekit.RoomName(r.URL.Query().Get("room"))
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	room, err := s.roomManager.getLocalRoom(r.Context(), roomName)
+	if err != nil {
+		handleError(w, r, statusForError(err), err, "room", roomName)
+		return
+	}
+	rules := room.GetSubscriptionPolicy()
+	if rules == nil {
+		rules = []config.SubscriptionRule{}
+	}
+	writeJSON(w, http.StatusOK, &SubscriptionPolicy{Room: string(roomName), Rules: rules})
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"net/http"
+
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+const trackBridgePath = "/track_bridges"
+
+type CreateTrackBridgeRequest struct {
+	Room            string `json:"room"`
+	TrackSid        string `json:"track_sid"`
+	DestinationRoom string `json:"destination_room"`
+}
+
+type ListTrackBridgesRequest struct {
+	Room string `json:"room"`
+}
+
+type ListTrackBridgesResponse struct {
+	Bridges []*TrackBridgeInfo `json:"bridges"`
+}
+
+type DeleteTrackBridgeRequest struct {
+	// room the bridged track is published in
+	Room     string `json:"room"`
+	BridgeID string `json:"bridge_id"`
+}
+
+type TrackBridgeInfo struct {
+	BridgeID            string `json:"bridge_id"`
+	Room                string `json:"room"`
+	TrackSid            string `json:"track_sid"`
+	ParticipantIdentity string `json:"participant_identity"`
+	DestinationRoom     string `json:"destination_room"`
+	CreatedAt           int64  `json:"created_at"`
+}
+
+func trackBridgeInfo(b *rtc.TrackBridge) *TrackBridgeInfo {
+	return &TrackBridgeInfo{
+		BridgeID:            b.ID(),
+		Room:                string(b.SourceRoom()),
+		TrackSid:            string(b.TrackID()),
+		ParticipantIdentity: string(b.Publisher().Identity()),
+		DestinationRoom:     string(b.DestinationRoom()),
+		CreatedAt:           b.CreatedAt().UnixMilli(),
+	}
+}
+
+// TrackBridgeService lets admins forward tracks of a room to other rooms hosted on the same node,
+// e.g. a stage to its overflow rooms, without publishers joining more than one room.
+// Bridges live as long as the track, the publisher and both rooms do, or until they are deleted.
+// Requests are served by the RoomService, which routes them to the node hosting the track's room.
+// Bridges to a room hosted on another node are rejected with 412.
+type TrackBridgeService struct {
+	roomService *RoomService
+}
+
+func NewTrackBridgeService(roomService *RoomService) *TrackBridgeService {
+	return &TrackBridgeService{
+		roomService: roomService,
+	}
+}
+
+func (s *TrackBridgeService) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST "+trackBridgePath, s.handleCreate)
+	mux.HandleFunc("GET "+trackBridgePath, s.handleList)
+	mux.HandleFunc("DELETE "+trackBridgePath+"/{bridge}", s.handleDelete)
+}
+
+func (s *TrackBridgeService) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req CreateTrackBridgeRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	info, err := s.roomService.CreateTrackBridge(r.Context(), &req)
+	if err != nil {
+		handleError(w, r, statusForError(err), err, "room", req.Room, "trackID", req.TrackSid, "destinationRoom", req.DestinationRoom)
+		return
+	}
+	writeJSON(w, http.StatusCreated, info)
+}
+
+func (s *TrackBridgeService) handleList(w http.ResponseWriter, r *http.Request) {
+	roomName := r.URL.Query().Get("room")
+	res, err := s.roomService.ListTrackBridges(r.Context(), &ListTrackBridgesRequest{Room: roomName})
+	if err != nil {
+		handleError(w, r, statusForError(err), err, "room", roomName)
+		return
+	}
+	writeJSON(w, http.StatusOK, res.Bridges)
+}
+
+// handleDelete takes the room the bridged track is published in as the room query parameter
+func (s *TrackBridgeService) handleDelete(w http.ResponseWriter, r *http.Request) {
+	req := &DeleteTrackBridgeRequest{
+		Room:     r.URL.Query().Get("room"),
+		BridgeID: r.PathValue("bridge"),
+	}
+	info, err := s.roomService.DeleteTrackBridge(r.Context(), req)
+	if err != nil {
+		handleError(w, r, statusForError(err), err, "room", req.Room, "bridgeID", req.BridgeID)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
@@ -16,16 +16,23 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net"
 	"net/http"
 	"regexp"
 	"strings"
 
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
 )
 
+// upper bound for the JSON request bodies of the admin HTTP endpoints
+const maxJSONBodySize = 16 * 1024
+
 func handleError(w http.ResponseWriter, r *http.Request, status int, err error, keysAndValues ...interface{}) {
 	keysAndValues = append(keysAndValues, "status", status)
 	if r != nil && r.URL != nil {
@@ -38,6 +45,39 @@ func handleError(w http.ResponseWriter, r *http.Request, status int, err error,
 	_, _ = w.Write([]byte(err.Error()))
 }
 
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	var b []byte
+	var err error
+	if m, ok := v.(proto.Message); ok {
+		b, err = protojson.Marshal(m)
+	} else {
+		b, err = json.Marshal(v)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
+
+// readJSON decodes the JSON request body into v, responding with 413 when the body is larger than maxJSONBodySize
+// and with 400 when it cannot be decoded. It returns false when an error response was written.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBodySize)).Decode(v); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			handleError(w, r, http.StatusRequestEntityTooLarge, err)
+		} else {
+			handleError(w, r, http.StatusBadRequest, err)
+		}
+		return false
+	}
+	return true
+}
+
 func boolValue(s string) bool {
 	return s == "1" || s == "true"
 }
@@ -0,0 +1,726 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/frostbyte73/core"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils/guid"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/telemetry"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+)
+
+const (
+	webhookNumWorkers          = 10
+	webhookDefaultTimeout      = 10 * time.Second
+	webhookDefaultMaxRetries   = 4
+	webhookDefaultRetryWaitMin = time.Second
+	webhookDefaultRetryWaitMax = 30 * time.Second
+	webhookDefaultQueueSize    = 100
+
+	// a node owns the pending deliveries it works on for this long, renewing while they are queued.
+	// once expired, any node sweeping the store takes them over.
+	webhookLeaseDuration = 2 * time.Minute
+	webhookSweepInterval = 30 * time.Second
+)
+
+var webhookEvents = map[string]bool{
+	webhook.EventRoomStarted:       true,
+	webhook.EventRoomFinished:      true,
+	telemetry.EventRoomExpiring:    true,
+	webhook.EventParticipantJoined: true,
+	webhook.EventParticipantLeft:   true,
+	webhook.EventTrackPublished:    true,
+	webhook.EventTrackUnpublished:  true,
+	webhook.EventEgressStarted:     true,
+	webhook.EventEgressUpdated:     true,
+	webhook.EventEgressEnded:       true,
+	webhook.EventIngressStarted:    true,
+	webhook.EventIngressEnded:      true,
+}
+
+// WebhookDelivery is an event on its way to one endpoint, or given up on after exhausting its retries
+type WebhookDelivery struct {
+	ID    string `json:"id"`
+	URL   string `json:"url"`
+	Event string `json:"event"`
+	Room  string `json:"room,omitempty"`
+	// the signed request body, livekit.WebhookEvent as JSON
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error,omitempty"`
+	CreatedAt int64           `json:"created_at"`
+	// node delivering the event, and until when it holds it
+	NodeID         string `json:"node_id,omitempty"`
+	LeaseExpiresAt int64  `json:"lease_expires_at,omitempty"`
+	DeadLetteredAt int64  `json:"dead_lettered_at,omitempty"`
+}
+
+type webhookEndpoint struct {
+	url       string
+	apiKey    string
+	apiSecret string
+	events    map[string]bool
+	rooms     []string
+
+	maxRetries   int
+	retryWaitMin time.Duration
+	retryWaitMax time.Duration
+	queueSize    int
+
+	client *http.Client
+	pool   core.QueuePool
+	// deliveries submitted to the pool and not started yet, protected by notifier lock
+	queued int
+
+	// held for reading while submitting, no submits reach the pool once it is closed
+	closeLock sync.RWMutex
+	closed    bool
+}
+
+// close stops the endpoint taking deliveries, unless forced queued deliveries are completed first
+func (e *webhookEndpoint) close(force bool) {
+	e.closeLock.Lock()
+	e.closed = true
+	e.closeLock.Unlock()
+
+	if force {
+		e.pool.Kill()
+	} else {
+		e.pool.Drain()
+	}
+}
+
+func (e *webhookEndpoint) accepts(event *livekit.WebhookEvent) bool {
+	if len(e.events) != 0 && !e.events[event.Event] {
+		return false
+	}
+	if len(e.rooms) == 0 {
+		return true
+	}
+
+	roomName := webhookEventRoomName(event)
+	if roomName == "" {
+		return false
+	}
+	for _, pattern := range e.rooms {
+		if ok, _ := path.Match(pattern, roomName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *webhookEndpoint) backoff(attempts int) time.Duration {
+	wait := e.retryWaitMin
+	for i := 1; i < attempts && wait < e.retryWaitMax; i++ {
+		wait *= 2
+	}
+	return min(wait, e.retryWaitMax)
+}
+
+// send makes a single attempt, returning whether a failure is worth retrying
+func (e *webhookEndpoint) send(payload []byte) (bool, error) {
+	sum := sha256.Sum256(payload)
+	token, err := auth.NewAccessToken(e.apiKey, e.apiSecret).
+		SetValidFor(5 * time.Minute).
+		SetSha256(base64.StdEncoding.EncodeToString(sum[:])).
+		ToJWT()
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", token)
+	// use a custom mime type to ensure signature is checked prior to parsing
+	req.Header.Set("Content-Type", "application/webhook+json")
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+	_ = res.Body.Close()
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return false, nil
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusRequestTimeout || res.StatusCode >= 500:
+		return true, fmt.Errorf("webhook endpoint returned %s", res.Status)
+	default:
+		// the receiver rejected the event, sending it again will not help
+		return false, fmt.Errorf("webhook endpoint returned %s", res.Status)
+	}
+}
+
+// webhookTask is a delivery this node is working on
+type webhookTask struct {
+	endpoint *webhookEndpoint
+
+	// serializes updates of the stored delivery
+	lock     sync.Mutex
+	delivery WebhookDelivery
+	done     bool
+}
+
+// WebhookNotifier delivers events to every endpoint whose event and room filters match,
+// signing them with the endpoint's key. Endpoints queue and retry independently,
+// so a slow consumer does not hold up the others.
+//
+// Events are persisted before they are queued and removed once acknowledged, failed attempts are retried
+// with exponential backoff. Events that exhaust their retries, or are rejected by the receiver, are kept
+// as dead letters to be inspected and replayed through WebhookService.
+// Delivery is at least once, a receiver can see an event again after a node crashes mid-request.
+type WebhookNotifier struct {
+	nodeID livekit.NodeID
+	store  WebhookStore
+	// when the store is not shared with other nodes, everything pending belongs to this node
+	exclusive bool
+	// closed on stop, when opened for webhooks only
+	ownedStore *LocalStore
+
+	lock sync.Mutex
+	// replaced on reload, deliveries already queued finish on the endpoint they were queued on
+	endpoints []*webhookEndpoint
+	inflight  map[string]*webhookTask
+	sweeping  bool
+	stopped   core.Fuse
+
+	processedHook func(ctx context.Context, whi *livekit.WebhookInfo)
+}
+
+func NewWebhookNotifier(conf config.WebHookConfig, provider auth.KeyProvider, store WebhookStore, nodeID livekit.NodeID) (*WebhookNotifier, error) {
+	if store == nil {
+		return nil, errors.New("webhooks require a store")
+	}
+
+	endpoints, err := newWebhookEndpoints(conf, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	_, shared := store.(*RedisStore)
+	n := &WebhookNotifier{
+		nodeID:    nodeID,
+		store:     store,
+		exclusive: !shared,
+		endpoints: endpoints,
+		inflight:  make(map[string]*webhookTask),
+	}
+
+	n.maybeStartSweeper()
+	return n, nil
+}
+
+// newWebhookEndpoints validates the webhook config, including the keys used to sign events
+func newWebhookEndpoints(conf config.WebHookConfig, provider auth.KeyProvider) ([]*webhookEndpoint, error) {
+	configs := make([]config.WebHookEndpointConfig, 0, len(conf.URLs)+len(conf.Endpoints))
+	for _, url := range conf.URLs {
+		configs = append(configs, config.WebHookEndpointConfig{URL: url})
+	}
+	configs = append(configs, conf.Endpoints...)
+
+	endpoints := make([]*webhookEndpoint, 0, len(configs))
+	for _, ec := range configs {
+		e, err := newWebhookEndpoint(ec, conf.APIKey, provider)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, e)
+	}
+	return endpoints, nil
+}
+
+func newWebhookEndpoint(conf config.WebHookEndpointConfig, defaultAPIKey string, provider auth.KeyProvider) (*webhookEndpoint, error) {
+	if conf.URL == "" {
+		return nil, errors.New("webhook endpoint is missing url")
+	}
+
+	apiKey := conf.APIKey
+	if apiKey == "" {
+		apiKey = defaultAPIKey
+	}
+	secret := provider.GetSecret(apiKey)
+	if secret == "" {
+		return nil, ErrWebHookMissingAPIKey
+	}
+
+	e := &webhookEndpoint{
+		url:          conf.URL,
+		apiKey:       apiKey,
+		apiSecret:    secret,
+		rooms:        conf.Rooms,
+		maxRetries:   webhookDefaultMaxRetries,
+		retryWaitMin: webhookDefaultRetryWaitMin,
+		retryWaitMax: webhookDefaultRetryWaitMax,
+		queueSize:    webhookDefaultQueueSize,
+	}
+	if len(conf.Events) != 0 {
+		e.events = make(map[string]bool, len(conf.Events))
+		for _, event := range conf.Events {
+			if !webhookEvents[event] {
+				return nil, fmt.Errorf("unknown webhook event %q for %s", event, conf.URL)
+			}
+			e.events[event] = true
+		}
+	}
+	for _, pattern := range conf.Rooms {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid webhook room pattern %q for %s: %w", pattern, conf.URL, err)
+		}
+	}
+
+	timeout := webhookDefaultTimeout
+	if conf.Timeout > 0 {
+		timeout = conf.Timeout
+	}
+	if conf.MaxRetries > 0 {
+		e.maxRetries = conf.MaxRetries
+	}
+	if conf.RetryWaitMin > 0 {
+		e.retryWaitMin = conf.RetryWaitMin
+	}
+	if conf.RetryWaitMax > 0 {
+		e.retryWaitMax = conf.RetryWaitMax
+	}
+	if conf.QueueSize > 0 {
+		e.queueSize = conf.QueueSize
+	}
+	e.client = &http.Client{Timeout: timeout}
+	// unbounded, queueSize is enforced before submitting
+	e.pool = core.NewQueuePool(webhookNumWorkers, core.QueueWorkerParams{QueueSize: e.queueSize})
+	return e, nil
+}
+
+// RegisterProcessedHook sets a callback invoked once a delivery is acknowledged or given up on
+func (n *WebhookNotifier) RegisterProcessedHook(hook func(ctx context.Context, whi *livekit.WebhookInfo)) {
+	n.lock.Lock()
+	n.processedHook = hook
+	n.lock.Unlock()
+}
+
+// SetKeys is a no-op, events are signed with the key configured for each endpoint
+func (n *WebhookNotifier) SetKeys(apiKey, apiSecret string) {}
+
+// SetFilter is a no-op, events are filtered by the event and room filters configured for each endpoint
+func (n *WebhookNotifier) SetFilter(params webhook.FilterParams) {}
+
+// QueueNotify delivers to the configured endpoints only, per event options (extra webhooks,
+// secrets) are not supported.
+func (n *WebhookNotifier) QueueNotify(ctx context.Context, event *livekit.WebhookEvent, _ ...webhook.NotifyOption) error {
+	n.lock.Lock()
+	endpoints := n.endpoints
+	n.lock.Unlock()
+
+	var payload []byte
+	for _, e := range endpoints {
+		if !e.accepts(event) {
+			continue
+		}
+		if payload == nil {
+			var err error
+			if payload, err = protojson.Marshal(event); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		t := &webhookTask{
+			endpoint: e,
+			delivery: WebhookDelivery{
+				ID:             guid.New("WH_"),
+				URL:            e.url,
+				Event:          event.Event,
+				Room:           webhookEventRoomName(event),
+				Payload:        payload,
+				CreatedAt:      now.UnixMilli(),
+				NodeID:         string(n.nodeID),
+				LeaseExpiresAt: now.Add(webhookLeaseDuration).UnixMilli(),
+			},
+		}
+		if err := n.store.StoreWebhookDelivery(ctx, &t.delivery); err != nil {
+			// still attempt to deliver, it is only lost if this node goes away
+			logger.Warnw("could not persist webhook", err, "event", event.Event, "url", e.url)
+		}
+		n.submit(t)
+	}
+	return nil
+}
+
+// Stop abandons queued and retrying deliveries, they stay in the store to be picked up again
+// after a restart, or by another node sharing the store.
+// Unless forced, requests in progress are given time to complete.
+func (n *WebhookNotifier) Stop(force bool) {
+	if !n.stopped.Break() {
+		return
+	}
+
+	n.lock.Lock()
+	endpoints := n.endpoints
+	n.lock.Unlock()
+
+	var wg sync.WaitGroup
+	for _, e := range endpoints {
+		wg.Add(1)
+		go func(e *webhookEndpoint) {
+			defer wg.Done()
+			e.close(force)
+		}(e)
+	}
+	wg.Wait()
+
+	if n.ownedStore != nil {
+		if err := n.ownedStore.Close(); err != nil {
+			logger.Warnw("could not close webhook store", err)
+		}
+	}
+}
+
+// setEndpoints replaces the endpoints events are sent to. Queued deliveries to endpoints that were removed
+// are still attempted, pending deliveries left in the store for them are dead-lettered by the next sweep.
+func (n *WebhookNotifier) setEndpoints(endpoints []*webhookEndpoint) {
+	n.lock.Lock()
+	prev := n.endpoints
+	n.endpoints = endpoints
+	n.lock.Unlock()
+
+	for _, e := range prev {
+		go e.close(false)
+	}
+	n.maybeStartSweeper()
+}
+
+// Replay queues a dead-lettered delivery again, with a fresh set of retries
+func (n *WebhookNotifier) Replay(ctx context.Context, id string) (*WebhookDelivery, error) {
+	d, err := n.store.LoadWebhookDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d.DeadLetteredAt == 0 {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	e := n.endpointForURL(d.URL)
+	if e == nil {
+		return nil, ErrWebhookEndpointNotFound
+	}
+
+	d.Attempts = 0
+	d.LastError = ""
+	d.DeadLetteredAt = 0
+	d.NodeID = string(n.nodeID)
+	d.LeaseExpiresAt = time.Now().Add(webhookLeaseDuration).UnixMilli()
+	if err = n.store.StoreWebhookDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+
+	n.submit(&webhookTask{endpoint: e, delivery: *d})
+	return d, nil
+}
+
+func (n *WebhookNotifier) submit(t *webhookTask) {
+	e := t.endpoint
+	id := t.delivery.ID
+
+	e.closeLock.RLock()
+	defer e.closeLock.RUnlock()
+	if e.closed {
+		// left in the store, the next sweep moves it to the endpoint replacing this one
+		return
+	}
+
+	n.lock.Lock()
+	if n.stopped.IsBroken() || n.inflight[id] != nil {
+		n.lock.Unlock()
+		return
+	}
+	if e.queued >= e.queueSize {
+		n.lock.Unlock()
+		// left in the store, the next sweep tries again
+		logger.Infow("webhook queue full, deferring delivery", "event", t.delivery.Event, "url", e.url)
+		return
+	}
+	n.inflight[id] = t
+	e.queued++
+	n.lock.Unlock()
+
+	key := t.delivery.Room
+	if key == "" {
+		key = id
+	}
+	e.pool.Submit(key, func() {
+		n.lock.Lock()
+		e.queued--
+		n.lock.Unlock()
+
+		n.deliver(t)
+
+		n.lock.Lock()
+		delete(n.inflight, id)
+		n.lock.Unlock()
+	})
+}
+
+func (n *WebhookNotifier) deliver(t *webhookTask) {
+	e := t.endpoint
+	for {
+		if n.stopped.IsBroken() {
+			return
+		}
+
+		sentAt := time.Now()
+		retryable, err := e.send(t.delivery.Payload)
+
+		t.lock.Lock()
+		d := &t.delivery
+		fields := []interface{}{
+			"id", d.ID,
+			"event", d.Event,
+			"room", d.Room,
+			"url", e.url,
+			"sendDuration", time.Since(sentAt),
+		}
+		if err == nil {
+			t.done = true
+			if serr := n.store.DeleteWebhookDelivery(context.Background(), d.ID); serr != nil {
+				logger.Warnw("could not remove delivered webhook", serr, fields...)
+			}
+			t.lock.Unlock()
+
+			prometheus.RecordWebhookDelivered(d.Event)
+			logger.Infow("sent webhook", fields...)
+			n.processed(t, sentAt, nil)
+			return
+		}
+
+		d.Attempts++
+		d.LastError = err.Error()
+		fields = append(fields, "attempts", d.Attempts)
+		if !retryable || d.Attempts > e.maxRetries {
+			t.done = true
+			d.DeadLetteredAt = time.Now().UnixMilli()
+			d.NodeID = ""
+			d.LeaseExpiresAt = 0
+			if serr := n.store.StoreWebhookDelivery(context.Background(), d); serr != nil {
+				logger.Warnw("could not dead-letter webhook", serr, fields...)
+			}
+			t.lock.Unlock()
+
+			prometheus.RecordWebhookDropped(d.Event)
+			logger.Warnw("failed to send webhook", err, fields...)
+			n.processed(t, sentAt, err)
+			return
+		}
+
+		wait := e.backoff(d.Attempts)
+		d.LeaseExpiresAt = time.Now().Add(wait + webhookLeaseDuration).UnixMilli()
+		if serr := n.store.StoreWebhookDelivery(context.Background(), d); serr != nil {
+			logger.Warnw("could not update webhook", serr, fields...)
+		}
+		t.lock.Unlock()
+
+		prometheus.RecordWebhookRetried(d.Event)
+		logger.Infow("retrying webhook", append(fields, "error", err, "wait", wait)...)
+
+		select {
+		case <-time.After(wait):
+		case <-n.stopped.Watch():
+			return
+		}
+	}
+}
+
+func (n *WebhookNotifier) processed(t *webhookTask, sentAt time.Time, err error) {
+	n.lock.Lock()
+	hook := n.processedHook
+	n.lock.Unlock()
+	if hook == nil {
+		return
+	}
+
+	d := &t.delivery
+	whi := &livekit.WebhookInfo{
+		EventId:        d.ID,
+		Event:          d.Event,
+		RoomName:       d.Room,
+		Url:            d.URL,
+		CreatedAt:      timestamppb.New(time.UnixMilli(d.CreatedAt)),
+		SentAt:         timestamppb.New(sentAt),
+		SendDurationNs: time.Since(sentAt).Nanoseconds(),
+		IsDropped:      err != nil,
+	}
+	if err != nil {
+		whi.SendError = err.Error()
+	}
+	hook(context.Background(), whi)
+}
+
+// maybeStartSweeper starts sweeping the store once there are endpoints. without them, this node would only
+// dead-letter the deliveries of other nodes it takes over. it keeps sweeping when endpoints are removed later,
+// to dead-letter the deliveries left for them.
+func (n *WebhookNotifier) maybeStartSweeper() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.sweeping || len(n.endpoints) == 0 || n.stopped.IsBroken() {
+		return
+	}
+	n.sweeping = true
+	go n.sweepWorker()
+}
+
+func (n *WebhookNotifier) sweepWorker() {
+	ticker := time.NewTicker(webhookSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		n.sweep()
+
+		select {
+		case <-n.stopped.Watch():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweep renews the leases of deliveries this node is working on,
+// and takes over pending deliveries that are not owned by a live node
+func (n *WebhookNotifier) sweep() {
+	ctx := context.Background()
+	now := time.Now()
+
+	n.lock.Lock()
+	tasks := make([]*webhookTask, 0, len(n.inflight))
+	for _, t := range n.inflight {
+		tasks = append(tasks, t)
+	}
+	n.lock.Unlock()
+
+	renewBefore := now.Add(2 * webhookSweepInterval).UnixMilli()
+	for _, t := range tasks {
+		t.lock.Lock()
+		if !t.done && t.delivery.LeaseExpiresAt < renewBefore {
+			t.delivery.LeaseExpiresAt = now.Add(webhookLeaseDuration).UnixMilli()
+			if err := n.store.StoreWebhookDelivery(ctx, &t.delivery); err != nil {
+				logger.Warnw("could not renew webhook lease", err, "id", t.delivery.ID)
+			}
+		}
+		t.lock.Unlock()
+	}
+
+	pending, err := n.store.ListWebhookDeliveries(ctx, false)
+	if err != nil {
+		logger.Warnw("could not list pending webhooks", err)
+		return
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].CreatedAt < pending[j].CreatedAt
+	})
+	for _, d := range pending {
+		n.lock.Lock()
+		owned := n.inflight[d.ID] != nil
+		n.lock.Unlock()
+		if owned || (!n.exclusive && d.LeaseExpiresAt > now.UnixMilli()) {
+			continue
+		}
+
+		// other nodes sweeping the store can list the same delivery, only one of them gets to claim it
+		d.NodeID = string(n.nodeID)
+		d.LeaseExpiresAt = now.Add(webhookLeaseDuration).UnixMilli()
+		claimed := true
+		if n.exclusive {
+			err = n.store.StoreWebhookDelivery(ctx, d)
+		} else {
+			claimed, err = n.store.ClaimWebhookDelivery(ctx, d)
+		}
+		if err != nil {
+			logger.Warnw("could not claim webhook", err, "id", d.ID)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		e := n.endpointForURL(d.URL)
+		if e == nil {
+			d.LastError = ErrWebhookEndpointNotFound.Error()
+			d.DeadLetteredAt = now.UnixMilli()
+			d.NodeID = ""
+			d.LeaseExpiresAt = 0
+			if err = n.store.StoreWebhookDelivery(ctx, d); err != nil {
+				logger.Warnw("could not dead-letter webhook", err, "id", d.ID)
+			}
+			prometheus.RecordWebhookDropped(d.Event)
+			continue
+		}
+
+		logger.Debugw("resuming webhook delivery", "id", d.ID, "event", d.Event, "url", d.URL, "attempts", d.Attempts)
+		n.submit(&webhookTask{endpoint: e, delivery: *d})
+	}
+}
+
+// canClaimWebhookDelivery reports whether nodeID can take over a stored delivery
+func canClaimWebhookDelivery(stored *WebhookDelivery, nodeID string, now time.Time) bool {
+	return stored != nil && stored.DeadLetteredAt == 0 && (stored.NodeID == nodeID || stored.LeaseExpiresAt <= now.UnixMilli())
+}
+
+func (n *WebhookNotifier) endpointForURL(url string) *webhookEndpoint {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for _, e := range n.endpoints {
+		if e.url == url {
+			return e
+		}
+	}
+	return nil
+}
+
+func webhookEventRoomName(event *livekit.WebhookEvent) string {
+	switch {
+	case event.Room != nil:
+		return event.Room.Name
+	case event.EgressInfo != nil:
+		return event.EgressInfo.RoomName
+	case event.IngressInfo != nil:
+		return event.IngressInfo.RoomName
+	}
+	return ""
+}
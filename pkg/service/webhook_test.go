@@ -0,0 +1,50 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestWebhookNotifierWithoutEndpoints(t *testing.T) {
+	ctx := context.Background()
+	store := service.NewLocalStore()
+	// left by a previous run, for an endpoint configured on another node
+	delivery := &service.WebhookDelivery{
+		ID:      "WH_1",
+		URL:     "http://localhost:7890",
+		Payload: []byte(`{}`),
+	}
+	require.NoError(t, store.StoreWebhookDelivery(ctx, delivery))
+
+	n, err := service.NewWebhookNotifier(config.WebHookConfig{}, auth.NewFileBasedKeyProviderFromMap(map[string]string{}), store, "node1")
+	require.NoError(t, err)
+	defer n.Stop(true)
+
+	// nothing sweeps the store, the delivery is not dead-lettered
+	require.Never(t, func() bool {
+		stored, err := store.LoadWebhookDelivery(ctx, delivery.ID)
+		return err != nil || stored.DeadLetteredAt != 0
+	}, 200*time.Millisecond, 10*time.Millisecond)
+}
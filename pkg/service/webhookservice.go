@@ -0,0 +1,176 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+)
+
+const webhookDeadLettersPath = "/webhooks/dead_letters"
+
+type PurgeWebhookDeadLettersResponse struct {
+	Deleted int `json:"deleted"`
+}
+
+// WebhookService is an admin API to inspect webhook events that could not be delivered,
+// and to replay or purge them. Listing and purging without a room requires a token that is not scoped to a room.
+type WebhookService struct {
+	notifier *WebhookNotifier
+	store    WebhookStore
+}
+
+func NewWebhookService(notifier *WebhookNotifier, store WebhookStore) *WebhookService {
+	if notifier != nil {
+		// the notifier may use its own store when the object store does not persist
+		store = notifier.store
+	}
+	return &WebhookService{
+		notifier: notifier,
+		store:    store,
+	}
+}
+
+func (s *WebhookService) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET "+webhookDeadLettersPath, s.handleList)
+	mux.HandleFunc("DELETE "+webhookDeadLettersPath, s.handlePurgeAll)
+	mux.HandleFunc("POST "+webhookDeadLettersPath+"/{delivery}/replay", s.handleReplay)
+	mux.HandleFunc("DELETE "+webhookDeadLettersPath+"/{delivery}", s.handlePurge)
+}
+
+// Stop stops delivering webhooks, pending events are kept in the store
+func (s *WebhookService) Stop() {
+	if s.notifier != nil {
+		s.notifier.Stop(false)
+	}
+}
+
+func (s *WebhookService) handleList(w http.ResponseWriter, r *http.Request) {
+	roomName := livekit.RoomName(r.URL.Query().Get("room"))
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	deliveries, err := s.listDeadLetters(r, roomName)
+	if err != nil {
+		handleError(w, r, statusForError(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+func (s *WebhookService) handleReplay(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.loadDeadLetter(w, r)
+	if !ok {
+		return
+	}
+	if s.notifier == nil {
+		handleError(w, r, statusForError(ErrWebhookNotEnabled), ErrWebhookNotEnabled)
+		return
+	}
+
+	d, err := s.notifier.Replay(r.Context(), d.ID)
+	if err != nil {
+		handleError(w, r, statusForError(err), err, "deliveryID", r.PathValue("delivery"))
+		return
+	}
+	logger.Infow("replaying webhook", "id", d.ID, "event", d.Event, "room", d.Room, "url", d.URL)
+	writeJSON(w, http.StatusOK, d)
+}
+
+func (s *WebhookService) handlePurge(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.loadDeadLetter(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.store.DeleteWebhookDelivery(r.Context(), d.ID); err != nil {
+		handleError(w, r, statusForError(err), err, "deliveryID", d.ID)
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+func (s *WebhookService) handlePurgeAll(w http.ResponseWriter, r *http.Request) {
+	roomName := livekit.RoomName(r.URL.Query().Get("room"))
+	if err := EnsureAdminPermission(r.Context(), roomName); err != nil {
+		handleError(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	deliveries, err := s.listDeadLetters(r, roomName)
+	if err != nil {
+		handleError(w, r, statusForError(err), err)
+		return
+	}
+	res := &PurgeWebhookDeadLettersResponse{}
+	for _, d := range deliveries {
+		if err = s.store.DeleteWebhookDelivery(r.Context(), d.ID); err != nil {
+			handleError(w, r, statusForError(err), err, "deliveryID", d.ID)
+			return
+		}
+		res.Deleted++
+	}
+	logger.Infow("purged webhook dead letters", "room", roomName, "deleted", res.Deleted)
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *WebhookService) listDeadLetters(r *http.Request, roomName livekit.RoomName) ([]*WebhookDelivery, error) {
+	if s.store == nil {
+		return nil, ErrWebhookNotEnabled
+	}
+	all, err := s.store.ListWebhookDeliveries(r.Context(), true)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*WebhookDelivery, 0, len(all))
+	for _, d := range all {
+		if roomName == "" || d.Room == string(roomName) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].DeadLetteredAt < deliveries[j].DeadLetteredAt
+	})
+	return deliveries, nil
+}
+
+func (s *WebhookService) loadDeadLetter(w http.ResponseWriter, r *http.Request) (*WebhookDelivery, bool) {
+	if s.store == nil {
+		handleError(w, r, statusForError(ErrWebhookNotEnabled), ErrWebhookNotEnabled)
+		return nil, false
+	}
+
+	id := r.PathValue("delivery")
+	d, err := s.store.LoadWebhookDelivery(r.Context(), id)
+	if err == nil && d.DeadLetteredAt == 0 {
+		// still being delivered
+		err = ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		handleError(w, r, statusForError(err), err, "deliveryID", id)
+		return nil, false
+	}
+	if err = EnsureAdminPermission(r.Context(), livekit.RoomName(d.Room)); err != nil {
+		handleError(w, r, http.StatusUnauthorized, err)
+		return nil, false
+	}
+	return d, true
+}
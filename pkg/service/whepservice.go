@@ -0,0 +1,175 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/utils/guid"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+	"github.com/livekit/livekit-server/pkg/utils"
+)
+
+const whepPathPrefix = "/whep/"
+
+// WHEPService serves WebRTC-HTTP egress (WHEP) viewers directly from the SFU.
+// every viewer is a hidden, subscribe-only participant whose subscriptions are fixed when the offer is answered,
+// tracks published after that are not added to the session, as WHEP has no way for the server to renegotiate.
+// players pick them up by starting a new session.
+type WHEPService struct {
+	sessions *oneShotSessions
+}
+
+func NewWHEPService(
+	conf *config.Config,
+	router routing.Router,
+	ra RoomAllocator,
+	roomManager *RoomManager,
+	currentNode routing.LocalNode,
+) *WHEPService {
+	return &WHEPService{
+		sessions: newOneShotSessions(conf, router, ra, roomManager, currentNode),
+	}
+}
+
+func (s *WHEPService) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST "+whepPathPrefix+"{room}/{target}", s.handlePlay)
+	mux.HandleFunc("PATCH "+whepPathPrefix+"{room}/{target}/{participant}", s.sessions.handleTrickle)
+	mux.HandleFunc("DELETE "+whepPathPrefix+"{room}/{target}/{participant}", s.sessions.handleDelete)
+}
+
+func (s *WHEPService) handlePlay(w http.ResponseWriter, r *http.Request) {
+	offer, ok := readSDPOffer(w, r)
+	if !ok {
+		return
+	}
+
+	roomName := livekit.RoomName(r.PathValue("room"))
+	target := r.PathValue("target")
+	claims, code, err := s.sessions.validate(r, roomName)
+	if err != nil {
+		handleError(w, r, code, err)
+		return
+	}
+	if !claims.Video.GetCanSubscribe() {
+		handleError(w, r, http.StatusUnauthorized, rtc.ErrPermissionDenied)
+		return
+	}
+
+	// viewers only watch an existing room, they never create one
+	room := s.sessions.roomManager.GetRoom(r.Context(), roomName)
+	if room == nil {
+		handleError(w, r, http.StatusNotFound, ErrRoomNotFound)
+		return
+	}
+	trackIDs := whepTrackIDs(room, target)
+	if len(trackIDs) == 0 {
+		handleError(w, r, http.StatusNotFound, ErrTrackNotFound)
+		return
+	}
+
+	// WHEP sessions only ever subscribe, and are not visible to other participants.
+	// several players commonly share a viewer token, so every session gets its own identity.
+	claims.Video.SetCanSubscribe(true)
+	claims.Video.SetCanPublish(false)
+	claims.Video.SetCanPublishData(false)
+	claims.Video.Hidden = true
+	owner := livekit.ParticipantIdentity(claims.Identity)
+	pi := s.sessions.participantInit(r, roomName, owner+livekit.ParticipantIdentity("#"+guid.New("WHEP_")), claims)
+
+	loggerFields := []any{
+		"participant", pi.Identity,
+		"room", roomName,
+		"target", target,
+	}
+	pLogger := utils.GetLogger(r.Context()).WithValues(loggerFields...)
+
+	room, participant, requestSource, err := s.sessions.start(r.Context(), roomName, pi)
+	if err != nil {
+		prometheus.IncrementParticipantJoinFail(1)
+		handleError(w, r, statusForError(err), err, loggerFields...)
+		return
+	}
+	prometheus.IncrementParticipantJoin(1)
+
+	// subscribe before answering, so the answer carries the down tracks
+	if code, err = subscribeWHEPTracks(room, participant, trackIDs); err != nil {
+		requestSource.Close()
+		handleError(w, r, code, err, loggerFields...)
+		return
+	}
+
+	answer, err := s.sessions.negotiate(participant, requestSource, owner, roomName, offer)
+	if err != nil {
+		requestSource.Close()
+		handleError(w, r, http.StatusBadRequest, err, loggerFields...)
+		return
+	}
+
+	pLogger.Infow("WHEP session started", "pID", participant.ID(), "tracks", trackIDs)
+	writeSDPAnswer(w, whepPathPrefix+string(roomName)+"/"+target+"/"+string(participant.ID()), answer)
+}
+
+// whepTrackIDs resolves the playback target, either a track SID or a participant identity/SID whose tracks are all played
+func whepTrackIDs(room *rtc.Room, target string) []livekit.TrackID {
+	if strings.HasPrefix(target, guid.TrackPrefix) {
+		trackID := livekit.TrackID(target)
+		for _, p := range room.GetParticipants() {
+			if p.GetPublishedTrack(trackID) != nil {
+				return []livekit.TrackID{trackID}
+			}
+		}
+		return nil
+	}
+
+	p := room.GetParticipant(livekit.ParticipantIdentity(target))
+	if p == nil && strings.HasPrefix(target, guid.ParticipantPrefix) {
+		p = room.GetParticipantByID(livekit.ParticipantID(target))
+	}
+	if p == nil {
+		return nil
+	}
+
+	var trackIDs []livekit.TrackID
+	for _, track := range p.GetPublishedTracks() {
+		trackIDs = append(trackIDs, track.ID())
+	}
+	return trackIDs
+}
+
+func subscribeWHEPTracks(room *rtc.Room, participant types.LocalParticipant, trackIDs []livekit.TrackID) (int, error) {
+	for _, trackID := range trackIDs {
+		res := room.ResolveMediaTrackForSubscriber(participant, trackID)
+		if res.Track == nil {
+			return http.StatusNotFound, ErrTrackNotFound
+		}
+		if !res.HasPermission {
+			return http.StatusForbidden, rtc.ErrNoTrackPermission
+		}
+		participant.SubscribeToTrack(trackID)
+	}
+
+	if len(participant.GetSubscribedTracks()) != len(trackIDs) {
+		return http.StatusInternalServerError, ErrOperationFailed
+	}
+	return http.StatusOK, nil
+}
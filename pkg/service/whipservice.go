@@ -0,0 +1,97 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"net/http"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/telemetry/prometheus"
+	"github.com/livekit/livekit-server/pkg/utils"
+)
+
+const whipPathPrefix = "/whip/"
+
+// WHIPService accepts WebRTC-HTTP ingestion (RFC 9725) publishers directly on the SFU.
+type WHIPService struct {
+	sessions *oneShotSessions
+}
+
+func NewWHIPService(
+	conf *config.Config,
+	router routing.Router,
+	ra RoomAllocator,
+	roomManager *RoomManager,
+	currentNode routing.LocalNode,
+) *WHIPService {
+	return &WHIPService{
+		sessions: newOneShotSessions(conf, router, ra, roomManager, currentNode),
+	}
+}
+
+func (s *WHIPService) SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST "+whipPathPrefix+"{room}", s.handlePublish)
+	mux.HandleFunc("PATCH "+whipPathPrefix+"{room}/{participant}", s.sessions.handleTrickle)
+	mux.HandleFunc("DELETE "+whipPathPrefix+"{room}/{participant}", s.sessions.handleDelete)
+}
+
+func (s *WHIPService) handlePublish(w http.ResponseWriter, r *http.Request) {
+	offer, ok := readSDPOffer(w, r)
+	if !ok {
+		return
+	}
+
+	roomName := livekit.RoomName(r.PathValue("room"))
+	claims, code, err := s.sessions.validate(r, roomName)
+	if err != nil {
+		handleError(w, r, code, err)
+		return
+	}
+	if !claims.Video.GetCanPublish() {
+		handleError(w, r, http.StatusUnauthorized, rtc.ErrPermissionDenied)
+		return
+	}
+	// WHIP sessions only ever publish
+	claims.Video.SetCanSubscribe(false)
+	pi := s.sessions.participantInit(r, roomName, livekit.ParticipantIdentity(claims.Identity), claims)
+
+	loggerFields := []any{
+		"participant", pi.Identity,
+		"room", roomName,
+	}
+	pLogger := utils.GetLogger(r.Context()).WithValues(loggerFields...)
+
+	_, participant, requestSource, err := s.sessions.start(r.Context(), roomName, pi)
+	if err != nil {
+		prometheus.IncrementParticipantJoinFail(1)
+		handleError(w, r, statusForError(err), err, loggerFields...)
+		return
+	}
+	prometheus.IncrementParticipantJoin(1)
+
+	answer, err := s.sessions.negotiate(participant, requestSource, pi.Identity, roomName, offer)
+	if err != nil {
+		requestSource.Close()
+		handleError(w, r, http.StatusBadRequest, err, loggerFields...)
+		return
+	}
+
+	pLogger.Infow("WHIP session started", "pID", participant.ID())
+	writeSDPAnswer(w, whipPathPrefix+string(roomName)+"/"+string(participant.ID()), answer)
+}
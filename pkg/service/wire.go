@@ -50,11 +50,13 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 		createStore,
 		wire.Bind(new(ServiceStore), new(ObjectStore)),
 		createKeyProvider,
+		wire.Bind(new(auth.KeyProvider), new(*ReloadableKeyProvider)),
+		getWebhookStore,
 		createWebhookNotifier,
+		getQueuedNotifier,
 		createClientConfiguration,
 		createForwardStats,
 		routing.CreateRouter,
-		getLimitConf,
 		config.DefaultAPIConfig,
 		wire.Bind(new(routing.MessageRouter), new(routing.Router)),
 		wire.Bind(new(livekit.RoomService), new(*RoomService)),
@@ -75,9 +77,22 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 		getSIPStore,
 		getSIPConfig,
 		NewSIPService,
+		getQuotaStore,
+		NewAPIKeyQuotas,
+		NewRateLimiter,
 		NewRoomAllocator,
 		NewRoomService,
 		NewRTCService,
+		NewWHIPService,
+		NewWHEPService,
+		NewRTPCaptureService,
+		NewTrackBridgeService,
+		NewSubscriptionPolicyService,
+		NewLobbyService,
+		NewStageService,
+		NewChatHistoryService,
+		NewRoomScheduleService,
+		NewWebhookService,
 		NewAgentService,
 		NewAgentDispatchService,
 		agent.NewAgentClient,
@@ -93,6 +108,7 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 		rpc.NewTopicFormatter,
 		rpc.NewTypedRoomClient,
 		rpc.NewTypedParticipantClient,
+		NewRoomAdminClient,
 		rpc.NewTypedAgentDispatchInternalClient,
 		NewLocalRoomManager,
 		NewTURNAuthHandler,
@@ -126,7 +142,15 @@ func getNodeID(currentNode routing.LocalNode) livekit.NodeID {
 	return currentNode.NodeID()
 }
 
-func createKeyProvider(conf *config.Config) (auth.KeyProvider, error) {
+func createKeyProvider(conf *config.Config) (*ReloadableKeyProvider, error) {
+	provider, err := newKeyProvider(conf)
+	if err != nil {
+		return nil, err
+	}
+	return NewReloadableKeyProvider(provider), nil
+}
+
+func newKeyProvider(conf *config.Config) (auth.KeyProvider, error) {
 	// prefer keyfile if set
 	if conf.KeyFile != "" {
 		var otherFilter os.FileMode = 0007
@@ -155,17 +179,44 @@ func createKeyProvider(conf *config.Config) (auth.KeyProvider, error) {
 	return auth.NewFileBasedKeyProviderFromMap(conf.Keys), nil
 }
 
-func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (webhook.QueuedNotifier, error) {
-	wc := conf.WebHook
-	if len(wc.URLs) == 0 {
+func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider, store WebhookStore, nodeID livekit.NodeID) (*WebhookNotifier, error) {
+	if store == nil {
 		return nil, nil
 	}
-	secret := provider.GetSecret(wc.APIKey)
-	if secret == "" {
-		return nil, ErrWebHookMissingAPIKey
+
+	// created without endpoints as well, they can be added by a reload
+	wc := conf.WebHook
+	var ownedStore *LocalStore
+	if ls, ok := store.(*LocalStore); ok && !ls.IsPersistent() {
+		switch {
+		case wc.StoreDir != "":
+			var err error
+			if ownedStore, err = NewPersistentLocalStore(config.LocalStoreConfig{Dir: wc.StoreDir}); err != nil {
+				return nil, err
+			}
+			store = ownedStore
+		case len(wc.URLs) != 0 || len(wc.Endpoints) != 0:
+			logger.Warnw("webhook events are not persisted, configure redis or webhook.store_dir to keep them across restarts", nil)
+		}
+	}
+
+	n, err := NewWebhookNotifier(wc, provider, store, nodeID)
+	if err != nil {
+		if ownedStore != nil {
+			_ = ownedStore.Close()
+		}
+		return nil, err
 	}
+	n.ownedStore = ownedStore
+	return n, nil
+}
 
-	return webhook.NewDefaultNotifier(wc.APIKey, secret, wc.URLs), nil
+func getQueuedNotifier(n *WebhookNotifier) webhook.QueuedNotifier {
+	// telemetry checks for a nil interface
+	if n == nil {
+		return nil
+	}
+	return n
 }
 
 func createRedisClient(conf *config.Config) (redis.UniversalClient, error) {
@@ -175,11 +226,17 @@ func createRedisClient(conf *config.Config) (redis.UniversalClient, error) {
 	return redisLiveKit.GetRedisClient(&conf.Redis)
 }
 
-func createStore(rc redis.UniversalClient) ObjectStore {
+func createStore(conf *config.Config, rc redis.UniversalClient) (ObjectStore, error) {
+	if conf.SQL.IsConfigured() {
+		return NewSQLStore(conf.SQL.DSN)
+	}
 	if rc != nil {
-		return NewRedisStore(rc)
+		return NewRedisStore(rc), nil
+	}
+	if conf.LocalStore.IsPersistent() {
+		return NewPersistentLocalStore(conf.LocalStore)
 	}
-	return NewLocalStore()
+	return NewLocalStore(), nil
 }
 
 func getMessageBus(rc redis.UniversalClient) psrpc.MessageBus {
@@ -193,6 +250,10 @@ func getEgressStore(s ObjectStore) EgressStore {
 	switch store := s.(type) {
 	case *RedisStore:
 		return store
+	case *SQLStore:
+		return store
+	case *LocalStore:
+		return store
 	default:
 		return nil
 	}
@@ -202,6 +263,10 @@ func getIngressStore(s ObjectStore) IngressStore {
 	switch store := s.(type) {
 	case *RedisStore:
 		return store
+	case *SQLStore:
+		return store
+	case *LocalStore:
+		return store
 	default:
 		return nil
 	}
@@ -211,6 +276,21 @@ func getAgentStore(s ObjectStore) AgentStore {
 	switch store := s.(type) {
 	case *RedisStore:
 		return store
+	case *SQLStore:
+		return store
+	case *LocalStore:
+		return store
+	default:
+		return nil
+	}
+}
+
+func getWebhookStore(s ObjectStore) WebhookStore {
+	switch store := s.(type) {
+	case *RedisStore:
+		return store
+	case *SQLStore:
+		return store
 	case *LocalStore:
 		return store
 	default:
@@ -218,6 +298,20 @@ func getAgentStore(s ObjectStore) AgentStore {
 	}
 }
 
+// getQuotaStore keeps quotas in the object store, so that nodes sharing it also share quotas
+func getQuotaStore(s ObjectStore) QuotaStore {
+	switch store := s.(type) {
+	case *RedisStore:
+		return store
+	case *SQLStore:
+		return store
+	case *LocalStore:
+		return store
+	default:
+		return NewLocalStore()
+	}
+}
+
 func getIngressConfig(conf *config.Config) *config.IngressConfig {
 	return &conf.Ingress
 }
@@ -226,6 +320,10 @@ func getSIPStore(s ObjectStore) SIPStore {
 	switch store := s.(type) {
 	case *RedisStore:
 		return store
+	case *SQLStore:
+		return store
+	case *LocalStore:
+		return store
 	default:
 		return nil
 	}
@@ -239,10 +337,6 @@ func createClientConfiguration() clientconfiguration.ClientConfigurationManager
 	return clientconfiguration.NewStaticClientConfigurationManager(clientconfiguration.StaticConfigurations)
 }
 
-func getLimitConf(config *config.Config) config.LimitConfig {
-	return config.Limit
-}
-
 func getRoomConfig(config *config.Config) config.RoomConfig {
 	return config.Room
 }
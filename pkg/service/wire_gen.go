@@ -36,7 +36,6 @@ import (
 // Injectors from wire.go:
 
 func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*LivekitServer, error) {
-	limitConfig := getLimitConf(conf)
 	apiConfig := config.DefaultAPIConfig()
 	universalClient, err := createRedisClient(conf)
 	if err != nil {
@@ -61,8 +60,13 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 		return nil, err
 	}
 	router := routing.CreateRouter(universalClient, currentNode, signalClient, roomManagerClient, keepalivePubSub)
-	objectStore := createStore(universalClient)
-	roomAllocator, err := NewRoomAllocator(conf, router, objectStore)
+	objectStore, err := createStore(conf, universalClient)
+	if err != nil {
+		return nil, err
+	}
+	quotaStore := getQuotaStore(objectStore)
+	apiKeyQuotas := NewAPIKeyQuotas(conf, quotaStore)
+	roomAllocator, err := NewRoomAllocator(conf, router, objectStore, apiKeyQuotas)
 	if err != nil {
 		return nil, err
 	}
@@ -73,14 +77,16 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	egressStore := getEgressStore(objectStore)
 	ingressStore := getIngressStore(objectStore)
 	sipStore := getSIPStore(objectStore)
-	keyProvider, err := createKeyProvider(conf)
+	reloadableKeyProvider, err := createKeyProvider(conf)
 	if err != nil {
 		return nil, err
 	}
-	queuedNotifier, err := createWebhookNotifier(conf, keyProvider)
+	webhookStore := getWebhookStore(objectStore)
+	webhookNotifier, err := createWebhookNotifier(conf, reloadableKeyProvider, webhookStore, nodeID)
 	if err != nil {
 		return nil, err
 	}
+	queuedNotifier := getQueuedNotifier(webhookNotifier)
 	analyticsService := telemetry.NewAnalyticsService(conf, currentNode)
 	telemetryService := telemetry.NewTelemetryService(queuedNotifier, analyticsService)
 	ioInfoService, err := NewIOInfoService(messageBus, egressStore, ingressStore, sipStore, telemetryService)
@@ -97,7 +103,11 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
-	roomService, err := NewRoomService(limitConfig, apiConfig, router, roomAllocator, objectStore, rtcEgressLauncher, topicFormatter, roomClient, participantClient)
+	roomAdminClient, err := NewRoomAdminClient(clientParams)
+	if err != nil {
+		return nil, err
+	}
+	roomService, err := NewRoomService(conf, apiConfig, router, roomAllocator, objectStore, rtcEgressLauncher, topicFormatter, roomClient, participantClient, roomAdminClient)
 	if err != nil {
 		return nil, err
 	}
@@ -119,11 +129,7 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 		return nil, err
 	}
 	sipService := NewSIPService(sipConfig, nodeID, messageBus, sipClient, sipStore, roomService, telemetryService)
-	rtcService := NewRTCService(conf, roomAllocator, objectStore, router, currentNode, telemetryService)
-	agentService, err := NewAgentService(conf, currentNode, messageBus, keyProvider)
-	if err != nil {
-		return nil, err
-	}
+	rtcService := NewRTCService(conf, roomAllocator, objectStore, router, currentNode, telemetryService, apiKeyQuotas)
 	clientConfigurationManager := createClientConfiguration()
 	client, err := agent.NewAgentClient(messageBus)
 	if err != nil {
@@ -131,9 +137,27 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	}
 	agentStore := getAgentStore(objectStore)
 	timedVersionGenerator := utils.NewDefaultTimedVersionGenerator()
-	turnAuthHandler := NewTURNAuthHandler(keyProvider)
+	turnAuthHandler := NewTURNAuthHandler(reloadableKeyProvider)
 	forwardStats := createForwardStats(conf)
-	roomManager, err := NewLocalRoomManager(conf, objectStore, currentNode, router, roomAllocator, telemetryService, clientConfigurationManager, client, agentStore, rtcEgressLauncher, timedVersionGenerator, turnAuthHandler, messageBus, forwardStats)
+	roomManager, err := NewLocalRoomManager(conf, objectStore, currentNode, router, roomAllocator, telemetryService, clientConfigurationManager, client, agentStore, rtcEgressLauncher, timedVersionGenerator, turnAuthHandler, messageBus, forwardStats, apiKeyQuotas)
+	if err != nil {
+		return nil, err
+	}
+	whipService := NewWHIPService(conf, router, roomAllocator, roomManager, currentNode)
+	whepService := NewWHEPService(conf, router, roomAllocator, roomManager, currentNode)
+	rtpCaptureService := NewRTPCaptureService(conf, roomManager)
+	trackBridgeService := NewTrackBridgeService(roomService)
+	subscriptionPolicyService := NewSubscriptionPolicyService(roomManager)
+	lobbyService := NewLobbyService(roomService)
+	stageService := NewStageService(roomService)
+	chatHistoryService := NewChatHistoryService(roomService)
+	roomScheduleService := NewRoomScheduleService(roomManager)
+	webhookService := NewWebhookService(webhookNotifier, webhookStore)
+	agentService, err := NewAgentService(conf, currentNode, messageBus, reloadableKeyProvider)
+	if err != nil {
+		return nil, err
+	}
+	rateLimiter, err := NewRateLimiter(conf)
 	if err != nil {
 		return nil, err
 	}
@@ -146,7 +170,7 @@ func InitializeServer(conf *config.Config, currentNode routing.LocalNode) (*Live
 	if err != nil {
 		return nil, err
 	}
-	livekitServer, err := NewLivekitServer(conf, roomService, agentDispatchService, egressService, ingressService, sipService, ioInfoService, rtcService, agentService, keyProvider, router, roomManager, signalServer, server, currentNode)
+	livekitServer, err := NewLivekitServer(conf, roomService, agentDispatchService, egressService, ingressService, sipService, ioInfoService, rtcService, whipService, whepService, rtpCaptureService, trackBridgeService, subscriptionPolicyService, lobbyService, stageService, chatHistoryService, roomScheduleService, webhookService, agentService, reloadableKeyProvider, rateLimiter, apiKeyQuotas, router, roomManager, signalServer, server, currentNode)
 	if err != nil {
 		return nil, err
 	}
@@ -186,7 +210,15 @@ func getNodeID(currentNode routing.LocalNode) livekit.NodeID {
 	return currentNode.NodeID()
 }
 
-func createKeyProvider(conf *config.Config) (auth.KeyProvider, error) {
+func createKeyProvider(conf *config.Config) (*ReloadableKeyProvider, error) {
+	provider, err := newKeyProvider(conf)
+	if err != nil {
+		return nil, err
+	}
+	return NewReloadableKeyProvider(provider), nil
+}
+
+func newKeyProvider(conf *config.Config) (auth.KeyProvider, error) {
 
 	if conf.KeyFile != "" {
 		var otherFilter os.FileMode = 0007
@@ -215,17 +247,43 @@ func createKeyProvider(conf *config.Config) (auth.KeyProvider, error) {
 	return auth.NewFileBasedKeyProviderFromMap(conf.Keys), nil
 }
 
-func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider) (webhook.QueuedNotifier, error) {
-	wc := conf.WebHook
-	if len(wc.URLs) == 0 {
+func createWebhookNotifier(conf *config.Config, provider auth.KeyProvider, store WebhookStore, nodeID livekit.NodeID) (*WebhookNotifier, error) {
+	if store == nil {
 		return nil, nil
 	}
-	secret := provider.GetSecret(wc.APIKey)
-	if secret == "" {
-		return nil, ErrWebHookMissingAPIKey
+
+	wc := conf.WebHook
+	var ownedStore *LocalStore
+	if ls, ok := store.(*LocalStore); ok && !ls.IsPersistent() {
+		switch {
+		case wc.StoreDir != "":
+			var err error
+			if ownedStore, err = NewPersistentLocalStore(config.LocalStoreConfig{Dir: wc.StoreDir}); err != nil {
+				return nil, err
+			}
+			store = ownedStore
+		case len(wc.URLs) != 0 || len(wc.Endpoints) != 0:
+			logger.Warnw("webhook events are not persisted, configure redis or webhook.store_dir to keep them across restarts", nil)
+		}
 	}
 
-	return webhook.NewDefaultNotifier(wc.APIKey, secret, wc.URLs), nil
+	n, err := NewWebhookNotifier(wc, provider, store, nodeID)
+	if err != nil {
+		if ownedStore != nil {
+			_ = ownedStore.Close()
+		}
+		return nil, err
+	}
+	n.ownedStore = ownedStore
+	return n, nil
+}
+
+func getQueuedNotifier(n *WebhookNotifier) webhook.QueuedNotifier {
+
+	if n == nil {
+		return nil
+	}
+	return n
 }
 
 func createRedisClient(conf *config.Config) (redis.UniversalClient, error) {
@@ -235,11 +293,17 @@ func createRedisClient(conf *config.Config) (redis.UniversalClient, error) {
 	return redis2.GetRedisClient(&conf.Redis)
 }
 
-func createStore(rc redis.UniversalClient) ObjectStore {
+func createStore(conf *config.Config, rc redis.UniversalClient) (ObjectStore, error) {
+	if conf.SQL.IsConfigured() {
+		return NewSQLStore(conf.SQL.DSN)
+	}
 	if rc != nil {
-		return NewRedisStore(rc)
+		return NewRedisStore(rc), nil
 	}
-	return NewLocalStore()
+	if conf.LocalStore.IsPersistent() {
+		return NewPersistentLocalStore(conf.LocalStore)
+	}
+	return NewLocalStore(), nil
 }
 
 func getMessageBus(rc redis.UniversalClient) psrpc.MessageBus {
@@ -253,6 +317,10 @@ func getEgressStore(s ObjectStore) EgressStore {
 	switch store := s.(type) {
 	case *RedisStore:
 		return store
+	case *SQLStore:
+		return store
+	case *LocalStore:
+		return store
 	default:
 		return nil
 	}
@@ -262,6 +330,10 @@ func getIngressStore(s ObjectStore) IngressStore {
 	switch store := s.(type) {
 	case *RedisStore:
 		return store
+	case *SQLStore:
+		return store
+	case *LocalStore:
+		return store
 	default:
 		return nil
 	}
@@ -271,6 +343,21 @@ func getAgentStore(s ObjectStore) AgentStore {
 	switch store := s.(type) {
 	case *RedisStore:
 		return store
+	case *SQLStore:
+		return store
+	case *LocalStore:
+		return store
+	default:
+		return nil
+	}
+}
+
+func getWebhookStore(s ObjectStore) WebhookStore {
+	switch store := s.(type) {
+	case *RedisStore:
+		return store
+	case *SQLStore:
+		return store
 	case *LocalStore:
 		return store
 	default:
@@ -278,6 +365,20 @@ func getAgentStore(s ObjectStore) AgentStore {
 	}
 }
 
+// getQuotaStore keeps quotas in the object store, so that nodes sharing it also share quotas
+func getQuotaStore(s ObjectStore) QuotaStore {
+	switch store := s.(type) {
+	case *RedisStore:
+		return store
+	case *SQLStore:
+		return store
+	case *LocalStore:
+		return store
+	default:
+		return NewLocalStore()
+	}
+}
+
 func getIngressConfig(conf *config.Config) *config.IngressConfig {
 	return &conf.Ingress
 }
@@ -286,6 +387,10 @@ func getSIPStore(s ObjectStore) SIPStore {
 	switch store := s.(type) {
 	case *RedisStore:
 		return store
+	case *SQLStore:
+		return store
+	case *LocalStore:
+		return store
 	default:
 		return nil
 	}
@@ -299,10 +404,6 @@ func createClientConfiguration() clientconfiguration.ClientConfigurationManager
 	return clientconfiguration.NewStaticClientConfigurationManager(clientconfiguration.StaticConfigurations)
 }
 
-func getLimitConf(config2 *config.Config) config.LimitConfig {
-	return config2.Limit
-}
-
 func getRoomConfig(config2 *config.Config) config.RoomConfig {
 	return config2.Room
 }
@@ -31,6 +31,7 @@ import (
 	"go.uber.org/atomic"
 
 	"github.com/livekit/livekit-server/pkg/sfu/audio"
+	"github.com/livekit/livekit-server/pkg/sfu/capture"
 	act "github.com/livekit/livekit-server/pkg/sfu/rtpextension/abscapturetime"
 	dd "github.com/livekit/livekit-server/pkg/sfu/rtpextension/dependencydescriptor"
 	"github.com/livekit/livekit-server/pkg/sfu/rtpstats"
@@ -139,6 +140,8 @@ type Buffer struct {
 	rtxPktBuf           []byte
 
 	absCaptureTimeExtID uint8
+
+	capture atomic.Pointer[capture.Writer]
 }
 
 // NewBuffer constructs a new Buffer
@@ -167,6 +170,11 @@ func (b *Buffer) SetLogger(logger logger.Logger) {
 	}
 }
 
+// SetCapture records every packet written to the buffer, nil stops recording
+func (b *Buffer) SetCapture(w *capture.Writer) {
+	b.capture.Store(w)
+}
+
 func (b *Buffer) SetPaused(paused bool) {
 	b.Lock()
 	defer b.Unlock()
@@ -318,6 +326,10 @@ func (b *Buffer) Write(pkt []byte) (n int, err error) {
 		return
 	}
 
+	if w := b.capture.Load(); w != nil {
+		w.WriteRTP(pkt)
+	}
+
 	b.Lock()
 	if b.closed.Load() {
 		b.Unlock()
@@ -825,6 +837,10 @@ func (b *Buffer) getExtPacket(rtpPacket *rtp.Packet, arrivalTime int64, flowStat
 
 	case utils.MimeTypeAV1:
 		ep.KeyFrame = IsAV1KeyFrame(rtpPacket.Payload)
+
+	case utils.MimeTypeH265:
+		ep.KeyFrame = IsH265KeyFrame(rtpPacket.Payload)
+		ep.Spatial = InvalidLayerSpatial // h.265 simulcast layers are separate streams, reset to invalid
 	}
 
 	if ep.KeyFrame {
@@ -314,6 +314,54 @@ func IsH264KeyFrame(payload []byte) bool {
 
 // -------------------------------------
 
+const (
+	h265NaluVPS = 32
+	h265NaluAP  = 48
+	h265NaluFU  = 49
+)
+
+// IsH265KeyFrame detects if h265 payload is a keyframe, i.e. it starts the parameter sets
+// sent ahead of an IRAP picture with a VPS. Payloads are packetized as in RFC 7798, without DONL fields.
+func IsH265KeyFrame(payload []byte) bool {
+	if len(payload) < 2 {
+		return false
+	}
+	nalu := (payload[0] >> 1) & 0x3F
+	switch {
+	case nalu < h265NaluAP:
+		// single NAL unit
+		return nalu == h265NaluVPS
+
+	case nalu == h265NaluAP:
+		i := 2
+		for i+2 <= len(payload) {
+			length := int(binary.BigEndian.Uint16(payload[i:]))
+			i += 2
+			if length < 2 || i+length > len(payload) {
+				return false
+			}
+			if (payload[i]>>1)&0x3F == h265NaluVPS {
+				return true
+			}
+			i += length
+		}
+		return false
+
+	case nalu == h265NaluFU:
+		if len(payload) < 3 {
+			return false
+		}
+		if (payload[2] & 0x80) == 0 {
+			// not a starting fragment
+			return false
+		}
+		return payload[2]&0x3F == h265NaluVPS
+	}
+	return false
+}
+
+// -------------------------------------
+
 // IsVP9KeyFrame detects if vp9 payload is a keyframe
 // taken from https://github.com/jech/galene/blob/master/codecs/codecs.go
 // all credits belongs to Juliusz Chroboczek @jech and the awesome Galene SFU
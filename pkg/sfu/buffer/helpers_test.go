@@ -108,3 +108,49 @@ func TestVP8Helper_Unmarshal(t *testing.T) {
 }
 
 // ------------------------------------------
+
+func TestIsH265KeyFrame(t *testing.T) {
+	vps := []byte{0x40, 0x01, 0x0c, 0x01}
+	sps := []byte{0x42, 0x01, 0x01, 0x01}
+	idr := []byte{0x26, 0x01, 0xaf, 0x86}
+	trail := []byte{0x02, 0x01, 0xd0, 0x09}
+
+	aggregate := func(nalus ...[]byte) []byte {
+		payload := []byte{0x60, 0x01}
+		for _, nalu := range nalus {
+			payload = append(payload, byte(len(nalu)>>8), byte(len(nalu)))
+			payload = append(payload, nalu...)
+		}
+		return payload
+	}
+	fragment := func(start bool, nalu []byte) []byte {
+		header := nalu[0] >> 1 & 0x3f
+		if start {
+			header |= 0x80
+		}
+		return append([]byte{0x62, 0x01, header}, nalu[2:]...)
+	}
+
+	tests := []struct {
+		name     string
+		payload  []byte
+		keyFrame bool
+	}{
+		{name: "empty", payload: nil},
+		{name: "vps", payload: vps, keyFrame: true},
+		{name: "sps", payload: sps},
+		{name: "idr without parameter sets", payload: idr},
+		{name: "trailing picture", payload: trail},
+		{name: "aggregated parameter sets", payload: aggregate(vps, sps, idr), keyFrame: true},
+		{name: "aggregated pictures", payload: aggregate(trail, trail)},
+		{name: "truncated aggregation", payload: aggregate(trail, vps)[:len(aggregate(trail, vps))-3]},
+		{name: "fragmented vps start", payload: fragment(true, vps), keyFrame: true},
+		{name: "fragmented vps continuation", payload: fragment(false, vps)},
+		{name: "fragmented idr", payload: fragment(true, idr)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.keyFrame, IsH265KeyFrame(tt.payload))
+		})
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/logger"
+)
+
+const (
+	// rtpdump file preamble as written by rtptools' rtpdump
+	rtpdumpPreamble = "#!rtpplay1.0 0.0.0.0/0\n"
+
+	rtpdumpFileHeaderSize   = 16
+	rtpdumpPacketHeaderSize = 8
+	maxPacketSize           = 0xffff - rtpdumpPacketHeaderSize
+
+	queueSize = 1024
+)
+
+var (
+	ErrInvalidLimits = errors.New("capture needs a size and a duration limit")
+)
+
+type WriterParams struct {
+	Path        string
+	MaxBytes    int64
+	MaxDuration time.Duration
+	Logger      logger.Logger
+}
+
+type Stats struct {
+	Bytes     int64
+	Packets   int64
+	Dropped   int64
+	StartedAt time.Time
+	EndedAt   time.Time
+}
+
+type packet struct {
+	at     time.Time
+	isRTCP bool
+	data   []byte
+}
+
+// Writer records RTP and RTCP packets in rtpdump format.
+// Packets are copied and written to disk off the media path. Once the size or duration limit
+// is reached, the writer closes itself and further packets are ignored.
+type Writer struct {
+	params WriterParams
+
+	file      *os.File
+	startedAt time.Time
+	timer     *time.Timer
+
+	lock    sync.Mutex
+	closed  bool
+	bytes   int64
+	packets chan packet
+
+	packetsWritten atomic.Int64
+	dropped        atomic.Int64
+	endedAt        atomic.Pointer[time.Time]
+
+	onDone func()
+	done   chan struct{}
+}
+
+func NewWriter(params WriterParams) (*Writer, error) {
+	if params.MaxBytes <= 0 || params.MaxDuration <= 0 {
+		return nil, ErrInvalidLimits
+	}
+
+	file, err := os.OpenFile(params.Path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Writer{
+		params:    params,
+		file:      file,
+		startedAt: time.Now(),
+		packets:   make(chan packet, queueSize),
+		done:      make(chan struct{}),
+	}
+
+	out := bufio.NewWriter(file)
+	if err := w.writeFileHeader(out); err != nil {
+		_ = file.Close()
+		_ = os.Remove(params.Path)
+		return nil, err
+	}
+	w.bytes = int64(len(rtpdumpPreamble) + rtpdumpFileHeaderSize)
+
+	w.timer = time.AfterFunc(params.MaxDuration, func() {
+		_ = w.Close()
+	})
+	go w.worker(out)
+	return w, nil
+}
+
+// OnDone is called once, after the capture file has been completely written
+func (w *Writer) OnDone(f func()) {
+	w.lock.Lock()
+	w.onDone = f
+	w.lock.Unlock()
+}
+
+func (w *Writer) Path() string {
+	return w.params.Path
+}
+
+func (w *Writer) WriteRTP(pkt []byte) {
+	w.enqueue(pkt, false)
+}
+
+func (w *Writer) WriteRTPHeaderAndPayload(hdr *rtp.Header, payload []byte) {
+	headerSize := hdr.MarshalSize()
+	buf := make([]byte, headerSize+len(payload))
+	if _, err := hdr.MarshalTo(buf); err != nil {
+		return
+	}
+	copy(buf[headerSize:], payload)
+	w.push(buf, false)
+}
+
+func (w *Writer) WriteRTCP(pkt []byte) {
+	w.enqueue(pkt, true)
+}
+
+func (w *Writer) Close() error {
+	w.lock.Lock()
+	if w.closed {
+		w.lock.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.packets)
+	w.lock.Unlock()
+
+	w.timer.Stop()
+	<-w.done
+	return nil
+}
+
+func (w *Writer) IsClosed() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.closed
+}
+
+func (w *Writer) Stats() Stats {
+	w.lock.Lock()
+	bytes := w.bytes
+	w.lock.Unlock()
+
+	stats := Stats{
+		Bytes:     bytes,
+		Packets:   w.packetsWritten.Load(),
+		Dropped:   w.dropped.Load(),
+		StartedAt: w.startedAt,
+	}
+	if endedAt := w.endedAt.Load(); endedAt != nil {
+		stats.EndedAt = *endedAt
+	}
+	return stats
+}
+
+func (w *Writer) enqueue(pkt []byte, isRTCP bool) {
+	data := make([]byte, len(pkt))
+	copy(data, pkt)
+	w.push(data, isRTCP)
+}
+
+func (w *Writer) push(data []byte, isRTCP bool) {
+	if len(data) > maxPacketSize {
+		w.dropped.Inc()
+		return
+	}
+
+	w.lock.Lock()
+	if w.closed {
+		w.lock.Unlock()
+		return
+	}
+
+	size := int64(rtpdumpPacketHeaderSize + len(data))
+	if w.bytes+size > w.params.MaxBytes {
+		w.lock.Unlock()
+		go w.Close()
+		return
+	}
+
+	select {
+	case w.packets <- packet{at: time.Now(), isRTCP: isRTCP, data: data}:
+		w.bytes += size
+	default:
+		// do not hold up forwarding when the disk cannot keep up
+		w.dropped.Inc()
+	}
+	w.lock.Unlock()
+}
+
+func (w *Writer) worker(out *bufio.Writer) {
+	var err error
+	for p := range w.packets {
+		if err != nil {
+			continue
+		}
+		if err = w.writePacket(out, p); err == nil {
+			w.packetsWritten.Inc()
+		}
+	}
+
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		w.params.Logger.Warnw("could not write capture", err, "path", w.params.Path)
+	}
+	if err := w.file.Close(); err != nil {
+		w.params.Logger.Warnw("could not close capture", err, "path", w.params.Path)
+	}
+
+	endedAt := time.Now()
+	w.endedAt.Store(&endedAt)
+	close(w.done)
+
+	w.lock.Lock()
+	onDone := w.onDone
+	w.lock.Unlock()
+	if onDone != nil {
+		onDone()
+	}
+}
+
+// RD_hdr_t: start of recording as a timeval, source address and port
+func (w *Writer) writeFileHeader(out *bufio.Writer) error {
+	if _, err := out.WriteString(rtpdumpPreamble); err != nil {
+		return err
+	}
+
+	var hdr [rtpdumpFileHeaderSize]byte
+	binary.BigEndian.PutUint32(hdr[0:4], uint32(w.startedAt.Unix()))
+	binary.BigEndian.PutUint32(hdr[4:8], uint32(w.startedAt.Nanosecond()/1000))
+	_, err := out.Write(hdr[:])
+	return err
+}
+
+// RD_packet_t: total length, RTP length (0 for RTCP) and offset from start of recording in ms
+func (w *Writer) writePacket(out *bufio.Writer, p packet) error {
+	var hdr [rtpdumpPacketHeaderSize]byte
+	binary.BigEndian.PutUint16(hdr[0:2], uint16(rtpdumpPacketHeaderSize+len(p.data)))
+	if !p.isRTCP {
+		binary.BigEndian.PutUint16(hdr[2:4], uint16(len(p.data)))
+	}
+	binary.BigEndian.PutUint32(hdr[4:8], uint32(p.at.Sub(w.startedAt).Milliseconds()))
+	if _, err := out.Write(hdr[:]); err != nil {
+		return err
+	}
+
+	_, err := out.Write(p.data)
+	return err
+}
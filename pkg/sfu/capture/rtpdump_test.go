@@ -0,0 +1,130 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/logger"
+)
+
+func TestWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.rtpdump")
+	w, err := NewWriter(WriterParams{
+		Path:        path,
+		MaxBytes:    1 << 20,
+		MaxDuration: time.Minute,
+		Logger:      logger.GetLogger(),
+	})
+	require.NoError(t, err)
+
+	var done atomic.Bool
+	w.OnDone(func() { done.Store(true) })
+
+	hdr := &rtp.Header{Version: 2, PayloadType: 111, SequenceNumber: 10, Timestamp: 960, SSRC: 1234}
+	w.WriteRTPHeaderAndPayload(hdr, []byte{1, 2, 3})
+	rawRTP, err := (&rtp.Packet{Header: *hdr, Payload: []byte{4, 5}}).Marshal()
+	require.NoError(t, err)
+	w.WriteRTP(rawRTP)
+	rawRTCP, err := (&rtcp.PictureLossIndication{SenderSSRC: 1, MediaSSRC: 1234}).Marshal()
+	require.NoError(t, err)
+	w.WriteRTCP(rawRTCP)
+
+	require.NoError(t, w.Close())
+	require.True(t, done.Load())
+	require.Equal(t, int64(3), w.Stats().Packets)
+
+	// writes after close are ignored
+	w.WriteRTP(rawRTP)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, rtpdumpPreamble, string(data[:len(rtpdumpPreamble)]))
+	data = data[len(rtpdumpPreamble)+rtpdumpFileHeaderSize:]
+
+	type record struct {
+		isRTCP bool
+		data   []byte
+	}
+	var records []record
+	for len(data) > 0 {
+		length := int(binary.BigEndian.Uint16(data[0:2]))
+		plen := int(binary.BigEndian.Uint16(data[2:4]))
+		payload := data[rtpdumpPacketHeaderSize:length]
+		if plen != 0 {
+			require.Equal(t, len(payload), plen)
+		}
+		records = append(records, record{isRTCP: plen == 0, data: payload})
+		data = data[length:]
+	}
+	require.Len(t, records, 3)
+
+	var pkt rtp.Packet
+	require.NoError(t, pkt.Unmarshal(records[0].data))
+	require.Equal(t, uint16(10), pkt.SequenceNumber)
+	require.Equal(t, []byte{1, 2, 3}, pkt.Payload)
+	require.Equal(t, rawRTP, records[1].data)
+	require.True(t, records[2].isRTCP)
+	require.Equal(t, rawRTCP, records[2].data)
+}
+
+func TestWriterLimits(t *testing.T) {
+	t.Run("size", func(t *testing.T) {
+		w, err := NewWriter(WriterParams{
+			Path:        filepath.Join(t.TempDir(), "capture.rtpdump"),
+			MaxBytes:    100,
+			MaxDuration: time.Minute,
+			Logger:      logger.GetLogger(),
+		})
+		require.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			w.WriteRTP(make([]byte, 20))
+		}
+		require.Eventually(t, w.IsClosed, time.Second, 10*time.Millisecond)
+		require.NoError(t, w.Close())
+
+		info, err := os.Stat(w.Path())
+		require.NoError(t, err)
+		require.LessOrEqual(t, info.Size(), int64(100))
+	})
+
+	t.Run("duration", func(t *testing.T) {
+		w, err := NewWriter(WriterParams{
+			Path:        filepath.Join(t.TempDir(), "capture.rtpdump"),
+			MaxBytes:    1 << 20,
+			MaxDuration: 50 * time.Millisecond,
+			Logger:      logger.GetLogger(),
+		})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			return !w.Stats().EndedAt.IsZero()
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("requires limits", func(t *testing.T) {
+		_, err := NewWriter(WriterParams{Path: filepath.Join(t.TempDir(), "capture.rtpdump")})
+		require.ErrorIs(t, err, ErrInvalidLimits)
+	})
+}
@@ -403,6 +403,10 @@ func getPacketLossWeight(mimeType string, isFecEnabled bool) float64 {
 	case strings.HasPrefix(strings.ToLower(mimeType), "video/"):
 		// 2%: fall to GOOD, 6%: fall to POOR
 		plw = 10.0
+		if isFecEnabled {
+			// 3%: fall to GOOD, 9%: fall to POOR
+			plw /= 1.5
+		}
 	}
 
 	return plw
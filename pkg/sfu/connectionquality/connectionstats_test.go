@@ -674,6 +674,30 @@ func TestConnectionQuality(t *testing.T) {
 					},
 				},
 			},
+			// "video/*" - fec - 0 <= loss < 3%: EXCELLENT, 3% <= loss < 9%: GOOD, >= 9%: POOR
+			{
+				name:            "video/* - fec",
+				mimeType:        "video/vp8",
+				isFECEnabled:    true,
+				packetsExpected: 200,
+				expectedQualities: []expectedQuality{
+					{
+						packetLossPercentage: 2.0,
+						expectedMOS:          4.6,
+						expectedQuality:      livekit.ConnectionQuality_EXCELLENT,
+					},
+					{
+						packetLossPercentage: 5.0,
+						expectedMOS:          4.1,
+						expectedQuality:      livekit.ConnectionQuality_GOOD,
+					},
+					{
+						packetLossPercentage: 12.0,
+						expectedMOS:          2.1,
+						expectedQuality:      livekit.ConnectionQuality_POOR,
+					},
+				},
+			},
 		}
 
 		for _, tc := range testCases {
@@ -38,8 +38,10 @@ import (
 	"github.com/livekit/protocol/utils/mono"
 
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/livekit-server/pkg/sfu/capture"
 	"github.com/livekit/livekit-server/pkg/sfu/ccutils"
 	"github.com/livekit/livekit-server/pkg/sfu/connectionquality"
+	"github.com/livekit/livekit-server/pkg/sfu/fec"
 	"github.com/livekit/livekit-server/pkg/sfu/pacer"
 	act "github.com/livekit/livekit-server/pkg/sfu/rtpextension/abscapturetime"
 	dd "github.com/livekit/livekit-server/pkg/sfu/rtpextension/dependencydescriptor"
@@ -122,6 +124,28 @@ var (
 	}
 	H264KeyFrame2x2 = [][]byte{H264KeyFrame2x2SPS, H264KeyFrame2x2PPS, H264KeyFrame2x2IDR}
 
+	// 8x8 mid grey IDR picture, coded as a single PCM coding unit
+	H265KeyFrame8x8VPS = []byte{
+		0x40, 0x01, 0x0c, 0x01, 0xff, 0xff, 0x01, 0x60,
+		0x00, 0x00, 0x03, 0x00, 0x90, 0x00, 0x00, 0x03,
+		0x00, 0x00, 0x03, 0x00, 0x1e, 0xf0, 0x24,
+	}
+	H265KeyFrame8x8SPS = []byte{
+		0x42, 0x01, 0x01, 0x01, 0x60, 0x00, 0x00, 0x03,
+		0x00, 0x90, 0x00, 0x00, 0x03, 0x00, 0x00, 0x03,
+		0x00, 0x1e, 0xa1, 0x22, 0x5f, 0xea, 0xb1, 0x00,
+		0xf0, 0x40,
+	}
+	H265KeyFrame8x8PPS = []byte{
+		0x44, 0x01, 0xc0, 0x71, 0x80, 0xa4, 0x80,
+	}
+	H265KeyFrame8x8IDR = []byte{
+		0x26, 0x01, 0xaf, 0x86, 0x80, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xfe, 0x80,
+	}
+	H265KeyFrame8x8 = [][]byte{H265KeyFrame8x8VPS, H265KeyFrame8x8SPS, H265KeyFrame8x8PPS, H265KeyFrame8x8IDR}
+
 	OpusSilenceFrame = []byte{
 		0xf8, 0xff, 0xfe, 0x00, 0x00, 0x00, 0x00, 0x00,
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
@@ -258,6 +282,7 @@ type DownTrack struct {
 	payloadTypeRTX    uint8
 	sequencer         *sequencer
 	rtxSequenceNumber atomic.Uint64
+	fecEncoder        atomic.Pointer[fec.Encoder]
 
 	forwarder *Forwarder
 
@@ -320,6 +345,8 @@ type DownTrack struct {
 
 	pacer pacer.Pacer
 
+	capture atomic.Pointer[capture.Writer]
+
 	maxLayerNotifierChMu     sync.RWMutex
 	maxLayerNotifierCh       chan string
 	maxLayerNotifierChClosed bool
@@ -537,10 +564,28 @@ func (d *DownTrack) Bind(t webrtc.TrackLocalContext) (webrtc.RTPCodecParameters,
 		d.ssrcRTX = uint32(t.SSRCRetransmission())
 		d.payloadType = uint8(codec.PayloadType)
 		d.payloadTypeRTX = uint8(utils.FindRTXPayloadType(codec.PayloadType, d.negotiatedCodecParameters))
+		// FlexFEC is generated for video when the subscriber negotiated it
+		var (
+			payloadTypeFEC uint8
+			fecEncoder     *fec.Encoder
+		)
+		if ssrcFEC := uint32(t.SSRCForwardErrorCorrection()); ssrcFEC != 0 && d.kind == webrtc.RTPCodecTypeVideo {
+			for _, c := range d.negotiatedCodecParameters {
+				if strings.EqualFold(c.MimeType, fec.MimeTypeFlexFEC03) {
+					payloadTypeFEC = uint8(c.PayloadType)
+					fecEncoder = fec.NewEncoder(d.ssrc, ssrcFEC, payloadTypeFEC)
+					isFECEnabled = true
+					break
+				}
+			}
+		}
+		d.fecEncoder.Store(fecEncoder)
 		logFields = append(
 			logFields,
 			"payloadType", d.payloadType,
 			"payloadTypeRTX", d.payloadTypeRTX,
+			"ssrcFEC", t.SSRCForwardErrorCorrection(),
+			"payloadTypeFEC", payloadTypeFEC,
 			"codecParameters", d.negotiatedCodecParameters,
 		)
 		d.params.Logger.Debugw("DownTrack.Bind", logFields...)
@@ -571,7 +616,7 @@ func (d *DownTrack) Bind(t webrtc.TrackLocalContext) (webrtc.RTPCodecParameters,
 		d.setBindStateLocked(bindStateBound)
 		d.bindLock.Unlock()
 
-		d.forwarder.DetermineCodec(codec.RTPCodecCapability, d.params.Receiver.HeaderExtensions())
+		d.forwarder.DetermineCodec(codec.RTPCodecCapability, d.params.Receiver.HeaderExtensions(), d.params.Receiver.IsSVC())
 		d.connectionStats.Start(codec.MimeType, isFECEnabled)
 		d.params.Logger.Debugw("downtrack bound")
 	}
@@ -946,6 +991,13 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 		0,
 		extPkt.IsOutOfOrder,
 	)
+	if w := d.capture.Load(); w != nil {
+		w.WriteRTPHeaderAndPayload(hdr, payload)
+	}
+	var onSent func(*rtp.Header, []byte)
+	if d.fecEncoder.Load() != nil {
+		onSent = d.writeFEC
+	}
 	d.pacer.Enqueue(&pacer.Packet{
 		Header:             hdr,
 		HeaderSize:         headerSize,
@@ -956,6 +1008,7 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 		WriteStream:        d.writeStream,
 		Pool:               PacketFactory,
 		PoolEntity:         poolEntity,
+		OnSent:             onSent,
 	})
 
 	if extPkt.KeyFrame {
@@ -981,6 +1034,26 @@ func (d *DownTrack) WriteRTP(extPkt *buffer.ExtPacket, layer int32) error {
 	return nil
 }
 
+// writeFEC protects a media packet as it was sent,
+// FEC packets are sent once the group of media packets they protect is complete
+func (d *DownTrack) writeFEC(hdr *rtp.Header, payload []byte) {
+	fecEncoder := d.fecEncoder.Load()
+	if fecEncoder == nil {
+		return
+	}
+
+	for _, pkt := range fecEncoder.Encode(hdr, payload) {
+		d.pacer.Enqueue(&pacer.Packet{
+			Header:             &pkt.Header,
+			HeaderSize:         pkt.Header.MarshalSize(),
+			Payload:            pkt.Payload,
+			AbsSendTimeExtID:   uint8(d.absSendTimeExtID),
+			TransportWideExtID: uint8(d.transportWideExtID),
+			WriteStream:        d.writeStream,
+		})
+	}
+}
+
 // WritePaddingRTP tries to write as many padding only RTP packets as necessary
 // to satisfy given size to the DownTrack
 func (d *DownTrack) WritePaddingRTP(bytesToSend int, paddingOnMute bool, forceMarker bool) int {
@@ -1437,18 +1510,34 @@ func (d *DownTrack) IsDeficient() bool {
 	return d.forwarder.IsDeficient()
 }
 
+// getLayeredBitrate returns the bitrates of the layers including the FEC overhead,
+// so FEC is accounted for when allocating the channel capacity
+func (d *DownTrack) getLayeredBitrate() ([]int32, Bitrates) {
+	al, brs := d.params.Receiver.GetLayeredBitrate()
+	if fecEncoder := d.fecEncoder.Load(); fecEncoder != nil {
+		if overhead := fecEncoder.Overhead(); overhead != 0 {
+			for i := range brs {
+				for j := range brs[i] {
+					brs[i][j] += int64(float64(brs[i][j]) * overhead)
+				}
+			}
+		}
+	}
+	return al, brs
+}
+
 func (d *DownTrack) BandwidthRequested() int64 {
-	_, brs := d.params.Receiver.GetLayeredBitrate()
+	_, brs := d.getLayeredBitrate()
 	return d.forwarder.BandwidthRequested(brs)
 }
 
 func (d *DownTrack) DistanceToDesired() float64 {
-	al, brs := d.params.Receiver.GetLayeredBitrate()
+	al, brs := d.getLayeredBitrate()
 	return d.forwarder.DistanceToDesired(al, brs)
 }
 
 func (d *DownTrack) AllocateOptimal(allowOvershoot bool, hold bool) VideoAllocation {
-	al, brs := d.params.Receiver.GetLayeredBitrate()
+	al, brs := d.getLayeredBitrate()
 	allocation := d.forwarder.AllocateOptimal(al, brs, allowOvershoot, hold)
 	d.postKeyFrameRequestEvent()
 	d.maybeAddTransition(allocation.BandwidthNeeded, allocation.DistanceToDesired, allocation.PauseReason)
@@ -1456,7 +1545,7 @@ func (d *DownTrack) AllocateOptimal(allowOvershoot bool, hold bool) VideoAllocat
 }
 
 func (d *DownTrack) ProvisionalAllocatePrepare() {
-	al, brs := d.params.Receiver.GetLayeredBitrate()
+	al, brs := d.getLayeredBitrate()
 	d.forwarder.ProvisionalAllocatePrepare(al, brs)
 }
 
@@ -1498,7 +1587,7 @@ func (d *DownTrack) ProvisionalAllocateCommit() VideoAllocation {
 }
 
 func (d *DownTrack) AllocateNextHigher(availableChannelCapacity int64, allowOvershoot bool) (VideoAllocation, bool) {
-	al, brs := d.params.Receiver.GetLayeredBitrate()
+	al, brs := d.getLayeredBitrate()
 	allocation, available := d.forwarder.AllocateNextHigher(availableChannelCapacity, al, brs, allowOvershoot)
 	d.postKeyFrameRequestEvent()
 	d.maybeAddTransition(allocation.BandwidthNeeded, allocation.DistanceToDesired, allocation.PauseReason)
@@ -1506,7 +1595,7 @@ func (d *DownTrack) AllocateNextHigher(availableChannelCapacity int64, allowOver
 }
 
 func (d *DownTrack) GetNextHigherTransition(allowOvershoot bool) (VideoTransition, bool) {
-	availableLayers, brs := d.params.Receiver.GetLayeredBitrate()
+	availableLayers, brs := d.getLayeredBitrate()
 	transition, available := d.forwarder.GetNextHigherTransition(brs, allowOvershoot)
 	d.params.Logger.Debugw(
 		"stream: get next higher layer",
@@ -1519,7 +1608,7 @@ func (d *DownTrack) GetNextHigherTransition(allowOvershoot bool) (VideoTransitio
 }
 
 func (d *DownTrack) Pause() VideoAllocation {
-	al, brs := d.params.Receiver.GetLayeredBitrate()
+	al, brs := d.getLayeredBitrate()
 	allocation := d.forwarder.Pause(al, brs)
 	d.maybeAddTransition(allocation.BandwidthNeeded, allocation.DistanceToDesired, allocation.PauseReason)
 	return allocation
@@ -1581,6 +1670,8 @@ func (d *DownTrack) writeBlankFrameRTP(duration float32, generation uint32) chan
 			getBlankFrame = d.getVP8BlankFrame
 		case strings.ToLower(webrtc.MimeTypeH264):
 			getBlankFrame = d.getH264BlankFrame
+		case strings.ToLower(webrtc.MimeTypeH265):
+			getBlankFrame = d.getH265BlankFrame
 		default:
 			close(done)
 			return
@@ -1737,7 +1828,27 @@ func (d *DownTrack) getH264BlankFrame(_frameEndNeeded bool) ([]byte, error) {
 	return buf[:offset], nil
 }
 
+func (d *DownTrack) getH265BlankFrame(_frameEndNeeded bool) ([]byte, error) {
+	// aggregation packet (RFC 7798) carrying the parameter sets and the IDR picture
+	buf := make([]byte, 1000)
+	buf[0] = 48 << 1 // AP
+	buf[1] = 0x01    // TID 0
+	offset := 2
+	for _, payload := range H265KeyFrame8x8 {
+		binary.BigEndian.PutUint16(buf[offset:], uint16(len(payload)))
+		offset += 2
+		copy(buf[offset:offset+len(payload)], payload)
+		offset += len(payload)
+	}
+	offset += d.maybeAddTrailer(buf[offset:])
+	return buf[:offset], nil
+}
+
 func (d *DownTrack) handleRTCP(bytes []byte) {
+	if w := d.capture.Load(); w != nil {
+		w.WriteRTCP(bytes)
+	}
+
 	pkts, err := rtcp.Unmarshal(bytes)
 	if err != nil {
 		d.params.Logger.Errorw("could not unmarshal rtcp receiver packet", err)
@@ -1788,6 +1899,7 @@ func (d *DownTrack) handleRTCP(bytes []byte) {
 				SSRC:              p.SSRC,
 				ProfileExtensions: p.ProfileExtensions,
 			}
+			isFECOverheadChanged := false
 			for _, r := range p.Reports {
 				if r.SSRC != d.ssrc {
 					continue
@@ -1798,6 +1910,11 @@ func (d *DownTrack) handleRTCP(bytes []byte) {
 					rttToReport = rtt
 				}
 
+				if fecEncoder := d.fecEncoder.Load(); fecEncoder != nil && fecEncoder.UpdateLoss(r.FractionLost) {
+					d.params.Logger.Debugw("fec overhead changed", "fractionLost", r.FractionLost, "overhead", fecEncoder.Overhead())
+					isFECOverheadChanged = true
+				}
+
 				if d.playoutDelay != nil {
 					d.playoutDelay.OnSeqAcked(uint16(r.LastSequenceNumber))
 					// screen share track has inaccuracy jitter due to its low frame rate and bursty traffic
@@ -1807,6 +1924,11 @@ func (d *DownTrack) handleRTCP(bytes []byte) {
 					}
 				}
 			}
+			if isFECOverheadChanged {
+				if sal := d.getStreamAllocatorListener(); sal != nil {
+					sal.OnBitrateAvailabilityChanged(d)
+				}
+			}
 			// RTX-TODO: This is used for media loss proxying only as of 2024-12-15.
 			// Ideally, this should keep deltas between previous RTCP Receiver Report
 			// and current report, calculate the loss in the window and reconcile it with
@@ -1888,6 +2010,10 @@ func (d *DownTrack) handleRTCP(bytes []byte) {
 }
 
 func (d *DownTrack) handleRTCPRTX(bytes []byte) {
+	if w := d.capture.Load(); w != nil {
+		w.WriteRTCP(bytes)
+	}
+
 	pkts, err := rtcp.Unmarshal(bytes)
 	if err != nil {
 		d.params.Logger.Errorw("could not unmarshal rtcp rtx receiver packet", err)
@@ -1920,6 +2046,12 @@ func (d *DownTrack) handleRTCPRTX(bytes []byte) {
 	}
 }
 
+// SetCapture records forwarded and retransmitted packets as well as RTCP received from the subscriber,
+// nil stops recording
+func (d *DownTrack) SetCapture(w *capture.Writer) {
+	d.capture.Store(w)
+}
+
 func (d *DownTrack) SetConnected() {
 	d.bindLock.Lock()
 	if !d.connected.Swap(true) {
@@ -2028,6 +2160,9 @@ func (d *DownTrack) retransmitPacket(epm *extPacketMeta, sourcePkt []byte, isPro
 			isOutOfOrder,
 		)
 	}
+	if w := d.capture.Load(); w != nil {
+		w.WriteRTPHeaderAndPayload(hdr, payload)
+	}
 	d.pacer.Enqueue(&pacer.Packet{
 		Header:             hdr,
 		HeaderSize:         headerSize,
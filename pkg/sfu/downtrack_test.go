@@ -0,0 +1,128 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+func TestH265BlankFrame(t *testing.T) {
+	d := &DownTrack{}
+	payload, err := d.getH265BlankFrame(false)
+	require.NoError(t, err)
+	require.True(t, buffer.IsH265KeyFrame(payload))
+
+	// aggregation packet with TID 0
+	require.Equal(t, byte(48), payload[0]>>1&0x3f)
+	require.Equal(t, byte(1), payload[1]&0x07)
+
+	var nalus [][]byte
+	for offset := 2; offset < len(payload); {
+		require.LessOrEqual(t, offset+2, len(payload))
+		size := int(binary.BigEndian.Uint16(payload[offset:]))
+		offset += 2
+		require.LessOrEqual(t, offset+size, len(payload))
+		nalus = append(nalus, payload[offset:offset+size])
+		offset += size
+	}
+	require.Len(t, nalus, 4)
+
+	var naluTypes []byte
+	for _, nalu := range nalus {
+		naluTypes = append(naluTypes, nalu[0]>>1&0x3f)
+		// forbidden zero bit, layer 0, TID 0
+		require.Zero(t, nalu[0]&0x81)
+		require.Zero(t, nalu[1]>>3)
+		require.Equal(t, byte(1), nalu[1]&0x07)
+	}
+	require.Equal(t, []byte{32, 33, 34, 19}, naluTypes) // VPS, SPS, PPS, IDR_W_RADL
+
+	// seq_parameter_set_rbsp up to the picture size
+	sps := &expGolombReader{data: unescapeRBSP(nalus[1][2:])}
+	require.Zero(t, sps.bits(4))          // sps_video_parameter_set_id
+	maxSubLayersMinus1 := sps.bits(3)     // sps_max_sub_layers_minus1
+	require.Zero(t, maxSubLayersMinus1)   // keeps profile_tier_level at its fixed size
+	sps.bits(1)                           // sps_temporal_id_nesting_flag
+	sps.bits(96)                          // profile_tier_level
+	require.Zero(t, sps.ue())             // sps_seq_parameter_set_id
+	require.Equal(t, uint64(1), sps.ue()) // chroma_format_idc, 4:2:0
+	require.Equal(t, uint64(8), sps.ue()) // pic_width_in_luma_samples
+	require.Equal(t, uint64(8), sps.ue()) // pic_height_in_luma_samples
+	require.False(t, sps.overrun)
+
+	// pic_parameter_set_rbsp referring to the SPS
+	pps := &expGolombReader{data: unescapeRBSP(nalus[2][2:])}
+	require.Zero(t, pps.ue()) // pps_pic_parameter_set_id
+	require.Zero(t, pps.ue()) // pps_seq_parameter_set_id
+	require.False(t, pps.overrun)
+
+	// slice_segment_header of a complete picture referring to the PPS
+	slice := &expGolombReader{data: unescapeRBSP(nalus[3][2:])}
+	require.Equal(t, uint64(1), slice.bits(1)) // first_slice_segment_in_pic_flag
+	slice.bits(1)                              // no_output_of_prior_pics_flag
+	require.Zero(t, slice.ue())                // slice_pic_parameter_set_id
+	require.False(t, slice.overrun)
+}
+
+// unescapeRBSP removes the emulation prevention bytes of a NAL unit payload
+func unescapeRBSP(data []byte) []byte {
+	rbsp := make([]byte, 0, len(data))
+	zeros := 0
+	for _, b := range data {
+		if zeros >= 2 && b == 0x03 {
+			zeros = 0
+			continue
+		}
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+		rbsp = append(rbsp, b)
+	}
+	return rbsp
+}
+
+type expGolombReader struct {
+	data    []byte
+	pos     int
+	overrun bool
+}
+
+func (r *expGolombReader) bits(n int) uint64 {
+	var v uint64
+	for i := 0; i < n; i++ {
+		if r.pos >= len(r.data)*8 {
+			r.overrun = true
+			return 0
+		}
+		v = v<<1 | uint64(r.data[r.pos/8]>>(7-r.pos%8)&1)
+		r.pos++
+	}
+	return v
+}
+
+func (r *expGolombReader) ue() uint64 {
+	leadingZeros := 0
+	for r.bits(1) == 0 && !r.overrun {
+		leadingZeros++
+	}
+	return 1<<leadingZeros - 1 + r.bits(leadingZeros)
+}
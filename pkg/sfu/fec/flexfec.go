@@ -0,0 +1,278 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fec
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	MimeTypeFlexFEC03 = "video/flexfec-03"
+
+	// highest ratio of FEC to media packets sent
+	MaxOverhead = 0.5
+
+	rtpHeaderSize = 12
+
+	// FlexFEC-03 packet masks cover 15, 46 or 109 media packets following the base sequence number
+	maskBits1 = 15
+	maskBits2 = 46
+	maskBits3 = 109
+
+	headerSize1 = 20
+	headerSize2 = 24
+	headerSize3 = 32
+
+	// a group of media packets is protected at the end of a frame once it has enough packets,
+	// small frames are protected together
+	minGroupPackets = 4
+	maxGroupPackets = 48
+
+	// loss below this is left to NACKs
+	minLoss = 0.01
+	// protection is a multiple of the loss to recover most of it
+	lossToOverhead = 2.0
+	minOverhead    = 0.1
+	overheadStep   = 0.05
+	// weight of the previous loss when loss goes down, so protection is not dropped on a single good report
+	lossDecay = 0.75
+)
+
+// OverheadForLoss returns the ratio of FEC to media packets protecting a stream losing the given fraction of packets.
+// It is 0 for a stream not losing enough to need FEC.
+func OverheadForLoss(loss float64) float64 {
+	if loss < minLoss {
+		return 0
+	}
+	overhead := math.Ceil(max(loss*lossToOverhead, minOverhead)/overheadStep-1e-9) * overheadStep
+	return min(overhead, MaxOverhead)
+}
+
+// Encoder generates FlexFEC packets protecting a media stream, in the format of
+// draft-ietf-payload-flexible-fec-scheme-03 as received by libwebrtc.
+//
+// Media packets are protected in groups as they are sent. When n FEC packets protect a group,
+// FEC packet i covers media packets i, i+n, i+2n, ... of the group, so a burst of up to n lost
+// packets can be recovered. The number of FEC packets adapts to the loss the receiver reports.
+type Encoder struct {
+	lock sync.Mutex
+
+	mediaSSRC      uint32
+	ssrc           uint32
+	payloadType    uint8
+	sequenceNumber uint16
+
+	loss     float64
+	overhead float64
+
+	group       [][]byte
+	groupBaseSN uint16
+}
+
+func NewEncoder(mediaSSRC uint32, ssrc uint32, payloadType uint8) *Encoder {
+	return &Encoder{
+		mediaSSRC:      mediaSSRC,
+		ssrc:           ssrc,
+		payloadType:    payloadType,
+		sequenceNumber: uint16(rand.Intn(1 << 15)),
+	}
+}
+
+// UpdateLoss adapts protection to the fraction of lost packets reported in an RTCP receiver report.
+// Protection goes up with the loss right away, and down slowly. Returns true when the overhead changed.
+func (e *Encoder) UpdateLoss(fractionLost uint8) bool {
+	loss := float64(fractionLost) / 256
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if loss >= e.loss {
+		e.loss = loss
+	} else {
+		e.loss = lossDecay*e.loss + (1-lossDecay)*loss
+	}
+
+	overhead := OverheadForLoss(e.loss)
+	if overhead == e.overhead {
+		return false
+	}
+	e.overhead = overhead
+	if overhead == 0 {
+		e.group = nil
+	}
+	return true
+}
+
+// Overhead returns the ratio of FEC to media packets being sent
+func (e *Encoder) Overhead() float64 {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return e.overhead
+}
+
+// Encode adds a media packet, exactly as sent, to the group being protected.
+// It returns the FEC packets to send once a group is complete.
+func (e *Encoder) Encode(hdr *rtp.Header, payload []byte) []*rtp.Packet {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.overhead == 0 {
+		return nil
+	}
+
+	var fecPackets []*rtp.Packet
+	// also true for a packet older than the group
+	if len(e.group) != 0 && hdr.SequenceNumber-e.groupBaseSN >= maskBits3 {
+		fecPackets = e.protectGroup()
+	}
+
+	pkt := make([]byte, hdr.MarshalSize()+len(payload))
+	n, err := hdr.MarshalTo(pkt)
+	if err != nil {
+		return fecPackets
+	}
+	copy(pkt[n:], payload)
+
+	if len(e.group) == 0 {
+		e.groupBaseSN = hdr.SequenceNumber
+	}
+	e.group = append(e.group, pkt)
+	if (hdr.Marker && len(e.group) >= minGroupPackets) || len(e.group) >= maxGroupPackets {
+		fecPackets = append(fecPackets, e.protectGroup()...)
+	}
+	return fecPackets
+}
+
+func (e *Encoder) protectGroup() []*rtp.Packet {
+	group, baseSN := e.group, e.groupBaseSN
+	e.group = nil
+
+	numFEC := int(math.Ceil(float64(len(group))*e.overhead - 1e-9))
+	numFEC = max(1, min(numFEC, len(group)))
+	fecPackets := make([]*rtp.Packet, 0, numFEC)
+	for i := 0; i < numFEC; i++ {
+		fecPackets = append(fecPackets, e.fecPacket(group, baseSN, i, numFEC))
+	}
+	return fecPackets
+}
+
+func (e *Encoder) fecPacket(group [][]byte, baseSN uint16, index int, stride int) *rtp.Packet {
+	/*
+	    0                   1                   2                   3
+	    0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	   |R|F|P|X|  CC   |M| PT recovery |        length recovery        |
+	   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	   |                          TS recovery                          |
+	   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	   |   SSRCCount   |                    reserved                   |
+	   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	   |                             SSRC_i                            |
+	   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	   |           SN base_i           |k|          Mask [0-14]        |
+	   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	   |k|                   Mask [15-45] (optional)                   |
+	   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	   |k|                                                             |
+	   +-+                   Mask [46-108] (optional)                  |
+	   |                                                               |
+	   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	*/
+	var (
+		header    [headerSize3]byte
+		repair    []byte
+		mask1     uint16
+		mask2     uint32
+		mask3     uint64
+		maxOffset uint16
+		timestamp uint32
+	)
+	for i := index; i < len(group); i += stride {
+		pkt := group[i]
+
+		// XOR of everything but the sequence number and SSRC, which are known
+		header[0] ^= pkt[0]
+		header[1] ^= pkt[1]
+		length := uint16(len(pkt) - rtpHeaderSize)
+		header[2] ^= byte(length >> 8)
+		header[3] ^= byte(length)
+		for j := 4; j < 8; j++ {
+			header[j] ^= pkt[j]
+		}
+		if len(repair) < len(pkt)-rtpHeaderSize {
+			repair = append(repair, make([]byte, len(pkt)-rtpHeaderSize-len(repair))...)
+		}
+		for j, b := range pkt[rtpHeaderSize:] {
+			repair[j] ^= b
+		}
+
+		offset := binary.BigEndian.Uint16(pkt[2:4]) - baseSN
+		switch {
+		case offset < maskBits1:
+			mask1 |= 1 << (maskBits1 - 1 - offset)
+		case offset < maskBits2:
+			mask2 |= 1 << (maskBits2 - 1 - offset)
+		default:
+			mask3 |= 1 << (maskBits3 - 1 - offset)
+		}
+		maxOffset = max(maxOffset, offset)
+		timestamp = binary.BigEndian.Uint32(pkt[4:8])
+	}
+	// R and F bits are cleared, retransmissions and fixed masks are not used
+	header[0] &= 0x3f
+
+	header[8] = 1
+	binary.BigEndian.PutUint32(header[12:16], e.mediaSSRC)
+	binary.BigEndian.PutUint16(header[16:18], baseSN)
+
+	// k bit is set on the last mask
+	headerSize := headerSize1
+	switch {
+	case maxOffset < maskBits1:
+		binary.BigEndian.PutUint16(header[18:20], mask1|1<<15)
+	case maxOffset < maskBits2:
+		headerSize = headerSize2
+		binary.BigEndian.PutUint16(header[18:20], mask1)
+		binary.BigEndian.PutUint32(header[20:24], mask2|1<<31)
+	default:
+		headerSize = headerSize3
+		binary.BigEndian.PutUint16(header[18:20], mask1)
+		binary.BigEndian.PutUint32(header[20:24], mask2)
+		binary.BigEndian.PutUint64(header[24:32], mask3|1<<63)
+	}
+
+	payload := make([]byte, headerSize+len(repair))
+	copy(payload, header[:headerSize])
+	copy(payload[headerSize:], repair)
+
+	fecPacket := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    e.payloadType,
+			SequenceNumber: e.sequenceNumber,
+			Timestamp:      timestamp,
+			SSRC:           e.ssrc,
+		},
+		Payload: payload,
+	}
+	e.sequenceNumber++
+	return fecPacket
+}
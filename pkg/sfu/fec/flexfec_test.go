@@ -0,0 +1,299 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fec
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testMediaSSRC = 0x1234
+	testFECSSRC   = 0x5678
+	testFECPT     = 49
+)
+
+func TestOverheadForLoss(t *testing.T) {
+	require.Zero(t, OverheadForLoss(0))
+	require.Zero(t, OverheadForLoss(0.005))
+	require.InDelta(t, 0.1, OverheadForLoss(0.01), 1e-9)
+	require.InDelta(t, 0.1, OverheadForLoss(0.05), 1e-9)
+	require.InDelta(t, 0.15, OverheadForLoss(0.06), 1e-9)
+	require.InDelta(t, 0.4, OverheadForLoss(0.2), 1e-9)
+	require.InDelta(t, MaxOverhead, OverheadForLoss(0.6), 1e-9)
+}
+
+func TestEncoderUpdateLoss(t *testing.T) {
+	e := NewEncoder(testMediaSSRC, testFECSSRC, testFECPT)
+	require.False(t, e.UpdateLoss(0))
+	require.Zero(t, e.Overhead())
+
+	// 10% loss
+	require.True(t, e.UpdateLoss(26))
+	require.InDelta(t, 0.25, e.Overhead(), 1e-9)
+
+	// protection is kept through a single report without loss
+	require.True(t, e.UpdateLoss(0))
+	require.InDelta(t, 0.2, e.Overhead(), 1e-9)
+	for i := 0; i < 10; i++ {
+		e.UpdateLoss(0)
+	}
+	require.Zero(t, e.Overhead())
+
+	hdr := &rtp.Header{Version: 2, SSRC: testMediaSSRC, Marker: true}
+	require.Nil(t, e.Encode(hdr, []byte{1, 2, 3}))
+}
+
+func TestEncoderRecovery(t *testing.T) {
+	// frames of 1 to 12 packets, small frames are protected together
+	frames := []int{1, 3, 8, 2, 2, 12, 1, 1, 1, 1, 5, 9, 4, 7}
+
+	testCases := []struct {
+		name         string
+		fractionLost uint8
+		startSN      uint16
+		lose         func(group int, numFEC int, index int) bool
+		recoversAll  bool
+	}{
+		{
+			name:         "single loss",
+			fractionLost: 13,
+			lose: func(group int, numFEC int, index int) bool {
+				return index == group%numFEC
+			},
+			recoversAll: true,
+		},
+		{
+			name:         "burst as long as protection",
+			fractionLost: 64,
+			startSN:      65530,
+			lose: func(group int, numFEC int, index int) bool {
+				start := group % 3
+				return index >= start && index < start+numFEC
+			},
+			recoversAll: true,
+		},
+		{
+			name:         "fec lost too",
+			fractionLost: 64,
+			lose: func(group int, numFEC int, index int) bool {
+				// media packet 0 and a FEC packet not covering it are lost
+				return index == 0 || index == -1
+			},
+			recoversAll: true,
+		},
+		{
+			name:         "burst longer than protection",
+			fractionLost: 13,
+			lose: func(group int, numFEC int, index int) bool {
+				return index < numFEC+1
+			},
+			recoversAll: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := NewEncoder(testMediaSSRC, testFECSSRC, testFECPT)
+			require.True(t, e.UpdateLoss(tc.fractionLost))
+
+			sent := make(map[uint16][]byte)
+			received := make(map[uint16][]byte)
+			var fecPackets []*rtp.Packet
+
+			var group [][]byte
+			numGroups := 0
+			onGroup := func(fec []*rtp.Packet) {
+				if len(fec) == 0 {
+					return
+				}
+				for i, pkt := range group {
+					if !tc.lose(numGroups, len(fec), i) {
+						received[binary.BigEndian.Uint16(pkt[2:4])] = pkt
+					}
+				}
+				for i, f := range fec {
+					if !(tc.lose(numGroups, len(fec), -1) && i == 1%len(fec)) {
+						fecPackets = append(fecPackets, f)
+					}
+				}
+				group = nil
+				numGroups++
+			}
+
+			rng := rand.New(rand.NewSource(1))
+			sn := tc.startSN
+			for i, numPackets := range frames {
+				for j := 0; j < numPackets; j++ {
+					hdr := &rtp.Header{
+						Version:        2,
+						PayloadType:    96,
+						SequenceNumber: sn,
+						Timestamp:      uint32(3000 * i),
+						SSRC:           testMediaSSRC,
+						Marker:         j == numPackets-1,
+					}
+					if j%2 == 0 {
+						require.NoError(t, hdr.SetExtension(1, []byte{byte(sn), 1, 2}))
+					}
+					payload := make([]byte, 50+rng.Intn(1000))
+					rng.Read(payload)
+
+					pkt, err := hdr.Marshal()
+					require.NoError(t, err)
+					pkt = append(pkt, payload...)
+					sent[sn] = pkt
+					group = append(group, pkt)
+					sn++
+
+					onGroup(e.Encode(hdr, payload))
+				}
+			}
+			require.Greater(t, numGroups, 5)
+			require.Empty(t, group, "last frame should complete a group")
+			for _, f := range fecPackets {
+				require.Equal(t, uint8(testFECPT), f.PayloadType)
+				require.Equal(t, uint32(testFECSSRC), f.SSRC)
+			}
+
+			recoverPackets(t, received, fecPackets)
+			if !tc.recoversAll {
+				require.Less(t, len(received), len(sent))
+			} else {
+				require.Len(t, received, len(sent))
+			}
+			for sn, pkt := range received {
+				require.Equal(t, sent[sn], pkt, "sequence number %d", sn)
+			}
+		})
+	}
+}
+
+func TestEncoderLargeGroup(t *testing.T) {
+	e := NewEncoder(testMediaSSRC, testFECSSRC, testFECPT)
+	// 50% loss, every FEC packet protects two media packets
+	require.True(t, e.UpdateLoss(128))
+
+	sent := make(map[uint16][]byte)
+	received := make(map[uint16][]byte)
+	var fecPackets []*rtp.Packet
+	for i := 0; i < maxGroupPackets; i++ {
+		hdr := &rtp.Header{Version: 2, PayloadType: 96, SequenceNumber: uint16(100 + 2*i), Timestamp: 90000, SSRC: testMediaSSRC}
+		payload := []byte{byte(i), byte(i), byte(i)}
+		pkt, err := hdr.Marshal()
+		require.NoError(t, err)
+		pkt = append(pkt, payload...)
+		sent[hdr.SequenceNumber] = pkt
+		if i >= maxGroupPackets/2 {
+			received[hdr.SequenceNumber] = pkt
+		}
+		fecPackets = append(fecPackets, e.Encode(hdr, payload)...)
+	}
+	// a group is protected when it reaches the maximum size even without a frame end,
+	// with sequence number gaps needing the largest mask
+	require.Len(t, fecPackets, maxGroupPackets/2)
+	require.Len(t, fecPackets[0].Payload, headerSize3+3)
+
+	recoverPackets(t, received, fecPackets)
+	require.Equal(t, sent, received)
+}
+
+// recoverPackets recovers missing media packets from FEC packets, the way a FlexFEC-03 receiver does
+func recoverPackets(t *testing.T, received map[uint16][]byte, fecPackets []*rtp.Packet) {
+	for recovered := true; recovered; {
+		recovered = false
+		for _, f := range fecPackets {
+			fec := f.Payload
+			require.Zero(t, fec[0]&0xc0)
+			require.Equal(t, byte(1), fec[8])
+			require.Equal(t, uint32(testMediaSSRC), binary.BigEndian.Uint32(fec[12:16]))
+			baseSN := binary.BigEndian.Uint16(fec[16:18])
+
+			var offsets []uint16
+			headerSize := headerSize1
+			mask1 := binary.BigEndian.Uint16(fec[18:20])
+			for i := uint16(0); i < maskBits1; i++ {
+				if mask1&(1<<(maskBits1-1-i)) != 0 {
+					offsets = append(offsets, i)
+				}
+			}
+			if mask1&(1<<15) == 0 {
+				headerSize = headerSize2
+				mask2 := binary.BigEndian.Uint32(fec[20:24])
+				for i := uint16(maskBits1); i < maskBits2; i++ {
+					if mask2&(1<<(maskBits2-1-i)) != 0 {
+						offsets = append(offsets, i)
+					}
+				}
+				if mask2&(1<<31) == 0 {
+					headerSize = headerSize3
+					mask3 := binary.BigEndian.Uint64(fec[24:32])
+					require.NotZero(t, mask3&(1<<63))
+					for i := uint16(maskBits2); i < maskBits3; i++ {
+						if mask3&(1<<(maskBits3-1-i)) != 0 {
+							offsets = append(offsets, i)
+						}
+					}
+				}
+			}
+
+			var missing []uint16
+			for _, offset := range offsets {
+				if _, ok := received[baseSN+offset]; !ok {
+					missing = append(missing, baseSN+offset)
+				}
+			}
+			if len(missing) != 1 {
+				continue
+			}
+
+			header := append([]byte{}, fec[:8]...)
+			repair := append([]byte{}, fec[headerSize:]...)
+			for _, offset := range offsets {
+				pkt, ok := received[baseSN+offset]
+				if !ok {
+					continue
+				}
+				header[0] ^= pkt[0]
+				header[1] ^= pkt[1]
+				length := uint16(len(pkt) - rtpHeaderSize)
+				header[2] ^= byte(length >> 8)
+				header[3] ^= byte(length)
+				for j := 4; j < 8; j++ {
+					header[j] ^= pkt[j]
+				}
+				for j, b := range pkt[rtpHeaderSize:] {
+					repair[j] ^= b
+				}
+			}
+
+			length := binary.BigEndian.Uint16(header[2:4])
+			require.LessOrEqual(t, int(length), len(repair))
+			pkt := make([]byte, rtpHeaderSize, rtpHeaderSize+int(length))
+			pkt[0] = header[0]&0x3f | 0x80
+			pkt[1] = header[1]
+			binary.BigEndian.PutUint16(pkt[2:4], missing[0])
+			copy(pkt[4:8], header[4:8])
+			binary.BigEndian.PutUint32(pkt[8:12], testMediaSSRC)
+			pkt = append(pkt, repair[:length]...)
+			received[missing[0]] = pkt
+			recovered = true
+		}
+	}
+}
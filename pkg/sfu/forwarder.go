@@ -293,7 +293,7 @@ func (f *Forwarder) SetMaxTemporalLayerSeen(maxTemporalLayerSeen int32) bool {
 	return true
 }
 
-func (f *Forwarder) DetermineCodec(codec webrtc.RTPCodecCapability, extensions []webrtc.RTPHeaderExtensionParameter) {
+func (f *Forwarder) DetermineCodec(codec webrtc.RTPCodecCapability, extensions []webrtc.RTPHeaderExtensionParameter, isSVC bool) {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
@@ -321,7 +321,7 @@ func (f *Forwarder) DetermineCodec(codec webrtc.RTPCodecCapability, extensions [
 		}
 		f.vls.SetTemporalLayerSelector(temporallayerselector.NewVP8(f.logger))
 
-	case "video/h264":
+	case "video/h264", "video/h265":
 		if f.vls != nil {
 			f.vls = videolayerselector.NewSimulcastFromNull(f.vls)
 		} else {
@@ -349,20 +349,27 @@ func (f *Forwarder) DetermineCodec(codec webrtc.RTPCodecCapability, extensions [
 	case "video/av1":
 		// DD-TODO : we only enable dd layer selector for av1/vp9 now, in the future we can enable it for vp8 too
 		isDDAvailable := ddAvailable(extensions)
-		if isDDAvailable {
+		switch {
+		case isDDAvailable && isSVC:
 			if f.vls != nil {
 				f.vls = videolayerselector.NewDependencyDescriptorFromNull(f.vls)
 			} else {
 				f.vls = videolayerselector.NewDependencyDescriptor(f.logger)
 			}
-		} else {
+		case isDDAvailable:
+			// simulcast, layers are independent streams, each with its own dependency descriptor
+			if f.vls != nil {
+				f.vls = videolayerselector.NewAV1SimulcastFromNull(f.vls)
+			} else {
+				f.vls = videolayerselector.NewAV1Simulcast(f.logger)
+			}
+		default:
 			if f.vls != nil {
 				f.vls = videolayerselector.NewSimulcastFromNull(f.vls)
 			} else {
 				f.vls = videolayerselector.NewSimulcast(f.logger)
 			}
 		}
-		// SVC-TODO: Support for AV1 Simulcast
 	}
 }
 
@@ -1504,8 +1511,11 @@ func (f *Forwarder) Pause(availableLayers []int32, brs Bitrates) VideoAllocation
 
 func (f *Forwarder) updateAllocation(alloc VideoAllocation, reason string) VideoAllocation {
 	// restrict target temporal to 0 if codec does not support temporal layers
-	if alloc.TargetLayer.IsValid() && strings.ToLower(f.codec.MimeType) == "video/h264" {
-		alloc.TargetLayer.Temporal = 0
+	if alloc.TargetLayer.IsValid() {
+		switch strings.ToLower(f.codec.MimeType) {
+		case "video/h264", "video/h265":
+			alloc.TargetLayer.Temporal = 0
+		}
 	}
 
 	if alloc.IsDeficient != f.lastAllocation.IsDeficient ||
@@ -23,7 +23,9 @@ import (
 	"github.com/livekit/protocol/logger"
 
 	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	dd "github.com/livekit/livekit-server/pkg/sfu/rtpextension/dependencydescriptor"
 	"github.com/livekit/livekit-server/pkg/sfu/testutils"
+	"github.com/livekit/livekit-server/pkg/sfu/videolayerselector"
 )
 
 func disable(f *Forwarder) {
@@ -33,7 +35,7 @@ func disable(f *Forwarder) {
 
 func newForwarder(codec webrtc.RTPCodecCapability, kind webrtc.RTPCodecType) *Forwarder {
 	f := NewForwarder(kind, logger.GetLogger(), true, nil)
-	f.DetermineCodec(codec, nil)
+	f.DetermineCodec(codec, nil, buffer.IsSvcCodec(codec.MimeType))
 	return f
 }
 
@@ -57,6 +59,25 @@ func TestForwarderMute(t *testing.T) {
 	require.False(t, f.IsMuted())
 }
 
+func TestForwarderDetermineCodecAV1(t *testing.T) {
+	ddExtensions := []webrtc.RTPHeaderExtensionParameter{{URI: dd.ExtensionURI, ID: 8}}
+
+	// SVC, all spatial layers in one stream
+	f := NewForwarder(webrtc.RTPCodecTypeVideo, logger.GetLogger(), true, nil)
+	f.DetermineCodec(testutils.TestAV1Codec, ddExtensions, true)
+	require.IsType(t, &videolayerselector.DependencyDescriptor{}, f.vls)
+
+	// simulcast, dependency descriptor of each layer is rewritten
+	f = NewForwarder(webrtc.RTPCodecTypeVideo, logger.GetLogger(), true, nil)
+	f.DetermineCodec(testutils.TestAV1Codec, ddExtensions, false)
+	require.IsType(t, &videolayerselector.AV1Simulcast{}, f.vls)
+
+	// without dependency descriptor, layers are forwarded as is
+	f = NewForwarder(webrtc.RTPCodecTypeVideo, logger.GetLogger(), true, nil)
+	f.DetermineCodec(testutils.TestAV1Codec, nil, false)
+	require.IsType(t, &videolayerselector.Simulcast{}, f.vls)
+}
+
 func TestForwarderLayersAudio(t *testing.T) {
 	f := newForwarder(testutils.TestOpusCodec, webrtc.RTPCodecTypeAudio)
 
@@ -456,6 +477,26 @@ func TestForwarderAllocateOptimal(t *testing.T) {
 	require.Equal(t, expectedTargetLayer, f.TargetLayer())
 }
 
+func TestForwarderAllocateOptimalH265(t *testing.T) {
+	f := newForwarder(testutils.TestH265Codec, webrtc.RTPCodecTypeVideo)
+	require.NotNil(t, f.vls)
+
+	bitrates := Bitrates{
+		{2, 3, 0, 0},
+		{4, 5, 0, 0},
+		{6, 7, 0, 0},
+	}
+	f.SetMaxSpatialLayer(buffer.DefaultMaxLayerSpatial)
+	f.SetMaxTemporalLayer(buffer.DefaultMaxLayerTemporal)
+	f.SetMaxPublishedLayer(buffer.DefaultMaxLayerSpatial)
+	f.SetMaxTemporalLayerSeen(buffer.DefaultMaxLayerTemporal)
+
+	// simulcast layers are switched like h.264, without temporal layers
+	result := f.AllocateOptimal([]int32{0, 1, 2}, bitrates, true, false)
+	require.Equal(t, buffer.VideoLayer{Spatial: 2, Temporal: 0}, result.TargetLayer)
+	require.Equal(t, result.TargetLayer, f.TargetLayer())
+}
+
 func TestForwarderProvisionalAllocate(t *testing.T) {
 	f := newForwarder(testutils.TestVP8Codec, webrtc.RTPCodecTypeVideo)
 	f.SetMaxSpatialLayer(buffer.DefaultMaxLayerSpatial)
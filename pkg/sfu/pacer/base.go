@@ -76,6 +76,9 @@ func (b *Base) SendPacket(p *Packet) (int, error) {
 		return 0, err
 	}
 
+	if p.OnSent != nil {
+		p.OnSent(p.Header, p.Payload)
+	}
 	return written, nil
 }
 
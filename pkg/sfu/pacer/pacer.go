@@ -35,6 +35,8 @@ type Packet struct {
 	WriteStream        webrtc.TrackLocalWriter
 	Pool               *sync.Pool
 	PoolEntity         *[]byte
+	// called with the packet as written, before it is returned to the pool
+	OnSent func(header *rtp.Header, payload []byte)
 }
 
 type Pacer interface {
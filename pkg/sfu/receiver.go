@@ -90,6 +90,8 @@ type TrackReceiver interface {
 	StreamID() string
 	Codec() webrtc.RTPCodecParameters
 	HeaderExtensions() []webrtc.RTPHeaderExtensionParameter
+	// IsSVC returns true when all spatial layers are received in a single stream
+	IsSVC() bool
 	IsClosed() bool
 
 	ReadRTP(buf []byte, layer uint8, esn uint64) (int, error)
@@ -235,7 +237,7 @@ func NewWebRTCReceiver(
 		codec:    track.Codec(),
 		kind:     track.Kind(),
 		onRTCP:   onRTCP,
-		isSVC:    buffer.IsSvcCodec(track.Codec().MimeType),
+		isSVC:    isSVCTrack(track, trackInfo),
 		isRED:    buffer.IsRedCodec(track.Codec().MimeType),
 	}
 
@@ -266,7 +268,8 @@ func NewWebRTCReceiver(
 
 	w.streamTrackerManager = NewStreamTrackerManager(logger, trackInfo, w.isSVC, w.codec.ClockRate, streamTrackerManagerConfig)
 	w.streamTrackerManager.SetListener(w)
-	// SVC-TODO: Handle DD for non-SVC cases???
+	// simulcast layers are tracked per layer, the dependency descriptor of AV1 simulcast layers only
+	// feeds the temporal layers seen, see ObserveSimulcastDependencyDescriptor
 	if w.isSVC {
 		for _, ext := range receiver.GetParameters().HeaderExtensions {
 			if ext.URI == dd.ExtensionURI {
@@ -356,6 +359,10 @@ func (w *WebRTCReceiver) HeaderExtensions() []webrtc.RTPHeaderExtensionParameter
 	return w.receiver.GetParameters().HeaderExtensions
 }
 
+func (w *WebRTCReceiver) IsSVC() bool {
+	return w.isSVC
+}
+
 func (w *WebRTCReceiver) Kind() webrtc.RTPCodecType {
 	return w.kind
 }
@@ -728,7 +735,7 @@ func (w *WebRTCReceiver) forwardRTP(layer int32, buff *buffer.Buffer) {
 		}
 
 		spatialLayer := layer
-		if pkt.Spatial >= 0 {
+		if w.isSVC && pkt.Spatial >= 0 {
 			// svc packet, take spatial layer info from packet
 			spatialLayer = pkt.Spatial
 		}
@@ -764,6 +771,9 @@ func (w *WebRTCReceiver) forwardRTP(layer int32, buff *buffer.Buffer) {
 					pkt.DependencyDescriptor,
 				)
 			}
+			if !w.isSVC && pkt.DependencyDescriptor != nil && pkt.DependencyDescriptor.ActiveDecodeTargetsUpdated {
+				w.streamTrackerManager.ObserveSimulcastDependencyDescriptor(pkt.DependencyDescriptor)
+			}
 		}
 	}
 }
@@ -862,6 +872,22 @@ func (w *WebRTCReceiver) AddOnReady(fn func()) {
 
 // -----------------------------------------------------------
 
+// AV1 can be published either as SVC, with all spatial layers in a single stream, or as simulcast, with a stream
+// for each layer. Both send a RID, so the layer mode negotiated by the publisher decides, AV1 tracks of
+// publishers that do not send it are handled as SVC.
+func isSVCTrack(track TrackRemote, trackInfo *livekit.TrackInfo) bool {
+	mime := track.Codec().MimeType
+	if strings.EqualFold(mime, webrtc.MimeTypeAV1) {
+		for _, codec := range trackInfo.GetCodecs() {
+			if strings.EqualFold(codec.MimeType, mime) {
+				return codec.VideoLayerMode != livekit.VideoLayer_ONE_SPATIAL_LAYER_PER_STREAM
+			}
+		}
+	}
+
+	return buffer.IsSvcCodec(mime)
+}
+
 // closes all track senders in parallel, returns when all are closed
 func closeTrackSenders(senders []TrackSender) {
 	wg := sync.WaitGroup{}
@@ -23,8 +23,12 @@ import (
 	"testing"
 
 	"github.com/gammazero/workerpool"
+	"github.com/pion/webrtc/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/livekit"
 )
 
 func TestWebRTCReceiver_OnCloseHandler(t *testing.T) {
@@ -211,3 +215,71 @@ func writeRTP() {
 		s = h.Sum(s)
 	}
 }
+
+type testTrackRemote struct {
+	TrackRemote
+	rid  string
+	mime string
+}
+
+func (t *testTrackRemote) RID() string {
+	return t.rid
+}
+
+func (t *testTrackRemote) Codec() webrtc.RTPCodecParameters {
+	return webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: t.mime}}
+}
+
+func TestIsSVCTrack(t *testing.T) {
+	av1TrackInfo := func(mode livekit.VideoLayer_Mode) *livekit.TrackInfo {
+		return &livekit.TrackInfo{
+			Codecs: []*livekit.SimulcastCodecInfo{
+				{MimeType: "video/vp8"},
+				{MimeType: "video/av1", VideoLayerMode: mode},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		track     *testTrackRemote
+		trackInfo *livekit.TrackInfo
+		isSVC     bool
+	}{
+		{
+			name:      "AV1 simulcast",
+			track:     &testTrackRemote{rid: "f", mime: webrtc.MimeTypeAV1},
+			trackInfo: av1TrackInfo(livekit.VideoLayer_ONE_SPATIAL_LAYER_PER_STREAM),
+			isSVC:     false,
+		},
+		{
+			name:      "AV1 SVC with a RID",
+			track:     &testTrackRemote{rid: "q", mime: webrtc.MimeTypeAV1},
+			trackInfo: av1TrackInfo(livekit.VideoLayer_MULTIPLE_SPATIAL_LAYERS_PER_STREAM),
+			isSVC:     true,
+		},
+		{
+			name:      "AV1 without layer mode",
+			track:     &testTrackRemote{rid: "q", mime: webrtc.MimeTypeAV1},
+			trackInfo: av1TrackInfo(livekit.VideoLayer_MODE_UNUSED),
+			isSVC:     true,
+		},
+		{
+			name:      "VP8 simulcast",
+			track:     &testTrackRemote{rid: "f", mime: webrtc.MimeTypeVP8},
+			trackInfo: av1TrackInfo(livekit.VideoLayer_ONE_SPATIAL_LAYER_PER_STREAM),
+			isSVC:     false,
+		},
+		{
+			name:      "VP9",
+			track:     &testTrackRemote{mime: webrtc.MimeTypeVP9},
+			trackInfo: &livekit.TrackInfo{},
+			isSVC:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.isSVC, isSVCTrack(tt.track, tt.trackInfo))
+		})
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streamallocator
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+const (
+	AllocationPolicyDefault            = "default"
+	AllocationPolicyScreenShareFirst   = "screen_share_first"
+	AllocationPolicyActiveSpeakerFirst = "active_speaker_first"
+	AllocationPolicyEqualShare         = "equal_share"
+)
+
+// AllocationTrack is a track whose layers are allocated by an AllocationPolicy
+type AllocationTrack interface {
+	ID() livekit.TrackID
+	Source() livekit.TrackSource
+	Priority() uint8
+	MaxLayer() buffer.VideoLayer
+	// audio level of the publisher while it is an active speaker, 0 otherwise
+	SpeakerLevel() float64
+	ProvisionalAllocate(availableChannelCapacity int64, layer buffer.VideoLayer, allowPause bool, allowOvershoot bool) (bool, int64)
+}
+
+// AllocationPolicy decides which tracks get which layers when the channel capacity
+// is not enough for all tracks to stream at their optimal layers.
+type AllocationPolicy interface {
+	Name() string
+	// Allocate provisionally allocates layers to tracks within the available channel capacity.
+	// Tracks are paused when they do not get a layer, if pause is allowed.
+	Allocate(tracks []AllocationTrack, availableChannelCapacity int64, allowPause bool)
+	// Compare orders tracks by importance, negative when a is more important than b.
+	// More important tracks are boosted first when capacity frees up, and give back bits last.
+	Compare(a, b AllocationTrack) int
+	// tracks are allocated again when active speakers change
+	UsesActiveSpeakers() bool
+}
+
+var allocationPolicies = map[string]AllocationPolicy{
+	AllocationPolicyDefault:            defaultAllocationPolicy{},
+	AllocationPolicyScreenShareFirst:   screenShareFirstAllocationPolicy{},
+	AllocationPolicyActiveSpeakerFirst: activeSpeakerFirstAllocationPolicy{},
+	AllocationPolicyEqualShare:         equalShareAllocationPolicy{},
+}
+
+// GetAllocationPolicy returns a built-in policy by name
+func GetAllocationPolicy(name string) (AllocationPolicy, bool) {
+	policy, ok := allocationPolicies[name]
+	return policy, ok
+}
+
+// allocateLayerByLayer gives each track, in order, a chance at each layer before going up to the next one.
+// As long as there is enough capacity for the lowest layer, all tracks stream.
+// Returns the capacity left.
+func allocateLayerByLayer(tracks []AllocationTrack, availableChannelCapacity int64, allowPause bool) int64 {
+	for spatial := int32(0); spatial <= buffer.DefaultMaxLayerSpatial; spatial++ {
+		for temporal := int32(0); temporal <= buffer.DefaultMaxLayerTemporal; temporal++ {
+			layer := buffer.VideoLayer{
+				Spatial:  spatial,
+				Temporal: temporal,
+			}
+
+			for _, track := range tracks {
+				_, usedChannelCapacity := track.ProvisionalAllocate(availableChannelCapacity, layer, allowPause, FlagAllowOvershootWhileDeficient)
+				availableChannelCapacity -= usedChannelCapacity
+				if availableChannelCapacity < 0 {
+					availableChannelCapacity = 0
+				}
+			}
+		}
+	}
+	return availableChannelCapacity
+}
+
+// allocateFirst gives the tracks matching first all the layers they can take, in order, before the other tracks
+// are allocated layer by layer
+func allocateFirst(tracks []AllocationTrack, availableChannelCapacity int64, allowPause bool, first func(t AllocationTrack) bool) {
+	var rest []AllocationTrack
+	for _, track := range tracks {
+		if !first(track) {
+			rest = append(rest, track)
+			continue
+		}
+		availableChannelCapacity = allocateLayerByLayer([]AllocationTrack{track}, availableChannelCapacity, allowPause)
+	}
+	allocateLayerByLayer(rest, availableChannelCapacity, allowPause)
+}
+
+func compareByPriority(a, b AllocationTrack) int {
+	return cmp.Compare(b.Priority(), a.Priority())
+}
+
+// ------------------------------------------------
+
+// defaultAllocationPolicy tries to stream as many tracks as possible, then to give a fair allocation to all tracks.
+// Higher priority tracks get an earlier shot at each layer.
+type defaultAllocationPolicy struct{}
+
+func (defaultAllocationPolicy) Name() string {
+	return AllocationPolicyDefault
+}
+
+func (defaultAllocationPolicy) Allocate(tracks []AllocationTrack, availableChannelCapacity int64, allowPause bool) {
+	sorted := slices.Clone(tracks)
+	slices.SortStableFunc(sorted, func(a, b AllocationTrack) int {
+		if c := compareByPriority(a, b); c != 0 {
+			return c
+		}
+		// tracks with higher subscribed layers can use any additional capacity
+		if c := cmp.Compare(b.MaxLayer().Spatial, a.MaxLayer().Spatial); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.MaxLayer().Temporal, a.MaxLayer().Temporal)
+	})
+	allocateLayerByLayer(sorted, availableChannelCapacity, allowPause)
+}
+
+func (defaultAllocationPolicy) Compare(a, b AllocationTrack) int {
+	return compareByPriority(a, b)
+}
+
+func (defaultAllocationPolicy) UsesActiveSpeakers() bool {
+	return false
+}
+
+// ------------------------------------------------
+
+// screenShareFirstAllocationPolicy streams screen shares at their optimal layers before other tracks get any capacity
+type screenShareFirstAllocationPolicy struct{}
+
+func (screenShareFirstAllocationPolicy) Name() string {
+	return AllocationPolicyScreenShareFirst
+}
+
+func (p screenShareFirstAllocationPolicy) Allocate(tracks []AllocationTrack, availableChannelCapacity int64, allowPause bool) {
+	sorted := slices.Clone(tracks)
+	slices.SortStableFunc(sorted, p.Compare)
+	allocateFirst(sorted, availableChannelCapacity, allowPause, isScreenShare)
+}
+
+func (screenShareFirstAllocationPolicy) Compare(a, b AllocationTrack) int {
+	if isScreenShare(a) != isScreenShare(b) {
+		if isScreenShare(a) {
+			return -1
+		}
+		return 1
+	}
+	return compareByPriority(a, b)
+}
+
+func (screenShareFirstAllocationPolicy) UsesActiveSpeakers() bool {
+	return false
+}
+
+func isScreenShare(t AllocationTrack) bool {
+	return t.Source() == livekit.TrackSource_SCREEN_SHARE
+}
+
+// ------------------------------------------------
+
+// activeSpeakerFirstAllocationPolicy streams tracks of active speakers at their optimal layers, loudest first,
+// before other tracks get any capacity
+type activeSpeakerFirstAllocationPolicy struct{}
+
+func (activeSpeakerFirstAllocationPolicy) Name() string {
+	return AllocationPolicyActiveSpeakerFirst
+}
+
+func (p activeSpeakerFirstAllocationPolicy) Allocate(tracks []AllocationTrack, availableChannelCapacity int64, allowPause bool) {
+	sorted := slices.Clone(tracks)
+	slices.SortStableFunc(sorted, p.Compare)
+	allocateFirst(sorted, availableChannelCapacity, allowPause, func(t AllocationTrack) bool {
+		return t.SpeakerLevel() > 0
+	})
+}
+
+func (activeSpeakerFirstAllocationPolicy) Compare(a, b AllocationTrack) int {
+	if c := cmp.Compare(b.SpeakerLevel(), a.SpeakerLevel()); c != 0 {
+		return c
+	}
+	return compareByPriority(a, b)
+}
+
+func (activeSpeakerFirstAllocationPolicy) UsesActiveSpeakers() bool {
+	return true
+}
+
+// ------------------------------------------------
+
+// equalShareAllocationPolicy shares the capacity equally between tracks, regardless of their priority.
+// The track with the lowest allocated bitrate goes up a layer next, until no track can go up.
+type equalShareAllocationPolicy struct{}
+
+func (equalShareAllocationPolicy) Name() string {
+	return AllocationPolicyEqualShare
+}
+
+func (equalShareAllocationPolicy) Allocate(tracks []AllocationTrack, availableChannelCapacity int64, allowPause bool) {
+	type trackShare struct {
+		track     AllocationTrack
+		bitrate   int64
+		nextLayer buffer.VideoLayer
+	}
+	shares := make([]*trackShare, 0, len(tracks))
+	for _, track := range tracks {
+		shares = append(shares, &trackShare{track: track})
+	}
+	slices.SortStableFunc(shares, func(a, b *trackShare) int {
+		return cmp.Compare(a.track.ID(), b.track.ID())
+	})
+
+	for len(shares) != 0 {
+		lowest := slices.MinFunc(shares, func(a, b *trackShare) int {
+			return cmp.Compare(a.bitrate, b.bitrate)
+		})
+
+		allocated := false
+		for layer := lowest.nextLayer; layer.Spatial <= buffer.DefaultMaxLayerSpatial && !allocated; layer = nextLayer(layer) {
+			isCandidate, usedChannelCapacity := lowest.track.ProvisionalAllocate(availableChannelCapacity, layer, allowPause, FlagAllowOvershootWhileDeficient)
+			if !isCandidate {
+				continue
+			}
+			allocated = true
+			lowest.bitrate += usedChannelCapacity
+			lowest.nextLayer = nextLayer(layer)
+			availableChannelCapacity = max(availableChannelCapacity-usedChannelCapacity, 0)
+		}
+		if !allocated {
+			// cannot go up any more
+			shares = slices.DeleteFunc(shares, func(s *trackShare) bool { return s == lowest })
+		}
+	}
+}
+
+func (equalShareAllocationPolicy) Compare(_, _ AllocationTrack) int {
+	return 0
+}
+
+func (equalShareAllocationPolicy) UsesActiveSpeakers() bool {
+	return false
+}
+
+func nextLayer(layer buffer.VideoLayer) buffer.VideoLayer {
+	if layer.Temporal < buffer.DefaultMaxLayerTemporal {
+		return buffer.VideoLayer{Spatial: layer.Spatial, Temporal: layer.Temporal + 1}
+	}
+	return buffer.VideoLayer{Spatial: layer.Spatial + 1, Temporal: 0}
+}
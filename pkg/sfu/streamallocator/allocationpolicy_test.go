@@ -0,0 +1,202 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package streamallocator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+
+	"github.com/livekit/livekit-server/pkg/sfu"
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+)
+
+// syntheticTrack allocates layers from a fixed bitrate table, like a down track would
+type syntheticTrack struct {
+	id           livekit.TrackID
+	source       livekit.TrackSource
+	priority     uint8
+	speakerLevel float64
+	bitrates     sfu.Bitrates
+
+	allocated buffer.VideoLayer
+}
+
+func newSyntheticTrack(id livekit.TrackID, source livekit.TrackSource, spatialBitrates ...int64) *syntheticTrack {
+	t := &syntheticTrack{
+		id:        id,
+		source:    source,
+		priority:  PriorityDefaultVideo,
+		allocated: buffer.InvalidLayer,
+	}
+	if source == livekit.TrackSource_SCREEN_SHARE {
+		t.priority = PriorityDefaultScreenshare
+	}
+	for spatial, bitrate := range spatialBitrates {
+		t.bitrates[spatial][0] = bitrate
+	}
+	return t
+}
+
+func (t *syntheticTrack) ID() livekit.TrackID {
+	return t.id
+}
+
+func (t *syntheticTrack) Source() livekit.TrackSource {
+	return t.source
+}
+
+func (t *syntheticTrack) Priority() uint8 {
+	return t.priority
+}
+
+func (t *syntheticTrack) SpeakerLevel() float64 {
+	return t.speakerLevel
+}
+
+func (t *syntheticTrack) bitrateOf(layer buffer.VideoLayer) int64 {
+	if !layer.IsValid() {
+		return 0
+	}
+	return t.bitrates[layer.Spatial][layer.Temporal]
+}
+
+func (t *syntheticTrack) MaxLayer() buffer.VideoLayer {
+	maxLayer := buffer.InvalidLayer
+	for spatial := range t.bitrates {
+		for temporal := range t.bitrates[spatial] {
+			if t.bitrates[spatial][temporal] != 0 {
+				maxLayer = buffer.VideoLayer{Spatial: int32(spatial), Temporal: int32(temporal)}
+			}
+		}
+	}
+	return maxLayer
+}
+
+func (t *syntheticTrack) ProvisionalAllocate(availableChannelCapacity int64, layer buffer.VideoLayer, allowPause bool, _ bool) (bool, int64) {
+	requiredBitrate := t.bitrateOf(layer)
+	if layer.GreaterThan(t.MaxLayer()) || requiredBitrate == 0 {
+		return false, 0
+	}
+
+	alreadyAllocatedBitrate := t.bitrateOf(t.allocated)
+	if requiredBitrate <= availableChannelCapacity+alreadyAllocatedBitrate ||
+		(!allowPause && (!t.allocated.IsValid() || !layer.GreaterThan(t.allocated))) {
+		t.allocated = layer
+		return true, requiredBitrate - alreadyAllocatedBitrate
+	}
+	return false, 0
+}
+
+// simulateAllocation runs a policy over the tracks for each channel capacity in turn,
+// returning the bitrate allocated to each track at each step
+func simulateAllocation(policy AllocationPolicy, capacities []int64, allowPause bool, tracks ...*syntheticTrack) []map[livekit.TrackID]int64 {
+	allocationTracks := make([]AllocationTrack, 0, len(tracks))
+	for _, t := range tracks {
+		allocationTracks = append(allocationTracks, t)
+	}
+
+	steps := make([]map[livekit.TrackID]int64, 0, len(capacities))
+	for _, capacity := range capacities {
+		for _, t := range tracks {
+			t.allocated = buffer.InvalidLayer
+		}
+		policy.Allocate(allocationTracks, capacity, allowPause)
+
+		allocations := make(map[livekit.TrackID]int64, len(tracks))
+		for _, t := range tracks {
+			allocations[t.id] = t.bitrateOf(t.allocated)
+		}
+		steps = append(steps, allocations)
+	}
+	return steps
+}
+
+func TestAllocationPolicies(t *testing.T) {
+	newTracks := func() []*syntheticTrack {
+		camA := newSyntheticTrack("camA", livekit.TrackSource_CAMERA, 150_000, 500_000, 1_500_000)
+		camB := newSyntheticTrack("camB", livekit.TrackSource_CAMERA, 150_000, 500_000, 1_500_000)
+		camC := newSyntheticTrack("camC", livekit.TrackSource_CAMERA, 150_000, 500_000, 1_500_000)
+		camC.speakerLevel = 0.6
+		screen := newSyntheticTrack("screen", livekit.TrackSource_SCREEN_SHARE, 200_000, 800_000, 2_000_000)
+		return []*syntheticTrack{camA, camB, camC, screen}
+	}
+	capacities := []int64{500_000, 1_500_000, 3_000_000, 10_000_000}
+
+	testCases := []struct {
+		policy   string
+		expected []map[livekit.TrackID]int64
+	}{
+		{
+			policy: AllocationPolicyDefault,
+			expected: []map[livekit.TrackID]int64{
+				{"camA": 150_000, "camB": 150_000, "camC": 0, "screen": 200_000},
+				{"camA": 150_000, "camB": 150_000, "camC": 150_000, "screen": 800_000},
+				{"camA": 500_000, "camB": 500_000, "camC": 500_000, "screen": 800_000},
+				{"camA": 1_500_000, "camB": 1_500_000, "camC": 1_500_000, "screen": 2_000_000},
+			},
+		},
+		{
+			// screen share gets all it can before cameras
+			policy: AllocationPolicyScreenShareFirst,
+			expected: []map[livekit.TrackID]int64{
+				{"camA": 150_000, "camB": 150_000, "camC": 0, "screen": 200_000},
+				{"camA": 150_000, "camB": 150_000, "camC": 150_000, "screen": 800_000},
+				{"camA": 500_000, "camB": 150_000, "camC": 150_000, "screen": 2_000_000},
+				{"camA": 1_500_000, "camB": 1_500_000, "camC": 1_500_000, "screen": 2_000_000},
+			},
+		},
+		{
+			// camera of the active speaker gets all it can before other tracks
+			policy: AllocationPolicyActiveSpeakerFirst,
+			expected: []map[livekit.TrackID]int64{
+				{"camA": 0, "camB": 0, "camC": 500_000, "screen": 0},
+				{"camA": 0, "camB": 0, "camC": 1_500_000, "screen": 0},
+				{"camA": 500_000, "camB": 150_000, "camC": 1_500_000, "screen": 800_000},
+				{"camA": 1_500_000, "camB": 1_500_000, "camC": 1_500_000, "screen": 2_000_000},
+			},
+		},
+		{
+			// screen share has no precedence over cameras
+			policy: AllocationPolicyEqualShare,
+			expected: []map[livekit.TrackID]int64{
+				{"camA": 150_000, "camB": 150_000, "camC": 150_000, "screen": 0},
+				{"camA": 500_000, "camB": 500_000, "camC": 150_000, "screen": 200_000},
+				{"camA": 500_000, "camB": 500_000, "camC": 500_000, "screen": 800_000},
+				{"camA": 1_500_000, "camB": 1_500_000, "camC": 1_500_000, "screen": 2_000_000},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			policy, ok := GetAllocationPolicy(tc.policy)
+			require.True(t, ok)
+			require.Equal(t, tc.policy, policy.Name())
+
+			require.Equal(t, tc.expected, simulateAllocation(policy, capacities, true, newTracks()...))
+
+			// all tracks stream at their lowest layer when pause is not allowed
+			steps := simulateAllocation(policy, []int64{300_000}, false, newTracks()...)
+			for trackID, bitrate := range steps[0] {
+				require.NotZero(t, bitrate, trackID)
+			}
+		})
+	}
+
+	_, ok := GetAllocationPolicy("unknown")
+	require.False(t, ok)
+}
@@ -15,8 +15,9 @@
 package streamallocator
 
 import (
+	"cmp"
 	"fmt"
-	"sort"
+	"slices"
 	"sync"
 	"time"
 
@@ -92,6 +93,7 @@ const (
 	streamAllocatorSignalSetAllowPause
 	streamAllocatorSignalSetChannelCapacity
 	streamAllocatorSignalCongestionStateChange
+	streamAllocatorSignalSetAllocationPolicy
 )
 
 func (s streamAllocatorSignal) String() string {
@@ -122,6 +124,8 @@ func (s streamAllocatorSignal) String() string {
 		return "SET_CHANNEL_CAPACITY"
 	case streamAllocatorSignalCongestionStateChange:
 		return "CONGESTION_STATE_CHANGE"
+	case streamAllocatorSignalSetAllocationPolicy:
+		return "SET_ALLOCATION_POLICY"
 	default:
 		return fmt.Sprintf("%d", int(s))
 	}
@@ -194,6 +198,7 @@ type StreamAllocator struct {
 
 	enabled    bool
 	allowPause bool
+	policy     AllocationPolicy
 
 	committedChannelCapacity  int64
 	overriddenChannelCapacity int64
@@ -204,6 +209,7 @@ type StreamAllocator struct {
 	videoTracks          map[livekit.TrackID]*Track
 	isAllocateAllPending bool
 	rembTrackingSSRC     uint32
+	speakerLevels        map[livekit.ParticipantID]float64
 
 	state streamAllocatorState
 
@@ -223,6 +229,7 @@ func NewStreamAllocator(params StreamAllocatorParams, enabled bool, allowPause b
 		params:               params,
 		enabled:              enabled,
 		allowPause:           allowPause,
+		policy:               defaultAllocationPolicy{},
 		videoTracks:          make(map[livekit.TrackID]*Track),
 		state:                streamAllocatorStateStable,
 		activeProbeClusterId: ccutils.ProbeClusterIdInvalid,
@@ -289,6 +296,7 @@ func (s *StreamAllocator) AddTrack(downTrack *sfu.DownTrack, params AddTrackPara
 
 	trackID := livekit.TrackID(downTrack.ID())
 	s.videoTracksMu.Lock()
+	track.SetSpeakerLevel(s.speakerLevels[params.PublisherID])
 	oldTrack := s.videoTracks[trackID]
 	s.videoTracks[trackID] = track
 	s.videoTracksMu.Unlock()
@@ -331,6 +339,40 @@ func (s *StreamAllocator) SetTrackPriority(downTrack *sfu.DownTrack, priority ui
 	s.videoTracksMu.Unlock()
 }
 
+// SetActiveSpeakers updates the audio levels of the publishers of tracks, used by allocation policies favouring active speakers
+func (s *StreamAllocator) SetActiveSpeakers(speakers []*livekit.SpeakerInfo) {
+	speakerLevels := make(map[livekit.ParticipantID]float64, len(speakers))
+	for _, speaker := range speakers {
+		if speaker.Active {
+			speakerLevels[livekit.ParticipantID(speaker.Sid)] = float64(speaker.Level)
+		}
+	}
+
+	s.videoTracksMu.Lock()
+	s.speakerLevels = speakerLevels
+	changed := false
+	for _, track := range s.videoTracks {
+		if track.SetSpeakerLevel(speakerLevels[track.PublisherID()]) {
+			changed = true
+		}
+	}
+	if changed && !s.isAllocateAllPending {
+		s.isAllocateAllPending = true
+		s.postEvent(Event{
+			Signal: streamAllocatorSignalAllocateAllTracks,
+		})
+	}
+	s.videoTracksMu.Unlock()
+}
+
+// SetAllocationPolicy changes how layers are allocated to tracks when the channel capacity is constrained
+func (s *StreamAllocator) SetAllocationPolicy(policy AllocationPolicy) {
+	s.postEvent(Event{
+		Signal: streamAllocatorSignalSetAllocationPolicy,
+		Data:   policy,
+	})
+}
+
 func (s *StreamAllocator) SetAllowPause(allowPause bool) {
 	s.postEvent(Event{
 		Signal: streamAllocatorSignalSetAllowPause,
@@ -631,6 +673,8 @@ func (s *StreamAllocator) postEvent(event Event) {
 			event.handleSignalSetChannelCapacity(event)
 		case streamAllocatorSignalCongestionStateChange:
 			s.handleSignalCongestionStateChange(event)
+		case streamAllocatorSignalSetAllocationPolicy:
+			s.handleSignalSetAllocationPolicy(event)
 		}
 	}, event)
 }
@@ -807,6 +851,15 @@ func (s *StreamAllocator) handleSignalSetAllowPause(event Event) {
 	s.allowPause = event.Data.(bool)
 }
 
+func (s *StreamAllocator) handleSignalSetAllocationPolicy(event Event) {
+	s.policy = event.Data.(AllocationPolicy)
+	s.params.Logger.Infow("stream allocator: setting allocation policy", "policy", s.policy.Name())
+
+	if s.state == streamAllocatorStateDeficient {
+		s.allocateAllTracks()
+	}
+}
+
 func (s *StreamAllocator) handleSignalSetChannelCapacity(event Event) {
 	s.overriddenChannelCapacity = event.Data.(int64)
 	if s.overriddenChannelCapacity > 0 {
@@ -1114,16 +1167,7 @@ func (s *StreamAllocator) allocateAllTracks() {
 	}
 
 	//
-	// Goals:
-	//   1. Stream as many tracks as possible, i.e. no pauses.
-	//   2. Try to give fair allocation to all track.
-	//
-	// Start with the lowest layer and give each track a chance at that layer and keep going up.
-	// As long as there is enough bandwidth for tracks to stream at the lowest layer, the first goal is achieved.
-	//
-	// Tracks that have higher subscribed layer can use any additional available bandwidth. This tried to achieve the second goal.
-	//
-	// If there is not enough bandwidth even for the lowest layer, tracks at lower priorities will be paused.
+	// Exempt tracks are allocated first, the allocation policy decides how managed tracks share what is left.
 	//
 	update := NewStreamStateUpdate()
 
@@ -1162,29 +1206,16 @@ func (s *StreamAllocator) allocateAllTracks() {
 			updateStreamStateChange(track, allocation, update)
 		}
 	} else {
-		sorted := s.getSorted()
-		for _, track := range sorted {
+		managedTracks := s.getManagedTracks()
+		allocationTracks := make([]AllocationTrack, 0, len(managedTracks))
+		for _, track := range managedTracks {
 			track.ProvisionalAllocatePrepare()
+			allocationTracks = append(allocationTracks, track)
 		}
 
-		for spatial := int32(0); spatial <= buffer.DefaultMaxLayerSpatial; spatial++ {
-			for temporal := int32(0); temporal <= buffer.DefaultMaxLayerTemporal; temporal++ {
-				layer := buffer.VideoLayer{
-					Spatial:  spatial,
-					Temporal: temporal,
-				}
-
-				for _, track := range sorted {
-					_, usedChannelCapacity := track.ProvisionalAllocate(availableChannelCapacity, layer, s.allowPause, FlagAllowOvershootWhileDeficient)
-					availableChannelCapacity -= usedChannelCapacity
-					if availableChannelCapacity < 0 {
-						availableChannelCapacity = 0
-					}
-				}
-			}
-		}
+		s.policy.Allocate(allocationTracks, availableChannelCapacity, s.allowPause)
 
-		for _, track := range sorted {
+		for _, track := range managedTracks {
 			allocation := track.ProvisionalAllocateCommit()
 			updateStreamStateChange(track, allocation, update)
 		}
@@ -1362,55 +1393,67 @@ func (s *StreamAllocator) getTracks() []*Track {
 	return tracks
 }
 
-func (s *StreamAllocator) getSorted() TrackSorter {
+func (s *StreamAllocator) getManagedTracks() []*Track {
 	s.videoTracksMu.RLock()
-	var trackSorter TrackSorter
+	var tracks []*Track
 	for _, track := range s.videoTracks {
 		if !track.IsManaged() {
 			continue
 		}
 
-		trackSorter = append(trackSorter, track)
+		tracks = append(tracks, track)
 	}
 	s.videoTracksMu.RUnlock()
 
-	sort.Sort(trackSorter)
-
-	return trackSorter
+	return tracks
 }
 
-func (s *StreamAllocator) getMinDistanceSorted(exclude *Track) MinDistanceSorter {
+// getMinDistanceSorted is used to find excess bandwidth in cooperative allocation.
+// So, less important tracks come earlier so that they contribute bandwidth to more important tracks.
+func (s *StreamAllocator) getMinDistanceSorted(exclude *Track) []*Track {
 	s.videoTracksMu.RLock()
-	var minDistanceSorter MinDistanceSorter
+	var tracks []*Track
 	for _, track := range s.videoTracks {
 		if !track.IsManaged() || track == exclude {
 			continue
 		}
 
-		minDistanceSorter = append(minDistanceSorter, track)
+		tracks = append(tracks, track)
 	}
 	s.videoTracksMu.RUnlock()
 
-	sort.Sort(minDistanceSorter)
+	slices.SortFunc(tracks, func(a, b *Track) int {
+		if c := s.policy.Compare(b, a); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.DistanceToDesired(), b.DistanceToDesired())
+	})
 
-	return minDistanceSorter
+	return tracks
 }
 
-func (s *StreamAllocator) getMaxDistanceSortedDeficient() MaxDistanceSorter {
+// getMaxDistanceSortedDeficient is used to find a deficient track to use for probing during recovery from congestion.
+// So, more important tracks come earlier so that they have a chance to recover sooner.
+func (s *StreamAllocator) getMaxDistanceSortedDeficient() []*Track {
 	s.videoTracksMu.RLock()
-	var maxDistanceSorter MaxDistanceSorter
+	var tracks []*Track
 	for _, track := range s.videoTracks {
 		if !track.IsManaged() || !track.IsDeficient() {
 			continue
 		}
 
-		maxDistanceSorter = append(maxDistanceSorter, track)
+		tracks = append(tracks, track)
 	}
 	s.videoTracksMu.RUnlock()
 
-	sort.Sort(maxDistanceSorter)
+	slices.SortFunc(tracks, func(a, b *Track) int {
+		if c := s.policy.Compare(a, b); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.DistanceToDesired(), a.DistanceToDesired())
+	})
 
-	return maxDistanceSorter
+	return tracks
 }
 
 // ------------------------------------------------
@@ -32,6 +32,8 @@ type Track struct {
 
 	maxLayer buffer.VideoLayer
 
+	speakerLevel float64
+
 	totalPackets       uint32
 	totalRepeatedNacks uint32
 
@@ -105,6 +107,23 @@ func (t *Track) Priority() uint8 {
 	return t.priority
 }
 
+func (t *Track) SetSpeakerLevel(level float64) bool {
+	if t.speakerLevel == level {
+		return false
+	}
+
+	t.speakerLevel = level
+	return true
+}
+
+func (t *Track) SpeakerLevel() float64 {
+	return t.speakerLevel
+}
+
+func (t *Track) Source() livekit.TrackSource {
+	return t.source
+}
+
 func (t *Track) DownTrack() *sfu.DownTrack {
 	return t.downTrack
 }
@@ -130,6 +149,10 @@ func (t *Track) SetMaxLayer(layer buffer.VideoLayer) bool {
 	return true
 }
 
+func (t *Track) MaxLayer() buffer.VideoLayer {
+	return t.maxLayer
+}
+
 func (t *Track) WritePaddingRTP(bytesToSend int) int {
 	return t.downTrack.WritePaddingRTP(bytesToSend, false, false)
 }
@@ -201,81 +224,3 @@ func (t *Track) GetNackDelta() (uint32, uint32) {
 
 	return packetDelta, nackDelta
 }
-
-// ------------------------------------------------
-
-type TrackSorter []*Track
-
-func (t TrackSorter) Len() int {
-	return len(t)
-}
-
-func (t TrackSorter) Swap(i, j int) {
-	t[i], t[j] = t[j], t[i]
-}
-
-func (t TrackSorter) Less(i, j int) bool {
-	//
-	// TrackSorter is used to allocate layer-by-layer.
-	// So, higher priority track should come earlier so that it gets an earlier shot at each layer
-	//
-	if t[i].priority != t[j].priority {
-		return t[i].priority > t[j].priority
-	}
-
-	if t[i].maxLayer.Spatial != t[j].maxLayer.Spatial {
-		return t[i].maxLayer.Spatial > t[j].maxLayer.Spatial
-	}
-
-	return t[i].maxLayer.Temporal > t[j].maxLayer.Temporal
-}
-
-// ------------------------------------------------
-
-type MaxDistanceSorter []*Track
-
-func (m MaxDistanceSorter) Len() int {
-	return len(m)
-}
-
-func (m MaxDistanceSorter) Swap(i, j int) {
-	m[i], m[j] = m[j], m[i]
-}
-
-func (m MaxDistanceSorter) Less(i, j int) bool {
-	//
-	// MaxDistanceSorter is used to find a deficient track to use for probing during recovery from congestion.
-	// So, higher priority track should come earlier so that they have a chance to recover sooner.
-	//
-	if m[i].priority != m[j].priority {
-		return m[i].priority > m[j].priority
-	}
-
-	return m[i].DistanceToDesired() > m[j].DistanceToDesired()
-}
-
-// ------------------------------------------------
-
-type MinDistanceSorter []*Track
-
-func (m MinDistanceSorter) Len() int {
-	return len(m)
-}
-
-func (m MinDistanceSorter) Swap(i, j int) {
-	m[i], m[j] = m[j], m[i]
-}
-
-func (m MinDistanceSorter) Less(i, j int) bool {
-	//
-	// MinDistanceSorter is used to find excess bandwidth in cooperative allocation.
-	// So, lower priority track should come earlier so that they contribute bandwidth to higher priority tracks.
-	//
-	if m[i].priority != m[j].priority {
-		return m[i].priority < m[j].priority
-	}
-
-	return m[i].DistanceToDesired() < m[j].DistanceToDesired()
-}
-
-// ------------------------------------------------
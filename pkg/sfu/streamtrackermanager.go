@@ -621,6 +621,26 @@ func (s *StreamTrackerManager) GetMaxTemporalLayerSeen() int32 {
 	return s.maxTemporalLayerSeen
 }
 
+// ObserveSimulcastDependencyDescriptor takes the temporal layers of a simulcast layer from the active decode targets of
+// its dependency descriptor, e.g. for AV1 simulcast, so that they are known before their bitrate is reported.
+func (s *StreamTrackerManager) ObserveSimulcastDependencyDescriptor(ddVal *buffer.ExtDependencyDescriptor) {
+	if s.isSVC || ddVal == nil || !ddVal.ActiveDecodeTargetsUpdated {
+		return
+	}
+	mask := ddVal.Descriptor.ActiveDecodeTargetsBitmask
+	if mask == nil {
+		return
+	}
+
+	maxTemporalLayer := buffer.InvalidLayerTemporal
+	for _, dt := range ddVal.DecodeTargets {
+		if *mask&(1<<dt.Target) != 0 && dt.Layer.Temporal > maxTemporalLayer {
+			maxTemporalLayer = dt.Layer.Temporal
+		}
+	}
+	s.setMaxTemporalLayerSeen(min(maxTemporalLayer, buffer.DefaultMaxLayerTemporal))
+}
+
 func (s *StreamTrackerManager) updateMaxTemporalLayerSeen(brs Bitrates) {
 	maxTemporalLayerSeen := buffer.InvalidLayerTemporal
 done:
@@ -633,6 +653,10 @@ done:
 		}
 	}
 
+	s.setMaxTemporalLayerSeen(maxTemporalLayerSeen)
+}
+
+func (s *StreamTrackerManager) setMaxTemporalLayerSeen(maxTemporalLayerSeen int32) {
 	s.lock.Lock()
 	if maxTemporalLayerSeen <= s.maxTemporalLayerSeen {
 		s.lock.Unlock()
@@ -0,0 +1,66 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sfu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	dd "github.com/livekit/livekit-server/pkg/sfu/rtpextension/dependencydescriptor"
+)
+
+func TestStreamTrackerManagerSimulcastDependencyDescriptor(t *testing.T) {
+	newDD := func(mask uint32) *buffer.ExtDependencyDescriptor {
+		return &buffer.ExtDependencyDescriptor{
+			Descriptor: &dd.DependencyDescriptor{ActiveDecodeTargetsBitmask: &mask},
+			// L1T3
+			DecodeTargets: []buffer.DependencyDescriptorDecodeTarget{
+				{Target: 0, Layer: buffer.VideoLayer{Spatial: 0, Temporal: 2}},
+				{Target: 1, Layer: buffer.VideoLayer{Spatial: 0, Temporal: 1}},
+				{Target: 2, Layer: buffer.VideoLayer{Spatial: 0, Temporal: 0}},
+			},
+			ActiveDecodeTargetsUpdated: true,
+		}
+	}
+
+	t.Run("simulcast", func(t *testing.T) {
+		s := NewStreamTrackerManager(logger.GetLogger(), &livekit.TrackInfo{Type: livekit.TrackType_VIDEO}, false, 90000, DefaultStreamTrackerManagerConfig)
+		defer s.Close()
+		require.Equal(t, buffer.InvalidLayerTemporal, s.GetMaxTemporalLayerSeen())
+
+		s.ObserveSimulcastDependencyDescriptor(newDD(0b110))
+		require.Equal(t, int32(1), s.GetMaxTemporalLayerSeen())
+
+		s.ObserveSimulcastDependencyDescriptor(newDD(0b111))
+		require.Equal(t, int32(2), s.GetMaxTemporalLayerSeen())
+
+		// max temporal layer seen does not go down
+		s.ObserveSimulcastDependencyDescriptor(newDD(0b100))
+		require.Equal(t, int32(2), s.GetMaxTemporalLayerSeen())
+	})
+
+	t.Run("SVC uses dependency descriptor trackers", func(t *testing.T) {
+		s := NewStreamTrackerManager(logger.GetLogger(), &livekit.TrackInfo{Type: livekit.TrackType_VIDEO}, true, 90000, DefaultStreamTrackerManagerConfig)
+		defer s.Close()
+
+		s.ObserveSimulcastDependencyDescriptor(newDD(0b111))
+		require.Equal(t, buffer.InvalidLayerTemporal, s.GetMaxTemporalLayerSeen())
+	})
+}
@@ -98,6 +98,16 @@ var TestVP8Codec = webrtc.RTPCodecCapability{
 	ClockRate: 90000,
 }
 
+var TestH265Codec = webrtc.RTPCodecCapability{
+	MimeType:  "video/h265",
+	ClockRate: 90000,
+}
+
+var TestAV1Codec = webrtc.RTPCodecCapability{
+	MimeType:  "video/av1",
+	ClockRate: 90000,
+}
+
 var TestOpusCodec = webrtc.RTPCodecCapability{
 	MimeType:  "audio/opus",
 	ClockRate: 48000,
@@ -31,6 +31,8 @@ func toLowerSwitch(mimeType string) MimeType {
 		return MimeTypeH264
 	case "video/av1":
 		return MimeTypeAV1
+	case "video/h265":
+		return MimeTypeH265
 	default:
 		return MimeTypeUnknown
 	}
@@ -41,6 +43,8 @@ func TestMimeTypeMatch(t *testing.T) {
 	require.Equal(t, MimeTypeVP9, MatchMimeType("VIDEO/VP9"), "VIDEO/VP9")
 	require.Equal(t, MimeTypeH264, MatchMimeType("VIDEO/H264"), "VIDEO/H264")
 	require.Equal(t, MimeTypeAV1, MatchMimeType("VIDEO/AV1"), "VIDEO/AV1")
+	require.Equal(t, MimeTypeH265, MatchMimeType("VIDEO/H265"), "VIDEO/H265")
+	require.Equal(t, MimeTypeUnknown, MatchMimeType("VIDEO/H266"), "VIDEO/H266")
 }
 
 func BenchmarkMimeTypeMatch(b *testing.B) {
@@ -49,6 +53,7 @@ func BenchmarkMimeTypeMatch(b *testing.B) {
 		"video/VP9",
 		"video/H264",
 		"video/AV1",
+		"video/H265",
 	}
 
 	b.Run("ToLower/switch", func(b *testing.B) {
@@ -0,0 +1,95 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package videolayerselector
+
+import (
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	"github.com/livekit/protocol/logger"
+)
+
+// AV1Simulcast selects layers of AV1 simulcast, where each spatial layer is an independent stream
+// with its own dependency descriptor. Spatial layers are switched at key frames carrying a dependency
+// structure. Temporal layers of the forwarded stream are selected using its dependency descriptor,
+// which is rewritten so that the subscriber sees a single stream with contiguous frame numbers.
+type AV1Simulcast struct {
+	*Simulcast
+
+	dd         *DependencyDescriptor
+	previousDD *DependencyDescriptor
+}
+
+func NewAV1Simulcast(logger logger.Logger) *AV1Simulcast {
+	return &AV1Simulcast{
+		Simulcast: NewSimulcast(logger),
+	}
+}
+
+func NewAV1SimulcastFromNull(vls VideoLayerSelector) *AV1Simulcast {
+	return &AV1Simulcast{
+		Simulcast: NewSimulcastFromNull(vls),
+	}
+}
+
+func (a *AV1Simulcast) Select(extPkt *buffer.ExtPacket, layer int32) (result VideoLayerSelectorResult) {
+	ddwdt := extPkt.DependencyDescriptor
+	if ddwdt == nil {
+		// packet doesn't have dependency descriptor, forward layer as is
+		return a.Simulcast.Select(extPkt, layer)
+	}
+
+	// a new dependency structure is needed to decode the layer switched to
+	result = a.selectLayer(extPkt, layer, extPkt.KeyFrame && ddwdt.StructureUpdated)
+	if result.IsSwitching {
+		a.startLayer(ddwdt.ExtFrameNum)
+	}
+	if !result.IsSelected {
+		return
+	}
+	if a.dd == nil {
+		// current layer set without a switch point, wait for one
+		result.IsSelected = false
+		return
+	}
+
+	// the layer is a single spatial layer stream, select temporal layer within it
+	a.dd.SetTarget(buffer.VideoLayer{Spatial: 0, Temporal: a.targetLayer.Temporal})
+	ddResult := a.dd.Select(extPkt, 0)
+	if current := a.dd.GetCurrent(); current.IsValid() {
+		a.currentLayer.Temporal = current.Temporal
+	}
+
+	result.IsSelected = ddResult.IsSelected
+	result.IsRelevant = ddResult.IsRelevant
+	result.IsSwitching = result.IsSwitching || ddResult.IsSwitching
+	result.RTPMarker = ddResult.RTPMarker
+	result.DependencyDescriptorExtension = ddResult.DependencyDescriptorExtension
+	return
+}
+
+func (a *AV1Simulcast) Rollback() {
+	a.dd = a.previousDD
+
+	a.Simulcast.Rollback()
+}
+
+func (a *AV1Simulcast) startLayer(extFrameNum uint64) {
+	dd := NewDependencyDescriptor(a.logger)
+	if a.dd != nil {
+		dd.fnWrapper.ContinueFrom(&a.dd.fnWrapper, extFrameNum)
+	}
+
+	a.previousDD = a.dd
+	a.dd = dd
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package videolayerselector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/sfu/buffer"
+	dd "github.com/livekit/livekit-server/pkg/sfu/rtpextension/dependencydescriptor"
+	"github.com/livekit/protocol/logger"
+)
+
+func TestAV1Simulcast(t *testing.T) {
+	selector := NewAV1Simulcast(logger.GetLogger())
+	selector.SetMax(buffer.VideoLayer{Spatial: 2, Temporal: 2})
+	selector.SetMaxSeen(buffer.VideoLayer{Spatial: 2, Temporal: 2})
+	selector.SetTarget(buffer.VideoLayer{Spatial: 1, Temporal: 2})
+
+	// each simulcast layer is a single spatial layer stream with its own frame numbers
+	layerFrames := [][]*buffer.ExtPacket{
+		createDDFrames(buffer.VideoLayer{Spatial: 0, Temporal: 2}, 100),
+		createDDFrames(buffer.VideoLayer{Spatial: 0, Temporal: 2}, 30000),
+	}
+	// the chain protects the lowest temporal layer, refer to its previous frame
+	for _, frames := range layerFrames {
+		prevChainFrameNumber := frames[0].DependencyDescriptor.Descriptor.FrameNumber
+		for _, frame := range frames[1:] {
+			descriptor := frame.DependencyDescriptor.Descriptor
+			descriptor.FrameDependencies.ChainDiffs = []int{int(descriptor.FrameNumber - prevChainFrameNumber)}
+			if descriptor.FrameDependencies.TemporalId == 0 {
+				prevChainFrameNumber = descriptor.FrameNumber
+			}
+		}
+	}
+
+	frameNumber := func(ddBytes []byte, structure *dd.FrameDependencyStructure) uint16 {
+		var descriptor dd.DependencyDescriptor
+		ext := &dd.DependencyDescriptorExtension{
+			Descriptor: &descriptor,
+			Structure:  structure,
+		}
+		_, err := ext.Unmarshal(ddBytes)
+		require.NoError(t, err)
+		return descriptor.FrameNumber
+	}
+
+	// waits for a key frame to start
+	ret := selector.Select(layerFrames[1][1], 1)
+	require.False(t, ret.IsSelected)
+
+	// key frame without dependency structure cannot be switched to
+	keyFrame := *layerFrames[1][0]
+	ddNoStructure := *keyFrame.DependencyDescriptor
+	ddNoStructure.StructureUpdated = false
+	keyFrame.DependencyDescriptor = &ddNoStructure
+	ret = selector.Select(&keyFrame, 1)
+	require.False(t, ret.IsSelected)
+	require.False(t, selector.GetCurrent().IsValid())
+
+	ret = selector.Select(layerFrames[1][0], 1)
+	require.True(t, ret.IsSelected)
+	require.True(t, ret.IsSwitching)
+	require.True(t, ret.IsResuming)
+	require.Equal(t, int32(1), selector.GetCurrent().Spatial)
+	structure := layerFrames[1][0].DependencyDescriptor.Descriptor.AttachedStructure
+	require.Equal(t, uint16(30000), frameNumber(ret.DependencyDescriptorExtension, structure))
+
+	// forward all temporal layers of the current layer, drop other layers
+	lastFrameNumber := uint16(30000)
+	for i := 1; i < 10; i++ {
+		ret = selector.Select(layerFrames[0][i], 0)
+		require.False(t, ret.IsSelected)
+
+		ret = selector.Select(layerFrames[1][i], 1)
+		require.True(t, ret.IsSelected)
+		lastFrameNumber = frameNumber(ret.DependencyDescriptorExtension, structure)
+		require.Equal(t, layerFrames[1][i].DependencyDescriptor.Descriptor.FrameNumber, lastFrameNumber)
+	}
+	require.Equal(t, int32(2), selector.GetCurrent().Temporal)
+
+	// switch down to lowest temporal layer of layer 0, only at a key frame
+	selector.SetTarget(buffer.VideoLayer{Spatial: 0, Temporal: 0})
+	ret = selector.Select(layerFrames[0][10], 0)
+	require.False(t, ret.IsSelected)
+
+	ret = selector.Select(layerFrames[0][0], 0)
+	require.True(t, ret.IsSelected)
+	require.True(t, ret.IsSwitching)
+	require.False(t, ret.IsResuming)
+	require.Equal(t, buffer.VideoLayer{Spatial: 0, Temporal: 0}, selector.GetCurrent())
+
+	// frame numbers continue from the previous layer
+	structure = layerFrames[0][0].DependencyDescriptor.Descriptor.AttachedStructure
+	switchFrameNumber := frameNumber(ret.DependencyDescriptorExtension, structure)
+	require.Equal(t, lastFrameNumber+1, switchFrameNumber)
+
+	ret = selector.Select(layerFrames[1][10], 1)
+	require.False(t, ret.IsSelected)
+
+	var numSelected int
+	for i := 1; i < len(layerFrames[0]); i++ {
+		frame := layerFrames[0][i]
+		ret = selector.Select(frame, 0)
+		fd := frame.DependencyDescriptor.Descriptor.FrameDependencies
+		if fd.TemporalId != 0 {
+			require.False(t, ret.IsSelected)
+			require.True(t, ret.IsRelevant)
+			continue
+		}
+
+		require.True(t, ret.IsSelected)
+		numSelected++
+		// frame number differences are kept within a layer
+		require.Equal(
+			t,
+			frame.DependencyDescriptor.Descriptor.FrameNumber-layerFrames[0][0].DependencyDescriptor.Descriptor.FrameNumber,
+			frameNumber(ret.DependencyDescriptorExtension, structure)-switchFrameNumber,
+		)
+	}
+	require.Equal(t, 10, numSelected)
+
+	// failed switch is rolled back, forwarding continues on the previous layer
+	selector.SetTarget(buffer.VideoLayer{Spatial: 1, Temporal: 2})
+	previousLayer := selector.GetCurrent()
+	ret = selector.Select(layerFrames[1][0], 1)
+	require.True(t, ret.IsSwitching)
+	selector.Rollback()
+	require.Equal(t, previousLayer, selector.GetCurrent())
+	ret = selector.Select(layerFrames[0][len(layerFrames[0])-3], 0)
+	require.True(t, ret.IsSelected)
+}
@@ -52,3 +52,16 @@ func (f *FrameNumberWrapper) UpdateAndGet(new uint64, updateOffset bool) uint64
 	f.last = new
 	return new + f.offset
 }
+
+// ContinueFrom makes the frame numbers returned from the given frame number on follow the last frame number
+// returned by prev, so that independent streams forwarded one after another, like simulcast layers, have
+// contiguous frame numbers.
+func (f *FrameNumberWrapper) ContinueFrom(prev *FrameNumberWrapper, new uint64) {
+	if !prev.inited {
+		return
+	}
+
+	f.offset = prev.last + prev.offset + 1 - new
+	f.last = new
+	f.inited = true
+}
@@ -40,6 +40,11 @@ func (s *Simulcast) IsOvershootOkay() bool {
 }
 
 func (s *Simulcast) Select(extPkt *buffer.ExtPacket, layer int32) (result VideoLayerSelectorResult) {
+	return s.selectLayer(extPkt, layer, extPkt.KeyFrame)
+}
+
+// selectLayer switches layers only at packets which are switch points, i. e. start of a key frame
+func (s *Simulcast) selectLayer(extPkt *buffer.ExtPacket, layer int32, isSwitchPoint bool) (result VideoLayerSelectorResult) {
 	populateSwitches := func(isActive bool, reason string) {
 		result.IsSwitching = true
 
@@ -72,7 +77,7 @@ func (s *Simulcast) Select(extPkt *buffer.ExtPacket, layer int32) (result VideoL
 		isActive := s.currentLayer.IsValid()
 		found := false
 		reason := ""
-		if extPkt.KeyFrame {
+		if isSwitchPoint {
 			if layer > s.currentLayer.Spatial && layer <= s.targetLayer.Spatial {
 				reason = "upgrading layer"
 				found = true
@@ -103,7 +108,7 @@ func (s *Simulcast) Select(extPkt *buffer.ExtPacket, layer int32) (result VideoL
 	}
 
 	// if locked to higher than max layer due to overshoot, check if it can be dialed back
-	if s.currentLayer.Spatial > s.maxLayer.Spatial && layer <= s.maxLayer.Spatial && extPkt.KeyFrame {
+	if s.currentLayer.Spatial > s.maxLayer.Spatial && layer <= s.maxLayer.Spatial && isSwitchPoint {
 		s.previousLayer = s.currentLayer
 		s.currentLayer.Spatial = layer
 
@@ -27,6 +27,9 @@ import (
 	"github.com/livekit/protocol/webhook"
 )
 
+// EventRoomExpiring is sent ahead of a scheduled room closing at its expiry
+const EventRoomExpiring = "room_expiring"
+
 func (t *telemetryService) NotifyEvent(ctx context.Context, event *livekit.WebhookEvent) {
 	if t.notifier == nil {
 		return
@@ -71,6 +74,15 @@ func (t *telemetryService) RoomEnded(ctx context.Context, room *livekit.Room) {
 	})
 }
 
+func (t *telemetryService) RoomExpiring(ctx context.Context, room *livekit.Room) {
+	t.enqueue(func() {
+		t.NotifyEvent(ctx, &livekit.WebhookEvent{
+			Event: EventRoomExpiring,
+			Room:  room,
+		})
+	})
+}
+
 func (t *telemetryService) ParticipantJoined(
 	ctx context.Context,
 	room *livekit.Room,
@@ -0,0 +1,50 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	promReliableDataReplayCounter *prometheus.CounterVec
+)
+
+func initDataChannelStats(nodeID string, nodeType livekit.NodeType) {
+	promReliableDataReplayCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "datachannel",
+		Name:        "reliable_replay",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String()},
+	}, []string{"status"})
+
+	prometheus.MustRegister(promReliableDataReplayCounter)
+}
+
+// RecordReliableDataReplayed counts reliable data packets sent again to a resuming participant
+func RecordReliableDataReplayed(count int) {
+	if promReliableDataReplayCounter != nil {
+		promReliableDataReplayCounter.WithLabelValues("replayed").Add(float64(count))
+	}
+}
+
+// RecordReliableDataOverflowed counts reliable data packets a resuming participant missed that were no longer kept
+func RecordReliableDataOverflowed(count int) {
+	if promReliableDataReplayCounter != nil {
+		promReliableDataReplayCounter.WithLabelValues("overflowed").Add(float64(count))
+	}
+}
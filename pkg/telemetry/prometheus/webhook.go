@@ -0,0 +1,49 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+var (
+	promWebhookCounter *prometheus.CounterVec
+)
+
+func initWebhookStats(nodeID string, nodeType livekit.NodeType) {
+	promWebhookCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   livekitNamespace,
+		Subsystem:   "webhook",
+		Name:        "events",
+		ConstLabels: prometheus.Labels{"node_id": nodeID, "node_type": nodeType.String()},
+	}, []string{"event", "status"})
+
+	prometheus.MustRegister(promWebhookCounter)
+}
+
+func RecordWebhookDelivered(event string) {
+	promWebhookCounter.WithLabelValues(event, "delivered").Inc()
+}
+
+func RecordWebhookRetried(event string) {
+	promWebhookCounter.WithLabelValues(event, "retried").Inc()
+}
+
+// RecordWebhookDropped counts events that exhausted their retries and were dead-lettered
+func RecordWebhookDropped(event string) {
+	promWebhookCounter.WithLabelValues(event, "dropped").Inc()
+}
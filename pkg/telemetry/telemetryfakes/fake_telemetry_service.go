@@ -116,6 +116,12 @@ type FakeTelemetryService struct {
 		arg1 context.Context
 		arg2 *livekit.Room
 	}
+	RoomExpiringStub        func(context.Context, *livekit.Room)
+	roomExpiringMutex       sync.RWMutex
+	roomExpiringArgsForCall []struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+	}
 	RoomStartedStub        func(context.Context, *livekit.Room)
 	roomStartedMutex       sync.RWMutex
 	roomStartedArgsForCall []struct {
@@ -788,6 +794,39 @@ func (fake *FakeTelemetryService) RoomEndedArgsForCall(i int) (context.Context,
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeTelemetryService) RoomExpiring(arg1 context.Context, arg2 *livekit.Room) {
+	fake.roomExpiringMutex.Lock()
+	fake.roomExpiringArgsForCall = append(fake.roomExpiringArgsForCall, struct {
+		arg1 context.Context
+		arg2 *livekit.Room
+	}{arg1, arg2})
+	stub := fake.RoomExpiringStub
+	fake.recordInvocation("RoomExpiring", []interface{}{arg1, arg2})
+	fake.roomExpiringMutex.Unlock()
+	if stub != nil {
+		fake.RoomExpiringStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeTelemetryService) RoomExpiringCallCount() int {
+	fake.roomExpiringMutex.RLock()
+	defer fake.roomExpiringMutex.RUnlock()
+	return len(fake.roomExpiringArgsForCall)
+}
+
+func (fake *FakeTelemetryService) RoomExpiringCalls(stub func(context.Context, *livekit.Room)) {
+	fake.roomExpiringMutex.Lock()
+	defer fake.roomExpiringMutex.Unlock()
+	fake.RoomExpiringStub = stub
+}
+
+func (fake *FakeTelemetryService) RoomExpiringArgsForCall(i int) (context.Context, *livekit.Room) {
+	fake.roomExpiringMutex.RLock()
+	defer fake.roomExpiringMutex.RUnlock()
+	argsForCall := fake.roomExpiringArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
 func (fake *FakeTelemetryService) RoomStarted(arg1 context.Context, arg2 *livekit.Room) {
 	fake.roomStartedMutex.Lock()
 	fake.roomStartedArgsForCall = append(fake.roomStartedArgsForCall, struct {
@@ -1451,6 +1490,8 @@ func (fake *FakeTelemetryService) Invocations() map[string][][]interface{} {
 	defer fake.participantResumedMutex.RUnlock()
 	fake.roomEndedMutex.RLock()
 	defer fake.roomEndedMutex.RUnlock()
+	fake.roomExpiringMutex.RLock()
+	defer fake.roomExpiringMutex.RUnlock()
 	fake.roomStartedMutex.RLock()
 	defer fake.roomStartedMutex.RUnlock()
 	fake.sendEventMutex.RLock()
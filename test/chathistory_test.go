@@ -0,0 +1,112 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/service"
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+func TestChatHistory(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	const preset = "classroom"
+	s := createSingleNodeServer(func(c *config.Config) {
+		c.Room.RoomConfigurations = map[string]*livekit.RoomConfiguration{
+			preset: {},
+		}
+		c.Room.ChatHistories = map[string][]config.ChatHistoryConfig{
+			preset: {{Topic: rtc.ChatTopic, MaxMessages: 10}},
+		}
+	})
+	go func() {
+		if err := s.Start(); err != nil {
+			logger.Errorw("server returned error", err)
+		}
+	}()
+	waitForServerToStart(s)
+	defer s.Stop(true)
+
+	opts := &testclient.Options{
+		TokenCustomizer: func(token *auth.AccessToken, grants *auth.VideoGrant) {
+			token.SetRoomPreset(preset)
+		},
+	}
+	early := createRTCClient("chat_early", defaultServerPort, opts)
+	defer early.Stop()
+	waitUntilConnected(t, early)
+
+	for i := 1; i <= 2; i++ {
+		require.NoError(t, early.PublishDataPacket(&livekit.DataPacket{
+			Value: &livekit.DataPacket_ChatMessage{ChatMessage: &livekit.ChatMessage{
+				Id:        fmt.Sprintf("msg%d", i),
+				Timestamp: time.Now().UnixMilli(),
+				Message:   fmt.Sprintf("message %d", i),
+			}},
+		}, livekit.DataPacket_RELIABLE))
+	}
+
+	historyURL := fmt.Sprintf("http://localhost:%d/chat_history?room=%s", defaultServerPort, testRoom)
+	adminToken := adminRoomToken(testRoom)
+	var history service.ChatHistoryInfo
+	require.Eventually(t, func() bool {
+		res := whipRequest(t, http.MethodGet, historyURL, adminToken, "", "")
+		if res.StatusCode != http.StatusOK {
+			return false
+		}
+		history = service.ChatHistoryInfo{}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&history))
+		return len(history.Messages) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "message 1", history.Messages[0].Message)
+	require.Equal(t, "chat_early", history.Messages[0].ParticipantIdentity)
+	require.Equal(t, rtc.ChatTopic, history.Messages[0].Topic)
+
+	t.Run("requires room admin", func(t *testing.T) {
+		res := whipRequest(t, http.MethodGet, historyURL, joinToken(testRoom, "chat_early", nil), "", "")
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	// the late joiner is sent the messages it missed
+	late := createRTCClient("chat_late", defaultServerPort, opts)
+	defer late.Stop()
+	waitUntilConnected(t, late)
+	require.Eventually(t, func() bool {
+		var messages []string
+		for _, dp := range late.DataPacketsReceived() {
+			if chat := dp.GetChatMessage(); chat != nil {
+				messages = append(messages, chat.Message)
+			}
+		}
+		return len(messages) == 2 && messages[0] == "message 1" && messages[1] == "message 2"
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -22,6 +22,7 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -85,12 +86,15 @@ type RTCClient struct {
 	OnConnected         func()
 	OnDataReceived      func(data []byte, sid string)
 	refreshToken        string
+	dataPackets         []*livekit.DataPacket
 
 	// map of livekit.ParticipantID and last packet
 	lastPackets   map[livekit.ParticipantID]*rtp.Packet
 	bytesReceived map[livekit.ParticipantID]uint64
 
 	subscriptionResponse atomic.Pointer[livekit.SubscriptionResponse]
+	roomUpdate           atomic.Pointer[livekit.Room]
+	leaveRequest         atomic.Pointer[livekit.LeaveRequest]
 }
 
 var (
@@ -428,6 +432,10 @@ func (c *RTCClient) handleSignalResponse(res *livekit.SignalResponse) error {
 		c.pongReceivedAt.Store(msg.Pong)
 	case *livekit.SignalResponse_SubscriptionResponse:
 		c.subscriptionResponse.Store(msg.SubscriptionResponse)
+	case *livekit.SignalResponse_RoomUpdate:
+		c.roomUpdate.Store(msg.RoomUpdate.Room)
+	case *livekit.SignalResponse_Leave:
+		c.leaveRequest.Store(msg.Leave)
 	}
 	return nil
 }
@@ -539,6 +547,16 @@ func (c *RTCClient) GetSubscriptionResponseAndClear() *livekit.SubscriptionRespo
 	return c.subscriptionResponse.Swap(nil)
 }
 
+// LastRoomUpdate returns the room as last updated by the server
+func (c *RTCClient) LastRoomUpdate() *livekit.Room {
+	return c.roomUpdate.Load()
+}
+
+// LeaveRequest returns the leave request sent by the server, if any
+func (c *RTCClient) LeaveRequest() *livekit.LeaveRequest {
+	return c.leaveRequest.Load()
+}
+
 func (c *RTCClient) SendPing() error {
 	return c.SendRequest(&livekit.SignalRequest{
 		Message: &livekit.SignalRequest_Ping{
@@ -720,15 +738,19 @@ func (c *RTCClient) SendAddTrack(cid string, name string, trackType livekit.Trac
 }
 
 func (c *RTCClient) PublishData(data []byte, kind livekit.DataPacket_Kind) error {
+	return c.PublishDataPacket(&livekit.DataPacket{
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{Payload: data},
+		},
+	}, kind)
+}
+
+func (c *RTCClient) PublishDataPacket(dp *livekit.DataPacket, kind livekit.DataPacket_Kind) error {
 	if err := c.ensurePublisherConnected(); err != nil {
 		return err
 	}
 
-	dpData, err := proto.Marshal(&livekit.DataPacket{
-		Value: &livekit.DataPacket_User{
-			User: &livekit.UserPacket{Payload: data},
-		},
-	})
+	dpData, err := proto.Marshal(dp)
 	if err != nil {
 		return err
 	}
@@ -736,6 +758,13 @@ func (c *RTCClient) PublishData(data []byte, kind livekit.DataPacket_Kind) error
 	return c.publisher.SendDataPacket(kind, dpData)
 }
 
+// DataPacketsReceived returns the data packets received so far, in order
+func (c *RTCClient) DataPacketsReceived() []*livekit.DataPacket {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return slices.Clone(c.dataPackets)
+}
+
 func (c *RTCClient) GetPublishedTrackIDs() []string {
 	c.lock.Lock()
 	defer c.lock.Unlock()
@@ -781,6 +810,9 @@ func (c *RTCClient) handleDataMessage(kind livekit.DataPacket_Kind, data []byte)
 		return
 	}
 	dp.Kind = kind
+	c.lock.Lock()
+	c.dataPackets = append(c.dataPackets, dp)
+	c.lock.Unlock()
 	if val, ok := dp.Value.(*livekit.DataPacket_User); ok {
 		if c.OnDataReceived != nil {
 			c.OnDataReceived(val.User.Payload, val.User.ParticipantSid)
@@ -0,0 +1,137 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+func TestLobby(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	const preset = "waiting_room"
+	s := createSingleNodeServer(func(c *config.Config) {
+		c.Room.RoomConfigurations = map[string]*livekit.RoomConfiguration{
+			preset: {MaxParticipants: 2},
+		}
+		c.Room.Lobbies = map[string]config.LobbyConfig{
+			preset: {MaxSize: 5},
+		}
+	})
+	go func() {
+		if err := s.Start(); err != nil {
+			logger.Errorw("server returned error", err)
+		}
+	}()
+	waitForServerToStart(s)
+	defer s.Stop(true)
+
+	token := func(name string, admin bool) string {
+		return joinToken(testRoom, name, func(token *auth.AccessToken, grants *auth.VideoGrant) {
+			token.SetRoomPreset(preset)
+			grants.RoomAdmin = admin
+		})
+	}
+	opts := &testclient.Options{AutoSubscribe: true}
+	host := createRTCClientWithToken(token("lobby_host", true), defaultServerPort, opts)
+	defer host.Stop()
+	waitUntilConnected(t, host)
+
+	guest := createRTCClientWithToken(token("lobby_guest", false), defaultServerPort, opts)
+	defer guest.Stop()
+	waitUntilConnected(t, guest)
+
+	// the host is told about the guest waiting, the guest sees nobody
+	require.Eventually(t, func() bool {
+		pi := host.GetRemoteParticipant(guest.ID())
+		return pi != nil && pi.State == livekit.ParticipantInfo_JOINING && !pi.Permission.CanSubscribe
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Empty(t, guest.RemoteParticipants())
+
+	lobbyURL := fmt.Sprintf("http://localhost:%d/lobby", defaultServerPort)
+	adminToken := adminRoomToken(testRoom)
+	lobbyRequest := func(action, token, identity string) *http.Response {
+		body := fmt.Sprintf(`{"room":%q,"identity":%q}`, testRoom, identity)
+		return whipRequest(t, http.MethodPost, lobbyURL+"/"+action, token, "application/json", body)
+	}
+
+	t.Run("requires room admin", func(t *testing.T) {
+		res := whipRequest(t, http.MethodGet, lobbyURL+"?room="+testRoom, joinToken(testRoom, "lobby_guest", nil), "", "")
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		res = lobbyRequest("admit", adminRoomToken("other_room"), "lobby_guest")
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	res := whipRequest(t, http.MethodGet, lobbyURL+"?room="+testRoom, adminToken, "", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	listed := &livekit.ListParticipantsResponse{}
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, protojson.Unmarshal(body, listed))
+	require.Len(t, listed.Participants, 1)
+	require.Equal(t, "lobby_guest", listed.Participants[0].Identity)
+
+	res = lobbyRequest("admit", adminToken, "lobby_guest")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Eventually(t, func() bool {
+		pi := host.GetRemoteParticipant(guest.ID())
+		return pi != nil && pi.Permission.CanSubscribe && pi.State == livekit.ParticipantInfo_ACTIVE
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return guest.GetRemoteParticipant(host.ID()) != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	t.Run("only participants in the lobby are admitted", func(t *testing.T) {
+		res := lobbyRequest("admit", adminToken, "lobby_guest")
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("lobby is counted apart from max participants", func(t *testing.T) {
+		late := createRTCClientWithToken(token("lobby_late", false), defaultServerPort, opts)
+		defer late.Stop()
+		waitUntilConnected(t, late)
+		require.Eventually(t, func() bool {
+			return host.GetRemoteParticipant(late.ID()) != nil
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// the room is full
+		res := lobbyRequest("admit", adminToken, "lobby_late")
+		require.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+
+		res = lobbyRequest("reject", adminToken, "lobby_late")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Eventually(t, func() bool {
+			return host.GetRemoteParticipant(late.ID()) == nil
+		}, 5*time.Second, 10*time.Millisecond)
+		require.Nil(t, guest.GetRemoteParticipant(late.ID()))
+	})
+}
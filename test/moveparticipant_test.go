@@ -0,0 +1,181 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+func TestMoveParticipant(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	s := createSingleNodeServer(nil)
+	go func() {
+		if err := s.Start(); err != nil {
+			logger.Errorw("server returned error", err)
+		}
+	}()
+	waitForServerToStart(s)
+	defer s.Stop(true)
+
+	const destRoom = "move_dest"
+	opts := &testclient.Options{AutoSubscribe: true}
+	mover := createRTCClient("move_pub", defaultServerPort, opts)
+	defer mover.Stop()
+	stay := createRTCClient("move_stay", defaultServerPort, opts)
+	defer stay.Stop()
+	other := createRTCClientWithToken(joinToken(destRoom, "move_other", nil), defaultServerPort, opts)
+	defer other.Stop()
+	waitUntilConnected(t, mover, stay, other)
+
+	moverWriter, err := mover.AddStaticTrack("audio/opus", "audio", "webcam")
+	require.NoError(t, err)
+	defer moverWriter.Stop()
+	otherWriter, err := other.AddStaticTrack("audio/opus", "audio", "webcam")
+	require.NoError(t, err)
+	defer otherWriter.Stop()
+
+	require.Eventually(t, func() bool {
+		return len(stay.SubscribedTracks()[mover.ID()]) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	adminCtx := contextWithToken(joinTokenWithGrant("", &auth.VideoGrant{RoomAdmin: true, Room: testRoom, DestinationRoom: destRoom}))
+	move := func(ctx context.Context, dest string) error {
+		_, err := roomClient.MoveParticipant(ctx, &livekit.MoveParticipantRequest{
+			Room:            testRoom,
+			Identity:        "move_pub",
+			DestinationRoom: dest,
+		})
+		return err
+	}
+	requireCode := func(t *testing.T, code twirp.ErrorCode, err error) {
+		var twErr twirp.Error
+		require.True(t, errors.As(err, &twErr), "expected a twirp error, got %v", err)
+		require.Equal(t, code, twErr.Code())
+	}
+
+	t.Run("requires room admin", func(t *testing.T) {
+		err := move(contextWithToken(adminRoomToken(destRoom)), destRoom)
+		requireCode(t, twirp.Unauthenticated, err)
+	})
+
+	t.Run("requires the destination room", func(t *testing.T) {
+		err := move(contextWithToken(joinTokenWithGrant("", &auth.VideoGrant{RoomAdmin: true, Room: testRoom})), destRoom)
+		requireCode(t, twirp.Unauthenticated, err)
+		err = move(adminCtx, "move_elsewhere")
+		requireCode(t, twirp.Unauthenticated, err)
+	})
+
+	t.Run("destination must differ", func(t *testing.T) {
+		err := move(contextWithToken(joinTokenWithGrant("", &auth.VideoGrant{RoomAdmin: true, Room: testRoom, DestinationRoom: testRoom})), testRoom)
+		requireCode(t, twirp.InvalidArgument, err)
+	})
+
+	require.NoError(t, move(adminCtx, destRoom))
+	res, err := roomClient.GetParticipant(contextWithToken(adminRoomToken(destRoom)), &livekit.RoomParticipantIdentity{
+		Room:     destRoom,
+		Identity: "move_pub",
+	})
+	require.NoError(t, err)
+	require.Equal(t, string(mover.ID()), res.Sid)
+	require.Len(t, res.Tracks, 1)
+
+	// the room it left sees it go, the room it joined sees it with its track
+	require.Eventually(t, func() bool {
+		return stay.GetRemoteParticipant(mover.ID()) == nil && len(stay.SubscribedTracks()[mover.ID()]) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return other.GetRemoteParticipant(mover.ID()) != nil && len(other.SubscribedTracks()[mover.ID()]) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return mover.GetRemoteParticipant(stay.ID()) == nil && len(mover.SubscribedTracks()[other.ID()]) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	t.Run("participant is no longer in the source room", func(t *testing.T) {
+		requireCode(t, twirp.NotFound, move(adminCtx, destRoom))
+	})
+
+	t.Run("resumes in the destination room", func(t *testing.T) {
+		// the refreshed token names the room the participant was moved to
+		var refreshed string
+		require.Eventually(t, func() bool {
+			refreshed = mover.RefreshToken()
+			if refreshed == "" {
+				return false
+			}
+			verifier, err := auth.ParseAPIToken(refreshed)
+			require.NoError(t, err)
+			grants, err := verifier.Verify(testApiSecret)
+			require.NoError(t, err)
+			return grants.Video.Room == destRoom
+		}, 5*time.Second, 10*time.Millisecond)
+
+		header := make(http.Header)
+		testclient.SetAuthorizationToken(header, refreshed)
+		resumeURL := fmt.Sprintf("ws://localhost:%d/rtc?protocol=%d&reconnect=1&sid=%s", defaultServerPort, types.CurrentProtocol, mover.ID())
+		conn, _, err := websocket.DefaultDialer.Dial(resumeURL, header)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, payload, err := conn.ReadMessage()
+		require.NoError(t, err)
+		res := &livekit.SignalResponse{}
+		require.NoError(t, proto.Unmarshal(payload, res))
+		require.NotNil(t, res.GetReconnect(), "expected to resume, got %v", res)
+	})
+
+	t.Run("moves to another room", func(t *testing.T) {
+		const thirdRoom = "move_third"
+		ctx := contextWithToken(joinTokenWithGrant("", &auth.VideoGrant{RoomAdmin: true, Room: testRoom, DestinationRoom: thirdRoom}))
+		_, err := roomClient.MoveParticipant(ctx, &livekit.MoveParticipantRequest{
+			Room:            testRoom,
+			Identity:        "move_stay",
+			DestinationRoom: thirdRoom,
+		})
+		require.NoError(t, err)
+
+		res, err := roomClient.ListParticipants(contextWithToken(adminRoomToken(thirdRoom)), &livekit.ListParticipantsRequest{Room: thirdRoom})
+		require.NoError(t, err)
+		require.Len(t, res.Participants, 1)
+		require.Equal(t, string(stay.ID()), res.Participants[0].Sid)
+
+		_, err = roomClient.MoveParticipant(ctx, &livekit.MoveParticipantRequest{
+			Room:            testRoom,
+			Identity:        "move_stay",
+			DestinationRoom: thirdRoom,
+		})
+		requireCode(t, twirp.NotFound, err)
+	})
+}
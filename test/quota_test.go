@@ -0,0 +1,86 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchtv/twirp"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/testutils"
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+func TestAPIKeyQuotas(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	s := createSingleNodeServer(func(conf *config.Config) {
+		conf.Limit.MaxRoomsPerAPIKey = 1
+		conf.Limit.MaxParticipantsPerAPIKey = 1
+	})
+	go func() {
+		if err := s.Start(); err != nil {
+			logger.Errorw("server returned error", err)
+		}
+	}()
+	waitForServerToStart(s)
+	defer s.Stop(true)
+
+	t.Run("rooms", func(t *testing.T) {
+		ctx := contextWithToken(createRoomToken())
+		_, err := roomClient.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: testRoom})
+		require.NoError(t, err)
+
+		// creating the same room again does not count twice
+		_, err = roomClient.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: testRoom})
+		require.NoError(t, err)
+
+		_, err = roomClient.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: "another-room"})
+		var twErr twirp.Error
+		require.True(t, errors.As(err, &twErr))
+		require.Equal(t, twirp.ResourceExhausted, twErr.Code())
+	})
+
+	t.Run("participants", func(t *testing.T) {
+		c1 := createRTCClient("quota1", defaultServerPort, nil)
+		defer c1.Stop()
+		waitUntilConnected(t, c1)
+
+		_, err := testclient.NewWebSocketConn(fmt.Sprintf("ws://localhost:%d", defaultServerPort), joinToken(testRoom, "quota2", nil), nil)
+		require.Error(t, err)
+
+		// the quota is released when the participant leaves
+		c1.Stop()
+		testutils.WithTimeout(t, func() string {
+			ws, err := testclient.NewWebSocketConn(fmt.Sprintf("ws://localhost:%d", defaultServerPort), joinToken(testRoom, "quota2", nil), nil)
+			if err != nil {
+				return err.Error()
+			}
+			_ = ws.Close()
+			return ""
+		}, 5*time.Second)
+	})
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/webhook"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/testutils"
+)
+
+func TestReloadConfig(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	ts := newTestServer(":7891")
+	require.NoError(t, ts.Start())
+	defer ts.Stop()
+
+	s := createSingleNodeServer(nil)
+	go func() {
+		if err := s.Start(); err != nil {
+			logger.Errorw("server returned error", err)
+		}
+	}()
+	waitForServerToStart(s)
+	defer s.Stop(true)
+
+	const (
+		rotatedKey    = "rotatedkey"
+		rotatedSecret = "rotatedSecretExtendTo32BytesAsThatIsMinimum"
+	)
+	ts.provider = auth.NewFileBasedKeyProviderFromMap(map[string]string{rotatedKey: rotatedSecret})
+	createToken := func(key, secret string) string {
+		token, err := auth.NewAccessToken(key, secret).
+			AddGrant(&auth.VideoGrant{RoomCreate: true}).
+			ToJWT()
+		require.NoError(t, err)
+		return token
+	}
+	createRoom := func(token string) (*livekit.Room, error) {
+		return roomClient.CreateRoom(contextWithToken(token), &livekit.CreateRoomRequest{
+			Name:       "reloaded",
+			RoomPreset: "small",
+		})
+	}
+
+	newConfig := func(webhookEvents ...string) *config.Config {
+		conf, err := config.NewConfig(`
+keys:
+  rotatedkey: rotatedSecretExtendTo32BytesAsThatIsMinimum
+room:
+  room_configurations:
+    small:
+      max_participants: 2
+`, true, nil, nil)
+		require.NoError(t, err)
+		if len(webhookEvents) != 0 {
+			conf.WebHook.APIKey = rotatedKey
+			conf.WebHook.Endpoints = []config.WebHookEndpointConfig{{URL: "http://localhost:7891", Events: webhookEvents}}
+		}
+		return conf
+	}
+
+	_, err := createRoom(createToken(testApiKey, testApiSecret))
+	require.Error(t, err, "room configuration should not exist yet")
+
+	t.Run("invalid config is rejected", func(t *testing.T) {
+		require.Error(t, s.Reload(newConfig("not_an_event")))
+
+		// nothing was applied, including the valid parts
+		_, err := createRoom(createToken(rotatedKey, rotatedSecret))
+		require.Error(t, err)
+		_, err = createRoom(createToken(testApiKey, testApiSecret))
+		require.Error(t, err)
+	})
+
+	require.NoError(t, s.Reload(newConfig(webhook.EventRoomStarted)))
+
+	_, err = createRoom(createToken(testApiKey, testApiSecret))
+	require.Error(t, err, "old key should be rejected")
+
+	room, err := createRoom(createToken(rotatedKey, rotatedSecret))
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), room.MaxParticipants)
+
+	// webhooks were enabled by the reload, and are signed with the new key
+	testutils.WithTimeout(t, func() string {
+		if ts.GetEvent(webhook.EventRoomStarted) == nil {
+			return "did not receive RoomStarted"
+		}
+		return ""
+	})
+}
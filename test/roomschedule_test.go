@@ -0,0 +1,174 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchtv/twirp"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc"
+	"github.com/livekit/livekit-server/pkg/service"
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+func TestRoomSchedule(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	s := createSingleNodeServer(func(c *config.Config) {
+		c.Room.ExpiryWarnings = []time.Duration{time.Minute}
+	})
+	go func() {
+		if err := s.Start(); err != nil {
+			logger.Errorw("server returned error", err)
+		}
+	}()
+	waitForServerToStart(s)
+	defer s.Stop(true)
+
+	scheduleURL := fmt.Sprintf("http://localhost:%d/room_schedule", defaultServerPort)
+	validateURL := fmt.Sprintf("http://localhost:%d/rtc/validate?room=%s", defaultServerPort, testRoom)
+	adminToken := adminRoomToken(testRoom)
+	putSchedule := func(schedule *service.RoomSchedule) *http.Response {
+		body, err := json.Marshal(schedule)
+		require.NoError(t, err)
+		return whipRequest(t, http.MethodPut, scheduleURL, adminToken, "application/json", string(body))
+	}
+	validate := func() (int, string) {
+		res := whipRequest(t, http.MethodGet, validateURL, joinToken(testRoom, "scheduled", nil), "", "")
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, string(body)
+	}
+
+	t.Run("requires room admin", func(t *testing.T) {
+		res := whipRequest(t, http.MethodGet, scheduleURL+"?room="+testRoom, joinToken(testRoom, "scheduled", nil), "", "")
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("rejects invalid schedules", func(t *testing.T) {
+		res := putSchedule(&service.RoomSchedule{Room: testRoom, NotBefore: 200, ExpiresAt: 100})
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("rejects oversized bodies", func(t *testing.T) {
+		body := fmt.Sprintf(`{"room":%q,"metadata":%q}`, testRoom, strings.Repeat("a", 32*1024))
+		res := whipRequest(t, http.MethodPut, scheduleURL, adminToken, "application/json", body)
+		require.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+	})
+
+	// joins are rejected before the room opens
+	res := putSchedule(&service.RoomSchedule{Room: testRoom, NotBefore: time.Now().Add(time.Hour).Unix()})
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	code, body := validate()
+	require.Equal(t, http.StatusForbidden, code)
+	require.Contains(t, body, "room is not open yet")
+
+	t.Run("rejects WHIP before the room opens", func(t *testing.T) {
+		whipURL := fmt.Sprintf("http://localhost:%d/whip/%s", defaultServerPort, testRoom)
+		res := whipRequest(t, http.MethodPost, whipURL, joinToken(testRoom, "scheduled_whip", nil), "application/sdp", "v=0\r\n")
+		require.Equal(t, http.StatusPreconditionFailed, res.StatusCode)
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Contains(t, string(body), "room is not open yet")
+	})
+
+	res = whipRequest(t, http.MethodDelete, scheduleURL+"?room="+testRoom, adminToken, "", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	res = whipRequest(t, http.MethodGet, scheduleURL+"?room="+testRoom, adminToken, "", "")
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+	code, _ = validate()
+	require.Equal(t, http.StatusOK, code)
+
+	c := createRTCClient("scheduled", defaultServerPort, nil)
+	defer c.Stop()
+	waitUntilConnected(t, c)
+
+	t.Run("rejects moves into a room not open", func(t *testing.T) {
+		const destRoom = "scheduled_dest"
+		destToken := adminRoomToken(destRoom)
+		body, err := json.Marshal(&service.RoomSchedule{Room: destRoom, NotBefore: time.Now().Add(time.Hour).Unix()})
+		require.NoError(t, err)
+		res := whipRequest(t, http.MethodPut, scheduleURL, destToken, "application/json", string(body))
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		defer whipRequest(t, http.MethodDelete, scheduleURL+"?room="+destRoom, destToken, "", "")
+
+		moveCtx := contextWithToken(joinTokenWithGrant("", &auth.VideoGrant{RoomAdmin: true, Room: testRoom, DestinationRoom: destRoom}))
+		_, err = roomClient.MoveParticipant(moveCtx, &livekit.MoveParticipantRequest{
+			Room:            testRoom,
+			Identity:        "scheduled",
+			DestinationRoom: destRoom,
+		})
+		var twErr twirp.Error
+		require.True(t, errors.As(err, &twErr))
+		require.Equal(t, twirp.FailedPrecondition, twErr.Code())
+	})
+
+	// an open room is warned and closed at its expiry
+	expiresAt := time.Now().Add(2 * time.Second).Unix()
+	res = putSchedule(&service.RoomSchedule{Room: testRoom, ExpiresAt: expiresAt})
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Eventually(t, func() bool {
+		return lastRoomExpiry(c) == expiresAt
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// participants joining are told the room expires
+	c2 := createRTCClient("scheduled2", defaultServerPort, nil)
+	defer c2.Stop()
+	waitUntilConnected(t, c2)
+	require.Eventually(t, func() bool {
+		return lastRoomExpiry(c2) == expiresAt
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		leave := c.LeaveRequest()
+		return leave != nil && leave.Reason == livekit.DisconnectReason_ROOM_CLOSED
+	}, 5*time.Second, 10*time.Millisecond)
+
+	code, body = validate()
+	require.Equal(t, http.StatusForbidden, code)
+	require.Contains(t, body, "room has expired")
+}
+
+// lastRoomExpiry returns the last expiry a client was sent on rtc.RoomExpiringTopic
+func lastRoomExpiry(c *testclient.RTCClient) int64 {
+	var expiresAt int64
+	for _, dp := range c.DataPacketsReceived() {
+		if dp.GetUser().GetTopic() != rtc.RoomExpiringTopic {
+			continue
+		}
+		var e rtc.RoomExpiring
+		if err := json.Unmarshal(dp.GetUser().Payload, &e); err == nil {
+			expiresAt = e.ExpiresAt
+		}
+	}
+	return expiresAt
+}
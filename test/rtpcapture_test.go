@@ -0,0 +1,122 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+func TestRTPCapture(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	dir := t.TempDir()
+	s := createSingleNodeServer(func(c *config.Config) {
+		c.RTPCapture.Dir = dir
+	})
+	go func() {
+		if err := s.Start(); err != nil {
+			logger.Errorw("server returned error", err)
+		}
+	}()
+	waitForServerToStart(s)
+	defer s.Stop(true)
+
+	pub := createRTCClient("capture_pub", defaultServerPort, nil)
+	waitUntilConnected(t, pub)
+	defer pub.Stop()
+	sub := createRTCClient("capture_sub", defaultServerPort, &testclient.Options{AutoSubscribe: true})
+	waitUntilConnected(t, sub)
+	defer sub.Stop()
+
+	writer, err := pub.AddStaticTrack("audio/opus", "audio", "webcam")
+	require.NoError(t, err)
+	defer writer.Stop()
+
+	require.Eventually(t, func() bool {
+		return len(sub.SubscribedTracks()[pub.ID()]) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	trackID := sub.SubscribedTracks()[pub.ID()][0].ID()
+
+	captureURL := fmt.Sprintf("http://localhost:%d/rtp_capture", defaultServerPort)
+	adminToken := adminRoomToken(testRoom)
+	startCapture := func(token, subscriber string) *http.Response {
+		body := fmt.Sprintf(`{"room":%q,"track_sid":%q,"subscriber_identity":%q}`, testRoom, trackID, subscriber)
+		return whipRequest(t, http.MethodPost, captureURL, token, "application/json", body)
+	}
+
+	t.Run("requires room admin", func(t *testing.T) {
+		res := startCapture(joinToken(testRoom, "capture_pub", nil), "")
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	var captures []service.RTPCaptureInfo
+	for _, subscriber := range []string{"", "capture_sub"} {
+		res := startCapture(adminToken, subscriber)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		var info service.RTPCaptureInfo
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&info))
+		require.True(t, info.Active)
+		captures = append(captures, info)
+	}
+	require.Equal(t, "ingress", captures[0].Direction)
+	require.Equal(t, "egress", captures[1].Direction)
+
+	t.Run("one capture per track and direction", func(t *testing.T) {
+		res := startCapture(adminToken, "")
+		require.Equal(t, http.StatusConflict, res.StatusCode)
+	})
+
+	res := whipRequest(t, http.MethodGet, captureURL+"?room="+testRoom, adminToken, "", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var listed []service.RTPCaptureInfo
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&listed))
+	require.Len(t, listed, 2)
+
+	time.Sleep(time.Second)
+
+	for _, c := range captures {
+		res := whipRequest(t, http.MethodDelete, captureURL+"/"+c.CaptureID, adminToken, "", "")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		var info service.RTPCaptureInfo
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&info))
+		require.False(t, info.Active)
+		require.Greater(t, info.Packets, int64(10))
+
+		data, err := os.ReadFile(info.File)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(string(data), "#!rtpplay1.0 "))
+		require.EqualValues(t, info.Bytes, len(data))
+	}
+
+	res = whipRequest(t, http.MethodDelete, captureURL+"/RC_unknown", adminToken, "", "")
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}
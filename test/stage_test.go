@@ -0,0 +1,122 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+func TestStage(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	const preset = "townhall"
+	s := createSingleNodeServer(func(c *config.Config) {
+		c.Room.RoomConfigurations = map[string]*livekit.RoomConfiguration{
+			preset: {},
+		}
+		c.Room.Stages = map[string]config.StageConfig{
+			preset: {MaxPublishers: 1},
+		}
+	})
+	go func() {
+		if err := s.Start(); err != nil {
+			logger.Errorw("server returned error", err)
+		}
+	}()
+	waitForServerToStart(s)
+	defer s.Stop(true)
+
+	opts := &testclient.Options{
+		AutoSubscribe: true,
+		TokenCustomizer: func(token *auth.AccessToken, grants *auth.VideoGrant) {
+			token.SetRoomPreset(preset)
+		},
+	}
+	first := createRTCClient("stage_first", defaultServerPort, opts)
+	defer first.Stop()
+	second := createRTCClient("stage_second", defaultServerPort, opts)
+	defer second.Stop()
+	waitUntilConnected(t, first, second)
+
+	// the only slot goes to the first to publish
+	writer, err := first.AddStaticTrack("audio/opus", "audio", "webcam")
+	require.NoError(t, err)
+	defer writer.Stop()
+	_, err = second.AddStaticTrack("audio/opus", "audio", "webcam")
+	require.Error(t, err)
+
+	stageURL := fmt.Sprintf("http://localhost:%d/stage", defaultServerPort)
+	adminToken := adminRoomToken(testRoom)
+	stageRequest := func(action, identity string) *http.Response {
+		body := fmt.Sprintf(`{"room":%q,"identity":%q}`, testRoom, identity)
+		return whipRequest(t, http.MethodPost, stageURL+"/"+action, adminToken, "application/json", body)
+	}
+	getStage := func() *service.StageInfo {
+		res := whipRequest(t, http.MethodGet, stageURL+"?room="+testRoom, adminToken, "", "")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		info := &service.StageInfo{}
+		require.NoError(t, json.NewDecoder(res.Body).Decode(info))
+		return info
+	}
+
+	info := getStage()
+	require.Equal(t, uint32(1), info.MaxPublishers)
+	require.Equal(t, []string{"stage_first"}, info.OnStage)
+
+	t.Run("requires room admin", func(t *testing.T) {
+		res := whipRequest(t, http.MethodGet, stageURL+"?room="+testRoom, adminRoomToken("other_room"), "", "")
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	res := stageRequest("promote", "stage_second")
+	require.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	res = stageRequest("demote", "stage_second")
+	require.Equal(t, http.StatusPreconditionFailed, res.StatusCode)
+
+	// demoting unpublishes the tracks of the first, and frees the slot for the second
+	require.Eventually(t, func() bool {
+		return len(second.SubscribedTracks()[first.ID()]) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	res = stageRequest("demote", "stage_first")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Eventually(t, func() bool {
+		pi := second.GetRemoteParticipant(first.ID())
+		return pi != nil && len(pi.Tracks) == 0 && !pi.Permission.CanPublish
+	}, 5*time.Second, 10*time.Millisecond)
+
+	res = stageRequest("promote", "stage_second")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	writer2, err := second.AddStaticTrack("audio/opus", "audio2", "webcam")
+	require.NoError(t, err)
+	defer writer2.Stop()
+	require.Equal(t, []string{"stage_second"}, getStage().OnStage)
+}
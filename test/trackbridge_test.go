@@ -0,0 +1,153 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+
+	"github.com/livekit/livekit-server/pkg/service"
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+func TestTrackBridge(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	s := createSingleNodeServer(nil)
+	go func() {
+		if err := s.Start(); err != nil {
+			logger.Errorw("server returned error", err)
+		}
+	}()
+	waitForServerToStart(s)
+	defer s.Stop(true)
+
+	const overflowRoom = "bridge_overflow"
+	opts := &testclient.Options{AutoSubscribe: true}
+	pub := createRTCClient("bridge_pub", defaultServerPort, opts)
+	defer pub.Stop()
+	allowed := createRTCClientWithToken(joinToken(overflowRoom, "bridge_allowed", nil), defaultServerPort, opts)
+	defer allowed.Stop()
+	denied := createRTCClientWithToken(joinToken(overflowRoom, "bridge_denied", nil), defaultServerPort, opts)
+	defer denied.Stop()
+	waitUntilConnected(t, pub, allowed, denied)
+
+	// only one of the participants in the overflow room may subscribe
+	require.NoError(t, pub.SendRequest(&livekit.SignalRequest{
+		Message: &livekit.SignalRequest_SubscriptionPermission{
+			SubscriptionPermission: &livekit.SubscriptionPermission{
+				TrackPermissions: []*livekit.TrackPermission{
+					{ParticipantIdentity: "bridge_allowed", AllTracks: true},
+				},
+			},
+		},
+	}))
+
+	writer, err := pub.AddStaticTrack("audio/opus", "audio", "webcam")
+	require.NoError(t, err)
+	defer writer.Stop()
+	require.Eventually(t, func() bool {
+		return len(pub.GetPublishedTrackIDs()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	trackID := pub.GetPublishedTrackIDs()[0]
+
+	bridgeURL := fmt.Sprintf("http://localhost:%d/track_bridges", defaultServerPort)
+	adminToken := joinTokenWithGrant("", &auth.VideoGrant{RoomAdmin: true, RoomCreate: true, Room: testRoom, DestinationRoom: overflowRoom})
+	createBridge := func(token string) *http.Response {
+		body := fmt.Sprintf(`{"room":%q,"track_sid":%q,"destination_room":%q}`, testRoom, trackID, overflowRoom)
+		return whipRequest(t, http.MethodPost, bridgeURL, token, "application/json", body)
+	}
+
+	t.Run("requires room admin", func(t *testing.T) {
+		res := createBridge(adminRoomToken(overflowRoom))
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("requires the destination room", func(t *testing.T) {
+		res := createBridge(joinTokenWithGrant("", &auth.VideoGrant{RoomAdmin: true, RoomCreate: true, Room: testRoom}))
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	// the track is bridged once the server receives its media
+	var res *http.Response
+	require.Eventually(t, func() bool {
+		res = createBridge(adminToken)
+		return res.StatusCode != http.StatusNotFound
+	}, 5*time.Second, 50*time.Millisecond)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	var info service.TrackBridgeInfo
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&info))
+	require.Equal(t, trackID, info.TrackSid)
+	require.Equal(t, "bridge_pub", info.ParticipantIdentity)
+
+	t.Run("one bridge per track and room", func(t *testing.T) {
+		res := createBridge(adminToken)
+		require.Equal(t, http.StatusConflict, res.StatusCode)
+	})
+
+	// the publisher appears in the overflow room without joining it
+	require.Eventually(t, func() bool {
+		return len(allowed.SubscribedTracks()[pub.ID()]) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NotNil(t, denied.GetRemoteParticipant(pub.ID()))
+	time.Sleep(time.Second)
+	require.Empty(t, denied.SubscribedTracks()[pub.ID()])
+
+	res = whipRequest(t, http.MethodGet, bridgeURL+"?room="+testRoom, adminToken, "", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var listed []service.TrackBridgeInfo
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&listed))
+	require.Len(t, listed, 1)
+
+	res = whipRequest(t, http.MethodDelete, bridgeURL+"/"+info.BridgeID+"?room="+testRoom, adminToken, "", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Eventually(t, func() bool {
+		return allowed.GetRemoteParticipant(pub.ID()) == nil && len(allowed.SubscribedTracks()[pub.ID()]) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	res = whipRequest(t, http.MethodDelete, bridgeURL+"/"+info.BridgeID+"?room="+testRoom, adminToken, "", "")
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	t.Run("closes when the track is unpublished", func(t *testing.T) {
+		res := createBridge(adminToken)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		require.Eventually(t, func() bool {
+			return len(allowed.SubscribedTracks()[pub.ID()]) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		pub.Stop()
+		require.Eventually(t, func() bool {
+			return allowed.GetRemoteParticipant(pub.ID()) == nil
+		}, 5*time.Second, 10*time.Millisecond)
+
+		res = whipRequest(t, http.MethodGet, bridgeURL+"?room="+overflowRoom, adminRoomToken(overflowRoom), "", "")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		var listed []service.TrackBridgeInfo
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&listed))
+		require.Empty(t, listed)
+	})
+}
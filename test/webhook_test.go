@@ -16,6 +16,7 @@ package test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
@@ -41,7 +42,7 @@ import (
 )
 
 func TestWebhooks(t *testing.T) {
-	server, ts, finish, err := setupServerWithWebhook()
+	server, ts, finish, err := setupServerWithWebhook(nil)
 	require.NoError(t, err)
 	defer finish()
 
@@ -119,7 +120,183 @@ func TestWebhooks(t *testing.T) {
 	require.Equal(t, testRoom, ts.GetEvent(webhook.EventRoomFinished).Room.Name)
 }
 
-func setupServerWithWebhook() (server *service.LivekitServer, testServer *webhookTestServer, finishFunc func(), err error) {
+func TestWebhookEndpoints(t *testing.T) {
+	const (
+		billingKey    = "billingkey"
+		billingSecret = "billingsecret"
+	)
+
+	billing := newTestServer(":7891")
+	billing.provider = auth.NewFileBasedKeyProviderFromMap(map[string]string{billingKey: billingSecret})
+	require.NoError(t, billing.Start())
+	defer billing.Stop()
+	moderation := newTestServer(":7892")
+	require.NoError(t, moderation.Start())
+	defer moderation.Stop()
+
+	server, ts, finish, err := setupServerWithWebhook(func(conf *config.Config) {
+		conf.Keys[billingKey] = billingSecret
+		conf.WebHook.Endpoints = []config.WebHookEndpointConfig{
+			{
+				URL:    "http://localhost:7891",
+				APIKey: billingKey,
+				Events: []string{webhook.EventRoomFinished},
+			},
+			{
+				URL:   "http://localhost:7892",
+				Rooms: []string{"moderated-*"},
+			},
+		}
+	})
+	require.NoError(t, err)
+	defer finish()
+
+	c1 := createRTCClient("c1", defaultServerPort, nil)
+	waitUntilConnected(t, c1)
+	defer c1.Stop()
+	c2 := createRTCClientWithToken(joinToken("moderated-room", "c2", nil), defaultServerPort, nil)
+	waitUntilConnected(t, c2)
+	defer c2.Stop()
+
+	// endpoints from urls still receive everything
+	testutils.WithTimeout(t, func() string {
+		if ts.GetEvent(webhook.EventParticipantJoined) == nil {
+			return "did not receive ParticipantJoined"
+		}
+		return ""
+	})
+
+	testutils.WithTimeout(t, func() string {
+		ev := moderation.GetEvent(webhook.EventRoomStarted)
+		if ev == nil {
+			return "moderation did not receive RoomStarted"
+		}
+		require.Equal(t, "moderated-room", ev.Room.Name)
+		return ""
+	})
+
+	server.RoomManager().GetRoom(context.Background(), testRoom).Close(types.ParticipantCloseReasonNone)
+	testutils.WithTimeout(t, func() string {
+		ev := billing.GetEvent(webhook.EventRoomFinished)
+		if ev == nil {
+			return "billing did not receive RoomFinished"
+		}
+		require.Equal(t, testRoom, ev.Room.Name)
+		return ""
+	})
+
+	require.Nil(t, billing.GetEvent(webhook.EventRoomStarted))
+	require.Nil(t, billing.GetEvent(webhook.EventParticipantJoined))
+	require.Nil(t, moderation.GetEvent(webhook.EventRoomFinished))
+}
+
+func TestWebhookDeadLetters(t *testing.T) {
+	receiver := newTestServer(":7891")
+	require.NoError(t, receiver.Start())
+	defer receiver.Stop()
+	receiver.SetStatus(http.StatusServiceUnavailable)
+
+	_, _, finish, err := setupServerWithWebhook(func(conf *config.Config) {
+		conf.WebHook.StoreDir = t.TempDir()
+		conf.WebHook.Endpoints = []config.WebHookEndpointConfig{
+			{
+				URL:          "http://localhost:7891",
+				Events:       []string{webhook.EventParticipantJoined},
+				MaxRetries:   2,
+				RetryWaitMin: 10 * time.Millisecond,
+				RetryWaitMax: 20 * time.Millisecond,
+			},
+		}
+	})
+	require.NoError(t, err)
+	defer finish()
+
+	deadLettersURL := fmt.Sprintf("http://localhost:%d/webhooks/dead_letters", defaultServerPort)
+	listDeadLetters := func(query, token string) []*service.WebhookDelivery {
+		res := whipRequest(t, http.MethodGet, deadLettersURL+query, token, "", "")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		var deliveries []*service.WebhookDelivery
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&deliveries))
+		return deliveries
+	}
+
+	c1 := createRTCClient("c1", defaultServerPort, nil)
+	waitUntilConnected(t, c1)
+	defer c1.Stop()
+
+	var deliveries []*service.WebhookDelivery
+	testutils.WithTimeout(t, func() string {
+		deliveries = listDeadLetters("", adminRoomToken(""))
+		if len(deliveries) != 1 {
+			return "event was not dead-lettered"
+		}
+		return ""
+	})
+	d := deliveries[0]
+	require.Equal(t, webhook.EventParticipantJoined, d.Event)
+	require.Equal(t, testRoom, d.Room)
+	require.Equal(t, 3, d.Attempts)
+	require.Contains(t, d.LastError, "503")
+	require.Nil(t, receiver.GetEvent(webhook.EventParticipantJoined))
+
+	t.Run("requires room admin", func(t *testing.T) {
+		res := whipRequest(t, http.MethodGet, deadLettersURL, adminRoomToken(testRoom), "", "")
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		res = whipRequest(t, http.MethodPost, deadLettersURL+"/"+d.ID+"/replay", adminRoomToken("other"), "", "")
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		require.Len(t, listDeadLetters("?room="+testRoom, adminRoomToken(testRoom)), 1)
+	})
+
+	// replayed once the receiver recovers
+	receiver.SetStatus(http.StatusOK)
+	res := whipRequest(t, http.MethodPost, deadLettersURL+"/"+d.ID+"/replay", adminRoomToken(testRoom), "", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	testutils.WithTimeout(t, func() string {
+		ev := receiver.GetEvent(webhook.EventParticipantJoined)
+		if ev == nil {
+			return "did not receive replayed ParticipantJoined"
+		}
+		require.Equal(t, "c1", ev.Participant.Identity)
+		return ""
+	})
+	require.Empty(t, listDeadLetters("", adminRoomToken("")))
+
+	res = whipRequest(t, http.MethodPost, deadLettersURL+"/"+d.ID+"/replay", adminRoomToken(""), "", "")
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	// rejected events are not retried
+	receiver.SetStatus(http.StatusBadRequest)
+	c2 := createRTCClient("c2", defaultServerPort, nil)
+	waitUntilConnected(t, c2)
+	defer c2.Stop()
+	testutils.WithTimeout(t, func() string {
+		deliveries = listDeadLetters("", adminRoomToken(""))
+		if len(deliveries) != 1 {
+			return "event was not dead-lettered"
+		}
+		return ""
+	})
+	require.Equal(t, 1, deliveries[0].Attempts)
+
+	res = whipRequest(t, http.MethodDelete, deadLettersURL+"?room="+testRoom, adminRoomToken(testRoom), "", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var purged service.PurgeWebhookDeadLettersResponse
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&purged))
+	require.Equal(t, 1, purged.Deleted)
+	require.Empty(t, listDeadLetters("", adminRoomToken("")))
+}
+
+func TestWebhookEndpointsValidation(t *testing.T) {
+	_, _, _, err := setupServerWithWebhook(func(conf *config.Config) {
+		conf.WebHook.Endpoints = []config.WebHookEndpointConfig{
+			{URL: "http://localhost:7891", Events: []string{"room_exploded"}},
+		}
+	})
+	require.Error(t, err)
+}
+
+func setupServerWithWebhook(configUpdater func(*config.Config)) (server *service.LivekitServer, testServer *webhookTestServer, finishFunc func(), err error) {
 	conf, err := config.NewConfig("", true, nil, nil)
 	if err != nil {
 		panic(fmt.Sprintf("could not create config: %v", err))
@@ -127,6 +304,9 @@ func setupServerWithWebhook() (server *service.LivekitServer, testServer *webhoo
 	conf.WebHook.URLs = []string{"http://localhost:7890"}
 	conf.WebHook.APIKey = testApiKey
 	conf.Keys = map[string]string{testApiKey: testApiSecret}
+	if configUpdater != nil {
+		configUpdater(conf)
+	}
 
 	testServer = newTestServer(":7890")
 	if err = testServer.Start(); err != nil {
@@ -141,6 +321,7 @@ func setupServerWithWebhook() (server *service.LivekitServer, testServer *webhoo
 
 	server, err = service.InitializeServer(conf, currentNode)
 	if err != nil {
+		testServer.Stop()
 		return
 	}
 
@@ -162,6 +343,7 @@ func setupServerWithWebhook() (server *service.LivekitServer, testServer *webhoo
 type webhookTestServer struct {
 	server   *http.Server
 	events   map[string]*livekit.WebhookEvent
+	status   int
 	lock     sync.Mutex
 	provider auth.KeyProvider
 }
@@ -178,7 +360,15 @@ func newTestServer(addr string) *webhookTestServer {
 	return s
 }
 
-func (s *webhookTestServer) ServeHTTP(_ http.ResponseWriter, r *http.Request) {
+func (s *webhookTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	status := s.status
+	s.lock.Unlock()
+	if status != 0 && status != http.StatusOK {
+		w.WriteHeader(status)
+		return
+	}
+
 	data, err := webhook.Receive(r, s.provider)
 	if err != nil {
 		logger.Errorw("could not receive webhook", err)
@@ -202,6 +392,13 @@ func (s *webhookTestServer) GetEvent(name string) *livekit.WebhookEvent {
 	return s.events[name]
 }
 
+// SetStatus makes the server fail requests with the given status, other than http.StatusOK
+func (s *webhookTestServer) SetStatus(status int) {
+	s.lock.Lock()
+	s.status = status
+	s.lock.Unlock()
+}
+
 func (s *webhookTestServer) ClearEvents() {
 	s.lock.Lock()
 	s.events = make(map[string]*livekit.WebhookEvent)
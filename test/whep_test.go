@@ -0,0 +1,151 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestWHEPPlay(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	_, finish := setupSingleNodeTest("TestWHEPPlay")
+	defer finish()
+
+	pub := createRTCClient("whep_pub", defaultServerPort, nil)
+	waitUntilConnected(t, pub)
+	defer pub.Stop()
+
+	audioWriter, err := pub.AddStaticTrack("audio/opus", "audio", "webcam")
+	require.NoError(t, err)
+	defer audioWriter.Stop()
+	videoWriter, err := pub.AddStaticTrack("video/vp8", "video", "webcam")
+	require.NoError(t, err)
+	defer videoWriter.Stop()
+
+	require.Eventually(t, func() bool {
+		res, err := roomClient.ListParticipants(contextWithToken(adminRoomToken(testRoom)), &livekit.ListParticipantsRequest{Room: testRoom})
+		return err == nil && len(res.Participants) == 1 && len(res.Participants[0].Tracks) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	defer pc.Close()
+
+	for _, kind := range []webrtc.RTPCodecType{webrtc.RTPCodecTypeAudio, webrtc.RTPCodecTypeVideo} {
+		_, err = pc.AddTransceiverFromKind(kind, webrtc.RTPTransceiverInit{Direction: webrtc.RTPTransceiverDirectionRecvonly})
+		require.NoError(t, err)
+	}
+	var audioPackets, videoPackets, remoteTracks atomic.Int32
+	pc.OnTrack(func(track *webrtc.TrackRemote, _ *webrtc.RTPReceiver) {
+		remoteTracks.Inc()
+		counter := &audioPackets
+		if track.Kind() == webrtc.RTPCodecTypeVideo {
+			counter = &videoPackets
+		}
+		for {
+			if _, _, err := track.ReadRTP(); err != nil {
+				return
+			}
+			counter.Inc()
+		}
+	})
+
+	offer, err := pc.CreateOffer(nil)
+	require.NoError(t, err)
+	require.NoError(t, pc.SetLocalDescription(offer))
+	<-webrtc.GatheringCompletePromise(pc)
+
+	viewerToken := joinToken(testRoom, "viewer", func(_ *auth.AccessToken, grant *auth.VideoGrant) {
+		grant.SetCanPublish(false)
+	})
+	whepURL := fmt.Sprintf("http://localhost:%d/whep/%s/%s", defaultServerPort, testRoom, "whep_pub")
+
+	t.Run("rejects unknown target", func(t *testing.T) {
+		url := fmt.Sprintf("http://localhost:%d/whep/%s/%s", defaultServerPort, testRoom, "nobody")
+		res := whipRequest(t, http.MethodPost, url, viewerToken, "application/sdp", pc.LocalDescription().SDP)
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("rejects token without subscribe permission", func(t *testing.T) {
+		token := joinToken(testRoom, "viewer", func(_ *auth.AccessToken, grant *auth.VideoGrant) {
+			grant.SetCanSubscribe(false)
+		})
+		res := whipRequest(t, http.MethodPost, whepURL, token, "application/sdp", pc.LocalDescription().SDP)
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	res := whipRequest(t, http.MethodPost, whepURL, viewerToken, "application/sdp", pc.LocalDescription().SDP)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	location := res.Header.Get("Location")
+	require.True(t, strings.HasPrefix(location, "/whep/"+testRoom+"/whep_pub/"))
+
+	answer, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: string(answer)}))
+
+	require.Eventually(t, func() bool {
+		return audioPackets.Load() > 10 && videoPackets.Load() > 10
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// viewers are hidden
+	require.Len(t, pub.RemoteParticipants(), 0)
+
+	t.Run("tracks published later are not played", func(t *testing.T) {
+		screenWriter, err := pub.AddStaticTrack("video/vp8", "screen", "screen")
+		require.NoError(t, err)
+		defer screenWriter.Stop()
+
+		require.Eventually(t, func() bool {
+			res, err := roomClient.ListParticipants(contextWithToken(adminRoomToken(testRoom)), &livekit.ListParticipantsRequest{Room: testRoom})
+			if err != nil {
+				return false
+			}
+			for _, p := range res.Participants {
+				if p.Identity == "whep_pub" {
+					return len(p.Tracks) == 3
+				}
+			}
+			return false
+		}, 5*time.Second, 10*time.Millisecond)
+
+		require.Never(t, func() bool {
+			return remoteTracks.Load() > 2
+		}, time.Second, 50*time.Millisecond)
+	})
+
+	res = whipRequest(t, http.MethodDelete, fmt.Sprintf("http://localhost:%d%s", defaultServerPort, location), viewerToken, "", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	require.Eventually(t, func() bool {
+		res, err := roomClient.ListParticipants(contextWithToken(adminRoomToken(testRoom)), &livekit.ListParticipantsRequest{Room: testRoom})
+		return err == nil && len(res.Participants) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
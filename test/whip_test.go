@@ -0,0 +1,164 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+
+	testclient "github.com/livekit/livekit-server/test/client"
+)
+
+func TestWHIPPublish(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	_, finish := setupSingleNodeTest("TestWHIPPublish")
+	defer finish()
+
+	sub := createRTCClient("whip_sub", defaultServerPort, &testclient.Options{AutoSubscribe: true})
+	waitUntilConnected(t, sub)
+	defer sub.Stop()
+
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	defer pc.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, codec := range []webrtc.RTPCodecCapability{
+		{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2},
+		{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+	} {
+		track, err := webrtc.NewTrackLocalStaticSample(codec, codec.MimeType, "whip")
+		require.NoError(t, err)
+		_, err = pc.AddTransceiverFromTrack(track, webrtc.RTPTransceiverInit{Direction: webrtc.RTPTransceiverDirectionSendonly})
+		require.NoError(t, err)
+		writer := testclient.NewTrackWriter(ctx, track, "")
+		require.NoError(t, writer.Start())
+		defer writer.Stop()
+	}
+
+	offer, err := pc.CreateOffer(nil)
+	require.NoError(t, err)
+	require.NoError(t, pc.SetLocalDescription(offer))
+	<-webrtc.GatheringCompletePromise(pc)
+
+	token := joinToken(testRoom, "whip_pub", nil)
+	whipURL := fmt.Sprintf("http://localhost:%d/whip/%s", defaultServerPort, testRoom)
+
+	t.Run("rejects missing token", func(t *testing.T) {
+		res := whipRequest(t, http.MethodPost, whipURL, "", "application/sdp", pc.LocalDescription().SDP)
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("rejects token for another room", func(t *testing.T) {
+		otherToken := joinToken("other", "whip_pub", nil)
+		res := whipRequest(t, http.MethodPost, whipURL, otherToken, "application/sdp", pc.LocalDescription().SDP)
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("rejects oversized offers", func(t *testing.T) {
+		offer := pc.LocalDescription().SDP + strings.Repeat("a=x-padding\r\n", 8*1024)
+		res := whipRequest(t, http.MethodPost, whipURL, token, "application/sdp", offer)
+		require.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+	})
+
+	res := whipRequest(t, http.MethodPost, whipURL, token, "application/sdp", pc.LocalDescription().SDP)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	require.Equal(t, "application/sdp", res.Header.Get("Content-Type"))
+	location := res.Header.Get("Location")
+	require.True(t, strings.HasPrefix(location, "/whip/"+testRoom+"/"))
+
+	answer, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: string(answer)}))
+
+	pID := livekit.ParticipantID(strings.TrimPrefix(location, "/whip/"+testRoom+"/"))
+	require.Eventually(t, func() bool {
+		return len(sub.SubscribedTracks()[pID]) == 2
+	}, 10*time.Second, 10*time.Millisecond)
+
+	resourceURL := fmt.Sprintf("http://localhost:%d%s", defaultServerPort, location)
+
+	t.Run("trickle", func(t *testing.T) {
+		frag := "a=ice-ufrag:abcd\r\na=ice-pwd:efgh\r\nm=audio 9 UDP/TLS/RTP/SAVPF 0\r\na=mid:0\r\na=candidate:1 1 udp 2130706431 127.0.0.1 50000 typ host\r\n"
+		res := whipRequest(t, http.MethodPatch, resourceURL, token, "application/trickle-ice-sdpfrag", frag)
+		require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		res = whipRequest(t, http.MethodPatch, resourceURL, token, "application/trickle-ice-sdpfrag", strings.Repeat(frag, 1024))
+		require.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+	})
+
+	t.Run("only the publisher can tear down", func(t *testing.T) {
+		otherToken := joinToken(testRoom, "someone_else", nil)
+		res := whipRequest(t, http.MethodDelete, resourceURL, otherToken, "", "")
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	res = whipRequest(t, http.MethodDelete, resourceURL, token, "", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	require.Eventually(t, func() bool {
+		return sub.GetRemoteParticipant(pID) == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	res = whipRequest(t, http.MethodDelete, resourceURL, token, "", "")
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestWHIPPublishRequiresPublishPermission(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+
+	_, finish := setupSingleNodeTest("TestWHIPPublishRequiresPublishPermission")
+	defer finish()
+
+	token := joinToken(testRoom, "whip_pub", func(_ *auth.AccessToken, grant *auth.VideoGrant) {
+		grant.SetCanPublish(false)
+	})
+	res := whipRequest(t, http.MethodPost, fmt.Sprintf("http://localhost:%d/whip/%s", defaultServerPort, testRoom), token, "application/sdp", "v=0\r\n")
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+func whipRequest(t *testing.T, method, url, token, contentType, body string) *http.Response {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = res.Body.Close() })
+	return res
+}